@@ -0,0 +1,31 @@
+// Command reaper-mock runs a fake REAPER Web Remote with a small demo session, so the
+// plugin and web pages can be tried without REAPER. Point web_remote_host at it.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/johnjallday/ori-reaper-plugin/pkg/webremote/mockserver"
+)
+
+func main() {
+	port := flag.Int("port", 8080, "port to listen on")
+	host := flag.String("host", "127.0.0.1", "address to listen on")
+	username := flag.String("username", "", "require this basic auth username")
+	password := flag.String("password", "", "require this basic auth password")
+	flag.Parse()
+
+	server := mockserver.New(mockserver.DemoTracks()...)
+	if *username != "" || *password != "" {
+		server.SetCredentials(*username, *password)
+	}
+
+	addr := net.JoinHostPort(*host, strconv.Itoa(*port))
+	fmt.Printf("Mock REAPER Web Remote listening on http://%s (try /_/TRACK)\n", addr)
+	log.Fatal(http.ListenAndServe(addr, server))
+}
@@ -3,6 +3,7 @@ module github.com/johnjallday/ori-reaper-plugin
 go 1.25.3
 
 require (
+	github.com/fsnotify/fsnotify v1.10.1
 	github.com/hashicorp/go-plugin v1.7.0
 	github.com/johnjallday/ori-agent v0.0.0-20250814050009-07ed70c7c8b8
 	github.com/shirou/gopsutil/v3 v3.24.5
@@ -0,0 +1,394 @@
+package bridge
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/errcode"
+	"github.com/johnjallday/ori-reaper-plugin/internal/i18n"
+	"github.com/johnjallday/ori-reaper-plugin/internal/platform"
+	"github.com/johnjallday/ori-reaper-plugin/internal/progress"
+)
+
+// DefaultTimeout is how long Run waits for REAPER to execute a bridge script
+const DefaultTimeout = 5 * time.Second
+
+// pollInterval is how often Run checks for the bridge output file
+const pollInterval = 100 * time.Millisecond
+
+// progressInterval is how often a script that keeps REAPER busy, such as a render,
+// reports that it is still running
+const progressInterval = 10 * time.Second
+
+// filePrefix starts the names of the script and output files each run writes to the temp directory
+const filePrefix = "ori_"
+
+// staleFileAge is how old leftover bridge files must be before they're cleaned up. Files
+// are left behind when REAPER finishes a script after Run stopped waiting for it.
+const staleFileAge = time.Hour
+
+// lateInterval is how often the output of a script that outlived its timeout is checked
+const lateInterval = time.Second
+
+// ErrTimeout is returned when REAPER doesn't finish a bridge script in time.
+// The script may still be running (e.g. waiting on a dialog).
+var ErrTimeout = errcode.New(errcode.Timeout, i18n.Error("error.bridge_timeout"))
+
+// backend is how the bridge checks for REAPER, launches scripts and exchanges files
+var backend = platform.DefaultBackend()
+
+var (
+	filesMu   sync.Mutex
+	keepFiles bool           // Leave script and output files in the temp directory for debugging
+	lastSweep time.Time      // When leftover files were last cleaned up
+	lateRuns  sync.WaitGroup // Scripts still being waited for after their timeout
+)
+
+// SetKeepFiles sets whether the script and output files of each run are left in the temp
+// directory instead of being removed, for debugging
+func SetKeepFiles(keep bool) {
+	filesMu.Lock()
+	defer filesMu.Unlock()
+	keepFiles = keep
+}
+
+// SetBackend replaces the process and file access used by Run, e.g. with fakes in tests.
+// Unset fields keep the local default.
+func SetBackend(b platform.Backend) {
+	backend = b.WithDefaults()
+}
+
+// errorMarker prefixes the output line written by fail(...) or a runtime error
+const errorMarker = "__ori_error"
+
+// luaPrelude is prepended to every bridge script. It defines out(...), which writes
+// one tab-separated line to the output file, escaping backslashes and newlines so
+// multi-line values survive the round trip, and fail(msg), which reports an error
+// back to Run. The body runs inside a function under pcall, so it may use return.
+// The file is written to a .tmp path and renamed when the script finishes, so the
+// reader never sees a partial result.
+const luaPrelude = `-- Ori REAPER Bridge
+local __ori_tmp = %s
+local __ori_out_path = %s
+local __ori_file = io.open(__ori_tmp, "w")
+
+local function __ori_escape(v)
+    local s = tostring(v)
+    s = s:gsub("\\", "\\\\")
+    s = s:gsub("\n", "\\n")
+    s = s:gsub("\r", "\\r")
+    s = s:gsub("\t", "\\t")
+    return s
+end
+
+local function out(...)
+    if not __ori_file then return end
+    local parts = {}
+    for i = 1, select("#", ...) do
+        parts[#parts + 1] = __ori_escape(select(i, ...))
+    end
+    __ori_file:write(table.concat(parts, "\t") .. "\n")
+end
+
+local function fail(msg)
+    out("__ori_error", msg)
+end
+
+local function __ori_main()
+`
+
+// luaEpilogue closes and publishes the output file
+const luaEpilogue = `
+end
+
+local __ori_ok, __ori_err = pcall(__ori_main)
+if not __ori_ok then
+    fail(__ori_err)
+end
+
+if __ori_file then
+    __ori_file:close()
+    os.remove(__ori_out_path)
+    os.rename(__ori_tmp, __ori_out_path)
+end
+`
+
+// Run executes a Lua snippet inside REAPER and returns the lines it wrote with out(...).
+// Each returned line is split into its tab-separated fields and unescaped.
+// If the snippet calls fail(msg) or raises a Lua error, Run returns it as an error.
+// The name is used to build the temporary script and output file names.
+// Waiting stops early if ctx is done.
+func Run(ctx context.Context, name, body string) ([][]string, error) {
+	return RunWithTimeout(ctx, name, body, DefaultTimeout)
+}
+
+// RunWithTimeout is like Run but waits up to timeout for the script to finish,
+// for scripts that trigger long-running work such as rendering
+func RunWithTimeout(ctx context.Context, name, body string, timeout time.Duration) ([][]string, error) {
+	running, err := backend.Processes.IsReaperRunning()
+	if err != nil {
+		return nil, fmt.Errorf("could not check for REAPER process: %w", err)
+	}
+	if !running {
+		return nil, errcode.New(errcode.ReaperNotRunning, i18n.Errorf("error.reaper_not_running"))
+	}
+
+	// Every run gets its own files so concurrent runs, from several agents or plugin
+	// instances, never read each other's output
+	suffix := make([]byte, 6)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, fmt.Errorf("failed to generate temp script name: %w", err)
+	}
+	run := hex.EncodeToString(suffix)
+	tmpDir := os.TempDir()
+	base := filepath.Join(tmpDir, fmt.Sprintf("%s%s_%s", filePrefix, name, run))
+	scriptPath := base + ".lua"
+	outputPath := base + "_output.txt"
+
+	script := fmt.Sprintf(luaPrelude, LuaString(outputPath+".tmp"), LuaString(outputPath)) + body + luaEpilogue
+
+	if err := backend.FS.WriteFile(scriptPath, []byte(script), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write temp script: %w", err)
+	}
+	keep := cleanUp(tmpDir)
+
+	started := time.Now()
+	data, err := execute(ctx, name, scriptPath, outputPath, timeout)
+	if errors.Is(err, ErrTimeout) {
+		// The script is still running in REAPER, and may not even have been read yet;
+		// pick up its output when it finishes
+		lateRuns.Add(1)
+		go awaitLate(name, run, script, started, scriptPath, outputPath, keep)
+	} else if !keep {
+		backend.FS.Remove(scriptPath)
+		backend.FS.Remove(outputPath)
+	}
+	var rows [][]string
+	if err == nil {
+		rows, err = parseResult(data)
+	}
+	record(name, run, script, started, data, err)
+	if err != nil {
+		if keep {
+			return nil, fmt.Errorf("%w (bridge files kept: %s)", err, base+".*")
+		}
+		return nil, err
+	}
+	return rows, nil
+}
+
+// parseResult parses bridge output, returning the error the script reported with fail(msg)
+// or raised, if any
+func parseResult(data []byte) ([][]string, error) {
+	rows := parseOutput(string(data))
+	for _, row := range rows {
+		if row[0] == errorMarker {
+			msg := "unknown error"
+			if len(row) > 1 {
+				msg = row[1]
+			}
+			return nil, fmt.Errorf("REAPER script error: %s", msg)
+		}
+	}
+	return rows, nil
+}
+
+// awaitLate waits up to staleFileAge for the output of a script that was still running
+// when Run stopped waiting, then replaces its history record, which says it timed out,
+// with how it really ended. The script file is removed only then, since REAPER may
+// not have started it yet.
+func awaitLate(name, run, script string, started time.Time, scriptPath, outputPath string, keep bool) {
+	defer lateRuns.Done()
+	if !keep {
+		defer backend.FS.Remove(scriptPath)
+	}
+	for time.Since(started) < staleFileAge {
+		data, err := backend.FS.ReadFile(outputPath)
+		if err == nil {
+			_, err = parseResult(data)
+			record(name, run, script, started, data, err)
+			if !keep {
+				backend.FS.Remove(outputPath)
+			}
+			return
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return
+		}
+		platform.Sleep(context.Background(), lateInterval)
+	}
+}
+
+// cleanUp removes bridge files older than staleFileAge from dir, at most once per
+// staleFileAge, unless files are being kept. It returns whether they are.
+func cleanUp(dir string) bool {
+	filesMu.Lock()
+	defer filesMu.Unlock()
+	if keepFiles {
+		return true
+	}
+	if time.Since(lastSweep) < staleFileAge {
+		return false
+	}
+	lastSweep = time.Now()
+
+	entries, err := backend.FS.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, filePrefix) ||
+			!(strings.HasSuffix(name, ".lua") || strings.HasSuffix(name, "_output.txt") || strings.HasSuffix(name, "_output.txt.tmp")) {
+			continue
+		}
+		if info, err := entry.Info(); err == nil && time.Since(info.ModTime()) > staleFileAge {
+			backend.FS.Remove(filepath.Join(dir, name))
+		}
+	}
+	return false
+}
+
+// execute launches a bridge script in REAPER and returns its output once written
+func execute(ctx context.Context, name, scriptPath, outputPath string, timeout time.Duration) ([]byte, error) {
+	if err := backend.Launcher.ExecuteScript(ctx, scriptPath); err != nil {
+		return nil, fmt.Errorf("failed to execute script in REAPER: %w", err)
+	}
+	return waitForOutput(ctx, name, outputPath, timeout)
+}
+
+// runFileBody runs a script file from a bridge script. reaper.get_action_context is
+// wrapped to report the script's own path instead of the bridge script's, so scripts
+// that load files next to themselves keep working; debug.getinfo already sees the file.
+const runFileBody = `local script_path = %s
+local get_action_context = reaper.get_action_context
+reaper.get_action_context = function()
+    local values = table.pack(get_action_context())
+    values[2] = script_path
+    return table.unpack(values, 1, values.n)
+end
+
+local ok, err = xpcall(function() dofile(script_path) end, debug.traceback)
+if not ok then
+    fail(err)
+end
+`
+
+// RunFile executes an existing Lua script file inside REAPER under xpcall, so a runtime
+// error is returned together with its stack trace instead of being lost.
+// If the script is still running when the timeout expires, RunFile returns ErrTimeout and
+// how the script ends is recorded in the execution history once it does.
+// Errors raised later from reaper.defer callbacks are not captured.
+func RunFile(ctx context.Context, name, scriptPath string, timeout time.Duration) error {
+	body := fmt.Sprintf(runFileBody, LuaString(scriptPath))
+	_, err := RunWithTimeout(ctx, name, body, timeout)
+	return err
+}
+
+// waitForOutput polls for the output file until it appears, the timeout expires or ctx is
+// done, reporting every progressInterval that the script named name is still running
+func waitForOutput(ctx context.Context, name, path string, timeout time.Duration) ([]byte, error) {
+	started := time.Now()
+	deadline := started.Add(timeout)
+	nextReport := started.Add(progressInterval)
+	for {
+		data, err := backend.FS.ReadFile(path)
+		if err == nil {
+			return data, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read output file: %w", err)
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%w (waited %s)", ErrTimeout, timeout)
+		}
+		if time.Now().After(nextReport) {
+			progress.Report(ctx, fmt.Sprintf("Waiting for REAPER to finish %s (%s so far)", name, time.Since(started).Round(time.Second)), 0, 0)
+			nextReport = nextReport.Add(progressInterval)
+		}
+		if err := platform.Sleep(ctx, pollInterval); err != nil {
+			return nil, fmt.Errorf("stopped waiting for REAPER to run the bridge script: %w", err)
+		}
+	}
+}
+
+// parseOutput splits bridge output into lines of unescaped fields
+func parseOutput(data string) [][]string {
+	var rows [][]string
+	for _, line := range strings.Split(data, "\n") {
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		for i, f := range fields {
+			fields[i] = unescape(f)
+		}
+		rows = append(rows, fields)
+	}
+	return rows
+}
+
+// unescape reverses the escaping done by the Lua out(...) helper
+func unescape(s string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' || i+1 >= len(s) {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// LuaString returns s as a double-quoted Lua string literal
+func LuaString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '\\':
+			b.WriteString(`\\`)
+		case '"':
+			b.WriteString(`\"`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if c < 0x20 || c == 0x7f {
+				fmt.Fprintf(&b, `\%03d`, c)
+			} else {
+				b.WriteByte(c)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
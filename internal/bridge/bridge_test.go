@@ -0,0 +1,188 @@
+package bridge
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/platform"
+)
+
+// fakeReaper stands in for a running REAPER: run is called with each bridge script's
+// content and the output paths its prelude names
+type fakeReaper struct {
+	run func(script, tmpPath, outputPath string) error
+}
+
+func (f *fakeReaper) IsReaperRunning() (bool, error) { return true, nil }
+func (f *fakeReaper) LaunchReaper() error            { return nil }
+func (f *fakeReaper) QuitReaper(context.Context, time.Duration) error {
+	return nil
+}
+
+// preludePath matches the output path assignments at the top of a bridge script
+var preludePath = regexp.MustCompile(`(?m)^local (__ori_tmp|__ori_out_path) = (".*")$`)
+
+func (f *fakeReaper) ExecuteScript(ctx context.Context, scriptPath string) error {
+	data, err := os.ReadFile(scriptPath)
+	if err != nil {
+		return err
+	}
+	paths := map[string]string{}
+	for _, match := range preludePath.FindAllStringSubmatch(string(data), -1) {
+		if paths[match[1]], err = strconv.Unquote(match[2]); err != nil {
+			return err
+		}
+	}
+	return f.run(string(data), paths["__ori_tmp"], paths["__ori_out_path"])
+}
+
+// useFakeReaper routes the bridge to fake for the rest of the test, with the execution
+// history in a temporary folder
+func useFakeReaper(t *testing.T, fake *fakeReaper) {
+	t.Helper()
+	cache := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cache)
+	t.Setenv("HOME", cache)
+	t.Setenv("LocalAppData", cache)
+	SetBackend(platform.Backend{Processes: fake, Launcher: fake})
+	t.Cleanup(func() {
+		lateRuns.Wait()
+		SetBackend(platform.DefaultBackend())
+	})
+}
+
+// writeFailingScript writes a script that fails, naming the path get_action_context gives it
+func writeFailingScript(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "Failing Script.lua")
+	script := "local _, path = reaper.get_action_context()\nerror(\"boom in \" .. path)\n"
+	if err := os.WriteFile(path, []byte(script), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRunFileReportsScriptError(t *testing.T) {
+	scriptPath := writeFailingScript(t)
+	trace := scriptPath + ":2: boom in " + scriptPath + "\nstack traceback:\n\t[C]: in function 'error'"
+	useFakeReaper(t, &fakeReaper{run: func(script, tmpPath, outputPath string) error {
+		if !strings.Contains(script, "local script_path = "+LuaString(scriptPath)) {
+			t.Errorf("harness doesn't run %s:\n%s", scriptPath, script)
+		}
+		if !strings.Contains(script, "reaper.get_action_context = function()") {
+			t.Errorf("harness doesn't give the script its own path:\n%s", script)
+		}
+		// What the harness writes when the script raises an error
+		line := errorMarker + "\t" + strings.NewReplacer("\\", "\\\\", "\n", "\\n", "\t", "\\t").Replace(trace) + "\n"
+		return os.WriteFile(outputPath, []byte(line), 0644)
+	}})
+
+	err := RunFile(context.Background(), "run_script", scriptPath, time.Second)
+	if err == nil {
+		t.Fatal("RunFile succeeded, want the script's error")
+	}
+	if !strings.Contains(err.Error(), trace) {
+		t.Errorf("RunFile error = %q, want it to contain the stack trace %q", err, trace)
+	}
+}
+
+func TestRunFileHarnessInLua(t *testing.T) {
+	lua, err := exec.LookPath("lua")
+	if err != nil {
+		t.Skip("lua is not installed")
+	}
+	if version, _ := exec.Command(lua, "-v").CombinedOutput(); !regexp.MustCompile(`Lua 5\.[34]`).Match(version) {
+		t.Skipf("REAPER runs Lua 5.4; found %s", version)
+	}
+	scriptPath := writeFailingScript(t)
+	useFakeReaper(t, &fakeReaper{run: func(script, tmpPath, outputPath string) error {
+		harness := filepath.Join(t.TempDir(), "harness.lua")
+		if err := os.WriteFile(harness, []byte(script), 0644); err != nil {
+			return err
+		}
+		stub := `reaper = {get_action_context = function() return false, "harness.lua", 0, 0, 0, 0, 0, "" end}`
+		if out, err := exec.Command(lua, "-e", stub, harness).CombinedOutput(); err != nil {
+			t.Errorf("harness failed to run: %v\n%s", err, out)
+		}
+		return nil
+	}})
+
+	err = RunFile(context.Background(), "run_script", scriptPath, 5*time.Second)
+	if err == nil {
+		t.Fatal("RunFile succeeded, want the script's error")
+	}
+	if !strings.Contains(err.Error(), "boom in "+scriptPath) {
+		t.Errorf("RunFile error = %q, want the script to see its own path %s", err, scriptPath)
+	}
+	if !strings.Contains(err.Error(), "stack traceback") {
+		t.Errorf("RunFile error = %q, want a stack trace", err)
+	}
+}
+
+func TestRunFileRecordsLateResult(t *testing.T) {
+	scriptPath := writeFailingScript(t)
+	outputs := make(chan string, 1)
+	useFakeReaper(t, &fakeReaper{run: func(script, tmpPath, outputPath string) error {
+		outputs <- outputPath // The script keeps running past the timeout
+		return nil
+	}})
+
+	err := RunFile(context.Background(), "run_script", scriptPath, 200*time.Millisecond)
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("RunFile error = %v, want ErrTimeout", err)
+	}
+	outputPath := <-outputs
+	// REAPER may not have read the bridge script yet
+	bridgeScript := strings.TrimSuffix(outputPath, "_output.txt") + ".lua"
+	if _, err := os.Stat(bridgeScript); err != nil {
+		t.Errorf("bridge script removed while REAPER may still run it: %v", err)
+	}
+	if err := os.WriteFile(outputPath, []byte(errorMarker+"\tlate failure\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		executions, err := ListExecutions("run_script", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(executions) == 1 && executions[0].Outcome == OutcomeError {
+			if !strings.Contains(executions[0].Error, "late failure") {
+				t.Errorf("recorded error = %q, want the script's late failure", executions[0].Error)
+			}
+			lateRuns.Wait()
+			if _, err := os.Stat(bridgeScript); !os.IsNotExist(err) {
+				t.Errorf("bridge script left after its late output was read: %v", err)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("execution history = %+v, want the late failure recorded", executions)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestRecordKeepsRunsStartedTogether(t *testing.T) {
+	useFakeReaper(t, &fakeReaper{})
+	started := time.Now()
+	record("get_context", "1a2b3c", "-- first", started, []byte("first\n"), nil)
+	record("get_context", "4d5e6f", "-- second", started, []byte("second\n"), nil)
+
+	executions, err := ListExecutions("get_context", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(executions) != 2 || executions[0].ID == executions[1].ID {
+		t.Errorf("execution history = %+v, want a record for each run", executions)
+	}
+}
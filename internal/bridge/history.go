@@ -0,0 +1,253 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultHistoryLimit is how many executions are kept in the history unless configured
+const DefaultHistoryLimit = 200
+
+// historyOutputLimit caps how much of a script's output is kept in its history record
+const historyOutputLimit = 4096
+
+// historyIDFormat timestamps execution IDs so they sort in the order scripts ran
+const historyIDFormat = "20060102-150405.000"
+
+// Outcomes of an execution
+const (
+	OutcomeOK        = "ok"
+	OutcomeError     = "error"     // The script failed or couldn't be launched
+	OutcomeTimeout   = "timeout"   // REAPER didn't finish in time; the script may still be running
+	OutcomeCancelled = "cancelled" // The operation was cancelled while waiting
+)
+
+var (
+	historyMu    sync.Mutex
+	historyLimit = DefaultHistoryLimit
+)
+
+// Execution is the record of a generated script run through the bridge
+type Execution struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"` // What the script does, e.g. "get_master" or "run_script"
+	Time       time.Time `json:"time"`
+	DurationMs int64     `json:"duration_ms"`
+	Outcome    string    `json:"outcome"`
+	Error      string    `json:"error,omitempty"`
+	Output     string    `json:"output,omitempty"` // What the script reported, shortened
+}
+
+// SetHistoryLimit sets how many executions are kept in the history; older ones are
+// removed as new ones are recorded. 0 uses DefaultHistoryLimit and a negative limit
+// turns the history off.
+func SetHistoryLimit(limit int) {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+	if limit == 0 {
+		limit = DefaultHistoryLimit
+	}
+	historyLimit = limit
+}
+
+// HistoryDir returns the folder executions are recorded in, under the user's cache directory
+func HistoryDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot locate the cache directory for the execution history: %w", err)
+	}
+	return filepath.Join(cacheDir, "ori-reaper", "executions"), nil
+}
+
+// outcomeOf classifies the error a script run ended with
+func outcomeOf(err error) string {
+	switch {
+	case err == nil:
+		return OutcomeOK
+	case errors.Is(err, ErrTimeout):
+		return OutcomeTimeout
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return OutcomeCancelled
+	}
+	return OutcomeError
+}
+
+// record saves a script and how its run went to the history, as <id>.lua with the script
+// and <id>.json with the Execution, then removes the oldest records over the limit. run is
+// the random suffix of the run's bridge files, so runs started in the same millisecond keep
+// their own records. Recording is best effort: failures never affect the run.
+func record(name, run, script string, started time.Time, output []byte, runErr error) {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+	if historyLimit < 0 {
+		return
+	}
+	dir, err := HistoryDir()
+	if err != nil {
+		return
+	}
+	if err := backend.FS.MkdirAll(dir, 0755); err != nil {
+		return
+	}
+
+	execution := Execution{
+		ID:         started.Format(historyIDFormat) + "_" + name + "_" + run,
+		Name:       name,
+		Time:       started,
+		DurationMs: time.Since(started).Milliseconds(),
+		Outcome:    outcomeOf(runErr),
+		Output:     string(output),
+	}
+	if runErr != nil {
+		execution.Error = runErr.Error()
+	}
+	if len(execution.Output) > historyOutputLimit {
+		execution.Output = execution.Output[:historyOutputLimit] + "\n…"
+	}
+	data, err := json.MarshalIndent(execution, "", "  ")
+	if err != nil {
+		return
+	}
+	base := filepath.Join(dir, execution.ID)
+	if backend.FS.WriteFile(base+".lua", []byte(script), 0644) != nil || backend.FS.WriteFile(base+".json", data, 0644) != nil {
+		return
+	}
+
+	ids, err := historyIDs(dir)
+	if err != nil {
+		return
+	}
+	for _, id := range ids[:max(len(ids)-historyLimit, 0)] {
+		backend.FS.Remove(filepath.Join(dir, id+".json"))
+		backend.FS.Remove(filepath.Join(dir, id+".lua"))
+	}
+}
+
+// historyIDs returns the IDs of the recorded executions, oldest first
+func historyIDs(dir string) ([]string, error) {
+	entries, err := backend.FS.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, entry := range entries {
+		if id, ok := strings.CutSuffix(entry.Name(), ".json"); ok && !entry.IsDir() {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// ListExecutions returns the latest limit recorded executions, newest first, keeping only
+// those whose name contains filter (case-insensitive)
+func ListExecutions(filter string, limit int) ([]Execution, error) {
+	dir, err := HistoryDir()
+	if err != nil {
+		return nil, err
+	}
+	ids, err := historyIDs(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []Execution{}, nil
+		}
+		return nil, fmt.Errorf("failed to read the execution history: %w", err)
+	}
+
+	filter = strings.ToLower(strings.TrimSpace(filter))
+	executions := []Execution{}
+	for i := len(ids) - 1; i >= 0 && (limit <= 0 || len(executions) < limit); i-- {
+		execution, err := readExecution(dir, ids[i])
+		if err != nil {
+			continue // Removed while listing, or not a record
+		}
+		if filter == "" || strings.Contains(strings.ToLower(execution.Name), filter) {
+			executions = append(executions, *execution)
+		}
+	}
+	return executions, nil
+}
+
+// GetExecution returns a recorded execution and the script it ran
+func GetExecution(id string) (*Execution, string, error) {
+	id = strings.TrimSpace(id)
+	if id == "" || strings.ContainsAny(id, `/\`) || strings.Contains(id, "..") {
+		return nil, "", fmt.Errorf("invalid execution ID %q", id)
+	}
+	dir, err := HistoryDir()
+	if err != nil {
+		return nil, "", err
+	}
+	execution, err := readExecution(dir, id)
+	if err != nil {
+		return nil, "", fmt.Errorf("no execution %q in the history; use 'list_executions' to see them", id)
+	}
+	script, err := backend.FS.ReadFile(filepath.Join(dir, id+".lua"))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read the script of execution %s: %w", id, err)
+	}
+	return execution, string(script), nil
+}
+
+// readExecution reads the record of one execution
+func readExecution(dir, id string) (*Execution, error) {
+	data, err := backend.FS.ReadFile(filepath.Join(dir, id+".json"))
+	if err != nil {
+		return nil, err
+	}
+	var execution Execution
+	if err := json.Unmarshal(data, &execution); err != nil {
+		return nil, err
+	}
+	return &execution, nil
+}
+
+// FormatExecutions formats recorded executions as a list, newest first
+func FormatExecutions(executions []Execution) string {
+	if len(executions) == 0 {
+		return "No generated scripts have been run yet."
+	}
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%d recent execution(s), newest first:\n\n", len(executions)))
+	for _, e := range executions {
+		icon := "✅"
+		switch e.Outcome {
+		case OutcomeError:
+			icon = "❌"
+		case OutcomeTimeout, OutcomeCancelled:
+			icon = "⏱️"
+		}
+		b.WriteString(fmt.Sprintf("%s %s  %s  %s (%dms)", icon, e.ID, e.Time.Format("2006-01-02 15:04:05"), e.Name, e.DurationMs))
+		if e.Error != "" {
+			b.WriteString(": " + strings.SplitN(e.Error, "\n", 2)[0])
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\nUse 'list_executions' with name=<id> to see an execution's script and output.")
+	return b.String()
+}
+
+// FormatExecution formats one execution with its script
+func FormatExecution(e *Execution, script string) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Execution %s\n", e.ID))
+	b.WriteString(fmt.Sprintf("  Script: %s\n", e.Name))
+	b.WriteString(fmt.Sprintf("  Ran at: %s (%dms)\n", e.Time.Format("2006-01-02 15:04:05"), e.DurationMs))
+	b.WriteString(fmt.Sprintf("  Outcome: %s\n", e.Outcome))
+	if e.Error != "" {
+		b.WriteString(fmt.Sprintf("  Error: %s\n", e.Error))
+	}
+	if e.Output != "" {
+		b.WriteString("\nOutput:\n" + e.Output + "\n")
+	}
+	b.WriteString("\nScript:\n```lua\n" + strings.TrimRight(script, "\n") + "\n```")
+	return b.String()
+}
@@ -0,0 +1,86 @@
+package confirm
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/errcode"
+	"github.com/johnjallday/ori-reaper-plugin/internal/i18n"
+)
+
+// DefaultTTL is how long a pending operation waits for confirmation
+const DefaultTTL = 5 * time.Minute
+
+// Pending is a high-risk operation waiting for the caller to confirm it
+type Pending struct {
+	Token     string    `json:"token"`
+	Operation string    `json:"operation"`
+	Args      string    `json:"-"` // The original call arguments, replayed on confirmation
+	Summary   string    `json:"summary"`
+	Expires   time.Time `json:"expires"`
+}
+
+// Store holds pending operations until they are confirmed or expire
+type Store struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	pending map[string]*Pending
+}
+
+// NewStore creates a store whose pending operations expire after ttl
+func NewStore(ttl time.Duration) *Store {
+	return &Store{ttl: ttl, pending: make(map[string]*Pending)}
+}
+
+// Add records an operation and returns the pending entry with its confirmation token
+func (s *Store) Add(operation, args, summary string) (*Pending, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, fmt.Errorf("failed to generate confirmation token: %w", err)
+	}
+
+	p := &Pending{
+		Token:     hex.EncodeToString(buf),
+		Operation: operation,
+		Args:      args,
+		Summary:   summary,
+		Expires:   time.Now().Add(s.ttl),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.purgeExpired()
+	s.pending[p.Token] = p
+	return p, nil
+}
+
+// Take removes and returns the pending operation for token.
+// A token can only be used once, and only for the operation it was issued for.
+func (s *Store) Take(token, operation string) (*Pending, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.purgeExpired()
+
+	p, ok := s.pending[token]
+	if !ok {
+		return nil, errcode.New(errcode.InvalidConfirmation, i18n.Errorf("confirm.unknown_token"))
+	}
+	if operation != "" && p.Operation != operation {
+		return nil, errcode.New(errcode.InvalidConfirmation, i18n.Errorf("confirm.wrong_operation", p.Operation, operation))
+	}
+	delete(s.pending, token)
+	return p, nil
+}
+
+// purgeExpired drops pending operations past their expiry; callers hold s.mu
+func (s *Store) purgeExpired() {
+	now := time.Now()
+	for token, p := range s.pending {
+		if now.After(p.Expires) {
+			delete(s.pending, token)
+		}
+	}
+}
@@ -1,19 +1,46 @@
 package context
 
 import (
+	gocontext "context"
 	"fmt"
-	"os"
-	"os/exec"
 	"path/filepath"
-	"runtime"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/johnjallday/ori-reaper-plugin/internal/bridge"
 	"github.com/johnjallday/ori-reaper-plugin/internal/platform"
+	"github.com/johnjallday/ori-reaper-plugin/internal/project"
+	"github.com/johnjallday/ori-reaper-plugin/internal/scripts"
 )
 
-// GetREAPERContext retrieves the current REAPER context (project name, state, etc.)
-func GetREAPERContext() (*REAPERContext, error) {
+// lastProject is the project directory REAPER last reported and when, so callers that
+// only need the project can skip asking again
+var lastProject struct {
+	mu  sync.Mutex
+	dir string
+	at  time.Time
+}
+
+// LastProjectDir returns the directory of the project REAPER last reported ("" for none)
+// and when it was reported. The time is zero if REAPER hasn't been asked yet.
+func LastProjectDir() (string, time.Time) {
+	lastProject.mu.Lock()
+	defer lastProject.mu.Unlock()
+	return lastProject.dir, lastProject.at
+}
+
+// rememberProjectDir records dir as the project directory REAPER reported
+func rememberProjectDir(dir string) {
+	lastProject.mu.Lock()
+	defer lastProject.mu.Unlock()
+	lastProject.dir = dir
+	lastProject.at = time.Now()
+}
+
+// GetREAPERContext retrieves the current REAPER context (project name, state, etc.).
+// goctx cancels the query to REAPER.
+func GetREAPERContext(goctx gocontext.Context) (*REAPERContext, error) {
 	ctx := &REAPERContext{
 		LastChecked: time.Now(),
 	}
@@ -25,12 +52,19 @@ func GetREAPERContext() (*REAPERContext, error) {
 	}
 	ctx.IsRunning = running
 
+	// Python support depends on reaper.ini and the system, so report it even when REAPER is closed
+	ctx.Python = scripts.GetPythonStatus()
+	if !ctx.Python.CanRun {
+		ctx.Warnings = append(ctx.Warnings, "Python ReaScripts probably won't run: "+ctx.Python.Reason)
+	}
+
 	if !running {
+		rememberProjectDir("")
 		return ctx, nil
 	}
 
 	// Get project name and path by executing a temporary Lua script
-	projectName, projectPath, err := getProjectInfo()
+	projectName, projectPath, err := getProjectInfo(goctx)
 	if err != nil {
 		// REAPER is running but we couldn't get project info
 		// This is not a fatal error - return what we have
@@ -40,26 +74,51 @@ func GetREAPERContext() (*REAPERContext, error) {
 	ctx.ProjectName = projectName
 	ctx.ProjectPath = projectPath
 
-	return ctx, nil
-}
+	if master, err := scripts.GetMasterState(goctx); err == nil {
+		ctx.Master = master
+		if master.Mute {
+			ctx.Warnings = append(ctx.Warnings, "The master track is muted")
+		}
+	}
+	if modes, err := scripts.GetEditModes(goctx); err == nil {
+		ctx.EditModes = modes
+		if modes.Ripple != "off" {
+			ctx.Warnings = append(ctx.Warnings, "Ripple editing is on ("+modes.Ripple+"): edits shift later items")
+		}
+	}
 
-// getProjectInfo executes a temporary Lua script in REAPER to get the current project name and path
-func getProjectInfo() (string, string, error) {
-	// Create a temporary Lua script that writes project info to a temp file
-	tmpDir := os.TempDir()
-	scriptPath := filepath.Join(tmpDir, "ori_get_context.lua")
-	outputPath := filepath.Join(tmpDir, "ori_context_output.txt")
+	// Read sample rate and render settings from the saved project file
+	// Unsaved changes in REAPER are not reflected until the project is saved
+	if projectPath != "" {
+		if root, err := project.ParseFile(filepath.Join(projectPath, projectName)); err == nil {
+			ctx.Render = project.ReadRenderSettings(root)
+			ctx.Warnings = append(ctx.Warnings, ctx.Render.Warnings()...)
+		}
+	}
 
-	// Lua script that gets project info and writes to file
-	// Using proper escaping for the path
-	escapedOutputPath := strings.ReplaceAll(outputPath, "\\", "\\\\")
+	return ctx, nil
+}
 
-	luaScript := fmt.Sprintf(`-- Ori Context Reader
--- Get current project info and write to temp file
+// GetProjectDir returns the directory of the project open in REAPER, or "" when REAPER
+// isn't running or the project hasn't been saved
+func GetProjectDir(goctx gocontext.Context) (string, error) {
+	running, err := platform.IsReaperRunning()
+	if err != nil {
+		return "", fmt.Errorf("failed to check if REAPER is running: %w", err)
+	}
+	if !running {
+		rememberProjectDir("")
+		return "", nil
+	}
+	_, projectPath, err := getProjectInfo(goctx)
+	return projectPath, err
+}
 
--- Use EnumProjects to get the current project path and name
--- -1 refers to the currently active project
-local retval, project_full_path = reaper.EnumProjects(-1, "")
+// getProjectInfo executes a Lua bridge script in REAPER to get the current project name and path
+func getProjectInfo(goctx gocontext.Context) (string, string, error) {
+	// Use EnumProjects to get the current project path and name
+	// -1 refers to the currently active project
+	rows, err := bridge.Run(goctx, "get_context", `local retval, project_full_path = reaper.EnumProjects(-1, "")
 
 -- Extract just the filename from the full path
 local project_name = "untitled"
@@ -71,80 +130,27 @@ if project_full_path and project_full_path ~= "" then
     project_path = project_full_path:match("^(.+)[/\\]") or ""
 end
 
--- Write to output file
-local file = io.open("%s", "w")
-if file then
-    file:write(project_name .. "\n")
-    file:write(project_path .. "\n")
-    file:close()
-end
-`, escapedOutputPath)
-
-	// Write the Lua script to temp file
-	if err := os.WriteFile(scriptPath, []byte(luaScript), 0644); err != nil {
-		return "", "", fmt.Errorf("failed to write temp script: %w", err)
-	}
-	defer os.Remove(scriptPath)
-
-	// Remove old output file if it exists
-	os.Remove(outputPath)
-
-	// Execute the script in REAPER using the same method as LaunchScript
-	if err := executeScriptInREAPER(scriptPath); err != nil {
-		return "", "", fmt.Errorf("failed to execute script in REAPER: %w", err)
-	}
-
-	// Wait for REAPER to execute the script and write the file
-	time.Sleep(1 * time.Second)
-
-	// Read the output file
-	data, err := os.ReadFile(outputPath)
+out(project_name, project_path)
+`)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to read output file (REAPER may not have executed the script): %w", err)
+		return "", "", err
 	}
-	// Don't delete output file yet for debugging
-	// defer os.Remove(outputPath)
-
-	// Parse the output
-	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
-	if len(lines) < 1 {
+	if len(rows) < 1 {
 		return "", "", fmt.Errorf("unexpected output format: no data")
 	}
 
-	projectName := strings.TrimSpace(lines[0])
+	projectName := strings.TrimSpace(rows[0][0])
 	projectPath := ""
-	if len(lines) >= 2 {
-		projectPath = strings.TrimSpace(lines[1])
+	if len(rows[0]) >= 2 {
+		projectPath = strings.TrimSpace(rows[0][1])
 	}
 
 	// If project name is empty or untitled, indicate no project is open
 	if projectName == "" || projectName == "untitled" {
+		rememberProjectDir("")
 		return "No project open", "", nil
 	}
 
+	rememberProjectDir(projectPath)
 	return projectName, projectPath, nil
 }
-
-// executeScriptInREAPER executes a Lua script in REAPER using platform-specific methods
-// Uses the same approach as platform.LaunchScript
-func executeScriptInREAPER(scriptPath string) error {
-	switch runtime.GOOS {
-	case "darwin":
-		// macOS: open -a Reaper <script>
-		cmd := exec.Command("open", "-a", "Reaper", scriptPath)
-		return cmd.Run()
-
-	case "windows":
-		// Windows: start with the registered app
-		cmd := exec.Command("cmd", "/c", "start", "", scriptPath)
-		return cmd.Run()
-
-	case "linux":
-		// Linux: launch reaper with script
-		cmd := exec.Command("reaper", scriptPath)
-		return cmd.Run()
-
-	default:
-		return fmt.Errorf("unsupported platform: %s", runtime.GOOS)
-	}
-}
@@ -1,11 +1,21 @@
 package context
 
-import "time"
+import (
+	"time"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/project"
+	"github.com/johnjallday/ori-reaper-plugin/internal/scripts"
+)
 
 // REAPERContext represents the current state of REAPER
 type REAPERContext struct {
-	IsRunning   bool      `json:"is_running"`
-	ProjectName string    `json:"project_name,omitempty"`
-	ProjectPath string    `json:"project_path,omitempty"`
-	LastChecked time.Time `json:"last_checked"`
+	IsRunning   bool                    `json:"is_running"`
+	ProjectName string                  `json:"project_name,omitempty"`
+	ProjectPath string                  `json:"project_path,omitempty"`
+	Render      *project.RenderSettings `json:"render,omitempty"`     // Sample rate and render settings from the saved .RPP
+	Warnings    []string                `json:"warnings,omitempty"`   // Mismatches worth telling the user about
+	Python      *scripts.PythonStatus   `json:"python,omitempty"`     // Whether .py ReaScripts can run
+	Master      *scripts.MasterState    `json:"master,omitempty"`     // Master fader, mute and solo mode
+	EditModes   *scripts.EditModes      `json:"edit_modes,omitempty"` // Ripple, snap and grid settings
+	LastChecked time.Time               `json:"last_checked"`
 }
@@ -0,0 +1,91 @@
+// Package errcode gives errors machine-readable codes, so callers such as the agent can
+// branch on the kind of failure instead of parsing the message. A code survives wrapping
+// with fmt.Errorf's %w, and errors.Is(err, code) reports whether err carries it.
+package errcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Code identifies a kind of failure
+type Code string
+
+// Codes of the failures callers are expected to handle
+const (
+	ScriptNotFound       Code = "SCRIPT_NOT_FOUND"       // No script by that name, or the file is gone
+	ReaperNotRunning     Code = "REAPER_NOT_RUNNING"     // Start REAPER and retry
+	WebRemoteUnreachable Code = "WEB_REMOTE_UNREACHABLE" // REAPER's Web Remote didn't answer; see setup_web_remote
+	WebRemoteAuthFailed  Code = "WEB_REMOTE_AUTH_FAILED" // The Web Remote wants credentials, or rejected them
+	ConfigParseError     Code = "CONFIG_PARSE_ERROR"     // A settings, manifest or REAPER configuration file couldn't be read
+	InvalidParameters    Code = "INVALID_PARAMETERS"     // The call's arguments aren't valid JSON for the operation
+	UnknownOperation     Code = "UNKNOWN_OPERATION"
+	InvalidConfirmation  Code = "INVALID_CONFIRMATION" // The confirm_token is unknown, expired or for another operation
+	Timeout              Code = "TIMEOUT"              // The operation or REAPER took longer than allowed
+	OperationFailed      Code = "OPERATION_FAILED"     // Any failure without a more specific code
+)
+
+// Error returns the code itself, so a Code can be the target of errors.Is
+func (c Code) Error() string {
+	return string(c)
+}
+
+// Error is an error with a code
+type Error struct {
+	Code Code
+	Err  error
+}
+
+// Error returns the message of the underlying error; the code isn't part of it
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is e's code
+func (e *Error) Is(target error) bool {
+	code, ok := target.(Code)
+	return ok && code == e.Code
+}
+
+// New returns err with code, or nil if err is nil
+func New(code Code, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Code: code, Err: err}
+}
+
+// Errorf is fmt.Errorf with a code
+func Errorf(code Code, format string, args ...interface{}) error {
+	return &Error{Code: code, Err: fmt.Errorf(format, args...)}
+}
+
+// Lookup returns the code of the outermost coded error in err's chain. Errors from a
+// context deadline count as Timeout.
+func Lookup(err error) (Code, bool) {
+	var coded *Error
+	switch {
+	case errors.As(err, &coded):
+		return coded.Code, true
+	case errors.Is(err, context.DeadlineExceeded):
+		return Timeout, true
+	}
+	return "", false
+}
+
+// Of returns the code of err, OperationFailed for errors without one, or "" for nil
+func Of(err error) Code {
+	if err == nil {
+		return ""
+	}
+	if code, ok := Lookup(err); ok {
+		return code
+	}
+	return OperationFailed
+}
@@ -0,0 +1,220 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/scripts"
+	"github.com/johnjallday/ori-reaper-plugin/internal/types"
+)
+
+// webhookTimeout bounds each webhook request
+const webhookTimeout = 10 * time.Second
+
+// Result is the outcome of one hook on one file
+type Result struct {
+	File  string `json:"file"`
+	Hook  string `json:"hook"`
+	Error string `json:"error,omitempty"`
+}
+
+// RunPostRender runs every hook, in order, on each rendered file
+// A "move" hook changes the path seen by the hooks that follow it.
+func RunPostRender(ctx context.Context, hooks []types.PostRenderHook, files []string) []Result {
+	var results []Result
+	for _, file := range files {
+		current := file
+		for _, hook := range hooks {
+			result := Result{File: current, Hook: describe(hook)}
+			next, err := run(ctx, hook, current)
+			if err != nil {
+				result.Error = err.Error()
+			} else {
+				current = next
+			}
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// run executes a single hook and returns the file's path afterwards
+func run(ctx context.Context, hook types.PostRenderHook, file string) (string, error) {
+	switch strings.ToLower(hook.Type) {
+	case "command":
+		return file, runCommand(ctx, hook.Command, file)
+	case "move":
+		return moveFile(file, hook.Folder)
+	case "webhook":
+		return file, postWebhook(ctx, hook.URL, file)
+	default:
+		return file, fmt.Errorf("unknown hook type: %s (valid types: command, move, webhook)", hook.Type)
+	}
+}
+
+// describe returns a short label for a hook
+func describe(hook types.PostRenderHook) string {
+	switch strings.ToLower(hook.Type) {
+	case "command":
+		return "command: " + hook.Command
+	case "move":
+		return "move to " + hook.Folder
+	case "webhook":
+		return "webhook " + hook.URL
+	default:
+		return hook.Type
+	}
+}
+
+// runCommand runs a shell command with {file} replaced by the quoted file path
+// The path is also available as the ORI_RENDER_FILE environment variable.
+func runCommand(ctx context.Context, command, file string) error {
+	if strings.TrimSpace(command) == "" {
+		return fmt.Errorf("command hook has no command")
+	}
+	quoted, err := shellQuote(file)
+	if err != nil {
+		return err
+	}
+	command = strings.ReplaceAll(command, "{file}", quoted)
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/c", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Env = append(os.Environ(), "ORI_RENDER_FILE="+file)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// shellQuote quotes a path for the platform shell. cmd has no way to escape % inside a
+// command line (it doesn't collapse %%), so on Windows the path is read from
+// ORI_RENDER_FILE instead: cmd expands it once, without expanding any % in the path.
+func shellQuote(s string) (string, error) {
+	if runtime.GOOS == "windows" {
+		if strings.Contains(s, `"`) {
+			return "", fmt.Errorf("cannot pass %s to a command: it contains a double quote", s)
+		}
+		return `"%ORI_RENDER_FILE%"`, nil
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'", nil
+}
+
+// moveFile moves file into folder, copying across devices when a rename isn't possible
+func moveFile(file, folder string) (string, error) {
+	if strings.TrimSpace(folder) == "" {
+		return file, fmt.Errorf("move hook has no folder")
+	}
+	if err := os.MkdirAll(folder, 0755); err != nil {
+		return file, fmt.Errorf("failed to create %s: %w", folder, err)
+	}
+
+	dst := filepath.Join(folder, filepath.Base(file))
+	if _, err := os.Stat(dst); err == nil {
+		return file, fmt.Errorf("destination already exists: %s", dst)
+	}
+
+	if err := os.Rename(file, dst); err == nil {
+		return dst, nil
+	}
+
+	// Rename fails across volumes (e.g. to a network share); copy then remove
+	in, err := os.Open(file)
+	if err != nil {
+		return file, err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return file, err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return file, err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return file, err
+	}
+	in.Close()
+	if err := os.Remove(file); err != nil {
+		return dst, fmt.Errorf("copied to %s but failed to remove original: %w", dst, err)
+	}
+	return dst, nil
+}
+
+// postWebhook sends a JSON notification about the rendered file
+func postWebhook(ctx context.Context, url, file string) error {
+	if strings.TrimSpace(url) == "" {
+		return fmt.Errorf("webhook hook has no url")
+	}
+
+	payload := map[string]interface{}{
+		"event": "render_complete",
+		"file":  file,
+		"name":  filepath.Base(file),
+	}
+	if info, err := os.Stat(file); err == nil {
+		payload["size"] = info.Size()
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid webhook url: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := scripts.HTTPClient(webhookTimeout).Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// FormatResults formats hook results as a readable summary
+func FormatResults(results []Result) string {
+	if len(results) == 0 {
+		return ""
+	}
+
+	var out strings.Builder
+	failed := 0
+	out.WriteString("Post-render hooks:\n")
+	for _, r := range results {
+		if r.Error != "" {
+			failed++
+			out.WriteString(fmt.Sprintf("  ✗ %s on %s: %s\n", r.Hook, filepath.Base(r.File), r.Error))
+		} else {
+			out.WriteString(fmt.Sprintf("  ✓ %s on %s\n", r.Hook, filepath.Base(r.File)))
+		}
+	}
+	if failed > 0 {
+		out.WriteString(fmt.Sprintf("%d of %d hook run(s) failed\n", failed, len(results)))
+	}
+	return out.String()
+}
@@ -0,0 +1,107 @@
+// Package i18n translates the plugin's user-facing messages. Messages are looked up by key
+// in the catalog of the selected locale, embedded from locales/<locale>.json, falling back
+// to English for locales or keys without a translation. Catalog entries are fmt formats;
+// they use explicit argument indexes such as %[2]s so translations can reorder arguments.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// DefaultLocale is the locale used when none is set or the one set isn't supported
+const DefaultLocale = "en"
+
+// Locales lists the supported locales
+var Locales = []string{"en", "ko", "ja", "de"}
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+var (
+	mu       sync.RWMutex
+	locale   = DefaultLocale
+	catalogs = loadCatalogs()
+)
+
+// loadCatalogs reads the embedded catalog of every supported locale. A broken catalog is a
+// build mistake, so it panics rather than silently showing keys.
+func loadCatalogs() map[string]map[string]string {
+	loaded := make(map[string]map[string]string, len(Locales))
+	for _, name := range Locales {
+		data, err := localeFiles.ReadFile("locales/" + name + ".json")
+		if err != nil {
+			panic(fmt.Sprintf("i18n: missing catalog for %s: %v", name, err))
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Sprintf("i18n: invalid catalog for %s: %v", name, err))
+		}
+		loaded[name] = messages
+	}
+	return loaded
+}
+
+// Normalize returns the supported locale matching a locale name such as "ko", "ja-JP" or
+// "de_DE.UTF-8", or DefaultLocale
+func Normalize(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if i := strings.IndexAny(name, "-_."); i >= 0 {
+		name = name[:i]
+	}
+	if _, ok := catalogs[name]; ok {
+		return name
+	}
+	return DefaultLocale
+}
+
+// SetLocale selects the locale messages are translated to; see Normalize
+func SetLocale(name string) {
+	mu.Lock()
+	defer mu.Unlock()
+	locale = Normalize(name)
+}
+
+// Locale returns the selected locale
+func Locale() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return locale
+}
+
+// format returns the catalog entry for key in the selected locale
+func format(key string) string {
+	if message, ok := catalogs[Locale()][key]; ok {
+		return message
+	}
+	if message, ok := catalogs[DefaultLocale][key]; ok {
+		return message
+	}
+	return key
+}
+
+// T returns the message for key in the selected locale, formatted with args
+func T(key string, args ...interface{}) string {
+	if len(args) == 0 {
+		return format(key)
+	}
+	return fmt.Sprintf(format(key), args...)
+}
+
+// Errorf is fmt.Errorf with the message for key in the selected locale, so %w wraps as usual
+func Errorf(key string, args ...interface{}) error {
+	return fmt.Errorf(format(key), args...)
+}
+
+// Error is an error whose message is looked up when it is shown, so a sentinel error
+// created at startup follows later locale changes. Errors with the same key are equal,
+// which keeps errors.Is working.
+type Error string
+
+// Error returns the message in the selected locale
+func (e Error) Error() string {
+	return T(string(e))
+}
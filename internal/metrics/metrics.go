@@ -0,0 +1,261 @@
+// Package metrics counts what the plugin does while it runs: operations and their
+// latencies, Web Remote and GitHub requests and their errors, and script list cache hits.
+// Collection is off until enabled and the counts are kept in memory only, since the
+// plugin started or metrics were last enabled.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Outcomes of an operation
+const (
+	OutcomeOK      = "ok"
+	OutcomeError   = "error"
+	OutcomeTimeout = "timeout"
+)
+
+// OperationStats summarizes the runs of one operation
+type OperationStats struct {
+	Count    int64   `json:"count"`
+	Errors   int64   `json:"errors"`   // Runs that failed, including timeouts
+	Timeouts int64   `json:"timeouts"` // Runs stopped by the operation timeout
+	TotalMs  float64 `json:"total_ms"`
+	AvgMs    float64 `json:"avg_ms"`
+	MaxMs    float64 `json:"max_ms"`
+}
+
+// RequestStats counts the requests made to a service and how many failed
+type RequestStats struct {
+	Requests  int64   `json:"requests"`
+	Errors    int64   `json:"errors"`
+	ErrorRate float64 `json:"error_rate"` // Errors per request, 0 to 1
+}
+
+// CacheStats counts cache lookups
+type CacheStats struct {
+	Hits    int64   `json:"hits"`
+	Misses  int64   `json:"misses"`
+	HitRate float64 `json:"hit_rate"` // Hits per lookup, 0 to 1
+}
+
+// Snapshot is the metrics collected since Since
+type Snapshot struct {
+	Enabled     bool                      `json:"enabled"`
+	Since       time.Time                 `json:"since"`
+	Operations  map[string]OperationStats `json:"operations"`
+	WebRemote   RequestStats              `json:"web_remote"`
+	GitHub      RequestStats              `json:"github"`       // GitHub API listings and script downloads
+	ScriptCache CacheStats                `json:"script_cache"` // Lookups of the parsed script list
+}
+
+var (
+	mu          sync.Mutex
+	enabled     bool
+	since       = time.Now()
+	operations  = make(map[string]*OperationStats)
+	webRemote   RequestStats
+	gitHub      RequestStats
+	scriptCache CacheStats
+)
+
+// SetEnabled turns collection on or off. Turning it on after it was off starts the
+// counts afresh.
+func SetEnabled(on bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	if on && !enabled {
+		since = time.Now()
+		operations = make(map[string]*OperationStats)
+		webRemote, gitHub, scriptCache = RequestStats{}, RequestStats{}, CacheStats{}
+	}
+	enabled = on
+}
+
+// ObserveOperation records a run of an operation that took d and ended with outcome
+func ObserveOperation(name string, d time.Duration, outcome string) {
+	mu.Lock()
+	defer mu.Unlock()
+	if !enabled {
+		return
+	}
+	stats := operations[name]
+	if stats == nil {
+		stats = &OperationStats{}
+		operations[name] = stats
+	}
+	ms := float64(d.Microseconds()) / 1000
+	stats.Count++
+	stats.TotalMs += ms
+	stats.MaxMs = max(stats.MaxMs, ms)
+	switch outcome {
+	case OutcomeTimeout:
+		stats.Timeouts++
+		stats.Errors++
+	case OutcomeError:
+		stats.Errors++
+	}
+}
+
+// CountWebRemote records a request to REAPER's Web Remote and whether it failed
+func CountWebRemote(failed bool) {
+	countRequest(&webRemote, failed)
+}
+
+// CountGitHub records a request to GitHub and whether it failed
+func CountGitHub(failed bool) {
+	countRequest(&gitHub, failed)
+}
+
+// countRequest adds a request to stats
+func countRequest(stats *RequestStats, failed bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	if !enabled {
+		return
+	}
+	stats.Requests++
+	if failed {
+		stats.Errors++
+	}
+}
+
+// CountScriptCache records a lookup of the script list cache
+func CountScriptCache(hit bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	if !enabled {
+		return
+	}
+	if hit {
+		scriptCache.Hits++
+	} else {
+		scriptCache.Misses++
+	}
+}
+
+// Get returns the metrics collected so far
+func Get() Snapshot {
+	mu.Lock()
+	defer mu.Unlock()
+	snapshot := Snapshot{
+		Enabled:     enabled,
+		Since:       since,
+		Operations:  make(map[string]OperationStats, len(operations)),
+		WebRemote:   webRemote,
+		GitHub:      gitHub,
+		ScriptCache: scriptCache,
+	}
+	for name, stats := range operations {
+		s := *stats
+		s.AvgMs = s.TotalMs / float64(s.Count)
+		snapshot.Operations[name] = s
+	}
+	for _, stats := range []*RequestStats{&snapshot.WebRemote, &snapshot.GitHub} {
+		if stats.Requests > 0 {
+			stats.ErrorRate = float64(stats.Errors) / float64(stats.Requests)
+		}
+	}
+	if lookups := scriptCache.Hits + scriptCache.Misses; lookups > 0 {
+		snapshot.ScriptCache.HitRate = float64(scriptCache.Hits) / float64(lookups)
+	}
+	return snapshot
+}
+
+// operationNames returns the operations in s, sorted
+func (s Snapshot) operationNames() []string {
+	names := make([]string, 0, len(s.Operations))
+	for name := range s.Operations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Format formats a snapshot as readable text
+func Format(s Snapshot) string {
+	if !s.Enabled {
+		return "Metrics are off. Set \"metrics\": true in the plugin settings to collect them."
+	}
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("📈 Metrics since %s (%s)\n", s.Since.Format("2006-01-02 15:04:05"), time.Since(s.Since).Round(time.Second)))
+
+	b.WriteString("\nOperations:\n")
+	if len(s.Operations) == 0 {
+		b.WriteString("  none yet\n")
+	}
+	for _, name := range s.operationNames() {
+		stats := s.Operations[name]
+		b.WriteString(fmt.Sprintf("  • %s: %d run(s), avg %.0fms, max %.0fms", name, stats.Count, stats.AvgMs, stats.MaxMs))
+		if stats.Errors > 0 {
+			b.WriteString(fmt.Sprintf(", %d error(s)", stats.Errors))
+			if stats.Timeouts > 0 {
+				b.WriteString(fmt.Sprintf(" (%d timeout(s))", stats.Timeouts))
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString(fmt.Sprintf("\nWeb Remote: %d request(s), %d error(s) (%.1f%%)\n", s.WebRemote.Requests, s.WebRemote.Errors, s.WebRemote.ErrorRate*100))
+	b.WriteString(fmt.Sprintf("GitHub: %d request(s), %d error(s) (%.1f%%)\n", s.GitHub.Requests, s.GitHub.Errors, s.GitHub.ErrorRate*100))
+	b.WriteString(fmt.Sprintf("Script list cache: %d hit(s), %d miss(es) (%.1f%% hit rate)", s.ScriptCache.Hits, s.ScriptCache.Misses, s.ScriptCache.HitRate*100))
+	return b.String()
+}
+
+// WritePrometheus writes a snapshot in the Prometheus text exposition format
+func WritePrometheus(w io.Writer, s Snapshot) error {
+	var b strings.Builder
+	metric := func(name, kind, help string) {
+		b.WriteString(fmt.Sprintf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind))
+	}
+
+	metric("ori_reaper_metrics_start_time_seconds", "gauge", "When metrics collection started, as a Unix time.")
+	b.WriteString(fmt.Sprintf("ori_reaper_metrics_start_time_seconds %d\n", s.Since.Unix()))
+
+	names := s.operationNames()
+	metric("ori_reaper_operations_total", "counter", "Operations run.")
+	for _, name := range names {
+		b.WriteString(fmt.Sprintf("ori_reaper_operations_total{operation=%q} %d\n", name, s.Operations[name].Count))
+	}
+	metric("ori_reaper_operation_errors_total", "counter", "Operations that failed, including timeouts.")
+	for _, name := range names {
+		b.WriteString(fmt.Sprintf("ori_reaper_operation_errors_total{operation=%q} %d\n", name, s.Operations[name].Errors))
+	}
+	metric("ori_reaper_operation_timeouts_total", "counter", "Operations stopped by their timeout.")
+	for _, name := range names {
+		b.WriteString(fmt.Sprintf("ori_reaper_operation_timeouts_total{operation=%q} %d\n", name, s.Operations[name].Timeouts))
+	}
+	metric("ori_reaper_operation_duration_seconds", "summary", "How long operations took.")
+	for _, name := range names {
+		stats := s.Operations[name]
+		b.WriteString(fmt.Sprintf("ori_reaper_operation_duration_seconds_sum{operation=%q} %g\n", name, stats.TotalMs/1000))
+		b.WriteString(fmt.Sprintf("ori_reaper_operation_duration_seconds_count{operation=%q} %d\n", name, stats.Count))
+	}
+	metric("ori_reaper_operation_duration_seconds_max", "gauge", "The longest run of each operation.")
+	for _, name := range names {
+		b.WriteString(fmt.Sprintf("ori_reaper_operation_duration_seconds_max{operation=%q} %g\n", name, s.Operations[name].MaxMs/1000))
+	}
+
+	for _, service := range []struct {
+		name, title string
+		stats       RequestStats
+	}{{"web_remote", "REAPER's Web Remote", s.WebRemote}, {"github", "GitHub", s.GitHub}} {
+		metric("ori_reaper_"+service.name+"_requests_total", "counter", "Requests made to "+service.title+".")
+		b.WriteString(fmt.Sprintf("ori_reaper_%s_requests_total %d\n", service.name, service.stats.Requests))
+		metric("ori_reaper_"+service.name+"_errors_total", "counter", "Requests to "+service.title+" that failed.")
+		b.WriteString(fmt.Sprintf("ori_reaper_%s_errors_total %d\n", service.name, service.stats.Errors))
+	}
+
+	metric("ori_reaper_script_cache_hits_total", "counter", "Script list lookups answered from the cache.")
+	b.WriteString(fmt.Sprintf("ori_reaper_script_cache_hits_total %d\n", s.ScriptCache.Hits))
+	metric("ori_reaper_script_cache_misses_total", "counter", "Script list lookups that read the scripts directory.")
+	b.WriteString(fmt.Sprintf("ori_reaper_script_cache_misses_total %d\n", s.ScriptCache.Misses))
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,126 @@
+package platform
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// FS is the file system access used for scripts, metadata and REAPER config files.
+// Replacing it lets the packages run against a fake or remote file system.
+type FS interface {
+	Open(name string) (fs.File, error)
+	ReadFile(name string) ([]byte, error)
+	WriteFile(name string, data []byte, perm fs.FileMode) error
+	Stat(name string) (fs.FileInfo, error)
+	ReadDir(name string) ([]fs.DirEntry, error)
+	Rename(oldpath, newpath string) error
+	Remove(name string) error
+	MkdirAll(path string, perm fs.FileMode) error
+}
+
+// ProcessChecker reports whether REAPER is running
+type ProcessChecker interface {
+	IsReaperRunning() (bool, error)
+}
+
+// Launcher starts REAPER and runs scripts in it
+type Launcher interface {
+	ExecuteScript(ctx context.Context, scriptPath string) error
+	LaunchReaper() error
+	QuitReaper(ctx context.Context, timeout time.Duration) error
+}
+
+// Backend bundles the file system and process access a component depends on
+type Backend struct {
+	FS        FS
+	Processes ProcessChecker
+	Launcher  Launcher
+}
+
+// DefaultBackend returns the backend for the local machine
+func DefaultBackend() Backend {
+	return Backend{FS: OSFS{}, Processes: System{}, Launcher: System{}}
+}
+
+// WithDefaults fills unset fields of b from DefaultBackend
+func (b Backend) WithDefaults() Backend {
+	def := DefaultBackend()
+	if b.FS == nil {
+		b.FS = def.FS
+	}
+	if b.Processes == nil {
+		b.Processes = def.Processes
+	}
+	if b.Launcher == nil {
+		b.Launcher = def.Launcher
+	}
+	return b
+}
+
+// IsLocal reports whether fsys is the local file system
+func IsLocal(fsys FS) bool {
+	_, ok := fsys.(OSFS)
+	return ok
+}
+
+// exclusiveCreator is implemented by file systems that can create a file only if it
+// doesn't exist yet, atomically
+type exclusiveCreator interface {
+	CreateExclusive(name string, data []byte, perm fs.FileMode) error
+}
+
+// CreateExclusive writes a new file, failing with an error matching fs.ErrExist if name
+// already exists. File systems without atomic exclusive creation fall back to a Stat
+// check, which can race with other writers.
+func CreateExclusive(fsys FS, name string, data []byte, perm fs.FileMode) error {
+	if creator, ok := fsys.(exclusiveCreator); ok {
+		return creator.CreateExclusive(name, data, perm)
+	}
+	if _, err := fsys.Stat(name); err == nil {
+		return &fs.PathError{Op: "create", Path: name, Err: fs.ErrExist}
+	}
+	return fsys.WriteFile(name, data, perm)
+}
+
+// OSFS is the local file system, backed by package os
+type OSFS struct{}
+
+func (OSFS) Open(name string) (fs.File, error)            { return os.Open(name) }
+func (OSFS) ReadFile(name string) ([]byte, error)         { return os.ReadFile(name) }
+func (OSFS) Stat(name string) (fs.FileInfo, error)        { return os.Stat(name) }
+func (OSFS) ReadDir(name string) ([]fs.DirEntry, error)   { return os.ReadDir(name) }
+func (OSFS) Rename(oldpath, newpath string) error         { return os.Rename(oldpath, newpath) }
+func (OSFS) Remove(name string) error                     { return os.Remove(name) }
+func (OSFS) MkdirAll(path string, perm fs.FileMode) error { return os.MkdirAll(path, perm) }
+func (OSFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+
+// CreateExclusive uses O_EXCL, which SMB and NFSv3+ shares honor across machines
+func (OSFS) CreateExclusive(name string, data []byte, perm fs.FileMode) error {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(name)
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return f.Close()
+}
+
+// System checks and launches the locally installed REAPER
+type System struct{}
+
+func (System) IsReaperRunning() (bool, error) { return IsReaperRunning() }
+func (System) LaunchReaper() error            { return LaunchReaper() }
+func (System) ExecuteScript(ctx context.Context, scriptPath string) error {
+	return ExecuteScript(ctx, scriptPath)
+}
+func (System) QuitReaper(ctx context.Context, timeout time.Duration) error {
+	return QuitReaper(ctx, timeout)
+}
@@ -1,6 +1,7 @@
 package platform
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -8,7 +9,9 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
+	"github.com/johnjallday/ori-reaper-plugin/internal/errcode"
 	"github.com/shirou/gopsutil/v3/process"
 )
 
@@ -58,33 +61,103 @@ func IsReaperRunning() (bool, error) {
 }
 
 // LaunchScript launches a REAPER script using platform-specific methods
-func LaunchScript(scriptsDir, base string) error {
+func LaunchScript(ctx context.Context, scriptsDir, base string) error {
 	scriptPath := filepath.Join(scriptsDir, base+".lua")
 
 	// Verify the script exists
 	if _, err := os.Stat(scriptPath); err != nil {
 		if errors.Is(err, os.ErrNotExist) {
-			return fmt.Errorf("script not found: %s", scriptPath)
+			return errcode.Errorf(errcode.ScriptNotFound, "script not found: %s", scriptPath)
 		}
 		return err
 	}
 
+	return ExecuteScript(ctx, scriptPath)
+}
+
+// ExecuteScript opens a script file in REAPER using platform-specific methods.
+// The launch command is killed if ctx is done first.
+func ExecuteScript(ctx context.Context, scriptPath string) error {
 	switch runtime.GOOS {
 	case "darwin":
 		// macOS: open -a Reaper <script>
-		cmd := exec.Command("open", "-a", "Reaper", scriptPath)
+		cmd := exec.CommandContext(ctx, "open", "-a", "Reaper", scriptPath)
 		return cmd.Run()
 
 	case "windows":
 		// Best effort: try to open with the registered app (REAPER) using "start".
 		// Note: requires proper association; otherwise, customize to call the REAPER exe with args.
-		cmd := exec.Command("cmd", "/c", "start", "", scriptPath)
+		cmd := exec.CommandContext(ctx, "cmd", "/c", "start", "", scriptPath)
 		return cmd.Run()
 
 	default: // linux
 		// If REAPER is in PATH and supports opening scripts directly
 		// you may need to adjust this depending on your REAPER install.
-		cmd := exec.Command("reaper", scriptPath)
+		cmd := exec.CommandContext(ctx, "reaper", scriptPath)
 		return cmd.Run()
 	}
 }
+
+// QuitReaper asks REAPER to quit normally (so it can prompt to save) and waits up to
+// timeout for the process to exit, or until ctx is done
+func QuitReaper(ctx context.Context, timeout time.Duration) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.CommandContext(ctx, "osascript", "-e", `quit app "REAPER"`)
+	case "windows":
+		// Without /F, taskkill sends a close request rather than terminating the process
+		cmd = exec.CommandContext(ctx, "taskkill", "/IM", "reaper.exe")
+	default:
+		return errors.New("quitting REAPER automatically is not supported on this platform; please restart REAPER manually")
+	}
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to ask REAPER to quit: %w", err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		running, err := IsReaperRunning()
+		if err != nil {
+			return err
+		}
+		if !running {
+			return nil
+		}
+		if err := Sleep(ctx, 500*time.Millisecond); err != nil {
+			return err
+		}
+	}
+	return fmt.Errorf("REAPER did not quit within %s (is a save dialog open?)", timeout)
+}
+
+// LaunchReaper starts REAPER without waiting for it to exit
+func LaunchReaper() error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", "-a", "Reaper")
+	case "windows":
+		cmd = exec.Command("cmd", "/c", "start", "", "reaper.exe")
+	default:
+		cmd = exec.Command("reaper")
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to launch REAPER: %w", err)
+	}
+	// Reap the child in the background where it stays attached (linux)
+	go cmd.Wait()
+	return nil
+}
+
+// Sleep waits for d, returning ctx's error early if ctx is done first
+func Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
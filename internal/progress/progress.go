@@ -0,0 +1,57 @@
+// Package progress reports the steps of long-running operations, such as renders,
+// archives and bundle installs, while they run. The reporter travels in the operation's
+// context so any code it calls can report without extra parameters.
+package progress
+
+import (
+	"context"
+	"fmt"
+)
+
+// Update is one step of an operation's progress
+type Update struct {
+	Step  string `json:"step"`            // What is happening, e.g. "Copying Audio/vox.wav"
+	Done  int    `json:"done"`            // Items finished so far
+	Total int    `json:"total,omitempty"` // Items in all, or 0 when unknown
+}
+
+// Percent returns how far the operation has got, or -1 when the total is unknown
+func (u Update) Percent() int {
+	if u.Total <= 0 {
+		return -1
+	}
+	return u.Done * 100 / u.Total
+}
+
+// String formats an update as a single status line
+func (u Update) String() string {
+	if u.Total <= 0 {
+		return u.Step
+	}
+	return fmt.Sprintf("%3d%% (%d/%d) %s", u.Percent(), u.Done, u.Total, u.Step)
+}
+
+// Func receives progress updates; it may be called from several goroutines
+type Func func(Update)
+
+// contextKey is the context key of the reporter
+type contextKey struct{}
+
+// WithFunc returns a copy of ctx that reports progress to report
+func WithFunc(ctx context.Context, report Func) context.Context {
+	return context.WithValue(ctx, contextKey{}, report)
+}
+
+// Enabled reports whether ctx carries a reporter
+func Enabled(ctx context.Context) bool {
+	report, _ := ctx.Value(contextKey{}).(Func)
+	return report != nil
+}
+
+// Report sends an update to the reporter in ctx, if any. A total of 0 means the
+// number of items isn't known.
+func Report(ctx context.Context, step string, done, total int) {
+	if report, _ := ctx.Value(contextKey{}).(Func); report != nil {
+		report(Update{Step: step, Done: done, Total: total})
+	}
+}
@@ -0,0 +1,358 @@
+package project
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/progress"
+)
+
+// ArchiveOptions controls how a project is archived
+type ArchiveOptions struct {
+	Destination string // Target folder, or .zip file when Zip is set
+	Zip         bool   // Write a zip file instead of a folder
+	Trim        bool   // Only copy media the project references, skipping unused files in the project folder
+}
+
+// ArchiveResult summarizes an archive operation
+type ArchiveResult struct {
+	Destination string   `json:"destination"`
+	FilesCopied int      `json:"files_copied"`
+	TotalSize   int64    `json:"total_size"`
+	Missing     []string `json:"missing,omitempty"` // Referenced media that could not be found
+}
+
+// archiveWriter receives the files that make up an archive
+type archiveWriter interface {
+	copyFile(rel, src string) (int64, error)
+	writeData(rel string, data []byte) error
+	close() error
+}
+
+// Archive copies a project file and its media into a self-contained folder or zip.
+// Media outside the project folder is collected into a Media/ subfolder and the
+// archived .RPP is rewritten to point at the copies. Each copied file is reported as
+// progress, and the archive stops between files once ctx is done.
+func Archive(ctx context.Context, projectFile string, opts ArchiveOptions) (*ArchiveResult, error) {
+	if strings.TrimSpace(opts.Destination) == "" {
+		return nil, fmt.Errorf("destination is required for archiving")
+	}
+
+	root, err := ParseFile(projectFile)
+	if err != nil {
+		return nil, err
+	}
+
+	projectDir := filepath.Dir(projectFile)
+	destination, err := filepath.Abs(opts.Destination)
+	if err != nil {
+		return nil, fmt.Errorf("invalid destination: %w", err)
+	}
+	if opts.Zip && !strings.HasSuffix(strings.ToLower(destination), ".zip") {
+		destination += ".zip"
+	}
+
+	result := &ArchiveResult{Destination: destination}
+
+	// Plan where every file goes, keyed by source path
+	plan := make(map[string]string)
+	var order []string
+	used := make(map[string]bool)
+	addFile := func(src, rel string) {
+		if _, ok := plan[src]; ok {
+			return
+		}
+		rel = uniqueName(rel, used)
+		used[strings.ToLower(rel)] = true
+		plan[src] = rel
+		order = append(order, src)
+	}
+
+	for _, media := range MediaReferences(root, projectDir) {
+		if _, err := os.Stat(media.Path); err != nil {
+			result.Missing = append(result.Missing, media.Path)
+			continue
+		}
+		if rel, ok := relativeTo(projectDir, media.Path); ok {
+			addFile(media.Path, rel)
+		} else {
+			addFile(media.Path, filepath.Join("Media", filepath.Base(media.Path)))
+		}
+	}
+
+	if !opts.Trim {
+		err := filepath.WalkDir(projectDir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if path == destination {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if path == projectFile || path == destination {
+				return nil
+			}
+			rel, _ := filepath.Rel(projectDir, path)
+			addFile(path, rel)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan project folder: %w", err)
+		}
+	}
+
+	rewritten, err := rewriteMediaPaths(projectFile, projectDir, plan)
+	if err != nil {
+		return nil, err
+	}
+
+	var writer archiveWriter
+	if opts.Zip {
+		writer, err = newZipArchiveWriter(destination)
+	} else {
+		writer, err = newDirArchiveWriter(destination)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	total := len(order) + 1
+	progress.Report(ctx, "Writing "+filepath.Base(projectFile), 0, total)
+	if err := writer.writeData(filepath.Base(projectFile), rewritten); err != nil {
+		writer.close()
+		return nil, err
+	}
+	result.FilesCopied++
+
+	for _, src := range order {
+		if err := ctx.Err(); err != nil {
+			writer.close()
+			return nil, fmt.Errorf("archive stopped after %d of %d files; %s is incomplete: %w", result.FilesCopied, total, destination, err)
+		}
+		progress.Report(ctx, "Copying "+filepath.ToSlash(plan[src]), result.FilesCopied, total)
+		size, err := writer.copyFile(plan[src], src)
+		if err != nil {
+			writer.close()
+			return nil, fmt.Errorf("failed to copy %s: %w", src, err)
+		}
+		result.FilesCopied++
+		result.TotalSize += size
+	}
+
+	if err := writer.close(); err != nil {
+		return nil, err
+	}
+	progress.Report(ctx, fmt.Sprintf("Archived %d file(s) to %s", result.FilesCopied, destination), total, total)
+
+	return result, nil
+}
+
+// rewriteMediaPaths returns the project file with FILE references pointing at archived copies
+func rewriteMediaPaths(projectFile, projectDir string, plan map[string]string) ([]byte, error) {
+	file, err := os.Open(projectFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open project file: %w", err)
+	}
+	defer file.Close()
+
+	var out strings.Builder
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("failed to read project file: %w", err)
+		}
+
+		trimmed := strings.TrimLeft(line, " \t")
+		if strings.HasPrefix(trimmed, "FILE ") {
+			tokens := Tokenize(strings.TrimRight(trimmed, "\r\n"))
+			if len(tokens) >= 2 {
+				if rel, ok := plan[ResolveMediaPath(tokens[1], projectDir)]; ok {
+					indent := line[:len(line)-len(trimmed)]
+					ending := line[len(strings.TrimRight(line, "\r\n")):]
+					// REAPER always quotes file names
+					newLine := "FILE " + QuoteString(filepath.ToSlash(rel))
+					if len(tokens) > 2 {
+						newLine += " " + FormatLine(tokens[2:])
+					}
+					line = indent + newLine + ending
+				}
+			}
+		}
+		out.WriteString(line)
+
+		if err == io.EOF {
+			break
+		}
+	}
+
+	return []byte(out.String()), nil
+}
+
+// FormatLine joins values into an RPP line, quoting values that need it
+func FormatLine(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = Quote(v)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// Quote quotes an RPP value if it is empty or contains whitespace or quotes
+func Quote(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t\"'`") {
+		return value
+	}
+	return QuoteString(value)
+}
+
+// QuoteString always quotes an RPP value, choosing a quote character the value
+// doesn't contain the way REAPER does
+func QuoteString(value string) string {
+	for _, q := range []string{`"`, `'`, "`"} {
+		if !strings.Contains(value, q) {
+			return q + value + q
+		}
+	}
+	// REAPER replaces backticks when every quote character is used
+	return "`" + strings.ReplaceAll(value, "`", "'") + "`"
+}
+
+// relativeTo returns path relative to dir if path is inside dir
+func relativeTo(dir, path string) (string, bool) {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return rel, true
+}
+
+// uniqueName returns rel, or rel with a numeric suffix if the name is already taken
+func uniqueName(rel string, used map[string]bool) string {
+	if !used[strings.ToLower(rel)] {
+		return rel
+	}
+	ext := filepath.Ext(rel)
+	base := strings.TrimSuffix(rel, ext)
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s_%d%s", base, i, ext)
+		if !used[strings.ToLower(candidate)] {
+			return candidate
+		}
+	}
+}
+
+// dirArchiveWriter writes an archive into a folder
+type dirArchiveWriter struct {
+	dir string
+}
+
+func newDirArchiveWriter(dir string) (*dirArchiveWriter, error) {
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+		return nil, fmt.Errorf("destination folder is not empty: %s", dir)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create destination folder: %w", err)
+	}
+	return &dirArchiveWriter{dir: dir}, nil
+}
+
+func (w *dirArchiveWriter) copyFile(rel, src string) (int64, error) {
+	dst := filepath.Join(w.dir, rel)
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return 0, err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return 0, err
+	}
+	size, err := io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return size, err
+}
+
+func (w *dirArchiveWriter) writeData(rel string, data []byte) error {
+	return os.WriteFile(filepath.Join(w.dir, rel), data, 0644)
+}
+
+func (w *dirArchiveWriter) close() error {
+	return nil
+}
+
+// zipArchiveWriter writes an archive into a zip file
+type zipArchiveWriter struct {
+	file *os.File
+	zw   *zip.Writer
+}
+
+func newZipArchiveWriter(path string) (*zipArchiveWriter, error) {
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("destination already exists: %s", path)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create zip file: %w", err)
+	}
+	return &zipArchiveWriter{file: file, zw: zip.NewWriter(file)}, nil
+}
+
+func (w *zipArchiveWriter) copyFile(rel, src string) (int64, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+	entry, err := w.zw.Create(filepath.ToSlash(rel))
+	if err != nil {
+		return 0, err
+	}
+	return io.Copy(entry, in)
+}
+
+func (w *zipArchiveWriter) writeData(rel string, data []byte) error {
+	entry, err := w.zw.Create(filepath.ToSlash(rel))
+	if err != nil {
+		return err
+	}
+	_, err = entry.Write(data)
+	return err
+}
+
+func (w *zipArchiveWriter) close() error {
+	if err := w.zw.Close(); err != nil {
+		w.file.Close()
+		return fmt.Errorf("failed to finish zip file: %w", err)
+	}
+	return w.file.Close()
+}
+
+// FormatArchiveResult formats an archive result as a readable summary
+func FormatArchiveResult(result *ArchiveResult) string {
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf("Archived project to %s\n", result.Destination))
+	out.WriteString(fmt.Sprintf("  Files: %d\n", result.FilesCopied))
+	out.WriteString(fmt.Sprintf("  Media size: %s\n", FormatSize(result.TotalSize)))
+	if len(result.Missing) > 0 {
+		out.WriteString(fmt.Sprintf("\n⚠️ %d referenced file(s) were missing and not archived:\n", len(result.Missing)))
+		for _, path := range result.Missing {
+			out.WriteString(fmt.Sprintf("  - %s\n", path))
+		}
+	}
+	return out.String()
+}
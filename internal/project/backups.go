@@ -0,0 +1,143 @@
+package project
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BackupFile is a .rpp-bak or autosave file belonging to a project
+type BackupFile struct {
+	Path     string    `json:"path"`
+	Kind     string    `json:"kind"` // "backup" or "autosave"
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+}
+
+// backupDirs are the folders (relative to the project folder) where REAPER may write backups
+var backupDirs = []string{".", "Backups"}
+
+// ListBackups finds backup and autosave files for a project, newest first
+func ListBackups(projectFile string) ([]BackupFile, error) {
+	projectDir := filepath.Dir(projectFile)
+	base := strings.ToLower(strings.TrimSuffix(filepath.Base(projectFile), filepath.Ext(projectFile)))
+
+	var backups []BackupFile
+	for _, sub := range backupDirs {
+		dir := filepath.Join(projectDir, sub)
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+		}
+
+		for _, e := range entries {
+			if e.IsDir() {
+				continue
+			}
+			kind := backupKind(strings.ToLower(e.Name()), base)
+			if kind == "" {
+				continue
+			}
+			info, err := e.Info()
+			if err != nil {
+				continue
+			}
+			backups = append(backups, BackupFile{
+				Path:     filepath.Join(dir, e.Name()),
+				Kind:     kind,
+				Size:     info.Size(),
+				Modified: info.ModTime(),
+			})
+		}
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Modified.After(backups[j].Modified)
+	})
+
+	return backups, nil
+}
+
+// backupKind classifies a lower-cased file name as a backup of the project named base
+func backupKind(name, base string) string {
+	if !strings.HasPrefix(name, base) {
+		return ""
+	}
+	switch {
+	case strings.Contains(name, "autosave") && (strings.HasSuffix(name, ".rpp") || strings.HasSuffix(name, ".rpp-bak")):
+		return "autosave"
+	case strings.HasSuffix(name, ".rpp-bak"):
+		return "backup"
+	default:
+		return ""
+	}
+}
+
+// RestoreBackup copies a backup to a new .RPP file and returns its path
+// If destination is empty, the copy is written next to the backup as <name>-restored.RPP.
+// Existing files are never overwritten.
+func RestoreBackup(backupFile, destination string) (string, error) {
+	if strings.TrimSpace(backupFile) == "" {
+		return "", fmt.Errorf("backup file path is required")
+	}
+
+	if destination == "" {
+		name := strings.TrimSuffix(filepath.Base(backupFile), filepath.Ext(backupFile))
+		destination = filepath.Join(filepath.Dir(backupFile), name+"-restored.RPP")
+	}
+	if !strings.EqualFold(filepath.Ext(destination), ".rpp") {
+		destination += ".RPP"
+	}
+	if _, err := os.Stat(destination); err == nil {
+		return "", fmt.Errorf("destination already exists: %s", destination)
+	}
+
+	in, err := os.Open(backupFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to open backup: %w", err)
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(destination, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", destination, err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return "", fmt.Errorf("failed to copy backup: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", destination, err)
+	}
+
+	return destination, nil
+}
+
+// FormatBackups formats a list of backups as a readable table
+func FormatBackups(projectFile string, backups []BackupFile) string {
+	if len(backups) == 0 {
+		return fmt.Sprintf("No backups or autosaves found for %s", filepath.Base(projectFile))
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Found %d backup(s) for %s:\n\n", len(backups), filepath.Base(projectFile)))
+	result.WriteString("Modified         | Kind     | Size      | File\n")
+	result.WriteString("-----------------|----------|-----------|-----\n")
+	for _, b := range backups {
+		result.WriteString(fmt.Sprintf("%s | %-8s | %9s | %s\n",
+			b.Modified.Format("2006-01-02 15:04"),
+			b.Kind,
+			FormatSize(b.Size),
+			b.Path,
+		))
+	}
+	result.WriteString("\nTo restore one, use 'restore_backup' with its path.")
+	return result.String()
+}
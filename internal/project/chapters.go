@@ -0,0 +1,112 @@
+package project
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/timeutil"
+)
+
+// DefaultFrameRate is used for EDL timecode when no frame rate is given
+const DefaultFrameRate = 30
+
+// RegionFormats lists the formats supported by EncodeRegions
+var RegionFormats = []string{"youtube", "ffmpeg", "edl", "csv", "json"}
+
+// EncodeRegions exports project regions as YouTube chapters, FFmpeg chapter metadata,
+// a CMX3600 EDL, CSV or JSON. title is used for the EDL header.
+func EncodeRegions(markers []Marker, format, title string, frameRate float64) ([]byte, error) {
+	regions := Regions(markers)
+	if len(regions) == 0 {
+		return nil, fmt.Errorf("project has no regions to export")
+	}
+	sort.SliceStable(regions, func(i, j int) bool {
+		return regions[i].Position < regions[j].Position
+	})
+
+	switch strings.ToLower(format) {
+	case "", "youtube":
+		return []byte(youtubeChapters(regions)), nil
+	case "ffmpeg":
+		return []byte(ffmpegChapters(regions)), nil
+	case "edl":
+		if frameRate <= 0 {
+			frameRate = DefaultFrameRate
+		}
+		return []byte(edl(regions, title, frameRate)), nil
+	case "csv", "json":
+		return EncodeMarkers(regions, format)
+	default:
+		return nil, fmt.Errorf("unsupported region format: %s. Supported formats: %s", format, strings.Join(RegionFormats, ", "))
+	}
+}
+
+// youtubeChapters formats regions as a YouTube description chapter list
+// YouTube requires the first chapter to start at 0:00, so one is added if needed.
+func youtubeChapters(regions []Marker) string {
+	var result strings.Builder
+	if regions[0].Position >= 1 {
+		result.WriteString("0:00 Start\n")
+	}
+	for _, r := range regions {
+		result.WriteString(fmt.Sprintf("%s %s\n", youtubeTimestamp(r.Position), regionName(r)))
+	}
+	return result.String()
+}
+
+// youtubeTimestamp formats seconds as M:SS or H:MM:SS
+func youtubeTimestamp(seconds float64) string {
+	total := int(seconds)
+	h, m, s := total/3600, (total%3600)/60, total%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%d:%02d", m, s)
+}
+
+// ffmpegChapters formats regions as an FFMETADATA1 file for ffmpeg -i meta.txt -map_metadata
+func ffmpegChapters(regions []Marker) string {
+	var result strings.Builder
+	result.WriteString(";FFMETADATA1\n")
+	for _, r := range regions {
+		result.WriteString("\n[CHAPTER]\nTIMEBASE=1/1000\n")
+		result.WriteString(fmt.Sprintf("START=%d\n", int64(math.Round(r.Position*1000))))
+		result.WriteString(fmt.Sprintf("END=%d\n", int64(math.Round(r.End*1000))))
+		result.WriteString(fmt.Sprintf("title=%s\n", escapeFFMetadata(regionName(r))))
+	}
+	return result.String()
+}
+
+// escapeFFMetadata escapes the characters FFMETADATA treats specially
+func escapeFFMetadata(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, "=", `\=`, ";", `\;`, "#", `\#`, "\n", `\`+"\n")
+	return replacer.Replace(s)
+}
+
+// edl formats regions as a CMX3600 edit decision list with one event per region
+func edl(regions []Marker, title string, frameRate float64) string {
+	var result strings.Builder
+	if title == "" {
+		title = "REAPER Regions"
+	}
+	result.WriteString(fmt.Sprintf("TITLE: %s\n", title))
+	result.WriteString("FCM: NON-DROP FRAME\n\n")
+
+	for i, r := range regions {
+		start := timeutil.FormatTimecode(r.Position, frameRate)
+		end := timeutil.FormatTimecode(r.End, frameRate)
+		result.WriteString(fmt.Sprintf("%03d  AX       AA/V  C        %s %s %s %s\n", i+1, start, end, start, end))
+		result.WriteString(fmt.Sprintf("* FROM CLIP NAME: %s\n\n", regionName(r)))
+	}
+	return result.String()
+}
+
+// regionName returns a region's name, or a numbered placeholder if it has none
+func regionName(r Marker) string {
+	if strings.TrimSpace(r.Name) == "" {
+		return fmt.Sprintf("Region %d", r.Index)
+	}
+	return r.Name
+}
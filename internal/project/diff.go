@@ -0,0 +1,228 @@
+package project
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Diff summarizes the differences between two versions of a project
+type Diff struct {
+	OldFile       string      `json:"old_file"`
+	NewFile       string      `json:"new_file"`
+	AddedTracks   []string    `json:"added_tracks,omitempty"`
+	RemovedTracks []string    `json:"removed_tracks,omitempty"`
+	Tracks        []TrackDiff `json:"tracks,omitempty"` // Changes on tracks present in both files
+	TempoChanges  []string    `json:"tempo_changes,omitempty"`
+}
+
+// TrackDiff lists item and FX changes on a single track
+type TrackDiff struct {
+	Name         string   `json:"name"`
+	AddedItems   []string `json:"added_items,omitempty"`
+	RemovedItems []string `json:"removed_items,omitempty"`
+	AddedFX      []string `json:"added_fx,omitempty"`
+	RemovedFX    []string `json:"removed_fx,omitempty"`
+}
+
+// IsEmpty reports whether the diff found no changes
+func (d *Diff) IsEmpty() bool {
+	return len(d.AddedTracks) == 0 && len(d.RemovedTracks) == 0 &&
+		len(d.Tracks) == 0 && len(d.TempoChanges) == 0
+}
+
+// DiffFiles parses two project files and compares them
+func DiffFiles(oldFile, newFile string) (*Diff, error) {
+	oldRoot, err := ParseFile(oldFile)
+	if err != nil {
+		return nil, err
+	}
+	newRoot, err := ParseFile(newFile)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := DiffSummaries(Summarize(oldRoot), Summarize(newRoot))
+	diff.OldFile = oldFile
+	diff.NewFile = newFile
+	return diff, nil
+}
+
+// DiffSummaries compares two project summaries. Tracks are matched by name
+// (in order, for duplicate names); items by source and position; FX by name.
+func DiffSummaries(oldSummary, newSummary *Summary) *Diff {
+	diff := &Diff{}
+
+	if oldSummary.Tempo != newSummary.Tempo {
+		diff.TempoChanges = append(diff.TempoChanges, fmt.Sprintf("Base tempo %g → %g BPM", oldSummary.Tempo, newSummary.Tempo))
+	}
+	if oldSummary.TimeSignature != newSummary.TimeSignature {
+		diff.TempoChanges = append(diff.TempoChanges, fmt.Sprintf("Time signature %s → %s", oldSummary.TimeSignature, newSummary.TimeSignature))
+	}
+	added, removed := diffStrings(tempoKeys(oldSummary.TempoChanges), tempoKeys(newSummary.TempoChanges))
+	for _, point := range added {
+		diff.TempoChanges = append(diff.TempoChanges, "Added tempo marker "+point)
+	}
+	for _, point := range removed {
+		diff.TempoChanges = append(diff.TempoChanges, "Removed tempo marker "+point)
+	}
+
+	oldTracks := trackKeys(oldSummary.Tracks)
+	newTracks := trackKeys(newSummary.Tracks)
+
+	for _, key := range newTracks.order {
+		if _, ok := oldTracks.byKey[key]; !ok {
+			diff.AddedTracks = append(diff.AddedTracks, displayTrackName(newTracks.byKey[key]))
+		}
+	}
+	for _, key := range oldTracks.order {
+		if _, ok := newTracks.byKey[key]; !ok {
+			diff.RemovedTracks = append(diff.RemovedTracks, displayTrackName(oldTracks.byKey[key]))
+		}
+	}
+
+	// Walk tracks in the new file's order so the report follows the session layout
+	for _, key := range newTracks.order {
+		oldTrack, ok := oldTracks.byKey[key]
+		if !ok {
+			continue
+		}
+		newTrack := newTracks.byKey[key]
+
+		td := TrackDiff{Name: displayTrackName(newTrack)}
+		td.AddedItems, td.RemovedItems = diffStrings(itemKeys(oldTrack.Items), itemKeys(newTrack.Items))
+		td.AddedFX, td.RemovedFX = diffStrings(oldTrack.FX, newTrack.FX)
+
+		if len(td.AddedItems)+len(td.RemovedItems)+len(td.AddedFX)+len(td.RemovedFX) > 0 {
+			diff.Tracks = append(diff.Tracks, td)
+		}
+	}
+
+	return diff
+}
+
+// trackIndex maps a stable key to each track
+type trackIndex struct {
+	byKey map[string]TrackInfo
+	order []string
+}
+
+// trackKeys keys tracks by name plus occurrence, so two "Vox" tracks become "Vox" and "Vox#2"
+func trackKeys(tracks []TrackInfo) trackIndex {
+	index := trackIndex{byKey: make(map[string]TrackInfo)}
+	seen := make(map[string]int)
+	for _, track := range tracks {
+		name := strings.ToLower(track.Name)
+		seen[name]++
+		key := name
+		if seen[name] > 1 {
+			key = fmt.Sprintf("%s#%d", name, seen[name])
+		}
+		index.byKey[key] = track
+		index.order = append(index.order, key)
+	}
+	return index
+}
+
+// displayTrackName returns a track's name, or its number if unnamed
+func displayTrackName(track TrackInfo) string {
+	if track.Name == "" {
+		return fmt.Sprintf("Track %d (unnamed)", track.Index)
+	}
+	return track.Name
+}
+
+// itemKeys describes items so identical items compare equal
+func itemKeys(items []ItemInfo) []string {
+	keys := make([]string, len(items))
+	for i, item := range items {
+		label := item.Source
+		if item.Name != "" {
+			label = item.Name
+		}
+		keys[i] = fmt.Sprintf("%s @ %.3fs (%.3fs)", label, item.Position, item.Length)
+	}
+	return keys
+}
+
+// tempoKeys describes tempo points so identical points compare equal
+func tempoKeys(points []TempoPoint) []string {
+	keys := make([]string, len(points))
+	for i, p := range points {
+		keys[i] = fmt.Sprintf("%g BPM @ %.3fs", p.BPM, p.Position)
+	}
+	return keys
+}
+
+// diffStrings compares two multisets of strings and returns what was added and removed
+func diffStrings(oldValues, newValues []string) (added, removed []string) {
+	counts := make(map[string]int)
+	for _, v := range oldValues {
+		counts[v]++
+	}
+	for _, v := range newValues {
+		if counts[v] > 0 {
+			counts[v]--
+			continue
+		}
+		added = append(added, v)
+	}
+
+	remaining := make(map[string]int)
+	for _, v := range newValues {
+		remaining[v]++
+	}
+	for _, v := range oldValues {
+		if remaining[v] > 0 {
+			remaining[v]--
+			continue
+		}
+		removed = append(removed, v)
+	}
+	return added, removed
+}
+
+// FormatDiff formats a project diff as a readable report
+func FormatDiff(diff *Diff) string {
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Comparing %s → %s\n\n", filepath.Base(diff.OldFile), filepath.Base(diff.NewFile)))
+
+	if diff.IsEmpty() {
+		result.WriteString("No differences in tracks, items, FX or tempo.")
+		return result.String()
+	}
+
+	writeList := func(title string, values []string, prefix string) {
+		if len(values) == 0 {
+			return
+		}
+		result.WriteString(title + "\n")
+		for _, v := range values {
+			result.WriteString(fmt.Sprintf("  %s %s\n", prefix, v))
+		}
+		result.WriteString("\n")
+	}
+
+	writeList("Tempo:", diff.TempoChanges, "~")
+	writeList("Added tracks:", diff.AddedTracks, "+")
+	writeList("Removed tracks:", diff.RemovedTracks, "-")
+
+	for _, td := range diff.Tracks {
+		result.WriteString(fmt.Sprintf("Track \"%s\":\n", td.Name))
+		for _, v := range td.AddedFX {
+			result.WriteString(fmt.Sprintf("  + FX %s\n", v))
+		}
+		for _, v := range td.RemovedFX {
+			result.WriteString(fmt.Sprintf("  - FX %s\n", v))
+		}
+		for _, v := range td.AddedItems {
+			result.WriteString(fmt.Sprintf("  + item %s\n", v))
+		}
+		for _, v := range td.RemovedItems {
+			result.WriteString(fmt.Sprintf("  - item %s\n", v))
+		}
+		result.WriteString("\n")
+	}
+
+	return strings.TrimRight(result.String(), "\n")
+}
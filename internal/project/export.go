@@ -0,0 +1,48 @@
+package project
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Export is the structured JSON form of a project file
+type Export struct {
+	File    string          `json:"file"`
+	Summary *Summary        `json:"summary"`
+	Render  *RenderSettings `json:"render"`
+	Media   []MediaFile     `json:"media,omitempty"`
+	Raw     *Node           `json:"raw,omitempty"` // Complete block tree, only with full export
+}
+
+// ExportJSON converts a project file to indented JSON
+// With full set, the complete block tree is included alongside the summary.
+func ExportJSON(projectFile string, full bool) ([]byte, error) {
+	root, err := ParseFile(projectFile)
+	if err != nil {
+		return nil, err
+	}
+
+	export := Export{
+		File:    projectFile,
+		Summary: Summarize(root),
+		Render:  ReadRenderSettings(root),
+		Media:   MediaReferences(root, filepath.Dir(projectFile)),
+	}
+	for i := range export.Media {
+		if info, err := os.Stat(export.Media[i].Path); err == nil {
+			export.Media[i].Exists = true
+			export.Media[i].Size = info.Size()
+		}
+	}
+	if full {
+		export.Raw = root
+	}
+
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal project: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,74 @@
+package project
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/bridge"
+)
+
+// InsertMedia inserts an audio or MIDI file into the current project via the Lua bridge.
+// trackIndex is 1-based; 0 uses the currently selected track. position is in seconds;
+// nil inserts at the edit cursor.
+func InsertMedia(ctx context.Context, file string, trackIndex int, position *float64) error {
+	if file == "" {
+		return fmt.Errorf("file path is required for inserting media")
+	}
+	if _, err := os.Stat(file); err != nil {
+		return fmt.Errorf("media file not found: %s", file)
+	}
+	if trackIndex < 0 {
+		return fmt.Errorf("track index must be 0 (selected track) or greater")
+	}
+
+	pos := -1.0
+	if position != nil {
+		if *position < 0 {
+			return fmt.Errorf("position must not be negative")
+		}
+		pos = *position
+	}
+
+	_, err := bridge.Run(ctx, "insert_media", fmt.Sprintf(`local path = %s
+local track_index = %d
+local position = %s
+
+reaper.Undo_BeginBlock()
+reaper.PreventUIRefresh(1)
+
+if track_index > 0 then
+    local track = reaper.GetTrack(0, track_index - 1)
+    if not track then
+        reaper.PreventUIRefresh(-1)
+        reaper.Undo_EndBlock("Ori: Insert media", -1)
+        return fail("track " .. track_index .. " not found")
+    end
+    reaper.SetOnlyTrackSelected(track)
+end
+
+local saved_cursor = reaper.GetCursorPosition()
+if position >= 0 then
+    reaper.SetEditCurPos(position, false, false)
+end
+
+-- Mode 0 adds the media to the current (selected) track at the edit cursor
+local inserted = reaper.InsertMedia(path, 0)
+
+if position >= 0 then
+    reaper.SetEditCurPos(saved_cursor, false, false)
+end
+
+reaper.PreventUIRefresh(-1)
+reaper.Undo_EndBlock("Ori: Insert media", -1)
+reaper.UpdateArrange()
+
+if inserted == 0 then
+    return fail("REAPER could not insert " .. path)
+end
+`, bridge.LuaString(file), trackIndex, formatSeconds(pos)))
+	if err != nil {
+		return fmt.Errorf("failed to insert media: %w", err)
+	}
+	return nil
+}
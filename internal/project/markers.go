@@ -0,0 +1,236 @@
+package project
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/bridge"
+)
+
+// markerColorFlag is set on custom marker colors in RPP files and the REAPER API
+const markerColorFlag = 0x1000000
+
+// Marker is a project marker or region
+type Marker struct {
+	Index    int     `json:"index"`         // Marker/region number shown in REAPER
+	Name     string  `json:"name"`          // Marker/region name
+	Position float64 `json:"position"`      // Start position in seconds
+	End      float64 `json:"end,omitempty"` // End position in seconds (regions only)
+	IsRegion bool    `json:"is_region"`     // True for regions
+	Color    string  `json:"color,omitempty"`
+}
+
+// markerCSVHeader is the column layout used for CSV import/export
+var markerCSVHeader = []string{"Type", "Index", "Name", "Start", "End", "Color"}
+
+// ReadMarkers extracts markers and regions from a parsed project
+// Regions are stored as two MARKER lines with the same index: start (with name) and end.
+func ReadMarkers(root *Node) []Marker {
+	var markers []Marker
+	openRegions := make(map[int]int) // region index -> position in markers
+
+	// MARKER <index> <position> <name> <flags> <color> ...
+	for _, values := range root.GetAll("MARKER") {
+		if len(values) < 4 {
+			continue
+		}
+		index, _ := strconv.Atoi(values[0])
+		position, _ := strconv.ParseFloat(values[1], 64)
+		flags, _ := strconv.Atoi(values[3])
+		isRegion := flags&1 != 0
+
+		if isRegion {
+			if i, ok := openRegions[index]; ok {
+				markers[i].End = position
+				delete(openRegions, index)
+				continue
+			}
+		}
+
+		marker := Marker{
+			Index:    index,
+			Name:     values[2],
+			Position: position,
+			IsRegion: isRegion,
+		}
+		if len(values) >= 5 {
+			if color, err := strconv.Atoi(values[4]); err == nil {
+				marker.Color = nativeColorToHex(color)
+			}
+		}
+		if isRegion {
+			openRegions[index] = len(markers)
+		}
+		markers = append(markers, marker)
+	}
+
+	return markers
+}
+
+// Regions returns only the regions from a list of markers
+func Regions(markers []Marker) []Marker {
+	var regions []Marker
+	for _, m := range markers {
+		if m.IsRegion {
+			regions = append(regions, m)
+		}
+	}
+	return regions
+}
+
+// nativeColorToHex converts a native REAPER color (0x01BBGGRR) to "#RRGGBB"
+// Returns "" for the default color
+func nativeColorToHex(color int) string {
+	if color&markerColorFlag == 0 {
+		return ""
+	}
+	r := color & 0xFF
+	g := (color >> 8) & 0xFF
+	b := (color >> 16) & 0xFF
+	return fmt.Sprintf("#%02X%02X%02X", r, g, b)
+}
+
+// parseHexColor converts "#RRGGBB" to its red, green and blue components
+func parseHexColor(hex string) (int, int, int, error) {
+	hex = strings.TrimPrefix(strings.TrimSpace(hex), "#")
+	if len(hex) != 6 {
+		return 0, 0, 0, fmt.Errorf("invalid color %q, expected #RRGGBB", hex)
+	}
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid color %q, expected #RRGGBB", hex)
+	}
+	return int(value >> 16 & 0xFF), int(value >> 8 & 0xFF), int(value & 0xFF), nil
+}
+
+// EncodeMarkers serializes markers as "csv" or "json"
+func EncodeMarkers(markers []Marker, format string) ([]byte, error) {
+	switch strings.ToLower(format) {
+	case "", "csv":
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		w.Write(markerCSVHeader)
+		for _, m := range markers {
+			kind := "marker"
+			end := ""
+			if m.IsRegion {
+				kind = "region"
+				end = formatSeconds(m.End)
+			}
+			w.Write([]string{kind, strconv.Itoa(m.Index), m.Name, formatSeconds(m.Position), end, m.Color})
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return nil, fmt.Errorf("failed to write CSV: %w", err)
+		}
+		return buf.Bytes(), nil
+	case "json":
+		data, err := json.MarshalIndent(markers, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal markers: %w", err)
+		}
+		return data, nil
+	default:
+		return nil, fmt.Errorf("unsupported marker format: %s. Supported formats: csv, json", format)
+	}
+}
+
+// DecodeMarkers parses markers from CSV or JSON; JSON is detected by a leading '['
+func DecodeMarkers(data string) ([]Marker, error) {
+	trimmed := strings.TrimSpace(data)
+	if trimmed == "" {
+		return nil, fmt.Errorf("no marker data provided")
+	}
+
+	if strings.HasPrefix(trimmed, "[") {
+		var markers []Marker
+		if err := json.Unmarshal([]byte(trimmed), &markers); err != nil {
+			return nil, fmt.Errorf("failed to parse marker JSON: %w", err)
+		}
+		return markers, nil
+	}
+
+	r := csv.NewReader(strings.NewReader(trimmed))
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse marker CSV: %w", err)
+	}
+
+	var markers []Marker
+	for i, record := range records {
+		if i == 0 && len(record) > 0 && strings.EqualFold(record[0], markerCSVHeader[0]) {
+			continue
+		}
+		if len(record) < 4 {
+			return nil, fmt.Errorf("line %d: expected at least Type, Index, Name, Start", i+1)
+		}
+
+		m := Marker{Name: record[2]}
+		m.IsRegion = strings.EqualFold(strings.TrimSpace(record[0]), "region")
+		m.Index, _ = strconv.Atoi(strings.TrimSpace(record[1]))
+		if m.Position, err = strconv.ParseFloat(strings.TrimSpace(record[3]), 64); err != nil {
+			return nil, fmt.Errorf("line %d: invalid start %q", i+1, record[3])
+		}
+		if m.IsRegion {
+			if len(record) < 5 {
+				return nil, fmt.Errorf("line %d: regions need an End value", i+1)
+			}
+			if m.End, err = strconv.ParseFloat(strings.TrimSpace(record[4]), 64); err != nil {
+				return nil, fmt.Errorf("line %d: invalid end %q", i+1, record[4])
+			}
+		}
+		if len(record) >= 6 {
+			m.Color = strings.TrimSpace(record[5])
+		}
+		markers = append(markers, m)
+	}
+
+	return markers, nil
+}
+
+// ImportMarkers inserts markers and regions into the current project via the Lua bridge
+func ImportMarkers(ctx context.Context, markers []Marker) error {
+	if len(markers) == 0 {
+		return fmt.Errorf("no markers to import")
+	}
+
+	var body strings.Builder
+	body.WriteString("reaper.Undo_BeginBlock()\n")
+	for _, m := range markers {
+		if m.IsRegion && m.End <= m.Position {
+			return fmt.Errorf("region %q ends before it starts", m.Name)
+		}
+		color := "0"
+		if m.Color != "" {
+			r, g, b, err := parseHexColor(m.Color)
+			if err != nil {
+				return err
+			}
+			color = fmt.Sprintf("reaper.ColorToNative(%d, %d, %d) | 0x1000000", r, g, b)
+		}
+		index := m.Index
+		if index <= 0 {
+			index = -1 // let REAPER choose
+		}
+		body.WriteString(fmt.Sprintf("reaper.AddProjectMarker2(0, %t, %s, %s, %s, %d, %s)\n",
+			m.IsRegion, formatSeconds(m.Position), formatSeconds(m.End), bridge.LuaString(m.Name), index, color))
+	}
+	body.WriteString("reaper.Undo_EndBlock(\"Ori: Import markers\", -1)\n")
+	body.WriteString("reaper.UpdateTimeline()\n")
+
+	if _, err := bridge.Run(ctx, "import_markers", body.String()); err != nil {
+		return fmt.Errorf("failed to import markers: %w", err)
+	}
+	return nil
+}
+
+// formatSeconds formats a position in seconds without trailing zeros
+func formatSeconds(seconds float64) string {
+	return strconv.FormatFloat(seconds, 'f', -1, 64)
+}
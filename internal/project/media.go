@@ -0,0 +1,135 @@
+package project
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MediaFile is a media file referenced by a project
+type MediaFile struct {
+	Path       string `json:"path"`           // Absolute path to the file
+	Type       string `json:"type"`           // Source type (e.g. "WAVE", "MIDI", "VIDEO")
+	Exists     bool   `json:"exists"`         // Whether the file is present on disk
+	Size       int64  `json:"size,omitempty"` // File size in bytes (0 if missing)
+	References int    `json:"references"`     // Number of items using this file
+}
+
+// MediaAudit summarizes the media used by a project
+type MediaAudit struct {
+	ProjectFile string      `json:"project_file"`
+	Files       []MediaFile `json:"files"`
+	Missing     int         `json:"missing"`
+	TotalSize   int64       `json:"total_size"`
+}
+
+// MediaReferences returns the media files referenced by SOURCE blocks in the project,
+// in order of first use and with duplicates merged. Relative paths are resolved
+// against projectDir, the way REAPER stores media inside the project folder.
+func MediaReferences(root *Node, projectDir string) []MediaFile {
+	var files []MediaFile
+	seen := make(map[string]int)
+
+	root.Walk(func(n *Node) {
+		if n.Name != "SOURCE" {
+			return
+		}
+		file := n.GetString("FILE")
+		if file == "" {
+			return
+		}
+		path := ResolveMediaPath(file, projectDir)
+		if i, ok := seen[path]; ok {
+			files[i].References++
+			return
+		}
+
+		sourceType := ""
+		if len(n.Params) > 0 {
+			sourceType = n.Params[0]
+		}
+		seen[path] = len(files)
+		files = append(files, MediaFile{
+			Path:       path,
+			Type:       sourceType,
+			References: 1,
+		})
+	})
+
+	return files
+}
+
+// ResolveMediaPath resolves a FILE value from an RPP against the project directory
+func ResolveMediaPath(file, projectDir string) string {
+	// Paths saved on another platform may use the other separator
+	file = filepath.FromSlash(strings.ReplaceAll(file, "\\", "/"))
+	if filepath.IsAbs(file) || projectDir == "" {
+		return file
+	}
+	return filepath.Join(projectDir, file)
+}
+
+// AuditMedia lists all media referenced by a project file, flags missing files and totals their size
+func AuditMedia(projectFile string) (*MediaAudit, error) {
+	root, err := ParseFile(projectFile)
+	if err != nil {
+		return nil, err
+	}
+
+	audit := &MediaAudit{
+		ProjectFile: projectFile,
+		Files:       MediaReferences(root, filepath.Dir(projectFile)),
+	}
+
+	for i := range audit.Files {
+		info, err := os.Stat(audit.Files[i].Path)
+		if err != nil {
+			audit.Missing++
+			continue
+		}
+		audit.Files[i].Exists = true
+		audit.Files[i].Size = info.Size()
+		audit.TotalSize += info.Size()
+	}
+
+	return audit, nil
+}
+
+// FormatMediaAudit formats a media audit as a readable report
+func FormatMediaAudit(audit *MediaAudit) string {
+	if len(audit.Files) == 0 {
+		return fmt.Sprintf("No media files referenced by %s", filepath.Base(audit.ProjectFile))
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Media audit for %s:\n", filepath.Base(audit.ProjectFile)))
+	result.WriteString(fmt.Sprintf("  Files: %d (%d missing)\n", len(audit.Files), audit.Missing))
+	result.WriteString(fmt.Sprintf("  Total size: %s\n\n", FormatSize(audit.TotalSize)))
+
+	for _, file := range audit.Files {
+		status := "✓"
+		size := FormatSize(file.Size)
+		if !file.Exists {
+			status = "✗ MISSING"
+			size = "-"
+		}
+		result.WriteString(fmt.Sprintf("  %s %s (%s, %s, %d use(s))\n", status, file.Path, file.Type, size, file.References))
+	}
+
+	return result.String()
+}
+
+// FormatSize formats a size in bytes to a human-readable string
+func FormatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
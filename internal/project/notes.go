@@ -0,0 +1,44 @@
+package project
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/bridge"
+)
+
+// GetNotes reads the current project's notes via the Lua bridge
+func GetNotes(ctx context.Context) (string, error) {
+	rows, err := bridge.Run(ctx, "get_project_notes", `out(reaper.GetSetProjectNotes(0, false, ""))
+`)
+	if err != nil {
+		return "", fmt.Errorf("failed to read project notes: %w", err)
+	}
+	if len(rows) == 0 {
+		return "", nil
+	}
+	return rows[0][0], nil
+}
+
+// SetNotes replaces the current project's notes, or appends to them when appendNotes is true
+func SetNotes(ctx context.Context, notes string, appendNotes bool) error {
+	appendFlag := "false"
+	if appendNotes {
+		appendFlag = "true"
+	}
+
+	_, err := bridge.Run(ctx, "set_project_notes", fmt.Sprintf(`local notes = %s
+if %s then
+    local existing = reaper.GetSetProjectNotes(0, false, "")
+    if existing ~= "" then
+        notes = existing .. "\n" .. notes
+    end
+end
+reaper.GetSetProjectNotes(0, true, notes)
+reaper.MarkProjectDirty(0)
+`, bridge.LuaString(notes), appendFlag))
+	if err != nil {
+		return fmt.Errorf("failed to write project notes: %w", err)
+	}
+	return nil
+}
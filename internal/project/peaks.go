@@ -0,0 +1,105 @@
+package project
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// peakExtension is the extension REAPER appends to media files for their peak caches
+const peakExtension = ".reapeaks"
+
+// PeakCleanup reports orphaned peak files found in a directory
+type PeakCleanup struct {
+	Directory string   `json:"directory"`
+	Scanned   int      `json:"scanned"`    // Number of .reapeaks files found
+	Orphans   []string `json:"orphans"`    // Peak files whose media no longer exists
+	TotalSize int64    `json:"total_size"` // Combined size of the orphaned peak files
+	DryRun    bool     `json:"dry_run"`    // True if nothing was removed
+	Failed    []string `json:"failed,omitempty"`
+}
+
+// CleanPeaks scans dir recursively for .reapeaks files whose source media is gone
+// and removes them unless dryRun is set
+func CleanPeaks(dir string, dryRun bool) (*PeakCleanup, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to access %s: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("not a directory: %s", dir)
+	}
+
+	cleanup := &PeakCleanup{Directory: dir, DryRun: dryRun}
+
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(strings.ToLower(d.Name()), peakExtension) {
+			return nil
+		}
+		cleanup.Scanned++
+
+		// Peak files sit next to their media: song.wav -> song.wav.reapeaks
+		mediaPath := path[:len(path)-len(peakExtension)]
+		if _, err := os.Stat(mediaPath); err == nil {
+			return nil
+		}
+
+		cleanup.Orphans = append(cleanup.Orphans, path)
+		if peakInfo, err := d.Info(); err == nil {
+			cleanup.TotalSize += peakInfo.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", dir, err)
+	}
+
+	if !dryRun {
+		for _, path := range cleanup.Orphans {
+			if err := os.Remove(path); err != nil {
+				cleanup.Failed = append(cleanup.Failed, path)
+			}
+		}
+	}
+
+	return cleanup, nil
+}
+
+// FormatPeakCleanup formats a peak cleanup report as a readable summary
+func FormatPeakCleanup(cleanup *PeakCleanup) string {
+	if len(cleanup.Orphans) == 0 {
+		return fmt.Sprintf("No orphaned peak files found in %s (%d .reapeaks files scanned)", cleanup.Directory, cleanup.Scanned)
+	}
+
+	var result strings.Builder
+	if cleanup.DryRun {
+		result.WriteString(fmt.Sprintf("Found %d orphaned peak file(s) in %s (%s). Dry run - nothing was removed:\n\n",
+			len(cleanup.Orphans), cleanup.Directory, FormatSize(cleanup.TotalSize)))
+	} else {
+		removed := len(cleanup.Orphans) - len(cleanup.Failed)
+		result.WriteString(fmt.Sprintf("Removed %d orphaned peak file(s) from %s, freeing %s:\n\n",
+			removed, cleanup.Directory, FormatSize(cleanup.TotalSize)))
+	}
+
+	for _, path := range cleanup.Orphans {
+		result.WriteString(fmt.Sprintf("  - %s\n", path))
+	}
+
+	if len(cleanup.Failed) > 0 {
+		result.WriteString(fmt.Sprintf("\n⚠️ Failed to remove %d file(s):\n", len(cleanup.Failed)))
+		for _, path := range cleanup.Failed {
+			result.WriteString(fmt.Sprintf("  - %s\n", path))
+		}
+	}
+
+	if cleanup.DryRun {
+		result.WriteString("\nRun again with dry_run=false to remove them.")
+	}
+
+	return result.String()
+}
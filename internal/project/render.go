@@ -0,0 +1,99 @@
+package project
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// renderFormats maps the four-character sink IDs stored in RENDER_CFG to format names
+var renderFormats = map[string]string{
+	"evaw": "WAV",
+	"ffia": "AIFF",
+	"calf": "FLAC",
+	"l3pm": "MP3",
+	"vggo": "OGG Vorbis",
+	"SggO": "Opus",
+	"kpvw": "WavPack",
+	"PMFF": "FFmpeg",
+	"ZXTD": "DDP",
+	"fvxe": "Video",
+}
+
+// RenderSettings describes a project's sample rate and render configuration
+type RenderSettings struct {
+	SampleRate       int    `json:"sample_rate,omitempty"`        // Project sample rate (Hz)
+	RenderSampleRate int    `json:"render_sample_rate,omitempty"` // Render sample rate (Hz), 0 = project rate
+	RenderFormat     string `json:"render_format,omitempty"`      // Render format (e.g. "WAV")
+	RenderBitDepth   int    `json:"render_bit_depth,omitempty"`   // Render bit depth, when the format has one
+	RenderChannels   int    `json:"render_channels,omitempty"`    // Number of render channels
+	RenderDirectory  string `json:"render_directory,omitempty"`   // Render output directory
+	RenderPattern    string `json:"render_pattern,omitempty"`     // Render file name pattern
+}
+
+// ReadRenderSettings extracts the sample rate and render settings from a parsed project
+func ReadRenderSettings(root *Node) *RenderSettings {
+	settings := &RenderSettings{
+		SampleRate:       root.GetInt("SAMPLERATE"),
+		RenderSampleRate: root.GetInt("RENDER_SRATE"),
+		RenderDirectory:  root.GetString("RENDER_FILE"),
+		RenderPattern:    root.GetString("RENDER_PATTERN"),
+	}
+
+	// RENDER_FMT <mode> <channels> <...>
+	if values := root.Get("RENDER_FMT"); len(values) >= 2 {
+		fmt.Sscanf(values[1], "%d", &settings.RenderChannels)
+	}
+
+	if cfg := root.Child("RENDER_CFG"); cfg != nil {
+		settings.RenderFormat, settings.RenderBitDepth = decodeRenderConfig(cfg)
+	}
+
+	return settings
+}
+
+// decodeRenderConfig decodes the base64 sink configuration in a RENDER_CFG block
+// The first four bytes identify the format; WAV, AIFF and FLAC store the bit depth next.
+func decodeRenderConfig(cfg *Node) (string, int) {
+	var encoded strings.Builder
+	for _, line := range cfg.Lines {
+		encoded.WriteString(strings.Join(line, ""))
+	}
+
+	data, err := base64.StdEncoding.DecodeString(encoded.String())
+	if err != nil || len(data) < 4 {
+		return "", 0
+	}
+
+	id := string(data[:4])
+	format, ok := renderFormats[id]
+	if !ok {
+		format = id
+	}
+
+	bitDepth := 0
+	switch id {
+	case "evaw", "ffia", "calf":
+		if len(data) > 4 {
+			bitDepth = int(data[4])
+		}
+	}
+
+	return format, bitDepth
+}
+
+// Warnings returns human-readable notes about mismatched render settings
+func (rs *RenderSettings) Warnings() []string {
+	var warnings []string
+	if rs.SampleRate > 0 && rs.RenderSampleRate > 0 && rs.SampleRate != rs.RenderSampleRate {
+		warnings = append(warnings, fmt.Sprintf("project is %s but render is set to %s",
+			FormatSampleRate(rs.SampleRate), FormatSampleRate(rs.RenderSampleRate)))
+	}
+	return warnings
+}
+
+// FormatSampleRate formats a sample rate in Hz as kHz (e.g. 44100 -> "44.1k")
+func FormatSampleRate(rate int) string {
+	khz := fmt.Sprintf("%.1f", float64(rate)/1000)
+	return strings.TrimSuffix(khz, ".0") + "k"
+}
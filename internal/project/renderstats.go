@@ -0,0 +1,183 @@
+package project
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/bridge"
+	"github.com/johnjallday/ori-reaper-plugin/internal/progress"
+)
+
+const (
+	// actionRenderLastSettings is "File: Render project, using the most recent render settings, auto-close render dialog"
+	actionRenderLastSettings = 42230
+
+	// renderTimeout bounds how long a render may take before the plugin stops waiting
+	renderTimeout = 30 * time.Minute
+)
+
+// RenderFileStats holds the render statistics REAPER reports for one output file
+type RenderFileStats struct {
+	File           string            `json:"file"`
+	IntegratedLUFS *float64          `json:"lufs_integrated,omitempty"` // LUFS-I
+	TruePeak       *float64          `json:"true_peak,omitempty"`       // dBTP
+	Peak           *float64          `json:"peak,omitempty"`            // dBFS sample peak
+	LoudnessRange  *float64          `json:"loudness_range,omitempty"`  // LRA in LU, the dynamic range of the program
+	Raw            map[string]string `json:"raw"`                       // Every statistic REAPER reported
+}
+
+// RenderResult describes the outcome of a render
+type RenderResult struct {
+	Files []string          `json:"files"`
+	Stats []RenderFileStats `json:"stats,omitempty"`
+}
+
+// luaReadRenderResult writes the render targets and statistics of the last render
+const luaReadRenderResult = `local _, targets = reaper.GetSetProjectInfo_String(0, "RENDER_TARGETS", "", false)
+local _, stats = reaper.GetSetProjectInfo_String(0, "RENDER_STATS", "", false)
+out("targets", targets)
+out("stats", stats)
+`
+
+// Render renders the current project with its most recent render settings and
+// returns the files produced along with their loudness statistics
+func Render(ctx context.Context) (*RenderResult, error) {
+	progress.Report(ctx, "Rendering the project with its most recent render settings", 0, 0)
+	rows, err := bridge.RunWithTimeout(ctx, "render_project", fmt.Sprintf(`reaper.Main_OnCommand(%d, 0)
+`, actionRenderLastSettings)+luaReadRenderResult, renderTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render project: %w", err)
+	}
+	result := parseRenderResult(rows)
+	reportRendered(ctx, result)
+	return result, nil
+}
+
+// reportRendered reports the files a render produced
+func reportRendered(ctx context.Context, result *RenderResult) {
+	progress.Report(ctx, fmt.Sprintf("Rendered %d file(s)", len(result.Files)), len(result.Files), len(result.Files))
+}
+
+// GetRenderStats returns the statistics of the most recent render in the current project
+func GetRenderStats(ctx context.Context) (*RenderResult, error) {
+	rows, err := bridge.Run(ctx, "get_render_stats", luaReadRenderResult)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read render statistics: %w", err)
+	}
+	return parseRenderResult(rows), nil
+}
+
+// parseRenderResult converts bridge output into a RenderResult
+func parseRenderResult(rows [][]string) *RenderResult {
+	result := &RenderResult{}
+	for _, row := range rows {
+		if len(row) < 2 {
+			continue
+		}
+		switch row[0] {
+		case "targets":
+			for _, target := range strings.Split(row[1], ";") {
+				if target = strings.TrimSpace(target); target != "" {
+					result.Files = append(result.Files, target)
+				}
+			}
+		case "stats":
+			result.Stats = ParseRenderStats(row[1])
+		}
+	}
+	return result
+}
+
+// ParseRenderStats parses REAPER's RENDER_STATS string: semicolon-separated KEY:value
+// pairs where each FILE key starts the statistics of a new output file
+func ParseRenderStats(stats string) []RenderFileStats {
+	var files []RenderFileStats
+	var current *RenderFileStats
+
+	for _, pair := range strings.Split(stats, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToUpper(strings.TrimSpace(key))
+
+		if key == "FILE" {
+			files = append(files, RenderFileStats{File: value, Raw: make(map[string]string)})
+			current = &files[len(files)-1]
+			continue
+		}
+		if current == nil {
+			files = append(files, RenderFileStats{Raw: make(map[string]string)})
+			current = &files[len(files)-1]
+		}
+
+		current.Raw[key] = value
+		number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			continue
+		}
+		switch key {
+		case "LUFSI":
+			current.IntegratedLUFS = &number
+		case "TRUEPEAK":
+			current.TruePeak = &number
+		case "PEAK":
+			current.Peak = &number
+		case "LRA":
+			current.LoudnessRange = &number
+		}
+	}
+
+	return files
+}
+
+// FormatRenderResult formats render output files and statistics as a readable report
+func FormatRenderResult(result *RenderResult) string {
+	var out strings.Builder
+
+	if len(result.Files) > 0 {
+		out.WriteString(fmt.Sprintf("Rendered %d file(s):\n", len(result.Files)))
+		for _, file := range result.Files {
+			out.WriteString(fmt.Sprintf("  - %s\n", file))
+		}
+		out.WriteString("\n")
+	}
+
+	if len(result.Stats) == 0 {
+		out.WriteString("No render statistics available. Enable loudness statistics in REAPER's render dialog to collect them.")
+		return out.String()
+	}
+
+	out.WriteString("File                           | LUFS-I  | True Peak | Peak    | LRA\n")
+	out.WriteString("-------------------------------|---------|-----------|---------|------\n")
+	for _, stats := range result.Stats {
+		out.WriteString(fmt.Sprintf("%-30s | %7s | %9s | %7s | %5s\n",
+			truncatePath(stats.File, 30),
+			formatStat(stats.IntegratedLUFS),
+			formatStat(stats.TruePeak),
+			formatStat(stats.Peak),
+			formatStat(stats.LoudnessRange),
+		))
+	}
+
+	return out.String()
+}
+
+// formatStat formats an optional statistic with one decimal place
+func formatStat(value *float64) string {
+	if value == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f", *value)
+}
+
+// truncatePath shortens a path from the left so the file name stays visible
+func truncatePath(path string, maxLen int) string {
+	if len(path) <= maxLen {
+		return path
+	}
+	return "..." + path[len(path)-maxLen+3:]
+}
@@ -0,0 +1,200 @@
+package project
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Node is a block in an RPP file, such as <REAPER_PROJECT>, <TRACK> or <ITEM>
+// RPP files are nested blocks: a line starting with "<" opens a block, a line
+// containing only ">" closes it, and every other line is a key followed by values.
+type Node struct {
+	Name     string     `json:"name"`               // Block name (e.g. "TRACK")
+	Params   []string   `json:"params,omitempty"`   // Values on the opening line
+	Lines    [][]string `json:"lines,omitempty"`    // Tokenized key/value lines, in file order
+	Children []*Node    `json:"children,omitempty"` // Nested blocks, in file order
+}
+
+// ParseFile parses the RPP file at path
+func ParseFile(path string) (*Node, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open project file: %w", err)
+	}
+	defer file.Close()
+
+	root, err := Parse(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return root, nil
+}
+
+// Parse reads an RPP document and returns its root block (normally REAPER_PROJECT)
+// Lines are read without a length limit, since embedded plugin state can be very long.
+func Parse(r io.Reader) (*Node, error) {
+	reader := bufio.NewReader(r)
+	var root *Node
+	var stack []*Node
+	lineNum := 0
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		lineNum++
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			// Skip blank lines
+		case strings.HasPrefix(trimmed, "<"):
+			tokens := Tokenize(trimmed[1:])
+			node := &Node{}
+			if len(tokens) > 0 {
+				node.Name = tokens[0]
+				node.Params = tokens[1:]
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, node)
+			} else if root == nil {
+				root = node
+			} else {
+				return nil, fmt.Errorf("line %d: unexpected second top-level block %s", lineNum, node.Name)
+			}
+			stack = append(stack, node)
+		case trimmed == ">":
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("line %d: unbalanced '>'", lineNum)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("line %d: content outside of a block", lineNum)
+			}
+			current := stack[len(stack)-1]
+			current.Lines = append(current.Lines, Tokenize(trimmed))
+		}
+
+		if err == io.EOF {
+			break
+		}
+	}
+
+	if root == nil {
+		return nil, fmt.Errorf("no RPP block found")
+	}
+	if len(stack) != 0 {
+		return nil, fmt.Errorf("unterminated block %s", stack[len(stack)-1].Name)
+	}
+	return root, nil
+}
+
+// Tokenize splits an RPP line into values. Values are separated by whitespace and
+// may be quoted with ", ' or ` (REAPER picks a quote character the value doesn't contain).
+func Tokenize(line string) []string {
+	var tokens []string
+	i := 0
+	for i < len(line) {
+		c := line[i]
+		if c == ' ' || c == '\t' {
+			i++
+			continue
+		}
+		if c == '"' || c == '\'' || c == '`' {
+			end := strings.IndexByte(line[i+1:], c)
+			if end == -1 {
+				tokens = append(tokens, line[i+1:])
+				break
+			}
+			tokens = append(tokens, line[i+1:i+1+end])
+			i += end + 2
+			continue
+		}
+		end := strings.IndexAny(line[i:], " \t")
+		if end == -1 {
+			tokens = append(tokens, line[i:])
+			break
+		}
+		tokens = append(tokens, line[i:i+end])
+		i += end
+	}
+	return tokens
+}
+
+// Get returns the values of the first line with the given key, or nil if absent
+func (n *Node) Get(key string) []string {
+	for _, line := range n.Lines {
+		if len(line) > 0 && line[0] == key {
+			return line[1:]
+		}
+	}
+	return nil
+}
+
+// GetString returns the first value of the line with the given key
+func (n *Node) GetString(key string) string {
+	values := n.Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// GetInt returns the first value of the line with the given key as an integer
+func (n *Node) GetInt(key string) int {
+	value, _ := strconv.Atoi(n.GetString(key))
+	return value
+}
+
+// GetFloat returns the first value of the line with the given key as a float
+func (n *Node) GetFloat(key string) float64 {
+	value, _ := strconv.ParseFloat(n.GetString(key), 64)
+	return value
+}
+
+// GetAll returns the values of every line with the given key
+func (n *Node) GetAll(key string) [][]string {
+	var result [][]string
+	for _, line := range n.Lines {
+		if len(line) > 0 && line[0] == key {
+			result = append(result, line[1:])
+		}
+	}
+	return result
+}
+
+// Child returns the first direct child block with the given name
+func (n *Node) Child(name string) *Node {
+	for _, child := range n.Children {
+		if child.Name == name {
+			return child
+		}
+	}
+	return nil
+}
+
+// ChildrenNamed returns all direct child blocks with the given name
+func (n *Node) ChildrenNamed(name string) []*Node {
+	var result []*Node
+	for _, child := range n.Children {
+		if child.Name == name {
+			result = append(result, child)
+		}
+	}
+	return result
+}
+
+// Walk calls fn for n and every nested block, depth first
+func (n *Node) Walk(fn func(*Node)) {
+	fn(n)
+	for _, child := range n.Children {
+		child.Walk(fn)
+	}
+}
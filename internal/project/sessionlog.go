@@ -0,0 +1,136 @@
+package project
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// sessionLogSuffix replaces the .RPP extension to name a project's session log
+const sessionLogSuffix = ".session.log"
+
+// sessionLogTimeFormat is how entry timestamps are written in the session log
+const sessionLogTimeFormat = "2006-01-02 15:04:05"
+
+// defaultSessionLogEntries is how many of the latest entries get_session_log shows by default
+const defaultSessionLogEntries = 50
+
+// SessionNote is one entry of a project's session log
+type SessionNote struct {
+	Time      time.Time `json:"time"`
+	Operation string    `json:"operation,omitempty"` // The operation the note is about
+	Note      string    `json:"note"`
+}
+
+// SessionLogPath returns the session log of a project: a text file next to the .RPP
+// named after it, e.g. Song.session.log for Song.RPP
+func SessionLogPath(projectFile string) string {
+	return strings.TrimSuffix(projectFile, filepath.Ext(projectFile)) + sessionLogSuffix
+}
+
+// AppendSessionNote adds a timestamped note to a project's session log, creating the log
+// if needed. Each entry is a "[time] (operation) note" line; further lines of the note
+// are indented.
+func AppendSessionNote(projectFile, operation, note string) (*SessionNote, error) {
+	note = strings.TrimSpace(strings.ReplaceAll(note, "\r\n", "\n"))
+	if note == "" {
+		return nil, errors.New("content is required: the note to log, e.g. what was changed and why")
+	}
+	operation = strings.TrimSpace(operation)
+	if strings.ContainsAny(operation, "()\n") {
+		return nil, fmt.Errorf("invalid operation name %q", operation)
+	}
+	if _, err := os.Stat(projectFile); err != nil {
+		return nil, fmt.Errorf("project file not found: %s", projectFile)
+	}
+
+	entry := &SessionNote{Time: time.Now(), Operation: operation, Note: note}
+	var line strings.Builder
+	line.WriteString("[" + entry.Time.Format(sessionLogTimeFormat) + "] ")
+	if operation != "" {
+		line.WriteString("(" + operation + ") ")
+	}
+	line.WriteString(strings.ReplaceAll(note, "\n", "\n  ") + "\n")
+
+	file, err := os.OpenFile(SessionLogPath(projectFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open session log: %w", err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString(line.String()); err != nil {
+		return nil, fmt.Errorf("failed to write session log: %w", err)
+	}
+	return entry, nil
+}
+
+// ReadSessionLog returns the latest limit entries of a project's session log, oldest
+// first (defaultSessionLogEntries when limit is 0 or less). A project without a log has
+// no entries.
+func ReadSessionLog(projectFile string, limit int) ([]SessionNote, error) {
+	if limit <= 0 {
+		limit = defaultSessionLogEntries
+	}
+	file, err := os.Open(SessionLogPath(projectFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []SessionNote{}, nil
+		}
+		return nil, fmt.Errorf("failed to open session log: %w", err)
+	}
+	defer file.Close()
+
+	var entries []SessionNote
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "  ") && len(entries) > 0 {
+			entries[len(entries)-1].Note += "\n" + line[2:]
+			continue
+		}
+		stamp, rest, ok := strings.Cut(strings.TrimPrefix(line, "["), "] ")
+		if !strings.HasPrefix(line, "[") || !ok {
+			continue // Hand edits that aren't entries
+		}
+		at, err := time.ParseInLocation(sessionLogTimeFormat, stamp, time.Local)
+		if err != nil {
+			continue
+		}
+		entry := SessionNote{Time: at, Note: rest}
+		if strings.HasPrefix(rest, "(") {
+			if operation, note, ok := strings.Cut(rest[1:], ") "); ok {
+				entry.Operation, entry.Note = operation, note
+			}
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading session log: %w", err)
+	}
+	if len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	return entries, nil
+}
+
+// FormatSessionLog formats session log entries as readable text
+func FormatSessionLog(projectFile string, entries []SessionNote) string {
+	name := filepath.Base(projectFile)
+	if len(entries) == 0 {
+		return fmt.Sprintf("The session log of %s is empty. Add to it with 'log_session_note'.", name)
+	}
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Session log of %s (%d entries):\n\n", name, len(entries)))
+	for _, entry := range entries {
+		b.WriteString(entry.Time.Format(sessionLogTimeFormat))
+		if entry.Operation != "" {
+			b.WriteString("  [" + entry.Operation + "]")
+		}
+		b.WriteString("\n  " + strings.ReplaceAll(entry.Note, "\n", "\n  ") + "\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,184 @@
+package project
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/bridge"
+	"github.com/johnjallday/ori-reaper-plugin/internal/progress"
+)
+
+// StemModes lists the ways render_stems splits a project into files
+var StemModes = []string{"tracks", "regions", "region_tracks"}
+
+// defaultStemPatterns are the file name patterns used per mode when none is given
+var defaultStemPatterns = map[string]string{
+	"tracks":        "$project - $track",
+	"regions":       "$project - $region",
+	"region_tracks": "$project - $region - $track",
+}
+
+// StemRequest describes a stem render
+type StemRequest struct {
+	Mode      string // One of StemModes; defaults to "tracks"
+	Tracks    []int  // Track numbers (1-based) to render; defaults to the selected tracks, or all tracks for region_tracks
+	Regions   []int  // Region numbers to render; defaults to every region
+	Pattern   string // File name pattern with REAPER wildcards; defaults per mode
+	Directory string // Output directory; defaults to the project's render directory
+}
+
+// RenderStems renders one file per track, per region or per region and track, using a
+// temporary change to the project's render source, bounds and region render matrix that
+// is undone afterwards, and returns the files produced
+func RenderStems(ctx context.Context, req StemRequest) (*RenderResult, error) {
+	if req.Mode == "" {
+		req.Mode = "tracks"
+	}
+	pattern, ok := defaultStemPatterns[req.Mode]
+	if !ok {
+		return nil, fmt.Errorf("unsupported stem mode: %s. Valid modes: %s", req.Mode, strings.Join(StemModes, ", "))
+	}
+	if req.Pattern != "" {
+		pattern = req.Pattern
+	}
+	for _, track := range req.Tracks {
+		if track < 1 {
+			return nil, fmt.Errorf("track numbers must be 1 or greater, got %d", track)
+		}
+	}
+
+	progress.Report(ctx, fmt.Sprintf("Rendering %s stems", strings.ReplaceAll(req.Mode, "_", " ")), 0, 0)
+	rows, err := bridge.RunWithTimeout(ctx, "render_stems", fmt.Sprintf(`local mode = %s
+local wanted_tracks = {%s}
+local wanted_regions = {%s}
+local pattern = %s
+local directory = %s
+
+-- Pick the tracks: given, selected, or (per region) all
+local tracks = {}
+for _, n in ipairs(wanted_tracks) do
+    local track = reaper.GetTrack(0, n - 1)
+    if not track then
+        return fail("track " .. n .. " not found")
+    end
+    tracks[#tracks + 1] = track
+end
+if #tracks == 0 then
+    for i = 0, reaper.CountTracks(0) - 1 do
+        local track = reaper.GetTrack(0, i)
+        if mode == "region_tracks" or reaper.IsTrackSelected(track) then tracks[#tracks + 1] = track end
+    end
+end
+if mode ~= "regions" and #tracks == 0 then
+    return fail("no tracks to render; select tracks or pass their numbers")
+end
+
+-- Pick the regions and remember the current render matrix
+local regions, all_regions, wanted = {}, {}, {}
+for _, n in ipairs(wanted_regions) do wanted[n] = true end
+local i = 0
+while true do
+    local ok, isrgn, _, _, _, number = reaper.EnumProjectMarkers(i)
+    if ok == 0 then break end
+    if isrgn then
+        all_regions[#all_regions + 1] = number
+        if #wanted_regions == 0 or wanted[number] then regions[#regions + 1] = number end
+        wanted[number] = nil
+    end
+    i = i + 1
+end
+for n in pairs(wanted) do
+    return fail("region " .. n .. " not found")
+end
+if mode ~= "tracks" and #regions == 0 then
+    return fail("the project has no regions to render")
+end
+
+local saved_matrix = {}
+for _, number in ipairs(all_regions) do
+    local j = 0
+    while true do
+        local track = reaper.EnumRegionRenderMatrix(0, number, j)
+        if not track then break end
+        saved_matrix[#saved_matrix + 1] = {number, track}
+        j = j + 1
+    end
+end
+
+local function get(key)
+    return reaper.GetSetProjectInfo(0, key, 0, false)
+end
+local function get_string(key)
+    local _, value = reaper.GetSetProjectInfo_String(0, key, "", false)
+    return value
+end
+local saved = {
+    settings = get("RENDER_SETTINGS"),
+    bounds = get("RENDER_BOUNDSFLAG"),
+    pattern = get_string("RENDER_PATTERN"),
+    file = get_string("RENDER_FILE"),
+}
+local saved_selection = {}
+for i = 0, reaper.CountTracks(0) - 1 do
+    local track = reaper.GetTrack(0, i)
+    saved_selection[#saved_selection + 1] = {track, reaper.IsTrackSelected(track)}
+end
+
+-- Configure: selected tracks as stems, or the region render matrix
+for _, entry in ipairs(saved_matrix) do
+    reaper.SetRegionRenderMatrix(0, entry[1], entry[2], -1)
+end
+if mode == "tracks" then
+    for _, entry in ipairs(saved_selection) do reaper.SetTrackSelected(entry[1], false) end
+    for _, track in ipairs(tracks) do reaper.SetTrackSelected(track, true) end
+    reaper.GetSetProjectInfo(0, "RENDER_SETTINGS", 2, true)
+    reaper.GetSetProjectInfo(0, "RENDER_BOUNDSFLAG", 1, true)
+else
+    for _, number in ipairs(regions) do
+        if mode == "regions" then
+            reaper.SetRegionRenderMatrix(0, number, reaper.GetMasterTrack(0), 1)
+        else
+            for _, track in ipairs(tracks) do reaper.SetRegionRenderMatrix(0, number, track, 1) end
+        end
+    end
+    reaper.GetSetProjectInfo(0, "RENDER_SETTINGS", 8, true)
+    reaper.GetSetProjectInfo(0, "RENDER_BOUNDSFLAG", 3, true)
+end
+reaper.GetSetProjectInfo_String(0, "RENDER_PATTERN", pattern, true)
+if directory ~= "" then reaper.GetSetProjectInfo_String(0, "RENDER_FILE", directory, true) end
+
+reaper.Main_OnCommand(%d, 0)
+`, bridge.LuaString(req.Mode), luaIntList(req.Tracks), luaIntList(req.Regions),
+		bridge.LuaString(pattern), bridge.LuaString(req.Directory), actionRenderLastSettings)+luaReadRenderResult+`
+-- Put the project's render setup back the way it was
+for _, number in ipairs(all_regions) do
+    for _, entry in ipairs(saved_selection) do reaper.SetRegionRenderMatrix(0, number, entry[1], -1) end
+    reaper.SetRegionRenderMatrix(0, number, reaper.GetMasterTrack(0), -1)
+end
+for _, entry in ipairs(saved_matrix) do
+    reaper.SetRegionRenderMatrix(0, entry[1], entry[2], 1)
+end
+reaper.GetSetProjectInfo(0, "RENDER_SETTINGS", saved.settings, true)
+reaper.GetSetProjectInfo(0, "RENDER_BOUNDSFLAG", saved.bounds, true)
+reaper.GetSetProjectInfo_String(0, "RENDER_PATTERN", saved.pattern, true)
+reaper.GetSetProjectInfo_String(0, "RENDER_FILE", saved.file, true)
+for _, entry in ipairs(saved_selection) do reaper.SetTrackSelected(entry[1], entry[2]) end
+`, renderTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render stems: %w", err)
+	}
+	result := parseRenderResult(rows)
+	reportRendered(ctx, result)
+	return result, nil
+}
+
+// luaIntList formats numbers as the contents of a Lua table constructor
+func luaIntList(numbers []int) string {
+	items := make([]string, len(numbers))
+	for i, n := range numbers {
+		items[i] = strconv.Itoa(n)
+	}
+	return strings.Join(items, ", ")
+}
@@ -0,0 +1,115 @@
+package project
+
+import (
+	"path/filepath"
+	"strconv"
+)
+
+// Summary is a structured view of the parts of a project the plugin reasons about
+type Summary struct {
+	Tempo         float64      `json:"tempo"`                    // Base tempo (BPM)
+	TimeSignature string       `json:"time_signature,omitempty"` // Base time signature (e.g. "4/4")
+	SampleRate    int          `json:"sample_rate,omitempty"`
+	TempoChanges  []TempoPoint `json:"tempo_changes,omitempty"`
+	Tracks        []TrackInfo  `json:"tracks"`
+}
+
+// TempoPoint is a point on the project tempo envelope
+type TempoPoint struct {
+	Position float64 `json:"position"` // Seconds
+	BPM      float64 `json:"bpm"`
+}
+
+// TrackInfo describes a track in a project file
+type TrackInfo struct {
+	Index int        `json:"index"` // Track index (1-based)
+	Name  string     `json:"name"`
+	FX    []string   `json:"fx,omitempty"`
+	Items []ItemInfo `json:"items,omitempty"`
+}
+
+// ItemInfo describes a media item in a project file
+type ItemInfo struct {
+	Name     string  `json:"name,omitempty"`
+	Position float64 `json:"position"` // Seconds
+	Length   float64 `json:"length"`   // Seconds
+	Source   string  `json:"source,omitempty"`
+}
+
+// Summarize extracts tempo, tracks, items and FX from a parsed project
+func Summarize(root *Node) *Summary {
+	summary := &Summary{
+		SampleRate: root.GetInt("SAMPLERATE"),
+	}
+
+	// TEMPO <bpm> <numerator> <denominator>
+	if tempo := root.Get("TEMPO"); len(tempo) > 0 {
+		summary.Tempo, _ = strconv.ParseFloat(tempo[0], 64)
+		if len(tempo) >= 3 {
+			summary.TimeSignature = tempo[1] + "/" + tempo[2]
+		}
+	}
+
+	// <TEMPOENVEX> holds PT <position> <bpm> ... lines
+	if env := root.Child("TEMPOENVEX"); env != nil {
+		for _, pt := range env.GetAll("PT") {
+			if len(pt) < 2 {
+				continue
+			}
+			pos, _ := strconv.ParseFloat(pt[0], 64)
+			bpm, _ := strconv.ParseFloat(pt[1], 64)
+			summary.TempoChanges = append(summary.TempoChanges, TempoPoint{Position: pos, BPM: bpm})
+		}
+	}
+
+	for i, trackNode := range root.ChildrenNamed("TRACK") {
+		track := TrackInfo{
+			Index: i + 1,
+			Name:  trackNode.GetString("NAME"),
+		}
+		if chain := trackNode.Child("FXCHAIN"); chain != nil {
+			track.FX = FXNames(chain)
+		}
+		for _, itemNode := range trackNode.ChildrenNamed("ITEM") {
+			track.Items = append(track.Items, readItem(itemNode))
+		}
+		summary.Tracks = append(summary.Tracks, track)
+	}
+
+	return summary
+}
+
+// readItem extracts the summary fields of an <ITEM> block
+func readItem(itemNode *Node) ItemInfo {
+	item := ItemInfo{
+		Name:     itemNode.GetString("NAME"),
+		Position: itemNode.GetFloat("POSITION"),
+		Length:   itemNode.GetFloat("LENGTH"),
+	}
+	itemNode.Walk(func(n *Node) {
+		if item.Source == "" && n.Name == "SOURCE" {
+			if file := n.GetString("FILE"); file != "" {
+				item.Source = filepath.Base(file)
+			} else if len(n.Params) > 0 {
+				item.Source = n.Params[0]
+			}
+		}
+	})
+	return item
+}
+
+// FXNames returns the plugin names in an <FXCHAIN> block, in chain order
+func FXNames(chain *Node) []string {
+	var names []string
+	for _, fx := range chain.Children {
+		switch fx.Name {
+		case "VST", "AU", "CLAP", "DX", "LV2", "JS", "VIDEO_EFFECT":
+			if len(fx.Params) > 0 && fx.Params[0] != "" {
+				names = append(names, fx.Params[0])
+			} else {
+				names = append(names, fx.Name)
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,44 @@
+package project
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/bridge"
+	"github.com/johnjallday/ori-reaper-plugin/internal/timeutil"
+)
+
+// GetTiming reads what converting positions depends on from the current project via the
+// Lua bridge: the tempo and time signature at the project start, the timecode frame rate
+// and the sample rate (the audio device's when the project doesn't set one)
+func GetTiming(ctx context.Context) (timeutil.Timing, error) {
+	rows, err := bridge.Run(ctx, "get_timing", `local num, denom, tempo = reaper.TimeMap_GetTimeSigAtTime(0, 0)
+local fps = reaper.TimeMap_curFrameRate(0)
+local srate = 0
+if reaper.GetSetProjectInfo(0, "PROJECT_SRATE_USE", 0, false) > 0 then
+    srate = reaper.GetSetProjectInfo(0, "PROJECT_SRATE", 0, false)
+end
+if srate <= 0 then
+    local ok, device = reaper.GetAudioDeviceInfo("SRATE", "")
+    srate = ok and tonumber(device) or 0
+end
+out(tempo, num, denom, fps, srate)
+`)
+	if err != nil {
+		return timeutil.Timing{}, fmt.Errorf("failed to read project timing: %w", err)
+	}
+	if len(rows) < 1 || len(rows[0]) < 5 {
+		return timeutil.Timing{}, fmt.Errorf("unexpected output format: no project timing data")
+	}
+
+	row := rows[0]
+	var timing timeutil.Timing
+	timing.Tempo, _ = strconv.ParseFloat(row[0], 64)
+	timing.BeatsPerBar, _ = strconv.Atoi(row[1])
+	timing.BeatUnit, _ = strconv.Atoi(row[2])
+	timing.FrameRate, _ = strconv.ParseFloat(row[3], 64)
+	sampleRate, _ := strconv.ParseFloat(row[4], 64)
+	timing.SampleRate = int(sampleRate)
+	return timing, nil
+}
@@ -0,0 +1,248 @@
+package scripts
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed builtin_actions.txt
+var builtinActionsTable string
+
+// defaultActionSearchLimit is how many matches search_actions returns when no limit is given
+const defaultActionSearchLimit = 10
+
+// Sources of the actions in the catalog
+const (
+	ActionSourceBuiltin = "builtin" // REAPER's own actions, from builtin_actions.txt
+	ActionSourceScript  = "script"  // ReaScripts registered in reaper-kb.ini (SCR entries)
+	ActionSourceCustom  = "custom"  // Custom actions in reaper-kb.ini (ACT entries)
+)
+
+// actionSearchSynonyms maps words people use to the words REAPER uses in action names
+var actionSearchSynonyms = map[string]string{
+	"add": "insert", "create": "insert", "new": "insert",
+	"delete": "remove", "erase": "remove", "clear": "remove",
+	"arm": "record", "rec": "record", "loop": "repeat", "open": "show",
+}
+
+// actionSearchStopWords are ignored in search queries
+var actionSearchStopWords = map[string]bool{
+	"a": true, "an": true, "the": true, "to": true, "of": true, "for": true, "in": true, "on": true,
+	"and": true, "my": true, "action": true, "command": true, "reaper": true,
+}
+
+// Action is one entry of the action catalog
+type Action struct {
+	ID        string   `json:"id"` // Command ID for run_action; empty for scripts REAPER hasn't assigned one yet
+	Name      string   `json:"name"`
+	Source    string   `json:"source"`
+	Shortcuts []string `json:"shortcuts,omitempty"`
+}
+
+// BuiltinActions returns the built-in actions of the embedded table
+func BuiltinActions() []Action {
+	var actions []Action
+	for _, line := range strings.Split(builtinActionsTable, "\n") {
+		if line = strings.TrimSpace(line); line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		id, name, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		actions = append(actions, Action{ID: id, Name: strings.TrimSpace(name), Source: ActionSourceBuiltin})
+	}
+	return actions
+}
+
+// LoadActionCatalog combines the built-in actions with the scripts and custom actions of
+// the Main section of reaper-kb.ini, attaching the key bindings of each. A missing
+// reaper-kb.ini leaves only the built-in actions.
+func LoadActionCatalog() ([]Action, error) {
+	actions := BuiltinActions()
+	kbIniPath, err := GetReaperKBIniPath()
+	if err != nil {
+		return actions, nil
+	}
+	content, err := configFS.ReadFile(kbIniPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return actions, nil
+		}
+		return nil, fmt.Errorf("failed to read reaper-kb.ini: %w", err)
+	}
+
+	mainSection := strconv.Itoa(customActionSectionMain)
+	shortcuts := make(map[string][]string)
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		quoted := strings.Split(line, `"`)
+		switch {
+		case fields[0] == "KEY" && len(fields) == 5 && fields[4] == mainSection:
+			// KEY <flags> <key> <command> <section>
+			flags, _ := strconv.Atoi(fields[1])
+			code, _ := strconv.Atoi(fields[2])
+			if shortcut := FormatShortcut(flags, code); shortcut != "" {
+				shortcuts[fields[3]] = append(shortcuts[fields[3]], shortcut)
+			}
+		case fields[0] == "SCR" && fields[2] == mainSection:
+			// SCR <flags> <section> [<id>] "<description>" "<path>"
+			if len(quoted) < 4 {
+				continue
+			}
+			action := Action{Name: quoted[1], Source: ActionSourceScript}
+			if id := fields[3]; !strings.HasPrefix(id, `"`) {
+				action.ID = "_" + id
+			}
+			actions = append(actions, action)
+		case fields[0] == "ACT" && fields[2] == mainSection:
+			// ACT <flags> <section> "<id>" "<description>" <command> ...
+			if len(quoted) < 4 {
+				continue
+			}
+			actions = append(actions, Action{ID: "_" + quoted[1], Name: quoted[3], Source: ActionSourceCustom})
+		}
+	}
+	for i := range actions {
+		actions[i].Shortcuts = shortcuts[actions[i].ID]
+	}
+	return actions, nil
+}
+
+// actionQueryWords splits a search query into lowercase words, dropping stop words
+func actionQueryWords(query string) []string {
+	var words []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return r == ' ' || r == ',' || r == ':' || r == '/' || r == '-' || r == '_'
+	}) {
+		if !actionSearchStopWords[word] {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// SearchActions returns the actions of the catalog that best match query, a command ID
+// or some words of the action's name in any order. Common words are mapped to REAPER's
+// ("add" finds "insert", "delete" finds "remove"). Returns at most limit actions.
+func SearchActions(actions []Action, query string, limit int) ([]Action, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, fmt.Errorf("filter is required: words of the action name to look for, e.g. 'insert track'")
+	}
+	if limit <= 0 {
+		limit = defaultActionSearchLimit
+	}
+	for _, action := range actions {
+		if action.ID != "" && strings.EqualFold(action.ID, query) {
+			return []Action{action}, nil
+		}
+	}
+
+	words := actionQueryWords(query)
+	if len(words) == 0 {
+		words = []string{strings.ToLower(query)}
+	}
+	type match struct {
+		action Action
+		score  int
+	}
+	var matches []match
+	for _, action := range actions {
+		name := strings.ToLower(action.Name)
+		score := 0
+		for _, word := range words {
+			switch {
+			case strings.Contains(name, word):
+				score += 2
+			case actionSearchSynonyms[word] != "" && strings.Contains(name, actionSearchSynonyms[word]):
+				score++
+			}
+		}
+		if score == 0 {
+			continue
+		}
+		if strings.Contains(name, strings.ToLower(query)) {
+			score += 2 * len(words) // The whole query as typed
+		}
+		matches = append(matches, match{action, score})
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return len(matches[i].action.Name) < len(matches[j].action.Name)
+	})
+
+	// Matches scoring under half the best one only share a word or two with the query
+	var results []Action
+	for _, m := range matches {
+		if len(results) == limit || m.score*2 < matches[0].score {
+			break
+		}
+		results = append(results, m.action)
+	}
+	return results, nil
+}
+
+// ResolveAction returns the command ID of an action given by command ID or by its exact
+// name (case-insensitive); other names are an error suggesting the closest actions
+func ResolveAction(actions []Action, action string) (string, error) {
+	action = strings.TrimSpace(action)
+	if action == "" {
+		return "", fmt.Errorf("command is required: a command ID such as 40001, or the action's exact name")
+	}
+	if isCommandID(action) {
+		return action, nil
+	}
+	for _, a := range actions {
+		if a.ID != "" && strings.EqualFold(a.Name, action) {
+			return a.ID, nil
+		}
+	}
+	suggestions, err := SearchActions(actions, action, 3)
+	if err != nil {
+		return "", err
+	}
+	if len(suggestions) == 0 {
+		return "", fmt.Errorf("no action named '%s'; use 'search_actions' to find its command ID", action)
+	}
+	names := make([]string, len(suggestions))
+	for i, s := range suggestions {
+		names[i] = fmt.Sprintf("%s (%s)", s.Name, s.ID)
+	}
+	return "", fmt.Errorf("no action named exactly '%s'. Did you mean: %s?", action, strings.Join(names, ", "))
+}
+
+// FormatActions formats search results as a list of command IDs and names
+func FormatActions(actions []Action, query string) string {
+	if len(actions) == 0 {
+		return fmt.Sprintf("No actions matching '%s'. The catalog holds common built-in actions and the scripts and custom actions in reaper-kb.ini; REAPER's Action List has the rest.", query)
+	}
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%d action(s) matching '%s':\n\n", len(actions), query))
+	for _, action := range actions {
+		id := action.ID
+		if id == "" {
+			id = "(no ID until REAPER restarts)"
+		}
+		b.WriteString(fmt.Sprintf("  %s  %s", id, action.Name))
+		if action.Source != ActionSourceBuiltin {
+			b.WriteString(" [" + action.Source + "]")
+		}
+		if len(action.Shortcuts) > 0 {
+			b.WriteString(" — " + strings.Join(action.Shortcuts, ", "))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\nUse 'run_action' with command=<ID> to run one.")
+	return b.String()
+}
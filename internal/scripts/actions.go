@@ -0,0 +1,95 @@
+package scripts
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// customActionSectionMain is the reaper-kb.ini section ID of the Main action list
+const customActionSectionMain = 0
+
+// CreateCustomAction adds a custom action (an ACT entry in reaper-kb.ini) that runs the
+// given command IDs in order. Commands are native numeric IDs (e.g. "40001") or named
+// IDs for scripts and extensions (e.g. "_RS1a2b..." or "_SWS_SAVESEL").
+// Returns the command ID of the new action. REAPER must be restarted to pick it up.
+func CreateCustomAction(name string, commands []string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", errors.New("name is required for 'create_custom_action' operation")
+	}
+	if strings.ContainsAny(name, "\"\n") {
+		return "", errors.New("action name can't contain double quotes or newlines")
+	}
+	if len(commands) == 0 {
+		return "", errors.New("commands are required for 'create_custom_action' operation")
+	}
+
+	for i, cmd := range commands {
+		cmd = strings.TrimSpace(cmd)
+		if !isCommandID(cmd) {
+			return "", fmt.Errorf("invalid command ID %q: use a numeric action ID or a named ID starting with _", cmd)
+		}
+		commands[i] = cmd
+	}
+
+	kbIniPath, err := GetReaperKBIniPath()
+	if err != nil {
+		return "", err
+	}
+
+	id, err := newActionID()
+	if err != nil {
+		return "", err
+	}
+
+	label := "Custom: " + name
+	// REAPER format: ACT <flags> <section> "<id>" "Custom: name" <command> <command> ...
+	entry := fmt.Sprintf(`ACT 0 %d "%s" "%s" %s`, customActionSectionMain, id, label, strings.Join(commands, " "))
+
+	err = updateConfigFile(kbIniPath, "create custom action "+name, func(content []byte) ([]byte, error) {
+		for _, line := range strings.Split(string(content), "\n") {
+			if strings.HasPrefix(line, "ACT ") && strings.Contains(line, `"`+label+`"`) {
+				return nil, fmt.Errorf("a custom action named %q already exists", name)
+			}
+		}
+
+		text := string(content)
+		if text != "" && !strings.HasSuffix(text, "\n") {
+			text += "\n"
+		}
+		return []byte(text + entry + "\n"), nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return "_" + id, nil
+}
+
+// isCommandID reports whether s looks like a REAPER command ID
+func isCommandID(s string) bool {
+	if s == "" {
+		return false
+	}
+	if strings.HasPrefix(s, "_") {
+		return len(s) > 1 && !strings.ContainsAny(s, " \t\"")
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// newActionID returns a random 32-character hex ID like the ones REAPER generates
+func newActionID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate action ID: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
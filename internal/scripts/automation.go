@@ -0,0 +1,172 @@
+package scripts
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/bridge"
+)
+
+// AutomationModes lists track automation modes in REAPER's I_AUTOMODE order
+var AutomationModes = []string{"trim", "read", "touch", "write", "latch", "latch_preview"}
+
+// AutomationState holds per-track automation modes and the global override
+type AutomationState struct {
+	Override string  `json:"override"` // "none" when no global override is active
+	Tracks   []Track `json:"tracks"`
+}
+
+// ParseAutomationMode converts a mode name to REAPER's I_AUTOMODE value
+func ParseAutomationMode(mode string) (int, error) {
+	normalized := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(mode)), " ", "_")
+	if normalized == "off" {
+		normalized = "trim"
+	}
+	for i, name := range AutomationModes {
+		if name == normalized {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unsupported automation mode: %s. Valid modes: %s", mode, strings.Join(AutomationModes, ", "))
+}
+
+// automationModeName converts an I_AUTOMODE value to its mode name
+func automationModeName(value int) string {
+	if value >= 0 && value < len(AutomationModes) {
+		return AutomationModes[value]
+	}
+	return "unknown"
+}
+
+// parseAutomationOverride converts an override name to GetGlobalAutomationOverride's value
+// Accepts the track modes plus "bypass" and "none" (no override)
+func parseAutomationOverride(mode string) (int, error) {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "none", "off", "":
+		return -1, nil
+	case "bypass":
+		return 5, nil
+	case "latch_preview":
+		return 0, fmt.Errorf("latch_preview cannot be used as a global override")
+	}
+	value, err := ParseAutomationMode(mode)
+	if err != nil {
+		return 0, fmt.Errorf("unsupported automation override: %s. Valid values: none, trim, read, touch, write, latch, bypass", mode)
+	}
+	return value, nil
+}
+
+// automationOverrideName converts a global override value to its name
+func automationOverrideName(value int) string {
+	switch value {
+	case -1:
+		return "none"
+	case 5:
+		return "bypass"
+	default:
+		return automationModeName(value)
+	}
+}
+
+// GetAutomationState reads every track's automation mode and the global override via the Lua bridge
+func GetAutomationState(ctx context.Context) (*AutomationState, error) {
+	rows, err := bridge.Run(ctx, "get_automation", `out("override", reaper.GetGlobalAutomationOverride())
+for i = 0, reaper.CountTracks(0) - 1 do
+    local track = reaper.GetTrack(0, i)
+    local _, name = reaper.GetTrackName(track)
+    out("track", i + 1, name, math.floor(reaper.GetMediaTrackInfo_Value(track, "I_AUTOMODE")))
+end
+`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read automation modes: %w", err)
+	}
+
+	state := &AutomationState{Override: "none"}
+	for _, row := range rows {
+		switch row[0] {
+		case "override":
+			if len(row) > 1 {
+				if value, err := strconv.Atoi(row[1]); err == nil {
+					state.Override = automationOverrideName(value)
+				}
+			}
+		case "track":
+			if len(row) < 4 {
+				continue
+			}
+			index, _ := strconv.Atoi(row[1])
+			mode, _ := strconv.Atoi(row[3])
+			state.Tracks = append(state.Tracks, Track{
+				Index:          index,
+				Name:           row[2],
+				AutomationMode: automationModeName(mode),
+			})
+		}
+	}
+
+	return state, nil
+}
+
+// SetTrackAutomationMode sets the automation mode of a track (1-based index) via the Lua bridge
+func SetTrackAutomationMode(ctx context.Context, trackIndex int, mode string) error {
+	if trackIndex < 1 {
+		return fmt.Errorf("track index must be 1 or greater")
+	}
+	value, err := ParseAutomationMode(mode)
+	if err != nil {
+		return err
+	}
+
+	_, err = bridge.Run(ctx, "set_automation_mode", fmt.Sprintf(`local track = reaper.GetTrack(0, %d)
+if not track then
+    return fail("track %d not found")
+end
+reaper.Undo_BeginBlock()
+reaper.SetMediaTrackInfo_Value(track, "I_AUTOMODE", %d)
+reaper.Undo_EndBlock("Ori: Set track automation mode", -1)
+`, trackIndex-1, trackIndex, value))
+	if err != nil {
+		return fmt.Errorf("failed to set automation mode: %w", err)
+	}
+	return nil
+}
+
+// SetAutomationOverride sets or clears REAPER's global automation override via the Lua bridge
+func SetAutomationOverride(ctx context.Context, mode string) error {
+	value, err := parseAutomationOverride(mode)
+	if err != nil {
+		return err
+	}
+
+	_, err = bridge.Run(ctx, "set_automation_override", fmt.Sprintf(`reaper.SetGlobalAutomationOverride(%d)
+`, value))
+	if err != nil {
+		return fmt.Errorf("failed to set automation override: %w", err)
+	}
+	return nil
+}
+
+// FormatAutomationState formats automation modes as a readable table
+func FormatAutomationState(state *AutomationState) string {
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Global automation override: %s\n\n", state.Override))
+
+	if len(state.Tracks) == 0 {
+		result.WriteString("No tracks found in REAPER project")
+		return result.String()
+	}
+
+	result.WriteString("Index | Name                    | Automation\n")
+	result.WriteString("------|-------------------------|-----------\n")
+	for _, track := range state.Tracks {
+		result.WriteString(fmt.Sprintf("%-5d | %-23s | %s\n",
+			track.Index,
+			truncateString(track.Name, 23),
+			track.AutomationMode,
+		))
+	}
+
+	return result.String()
+}
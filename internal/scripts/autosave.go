@@ -0,0 +1,61 @@
+package scripts
+
+import (
+	"fmt"
+	"strconv"
+)
+
+const (
+	// autosaveIntervalKey is the reaper.ini key holding the auto-save interval in minutes
+	autosaveIntervalKey = "autosaveint"
+	// autosaveModeKey is the reaper.ini key holding the auto-save option flags
+	autosaveModeKey = "autosavemode"
+)
+
+// AutosaveSettings describes REAPER's project auto-save preferences
+type AutosaveSettings struct {
+	IntervalMinutes int `json:"interval_minutes"` // 0 if not configured
+	Mode            int `json:"mode"`             // Raw auto-save option flags from reaper.ini
+}
+
+// GetAutosaveSettings reads the auto-save preferences from reaper.ini
+func GetAutosaveSettings() (*AutosaveSettings, error) {
+	settings := &AutosaveSettings{}
+
+	value, found, err := GetReaperIniValue("REAPER", autosaveIntervalKey)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		settings.IntervalMinutes, _ = strconv.Atoi(value)
+	}
+
+	value, found, err = GetReaperIniValue("REAPER", autosaveModeKey)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		settings.Mode, _ = strconv.Atoi(value)
+	}
+
+	return settings, nil
+}
+
+// SetAutosaveInterval writes the auto-save interval (in minutes) to reaper.ini
+func SetAutosaveInterval(minutes int) error {
+	if minutes < 1 || minutes > 1440 {
+		return fmt.Errorf("auto-save interval must be between 1 and 1440 minutes")
+	}
+	return SetReaperIniValue("REAPER", autosaveIntervalKey, strconv.Itoa(minutes))
+}
+
+// FormatAutosaveSettings formats auto-save preferences as a readable summary
+func FormatAutosaveSettings(settings *AutosaveSettings) string {
+	interval := "not configured"
+	if settings.IntervalMinutes > 0 {
+		interval = fmt.Sprintf("every %d minute(s)", settings.IntervalMinutes)
+	}
+	return fmt.Sprintf("REAPER Auto-save:\n"+
+		"  Interval: %s\n"+
+		"  Mode flags: %d\n", interval, settings.Mode)
+}
@@ -0,0 +1,385 @@
+package scripts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/errcode"
+	"github.com/johnjallday/ori-reaper-plugin/internal/progress"
+	"github.com/johnjallday/ori-reaper-plugin/internal/types"
+)
+
+// bundleInstallSteps are the stages InstallBundle reports: downloading, registering in
+// reaper-kb.ini and adding toolbar buttons
+const bundleInstallSteps = 3
+
+// InstalledBundle records what installing a bundle added, so uninstalling removes only that
+type InstalledBundle struct {
+	Scripts     []string        `json:"scripts,omitempty"`    // Script files the bundle downloaded
+	KBEntries   []string        `json:"kb_entries,omitempty"` // Lines added to reaper-kb.ini
+	Toolbar     []ToolbarButton `json:"toolbar,omitempty"`    // Toolbar buttons added
+	InstalledAt time.Time       `json:"installed_at"`
+}
+
+// LoadBundleManifest reads a bundle from a JSON manifest file
+func LoadBundleManifest(path string) (types.Bundle, error) {
+	var bundle types.Bundle
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return bundle, fmt.Errorf("failed to read bundle manifest: %w", err)
+	}
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return bundle, errcode.Errorf(errcode.ConfigParseError, "failed to parse bundle manifest %s: %w", path, err)
+	}
+	return bundle, validateBundle(bundle)
+}
+
+// FindBundle looks up a bundle by name (case-insensitive)
+func FindBundle(bundles []types.Bundle, name string) (types.Bundle, error) {
+	if strings.TrimSpace(name) == "" {
+		return types.Bundle{}, errors.New("bundle name is required")
+	}
+	for _, bundle := range bundles {
+		if strings.EqualFold(bundle.Name, name) {
+			return bundle, validateBundle(bundle)
+		}
+	}
+	return types.Bundle{}, fmt.Errorf("bundle not found: %s", name)
+}
+
+// validateBundle checks that a bundle's shortcuts and toolbar buttons can be installed
+func validateBundle(bundle types.Bundle) error {
+	if strings.TrimSpace(bundle.Name) == "" {
+		return errors.New("bundle has no name")
+	}
+	if len(bundle.Scripts) == 0 {
+		return fmt.Errorf("bundle '%s' lists no scripts", bundle.Name)
+	}
+	inBundle := make(map[string]bool)
+	for _, script := range bundle.Scripts {
+		if !isScriptFile(script) || filepath.Base(script) != script {
+			return fmt.Errorf("bundle '%s': invalid script filename %q", bundle.Name, script)
+		}
+		inBundle[script] = true
+	}
+
+	checkTarget := func(what, script, command string) error {
+		switch {
+		case script != "" && !inBundle[script]:
+			return fmt.Errorf("bundle '%s': %s refers to %s, which is not one of its scripts", bundle.Name, what, script)
+		case script == "" && !isCommandID(command):
+			return fmt.Errorf("bundle '%s': %s needs a script or a valid command ID", bundle.Name, what)
+		}
+		return nil
+	}
+	for _, shortcut := range bundle.Shortcuts {
+		if _, _, err := ParseShortcut(shortcut.Key); err != nil {
+			return fmt.Errorf("bundle '%s': %w", bundle.Name, err)
+		}
+		if err := checkTarget("shortcut "+shortcut.Key, shortcut.Script, shortcut.Command); err != nil {
+			return err
+		}
+	}
+	for _, item := range bundle.Toolbar {
+		if err := checkTarget("toolbar button", item.Script, item.Command); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scriptCommand returns the command ID of the script at scriptPath in reaper-kb.ini lines.
+// Unregistered scripts get a new SCR entry, returned so it can be appended; entries written
+// without a command ID are given one in place.
+func scriptCommand(lines []string, scriptPath string) (string, string, error) {
+	quotedPath := `"` + scriptPath + `"`
+	for i, line := range lines {
+		if !strings.HasPrefix(line, "SCR ") || !strings.Contains(line, quotedPath) {
+			continue
+		}
+		// SCR <flags> <section> <id> "<description>" "<path>"
+		fields := strings.Fields(line)
+		if len(fields) > 3 && !strings.HasPrefix(fields[3], `"`) {
+			return "_" + fields[3], "", nil
+		}
+		id, err := newActionID()
+		if err != nil {
+			return "", "", err
+		}
+		lines[i] = strings.Join(fields[:3], " ") + " RS" + id + " " + strings.Join(fields[3:], " ")
+		return "_RS" + id, "", nil
+	}
+
+	id, err := newActionID()
+	if err != nil {
+		return "", "", err
+	}
+	name := strings.TrimSuffix(filepath.Base(scriptPath), filepath.Ext(scriptPath))
+	return "_RS" + id, fmt.Sprintf(`SCR 4 0 RS%s "Script: %s" %s`, id, name, quotedPath), nil
+}
+
+// keyBinding returns the command bound to a key in the Main section, if any
+func keyBinding(lines []string, flags, code int) string {
+	for _, line := range lines {
+		// KEY <flags> <key> <command> <section>
+		fields := strings.Fields(line)
+		if len(fields) == 5 && fields[0] == "KEY" && fields[1] == strconv.Itoa(flags) &&
+			fields[2] == strconv.Itoa(code) && fields[4] == strconv.Itoa(customActionSectionMain) {
+			return fields[3]
+		}
+	}
+	return ""
+}
+
+// InstallBundle downloads a bundle's scripts that aren't installed yet, registers them in
+// reaper-kb.ini, and adds its shortcuts and toolbar buttons. Shortcuts for keys that are
+// already bound are skipped rather than replacing the existing binding. Each stage is
+// reported to the progress reporter in ctx, if any, and downloads to sd's progress function.
+func (sd *ScriptDownloader) InstallBundle(ctx context.Context, bundle types.Bundle, targetDir string) (string, error) {
+	if err := validateBundle(bundle); err != nil {
+		return "", err
+	}
+	sm := NewScriptManager(targetDir)
+	metadata, err := sm.LoadMetadata()
+	if err != nil {
+		return "", err
+	}
+	if _, ok := metadata.Bundles[bundle.Name]; ok {
+		return "", fmt.Errorf("bundle '%s' is already installed; uninstall it first to reinstall", bundle.Name)
+	}
+
+	record := InstalledBundle{InstalledAt: time.Now()}
+	var missing []string
+	for _, script := range bundle.Scripts {
+		if _, err := os.Stat(filepath.Join(targetDir, script)); os.IsNotExist(err) {
+			missing = append(missing, script)
+		}
+	}
+	if len(missing) > 0 {
+		progress.Report(ctx, fmt.Sprintf("Downloading %d script(s)", len(missing)), 0, bundleInstallSteps)
+		results, err := sd.DownloadScripts(ctx, missing, targetDir)
+		if err != nil {
+			return "", err
+		}
+		var failures []string
+		for _, r := range results {
+			if r.Error == "" {
+				record.Scripts = append(record.Scripts, r.Filename)
+			} else {
+				failures = append(failures, fmt.Sprintf("%s (%s)", r.Filename, r.Error))
+			}
+		}
+		if len(failures) > 0 {
+			sm.trashScripts(record.Scripts)
+			return "", fmt.Errorf("bundle '%s' was not installed; failed to download %s", bundle.Name, strings.Join(failures, ", "))
+		}
+	}
+
+	kbIniPath, err := GetReaperKBIniPath()
+	if err != nil {
+		return "", err
+	}
+	var commands map[string]string
+	target := func(script, command string) string {
+		if script != "" {
+			return commands[script]
+		}
+		return command
+	}
+	var notes []string
+	progress.Report(ctx, "Registering scripts and shortcuts in reaper-kb.ini", 1, bundleInstallSteps)
+	err = updateConfigFile(kbIniPath, "install bundle "+bundle.Name, func(content []byte) ([]byte, error) {
+		var lines []string
+		if text := strings.TrimRight(string(content), "\n"); text != "" {
+			lines = strings.Split(text, "\n")
+		}
+
+		commands, notes, record.KBEntries = make(map[string]string), nil, nil
+		for _, script := range bundle.Scripts {
+			command, entry, err := scriptCommand(lines, filepath.Join(targetDir, script))
+			if err != nil {
+				return nil, err
+			}
+			if entry != "" {
+				lines = append(lines, entry)
+				record.KBEntries = append(record.KBEntries, entry)
+			}
+			commands[script] = command
+		}
+
+		for _, shortcut := range bundle.Shortcuts {
+			flags, code, _ := ParseShortcut(shortcut.Key)
+			if existing := keyBinding(lines, flags, code); existing != "" {
+				notes = append(notes, fmt.Sprintf("Skipped shortcut %s: already bound to %s", shortcut.Key, existing))
+				continue
+			}
+			entry := fmt.Sprintf("KEY %d %d %s %d", flags, code, target(shortcut.Script, shortcut.Command), customActionSectionMain)
+			lines = append(lines, entry)
+			record.KBEntries = append(record.KBEntries, entry)
+		}
+		return []byte(strings.Join(lines, "\n") + "\n"), nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	progress.Report(ctx, fmt.Sprintf("Adding %d toolbar button(s)", len(bundle.Toolbar)), 2, bundleInstallSteps)
+	var buttons []ToolbarButton
+	for _, item := range bundle.Toolbar {
+		toolbar := item.Toolbar
+		if toolbar == "" {
+			toolbar = DefaultBundleToolbar
+		}
+		label := item.Label
+		if label == "" {
+			label = ToTitleCase(strings.ReplaceAll(strings.TrimSuffix(item.Script, filepath.Ext(item.Script)), "_", " "))
+		}
+		if label == "" {
+			label = item.Command
+		}
+		buttons = append(buttons, ToolbarButton{Toolbar: toolbar, Value: target(item.Script, item.Command) + " " + label})
+	}
+	if err := AddToolbarButtons(buttons); err != nil {
+		notes = append(notes, fmt.Sprintf("Toolbar buttons were not added: %v", err))
+	} else {
+		record.Toolbar = buttons
+	}
+
+	err = sm.updateMetadata(func(metadata *ScriptMetadata) error {
+		metadata.Bundles[bundle.Name] = record
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	progress.Report(ctx, fmt.Sprintf("Installed bundle '%s'", bundle.Name), bundleInstallSteps, bundleInstallSteps)
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("📦 Installed bundle '%s':\n", bundle.Name))
+	b.WriteString(fmt.Sprintf("  • %d script(s) downloaded, %d already present\n", len(record.Scripts), len(bundle.Scripts)-len(record.Scripts)))
+	b.WriteString(fmt.Sprintf("  • %d reaper-kb.ini entries added\n", len(record.KBEntries)))
+	b.WriteString(fmt.Sprintf("  • %d toolbar button(s) added\n", len(record.Toolbar)))
+	for _, note := range notes {
+		b.WriteString("  ⚠️ " + note + "\n")
+	}
+	b.WriteString("\nRestart REAPER to load the new actions, shortcuts and toolbar buttons.")
+	return b.String(), nil
+}
+
+// trashScripts moves script files to the trash, ignoring ones that are already gone
+func (sm *ScriptManager) trashScripts(filenames []string) {
+	for _, filename := range filenames {
+		path, err := sm.scriptFilePath(filename)
+		if err != nil {
+			continue
+		}
+		if _, err := sm.backend.FS.Stat(path); err == nil {
+			sm.moveToTrash(path)
+		}
+	}
+}
+
+// UninstallBundle removes what installing a bundle added: its reaper-kb.ini entries,
+// toolbar buttons, and the scripts it downloaded (moved to the trash). Scripts that were
+// already installed before the bundle are kept.
+func (sm *ScriptManager) UninstallBundle(name string) (string, error) {
+	metadata, err := sm.LoadMetadata()
+	if err != nil {
+		return "", err
+	}
+	var record InstalledBundle
+	found := false
+	for bundleName, r := range metadata.Bundles {
+		if strings.EqualFold(bundleName, name) {
+			name, record, found = bundleName, r, true
+			break
+		}
+	}
+	if !found {
+		return "", fmt.Errorf("bundle '%s' is not installed", name)
+	}
+
+	if len(record.KBEntries) > 0 {
+		kbIniPath, err := GetReaperKBIniPath()
+		if err != nil {
+			return "", err
+		}
+		added := make(map[string]bool)
+		for _, entry := range record.KBEntries {
+			added[entry] = true
+		}
+		err = updateConfigFile(kbIniPath, "uninstall bundle "+name, func(content []byte) ([]byte, error) {
+			var kept []string
+			for _, line := range strings.Split(string(content), "\n") {
+				if !added[line] {
+					kept = append(kept, line)
+				}
+			}
+			return []byte(strings.Join(kept, "\n")), nil
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if err := RemoveToolbarButtons(record.Toolbar); err != nil {
+		return "", err
+	}
+
+	sm.trashScripts(record.Scripts)
+
+	err = sm.updateMetadata(func(metadata *ScriptMetadata) error {
+		for _, script := range record.Scripts {
+			delete(metadata.Installed, script)
+			delete(metadata.Pins, script)
+		}
+		delete(metadata.Bundles, name)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Uninstalled bundle '%s': removed %d reaper-kb.ini entries and %d toolbar button(s), and moved %d script(s) to the trash. Restart REAPER to apply.",
+		name, len(record.KBEntries), len(record.Toolbar), len(record.Scripts)), nil
+}
+
+// FormatBundles lists the configured bundles and whether each is installed
+func (sm *ScriptManager) FormatBundles(bundles []types.Bundle) (string, error) {
+	if len(bundles) == 0 {
+		return "No bundles configured. Add them under \"bundles\" in the settings file, or install one from a manifest with 'install_bundle' and 'path'.", nil
+	}
+	metadata, err := sm.LoadMetadata()
+	if err != nil {
+		return "", err
+	}
+
+	sorted := append([]types.Bundle(nil), bundles...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("📦 Script bundles (%d):\n", len(sorted)))
+	for _, bundle := range sorted {
+		status := ""
+		if record, ok := metadata.Bundles[bundle.Name]; ok {
+			status = fmt.Sprintf(" ✅ installed %s", record.InstalledAt.Format("2006-01-02"))
+		}
+		b.WriteString(fmt.Sprintf("\n• %s%s\n", bundle.Name, status))
+		if bundle.Description != "" {
+			b.WriteString("  " + bundle.Description + "\n")
+		}
+		b.WriteString(fmt.Sprintf("  Scripts: %s\n", strings.Join(bundle.Scripts, ", ")))
+		if len(bundle.Shortcuts) > 0 || len(bundle.Toolbar) > 0 {
+			b.WriteString(fmt.Sprintf("  %d shortcut(s), %d toolbar button(s)\n", len(bundle.Shortcuts), len(bundle.Toolbar)))
+		}
+	}
+	return b.String(), nil
+}
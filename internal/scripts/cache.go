@@ -0,0 +1,171 @@
+package scripts
+
+import (
+	"bufio"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/johnjallday/ori-reaper-plugin/internal/metrics"
+	"github.com/johnjallday/ori-reaper-plugin/internal/platform"
+)
+
+// maxHeaderLines is how far into a script its metadata header is looked for
+const maxHeaderLines = 50
+
+// headerTagPattern matches ReaPack-style header tags such as "-- @description Foo"
+var headerTagPattern = regexp.MustCompile(`^[-\[\s]*@(\w+)\s+(.+?)\s*$`)
+
+// ScriptInfo is a .lua script in the scripts directory with the fields of its metadata header
+type ScriptInfo struct {
+	Name        string `json:"name"` // Base name without extension
+	Description string `json:"description,omitempty"`
+	Author      string `json:"author,omitempty"`
+	Version     string `json:"version,omitempty"`
+}
+
+// scriptListCache keeps the parsed script list of each scripts directory until
+// fsnotify reports a change in it
+type scriptListCache struct {
+	mu      sync.Mutex
+	dirs    map[string][]ScriptInfo
+	watcher *fsnotify.Watcher
+	failed  bool // The watcher couldn't be created; nothing is cached
+}
+
+// scriptCache is shared by every ScriptManager in the process
+var scriptCache = &scriptListCache{dirs: make(map[string][]ScriptInfo)}
+
+// CachedScripts returns the .lua scripts in dir with their headers, reading the directory
+// only when it changed since the last call. Without file watching support it always reads.
+func CachedScripts(dir string) ([]ScriptInfo, error) {
+	dir = filepath.Clean(dir)
+	scriptCache.mu.Lock()
+	defer scriptCache.mu.Unlock()
+
+	if infos, ok := scriptCache.dirs[dir]; ok {
+		metrics.CountScriptCache(true)
+		return append([]ScriptInfo(nil), infos...), nil
+	}
+	metrics.CountScriptCache(false)
+
+	// Watch before reading so no change between the two is missed. Only what is
+	// watched is cached, so the list can't go stale.
+	watched := scriptCache.watch(dir)
+	infos, err := readScriptInfos(platform.OSFS{}, dir)
+	if err != nil {
+		return nil, err
+	}
+	if watched {
+		scriptCache.dirs[dir] = infos
+	}
+	return append([]ScriptInfo(nil), infos...), nil
+}
+
+// CachedLuaScripts returns the base names of the .lua scripts in dir, like ListLuaScripts,
+// from the cache
+func CachedLuaScripts(dir string) ([]string, error) {
+	infos, err := CachedScripts(dir)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(infos))
+	for i, info := range infos {
+		names[i] = info.Name
+	}
+	return names, nil
+}
+
+// invalidateScriptCache drops the cached list of dir. Called after the plugin changes the
+// directory itself, since the fsnotify event arrives asynchronously.
+func invalidateScriptCache(dir string) {
+	scriptCache.mu.Lock()
+	defer scriptCache.mu.Unlock()
+	delete(scriptCache.dirs, filepath.Clean(dir))
+}
+
+// watch starts watching dir, creating the watcher on first use. Must be called with mu held.
+func (c *scriptListCache) watch(dir string) bool {
+	if c.failed {
+		return false
+	}
+	if c.watcher == nil {
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			c.failed = true
+			return false
+		}
+		c.watcher = watcher
+		go c.run()
+	}
+	return c.watcher.Add(dir) == nil
+}
+
+// run invalidates cached directories as change events arrive
+func (c *scriptListCache) run() {
+	for {
+		select {
+		case event, ok := <-c.watcher.Events:
+			if !ok {
+				return
+			}
+			c.mu.Lock()
+			delete(c.dirs, filepath.Dir(event.Name))
+			// The watched directory itself was removed or renamed
+			delete(c.dirs, filepath.Clean(event.Name))
+			c.mu.Unlock()
+		case _, ok := <-c.watcher.Errors:
+			if !ok {
+				return
+			}
+			// Events may have been dropped; start over
+			c.mu.Lock()
+			c.dirs = make(map[string][]ScriptInfo)
+			c.mu.Unlock()
+		}
+	}
+}
+
+// readScriptInfos lists the .lua scripts in dir on fsys and parses their headers
+func readScriptInfos(fsys platform.FS, dir string) ([]ScriptInfo, error) {
+	names, err := listLuaScripts(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]ScriptInfo, len(names))
+	for i, name := range names {
+		infos[i] = parseScriptHeader(fsys, filepath.Join(dir, name+".lua"))
+		infos[i].Name = name
+	}
+	return infos, nil
+}
+
+// parseScriptHeader reads the @description, @author and @version tags from the top of a
+// script. Unreadable files give an empty header.
+func parseScriptHeader(fsys platform.FS, path string) ScriptInfo {
+	var info ScriptInfo
+	file, err := fsys.Open(path)
+	if err != nil {
+		return info
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for line := 0; line < maxHeaderLines && scanner.Scan(); line++ {
+		match := headerTagPattern.FindStringSubmatch(scanner.Text())
+		if match == nil {
+			continue
+		}
+		switch strings.ToLower(match[1]) {
+		case "description":
+			info.Description = match[2]
+		case "author":
+			info.Author = match[2]
+		case "version":
+			info.Version = match[2]
+		}
+	}
+	return info
+}
@@ -0,0 +1,186 @@
+package scripts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// maxChangelogEntries caps how many commits are shown per script
+const maxChangelogEntries = 10
+
+// ChangelogEntry is one commit that touched a script
+type ChangelogEntry struct {
+	SHA     string    `json:"sha"`
+	Author  string    `json:"author"`
+	Date    time.Time `json:"date"`
+	Message string    `json:"message"`
+}
+
+// ScriptUpdateInfo describes the update available for one installed script
+type ScriptUpdateInfo struct {
+	Filename  string           `json:"filename"`
+	From      string           `json:"from,omitempty"`
+	To        string           `json:"to,omitempty"`
+	Pinned    bool             `json:"pinned,omitempty"`
+	Available bool             `json:"available"`
+	Changelog []ChangelogEntry `json:"changelog,omitempty"`
+	Error     string           `json:"error,omitempty"`
+}
+
+// commitsURL builds the GitHub commits API URL listing commits to a file, from the
+// contents API URL the file was listed with
+func commitsURL(file GitHubFile, since time.Time) (string, error) {
+	u, err := url.Parse(file.URL)
+	if err != nil {
+		return "", fmt.Errorf("invalid file URL %q: %w", file.URL, err)
+	}
+	// Contents API paths look like /repos/<owner>/<repo>/contents/<path>
+	parts := strings.SplitN(strings.TrimPrefix(u.Path, "/"), "/", 4)
+	if len(parts) < 4 || parts[0] != "repos" || !strings.HasPrefix(parts[3], "contents/") {
+		return "", fmt.Errorf("not a GitHub contents URL: %s", file.URL)
+	}
+
+	query := url.Values{}
+	query.Set("path", file.Path)
+	query.Set("per_page", fmt.Sprint(maxChangelogEntries))
+	if ref := u.Query().Get("ref"); ref != "" {
+		query.Set("sha", ref)
+	}
+	if !since.IsZero() {
+		query.Set("since", since.UTC().Format(time.RFC3339))
+	}
+	return fmt.Sprintf("%s://%s/repos/%s/%s/commits?%s", u.Scheme, u.Host, parts[1], parts[2], query.Encode()), nil
+}
+
+// fetchChangelog lists the commits to a script made after since, newest first
+func fetchChangelog(ctx context.Context, file GitHubFile, since time.Time) ([]ChangelogEntry, error) {
+	apiURL, err := commitsURL(file, since)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := httpGet(ctx, apiURL)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("GitHub API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var commits []struct {
+		SHA    string `json:"sha"`
+		Commit struct {
+			Message string `json:"message"`
+			Author  struct {
+				Name string    `json:"name"`
+				Date time.Time `json:"date"`
+			} `json:"author"`
+		} `json:"commit"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&commits); err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub API response: %w", err)
+	}
+
+	entries := make([]ChangelogEntry, 0, len(commits))
+	for _, c := range commits {
+		entries = append(entries, ChangelogEntry{
+			SHA:     c.SHA,
+			Author:  c.Commit.Author.Name,
+			Date:    c.Commit.Author.Date,
+			Message: c.Commit.Message,
+		})
+	}
+	return entries, nil
+}
+
+// CheckUpdates reports which installed scripts have a newer marketplace version, with the
+// commits made to each since it was installed. Nothing is downloaded. With no names (or a
+// single "all") every script installed from the marketplace is checked.
+func (sd *ScriptDownloader) CheckUpdates(ctx context.Context, names []string, targetDir string) ([]ScriptUpdateInfo, error) {
+	sm := NewScriptManager(targetDir)
+	metadata, err := sm.LoadMetadata()
+	if err != nil {
+		return nil, err
+	}
+	filenames, err := sm.updateTargets(metadata, names)
+	if err != nil {
+		return nil, err
+	}
+	latest, err := sd.latestVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]ScriptUpdateInfo, 0, len(filenames))
+	for _, filename := range filenames {
+		installed := metadata.Installed[filename]
+		_, pinned := metadata.Pins[filename]
+		info := ScriptUpdateInfo{Filename: filename, From: shortSHA(installed.SHA), Pinned: pinned}
+
+		file, ok := latest[filename]
+		if !ok {
+			info.Error = "no longer available in the marketplace"
+			infos = append(infos, info)
+			continue
+		}
+		info.To = shortSHA(file.SHA)
+		info.Available = installed.SHA != file.SHA
+		if info.Available {
+			changelog, err := fetchChangelog(ctx, file, installed.InstalledAt)
+			if err != nil {
+				info.Error = fmt.Sprintf("could not fetch changelog: %v", err)
+			}
+			info.Changelog = changelog
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// FormatUpdateInfo summarizes available updates and their changelogs
+func FormatUpdateInfo(infos []ScriptUpdateInfo) string {
+	available := 0
+	var b strings.Builder
+	for _, info := range infos {
+		switch {
+		case !info.Available && info.Error != "":
+			b.WriteString(fmt.Sprintf("❌ %s: %s\n", info.Filename, info.Error))
+			continue
+		case !info.Available:
+			b.WriteString(fmt.Sprintf("➖ %s: up to date (%s)\n", info.Filename, info.To))
+			continue
+		}
+
+		available++
+		from := info.From
+		if from == "" {
+			from = "unknown version"
+		}
+		b.WriteString(fmt.Sprintf("⬆️ %s: %s → %s", info.Filename, from, info.To))
+		if info.Pinned {
+			b.WriteString(" (pinned, will be skipped until unpinned)")
+		}
+		b.WriteString("\n")
+		for _, entry := range info.Changelog {
+			// Only the commit subject; bodies make the list hard to scan
+			subject, _, _ := strings.Cut(entry.Message, "\n")
+			b.WriteString(fmt.Sprintf("    • %s %s (%s, %s)\n", shortSHA(entry.SHA), subject,
+				entry.Author, entry.Date.Format("2006-01-02")))
+		}
+		if info.Error != "" {
+			b.WriteString(fmt.Sprintf("    ⚠️ %s\n", info.Error))
+		} else if len(info.Changelog) == 0 {
+			b.WriteString("    (no commit history found since this script was installed)\n")
+		}
+	}
+	return fmt.Sprintf("%d of %d scripts have updates:\n", available, len(infos)) + b.String()
+}
@@ -0,0 +1,66 @@
+package scripts
+
+import (
+	"sync"
+	"time"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/errcode"
+	"github.com/johnjallday/ori-reaper-plugin/internal/i18n"
+)
+
+// RetryPolicy controls how WebRemoteClient retries requests that fail to connect. Other
+// failures aren't retried, since the request may already have run an action.
+type RetryPolicy struct {
+	MaxRetries     int           // Retries after the first attempt; 0 disables retrying
+	InitialBackoff time.Duration // Wait before the first retry, doubled for each further retry
+	MaxBackoff     time.Duration // Upper bound for the wait between retries
+}
+
+// DefaultRetryPolicy rides out brief hiccups without making an unreachable REAPER slow to report
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries:     2,
+	InitialBackoff: 200 * time.Millisecond,
+	MaxBackoff:     2 * time.Second,
+}
+
+// circuitOpenDuration is how long requests to an unreachable Web Remote fail fast
+// before the next real connection attempt
+const circuitOpenDuration = 15 * time.Second
+
+// circuit remembers a recent connection failure for one Web Remote address
+type circuit struct {
+	openUntil time.Time
+	lastErr   error
+}
+
+// Clients are created per call, so circuit state is shared by address
+var (
+	circuitsMu sync.Mutex
+	circuits   = make(map[string]*circuit)
+)
+
+// checkCircuit returns an error while the circuit for baseURL is open
+func checkCircuit(baseURL string) error {
+	circuitsMu.Lock()
+	defer circuitsMu.Unlock()
+
+	c, ok := circuits[baseURL]
+	if !ok || time.Now().After(c.openUntil) {
+		return nil
+	}
+	return errcode.New(errcode.WebRemoteUnreachable, i18n.Errorf("error.web_remote_circuit_open", baseURL, c.lastErr, time.Until(c.openUntil).Round(time.Second)))
+}
+
+// openCircuit records a connection failure for baseURL
+func openCircuit(baseURL string, err error) {
+	circuitsMu.Lock()
+	defer circuitsMu.Unlock()
+	circuits[baseURL] = &circuit{openUntil: time.Now().Add(circuitOpenDuration), lastErr: err}
+}
+
+// closeCircuit clears any recorded failure for baseURL
+func closeCircuit(baseURL string) {
+	circuitsMu.Lock()
+	defer circuitsMu.Unlock()
+	delete(circuits, baseURL)
+}
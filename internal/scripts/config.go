@@ -2,33 +2,61 @@ package scripts
 
 import (
 	"bufio"
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
+	"net"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/platform"
+	"github.com/johnjallday/ori-reaper-plugin/internal/project"
 )
 
-// GetReaperIniPath returns the platform-specific path to reaper.ini
-func GetReaperIniPath() (string, error) {
-	var basePath string
+// configFS is where reaper.ini and reaper-kb.ini are read and written
+var configFS platform.FS = platform.OSFS{}
+
+// SetConfigFS replaces the file system used for REAPER's config files, e.g. with a fake
+// in tests. nil restores the local file system.
+func SetConfigFS(fsys platform.FS) {
+	if fsys == nil {
+		fsys = platform.OSFS{}
+	}
+	configFS = fsys
+}
+
+// maxIniLine is the longest reaper.ini line read. Some values, such as plugin state
+// and long recent file lists, run past bufio.Scanner's default 64 KB limit.
+const maxIniLine = 16 * 1024 * 1024
+
+// newIniScanner returns a line scanner for reaper.ini content that reads lines up to maxIniLine
+func newIniScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxIniLine)
+	return scanner
+}
 
+// GetReaperResourcePath returns the platform-specific REAPER resource directory
+// (the folder containing reaper.ini, Scripts, UserPlugins, etc.)
+func GetReaperResourcePath() (string, error) {
 	switch runtime.GOOS {
 	case "darwin": // macOS
 		homeDir, err := os.UserHomeDir()
 		if err != nil {
 			return "", fmt.Errorf("failed to get home directory: %w", err)
 		}
-		basePath = filepath.Join(homeDir, "Library", "Application Support", "REAPER")
+		return filepath.Join(homeDir, "Library", "Application Support", "REAPER"), nil
 
 	case "windows":
 		appData := os.Getenv("APPDATA")
 		if appData == "" {
 			return "", errors.New("APPDATA environment variable not set")
 		}
-		basePath = filepath.Join(appData, "REAPER")
+		return filepath.Join(appData, "REAPER"), nil
 
 	case "linux":
 		homeDir, err := os.UserHomeDir()
@@ -38,19 +66,26 @@ func GetReaperIniPath() (string, error) {
 		// Try common Linux paths
 		xdgConfig := os.Getenv("XDG_CONFIG_HOME")
 		if xdgConfig != "" {
-			basePath = filepath.Join(xdgConfig, "REAPER")
-		} else {
-			basePath = filepath.Join(homeDir, ".config", "REAPER")
+			return filepath.Join(xdgConfig, "REAPER"), nil
 		}
+		return filepath.Join(homeDir, ".config", "REAPER"), nil
 
 	default:
 		return "", fmt.Errorf("unsupported platform: %s", runtime.GOOS)
 	}
+}
+
+// GetReaperIniPath returns the platform-specific path to reaper.ini
+func GetReaperIniPath() (string, error) {
+	basePath, err := GetReaperResourcePath()
+	if err != nil {
+		return "", err
+	}
 
 	iniPath := filepath.Join(basePath, "reaper.ini")
 
 	// Check if the file exists
-	if _, err := os.Stat(iniPath); os.IsNotExist(err) {
+	if _, err := configFS.Stat(iniPath); os.IsNotExist(err) {
 		return "", fmt.Errorf("reaper.ini not found at %s (is REAPER installed?)", iniPath)
 	}
 
@@ -59,10 +94,57 @@ func GetReaperIniPath() (string, error) {
 
 // WebRemoteConfig represents the web remote control surface configuration
 type WebRemoteConfig struct {
-	Port      int    `json:"port"`
-	Enabled   bool   `json:"enabled"`
-	CSurfID   int    `json:"csurf_id"`   // The csurf_N index
-	RawConfig string `json:"raw_config"` // The full csurf line
+	Port        int    `json:"port"`
+	Enabled     bool   `json:"enabled"`
+	CSurfID     int    `json:"csurf_id"`               // The csurf_N index
+	DefaultPage string `json:"default_page,omitempty"` // Interface served at the root URL (HTTP entries)
+	AuthString  string `json:"-"`                      // "user:password" required by the Web Remote, if any (HTTP entries)
+	AllowRemote bool   `json:"allow_remote"`           // Whether other machines may connect (HTTP entries)
+	RawConfig   string `json:"-"`                      // The full csurf line
+}
+
+// HasAuth reports whether the Web Remote requires a username and password
+func (c *WebRemoteConfig) HasAuth() bool {
+	return c.AuthString != ""
+}
+
+// httpValue renders the config as an HTTP csurf value, keeping any fields of the
+// existing entry that WebRemoteConfig doesn't model:
+// HTTP <enabled> <port> '<user:password>' '<default page>' <allow remote> '<extra>'
+func (c *WebRemoteConfig) httpValue() string {
+	tokens := []string{"HTTP", "1", "8080", "", "index.html", "0", ""}
+	if strings.HasPrefix(c.RawConfig, "HTTP ") {
+		existing := project.Tokenize(c.RawConfig)
+		copy(tokens, existing)
+		if len(existing) > len(tokens) {
+			tokens = append(tokens, existing[len(tokens):]...)
+		}
+	}
+
+	tokens[1] = boolField(c.Enabled)
+	tokens[2] = strconv.Itoa(c.Port)
+	tokens[3] = c.AuthString
+	tokens[4] = c.DefaultPage
+	tokens[5] = boolField(c.AllowRemote)
+
+	fields := make([]string, len(tokens))
+	for i, token := range tokens {
+		switch i {
+		case 0, 1, 2, 5:
+			fields[i] = token
+		default:
+			fields[i] = project.QuoteString(token)
+		}
+	}
+	return strings.Join(fields, " ")
+}
+
+// boolField renders a boolean as a reaper.ini 0/1 field
+func boolField(v bool) string {
+	if v {
+		return "1"
+	}
+	return "0"
 }
 
 // GetWebRemotePort reads reaper.ini and extracts the web remote port from csurf entries
@@ -86,13 +168,13 @@ func GetWebRemoteConfig() (*WebRemoteConfig, error) {
 		return nil, err
 	}
 
-	file, err := os.Open(iniPath)
+	file, err := configFS.Open(iniPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open reaper.ini: %w", err)
 	}
 	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
+	scanner := newIniScanner(file)
 	for scanner.Scan() {
 		line := scanner.Text()
 		trimmed := strings.TrimSpace(line)
@@ -156,12 +238,26 @@ func GetWebRemoteConfig() (*WebRemoteConfig, error) {
 					}
 				}
 
-				return &WebRemoteConfig{
+				config := &WebRemoteConfig{
 					Port:      port,
 					Enabled:   enabled,
 					CSurfID:   csurfID,
 					RawConfig: csurfValue,
-				}, nil
+				}
+
+				// HTTP entries quote their string fields: HTTP <enabled> <port> '<auth>' '<page>' <allow remote> ...
+				if tokens := project.Tokenize(csurfValue); tokens[0] == "HTTP" {
+					if len(tokens) > 3 {
+						config.AuthString = tokens[3]
+					}
+					if len(tokens) > 4 {
+						config.DefaultPage = tokens[4]
+					}
+					if len(tokens) > 5 {
+						config.AllowRemote = tokens[5] == "1"
+					}
+				}
+				return config, nil
 			}
 		}
 	}
@@ -181,14 +277,14 @@ func GetAllCSurfEntries() (map[string]string, error) {
 		return nil, err
 	}
 
-	file, err := os.Open(iniPath)
+	file, err := configFS.Open(iniPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open reaper.ini: %w", err)
 	}
 	defer file.Close()
 
 	csurfEntries := make(map[string]string)
-	scanner := bufio.NewScanner(file)
+	scanner := newIniScanner(file)
 
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -256,24 +352,45 @@ func ParseCSurfEntries() ([]CSurfEntry, error) {
 
 // SetWebRemotePort creates a new web remote control surface entry with the specified port
 // Instead of modifying existing entries, this creates a new csurf_N entry
+// The new entry keeps the authentication, default page and remote access settings of the current one.
 func SetWebRemotePort(newPort int) error {
+	config := &WebRemoteConfig{DefaultPage: "index.html"}
+	if current, err := GetWebRemoteConfig(); err == nil {
+		config = current
+	}
+	config.Port = newPort
+	config.Enabled = true
+	_, err := addCSurfEntry(config.httpValue())
+	return err
+}
+
+// addCSurfEntry appends a control surface entry to reaper.ini after the existing
+// csurf_N lines and updates csurf_cnt. Returns the new entry's csurf_N number.
+func addCSurfEntry(value string) (int, error) {
 	iniPath, err := GetReaperIniPath()
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	// Read the entire file
-	file, err := os.Open(iniPath)
+	var newCSurfID int
+	err = updateConfigFile(iniPath, "add control surface", func(data []byte) ([]byte, error) {
+		var err error
+		newCSurfID, data, err = insertCSurfEntry(data, value)
+		return data, err
+	})
 	if err != nil {
-		return fmt.Errorf("failed to open reaper.ini: %w", err)
+		return 0, err
 	}
-	defer file.Close()
+	return newCSurfID, nil
+}
 
+// insertCSurfEntry adds a csurf_N entry to reaper.ini content, returning its number and the new content
+func insertCSurfEntry(data []byte, value string) (int, []byte, error) {
 	var lines []string
 	var maxCSurfID int = -1
 	var csurfCntLineIndex int = -1
 	var insertIndex int = -1
-	scanner := bufio.NewScanner(file)
+	scanner := newIniScanner(bytes.NewReader(data))
 	lineIndex := 0
 
 	for scanner.Scan() {
@@ -307,12 +424,12 @@ func SetWebRemotePort(newPort int) error {
 	}
 
 	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("error reading reaper.ini: %w", err)
+		return 0, nil, fmt.Errorf("error reading reaper.ini: %w", err)
 	}
 
 	// Create new csurf entry
 	newCSurfID := maxCSurfID + 1
-	newCSurfLine := fmt.Sprintf("csurf_%d=HTTP 1 %d '' 'index.html' 0 ''", newCSurfID, newPort)
+	newCSurfLine := fmt.Sprintf("csurf_%d=%s", newCSurfID, value)
 
 	// Insert the new line
 	if insertIndex == -1 {
@@ -331,23 +448,34 @@ func SetWebRemotePort(newPort int) error {
 		}
 	}
 
-	// Update csurf_cnt if it exists
+	// csurf_cnt is the number of csurf_N entries REAPER loads (csurf_0 .. csurf_<cnt-1>)
 	if csurfCntLineIndex != -1 {
-		// csurf_cnt appears to be the highest index, not the total count
-		// So set it to the new highest ID
-		lines[csurfCntLineIndex] = fmt.Sprintf("csurf_cnt=%d", newCSurfID)
+		lines[csurfCntLineIndex] = fmt.Sprintf("csurf_cnt=%d", newCSurfID+1)
 	} else {
-		// Add csurf_cnt if it doesn't exist
-		lines = append(lines, fmt.Sprintf("csurf_cnt=%d", newCSurfID))
+		lines = append(lines, fmt.Sprintf("csurf_cnt=%d", newCSurfID+1))
 	}
 
-	// Write the file back
-	content := strings.Join(lines, "\n")
-	if err := os.WriteFile(iniPath, []byte(content), 0644); err != nil {
-		return fmt.Errorf("failed to write reaper.ini: %w", err)
+	return newCSurfID, []byte(strings.Join(lines, "\n")), nil
+}
+
+// replaceCSurfEntry overwrites the value of an existing csurf_N entry in reaper.ini
+func replaceCSurfEntry(id int, value string) error {
+	iniPath, err := GetReaperIniPath()
+	if err != nil {
+		return err
 	}
 
-	return nil
+	key := fmt.Sprintf("csurf_%d=", id)
+	return updateConfigFile(iniPath, "update control surface", func(data []byte) ([]byte, error) {
+		lines := strings.Split(string(data), "\n")
+		for i, line := range lines {
+			if strings.HasPrefix(strings.TrimSpace(line), key) {
+				lines[i] = key + value
+				return []byte(strings.Join(lines, "\n")), nil
+			}
+		}
+		return nil, fmt.Errorf("csurf_%d not found in reaper.ini", id)
+	})
 }
 
 // SetWebRemoteEnabled enables or disables the web remote in reaper.ini
@@ -357,21 +485,20 @@ func SetWebRemoteEnabled(enabled bool) error {
 		return err
 	}
 
-	// Read the entire file
-	file, err := os.Open(iniPath)
-	if err != nil {
-		return fmt.Errorf("failed to open reaper.ini: %w", err)
-	}
-	defer file.Close()
-
-	var lines []string
-	var modified bool
-	scanner := bufio.NewScanner(file)
-
 	enabledVal := "0"
 	if enabled {
 		enabledVal = "1"
 	}
+	return updateConfigFile(iniPath, "enable web remote", func(data []byte) ([]byte, error) {
+		return setCSurfEnabled(data, enabledVal)
+	})
+}
+
+// setCSurfEnabled sets the enabled flag of the first web remote entry in reaper.ini content
+func setCSurfEnabled(data []byte, enabledVal string) ([]byte, error) {
+	var lines []string
+	var modified bool
+	scanner := newIniScanner(bytes.NewReader(data))
 
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -406,18 +533,185 @@ func SetWebRemoteEnabled(enabled bool) error {
 	}
 
 	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("error reading reaper.ini: %w", err)
+		return nil, fmt.Errorf("error reading reaper.ini: %w", err)
 	}
 
 	if !modified {
-		return errors.New("web remote (HTTP/WEBR) control surface not found in reaper.ini")
+		return nil, errors.New("web remote (HTTP/WEBR) control surface not found in reaper.ini")
 	}
 
-	// Write the file back
-	content := strings.Join(lines, "\n")
-	if err := os.WriteFile(iniPath, []byte(content), 0644); err != nil {
-		return fmt.Errorf("failed to write reaper.ini: %w", err)
+	return []byte(strings.Join(lines, "\n")), nil
+}
+
+// GetReaperIniValue reads a key from a section of reaper.ini (e.g. section "REAPER")
+// Returns the value and whether the key was found
+func GetReaperIniValue(section, key string) (string, bool, error) {
+	iniPath, err := GetReaperIniPath()
+	if err != nil {
+		return "", false, err
+	}
+
+	file, err := configFS.Open(iniPath)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to open reaper.ini: %w", err)
 	}
+	defer file.Close()
+
+	currentSection := ""
+	scanner := newIniScanner(file)
+	for scanner.Scan() {
+		trimmed := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff")) // A UTF-8 byte order mark starts the first line
 
-	return nil
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			currentSection = trimmed[1 : len(trimmed)-1]
+			continue
+		}
+
+		if !strings.EqualFold(currentSection, section) {
+			continue
+		}
+
+		parts := strings.SplitN(trimmed, "=", 2)
+		if len(parts) == 2 && parts[0] == key {
+			return parts[1], true, nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return "", false, fmt.Errorf("error reading reaper.ini: %w", err)
+	}
+
+	return "", false, nil
+}
+
+// SetReaperIniValue writes a key in a section of reaper.ini, adding the key or section if missing
+// REAPER rewrites reaper.ini when it exits, so changes should be made while REAPER is closed
+func SetReaperIniValue(section, key, value string) error {
+	iniPath, err := GetReaperIniPath()
+	if err != nil {
+		return err
+	}
+
+	return updateConfigFile(iniPath, "set "+key, func(data []byte) ([]byte, error) {
+		return setIniValue(data, section, key, value)
+	})
+}
+
+// setIniValue writes a key in a section of ini content, adding the key or section if missing
+func setIniValue(data []byte, section, key, value string) ([]byte, error) {
+	var lines []string
+	currentSection := ""
+	sectionEnd := -1
+	updated := false
+	scanner := newIniScanner(bytes.NewReader(data))
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			currentSection = trimmed[1 : len(trimmed)-1]
+		} else if strings.EqualFold(currentSection, section) {
+			parts := strings.SplitN(trimmed, "=", 2)
+			if !updated && len(parts) == 2 && parts[0] == key {
+				line = key + "=" + value
+				updated = true
+			}
+		}
+
+		lines = append(lines, line)
+		if strings.EqualFold(currentSection, section) && trimmed != "" {
+			// Remember the last non-empty line of the section for inserting new keys
+			sectionEnd = len(lines)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading reaper.ini: %w", err)
+	}
+
+	if !updated {
+		newLine := key + "=" + value
+		if sectionEnd == -1 {
+			lines = append(lines, "["+section+"]", newLine)
+		} else {
+			newLines := make([]string, 0, len(lines)+1)
+			newLines = append(newLines, lines[:sectionEnd]...)
+			newLines = append(newLines, newLine)
+			newLines = append(newLines, lines[sectionEnd:]...)
+			lines = newLines
+		}
+	}
+
+	return []byte(strings.Join(lines, "\n")), nil
+}
+
+// DefaultWebRemotePort is the port used when creating a web remote entry without one
+const DefaultWebRemotePort = 8080
+
+// IsPortInUse reports whether a local TCP port already has a listener
+func IsPortInUse(port int) bool {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return true
+	}
+	listener.Close()
+	return false
+}
+
+// ConfigureWebRemote updates the web remote control surface in reaper.ini, creating it
+// if missing. A zero port keeps the current port; a nil enabled keeps the current state
+// (new entries are enabled). REAPER must be restarted for changes to take effect.
+func ConfigureWebRemote(enabled *bool, port int) (*WebRemoteConfig, error) {
+	if port != 0 && (port < 1 || port > 65535) {
+		return nil, fmt.Errorf("invalid port %d: must be between 1 and 65535", port)
+	}
+
+	current, err := GetWebRemoteConfig()
+	if err != nil {
+		current = nil
+	}
+
+	// A port REAPER is already serving on is expected to be in use
+	if port != 0 && (current == nil || port != current.Port) && IsPortInUse(port) {
+		return nil, fmt.Errorf("port %d is already in use by another application", port)
+	}
+
+	if current == nil {
+		if port == 0 {
+			port = DefaultWebRemotePort
+			if IsPortInUse(port) {
+				return nil, fmt.Errorf("default port %d is already in use; choose another port", port)
+			}
+		}
+		config := &WebRemoteConfig{Port: port, Enabled: enabled == nil || *enabled, DefaultPage: "index.html"}
+		config.RawConfig = config.httpValue()
+		id, err := addCSurfEntry(config.RawConfig)
+		if err != nil {
+			return nil, err
+		}
+		config.CSurfID = id
+		return config, nil
+	}
+
+	if enabled != nil {
+		current.Enabled = *enabled
+	}
+	if port != 0 {
+		current.Port = port
+	}
+
+	if strings.HasPrefix(current.RawConfig, "HTTP ") {
+		current.RawConfig = current.httpValue()
+	} else {
+		// Older WEBR entries: field 1 is the enabled flag and the port is the last field
+		fields := strings.Fields(current.RawConfig)
+		fields[1] = boolField(current.Enabled)
+		fields[len(fields)-1] = strconv.Itoa(current.Port)
+		current.RawConfig = strings.Join(fields, " ")
+	}
+	if err := replaceCSurfEntry(current.CSurfID, current.RawConfig); err != nil {
+		return nil, err
+	}
+	return current, nil
 }
@@ -0,0 +1,126 @@
+package scripts
+
+import (
+	"bytes"
+	"encoding/binary"
+	"unicode/utf16"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/errcode"
+)
+
+// Byte order marks a config file may start with
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// configFormat is how a config file is encoded, so a rewrite keeps the encoding, byte
+// order mark and line endings of the original. Text that isn't valid UTF-8, such as
+// reaper.ini written in a Windows code page, is passed through byte for byte.
+type configFormat struct {
+	bom      []byte // Byte order mark, if the file had one
+	utf16    binary.ByteOrder
+	crlf     bool                // Most lines end with "\r\n", so new lines do too
+	eols     map[string][]string // Line endings of the original lines by text, in file order
+	finalEOL bool                // The last line ends with a line ending
+	known    bool                // Taken from an existing file; otherwise the writer's text is used as is
+}
+
+// decodeConfig returns config file content as text with "\n" line endings and without
+// a byte order mark, and the format to write it back in
+func decodeConfig(data []byte) ([]byte, configFormat, error) {
+	if len(data) == 0 {
+		return data, configFormat{}, nil
+	}
+	format := configFormat{known: true}
+	switch {
+	case bytes.HasPrefix(data, bomUTF8):
+		format.bom, data = bomUTF8, data[len(bomUTF8):]
+	case bytes.HasPrefix(data, bomUTF16LE):
+		format.bom, format.utf16, data = bomUTF16LE, binary.LittleEndian, data[len(bomUTF16LE):]
+	case bytes.HasPrefix(data, bomUTF16BE):
+		format.bom, format.utf16, data = bomUTF16BE, binary.BigEndian, data[len(bomUTF16BE):]
+	}
+	if format.utf16 != nil {
+		if len(data)%2 != 0 {
+			return nil, format, errcode.Errorf(errcode.ConfigParseError, "invalid UTF-16 content")
+		}
+		units := make([]uint16, len(data)/2)
+		for i := range units {
+			units[i] = format.utf16.Uint16(data[2*i:])
+		}
+		data = []byte(string(utf16.Decode(units)))
+	}
+
+	// Files edited on different systems can mix line endings, so each line's is kept
+	format.eols = make(map[string][]string)
+	text := make([]byte, 0, len(data))
+	crlf := 0
+	for line := range bytes.Lines(data) {
+		body, eol := splitEOL(line)
+		if eol == "\r\n" {
+			crlf++
+		}
+		format.eols[string(body)] = append(format.eols[string(body)], eol)
+		text = append(text, body...)
+		if eol != "" {
+			text = append(text, '\n')
+		}
+	}
+	format.crlf = crlf > 0 && crlf >= bytes.Count(data, []byte("\n"))-crlf
+	format.finalEOL = bytes.HasSuffix(data, []byte("\n"))
+	return text, format, nil
+}
+
+// splitEOL splits a line into its text and its line ending: "\n", "\r\n" or "" for a
+// last line without one
+func splitEOL(line []byte) ([]byte, string) {
+	if body, ok := bytes.CutSuffix(line, []byte("\r\n")); ok {
+		return body, "\r\n"
+	}
+	if body, ok := bytes.CutSuffix(line, []byte("\n")); ok {
+		return body, "\n"
+	}
+	return line, ""
+}
+
+// encode converts text with "\n" line endings back to the format. Lines that were in
+// the original keep their line ending, matched by text in order; new lines get the one
+// most lines use. Unchanged text is written back byte for byte.
+func (f configFormat) encode(text []byte) []byte {
+	if !f.known {
+		return text
+	}
+	newEOL := "\n"
+	if f.crlf {
+		newEOL = "\r\n"
+	}
+	used := make(map[string]int)
+	encoded := make([]byte, 0, len(text)+len(text)/32)
+	eol := ""
+	for line := range bytes.Lines(text) {
+		body, _ := splitEOL(line)
+		eol = newEOL
+		if ends, n := f.eols[string(body)], used[string(body)]; n < len(ends) {
+			if ends[n] != "" {
+				eol = ends[n]
+			}
+			used[string(body)]++
+		}
+		encoded = append(append(encoded, body...), eol...)
+	}
+	if !f.finalEOL {
+		encoded = encoded[:len(encoded)-len(eol)]
+	}
+	text = encoded
+	if f.utf16 != nil {
+		units := utf16.Encode([]rune(string(text)))
+		encoded := make([]byte, 2*len(units))
+		for i, unit := range units {
+			f.utf16.PutUint16(encoded[2*i:], unit)
+		}
+		text = encoded
+	}
+	return append(append([]byte{}, f.bom...), text...)
+}
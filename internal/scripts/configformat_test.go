@@ -0,0 +1,86 @@
+package scripts
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"unicode/utf16"
+)
+
+// updateTestConfig writes content to a config file, runs update on it through
+// updateConfigFile and returns what ends up on disk
+func updateTestConfig(t *testing.T, content []byte, update func([]byte) []byte) []byte {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "reaper.ini")
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	err := updateConfigFile(path, "test", func(data []byte) ([]byte, error) {
+		if bytes.Contains(data, []byte("\r")) {
+			t.Errorf("update got %q, want \"\\n\" line endings", data)
+		}
+		return update(data), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	written, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return written
+}
+
+// encodeUTF16LE encodes text as UTF-16 little endian with a byte order mark
+func encodeUTF16LE(text string) []byte {
+	encoded := append([]byte{}, bomUTF16LE...)
+	for _, unit := range utf16.Encode([]rune(text)) {
+		encoded = binary.LittleEndian.AppendUint16(encoded, unit)
+	}
+	return encoded
+}
+
+func TestUpdateConfigFileKeepsMixedLineEndings(t *testing.T) {
+	const mixed = "[reaper]\r\nx=1\ny=2\r\nz=3\r\nlast=4"
+	tests := []struct {
+		name   string
+		update func([]byte) []byte
+		want   string
+	}{
+		{"unchanged", func(data []byte) []byte { return data }, mixed},
+		{"edited line", func(data []byte) []byte {
+			return bytes.Replace(data, []byte("y=2"), []byte("y=5"), 1)
+		}, "[reaper]\r\nx=1\ny=5\r\nz=3\r\nlast=4"},
+		{"removed line", func(data []byte) []byte {
+			return bytes.Replace(data, []byte("x=1\n"), nil, 1)
+		}, "[reaper]\r\ny=2\r\nz=3\r\nlast=4"},
+		{"appended line", func(data []byte) []byte {
+			return append(data, "\nnew=5"...)
+		}, "[reaper]\r\nx=1\ny=2\r\nz=3\r\nlast=4\r\nnew=5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := updateTestConfig(t, []byte(mixed), tt.update); string(got) != tt.want {
+				t.Errorf("file = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateConfigFileKeepsUTF16(t *testing.T) {
+	original := encodeUTF16LE("[Main]\r\nKEY 1 65 40044 0\r\nSCR 4 0 RS1 \"Script: Café.lua\" \"Café.lua\"\r\n")
+
+	if got := updateTestConfig(t, original, func(data []byte) []byte { return data }); !bytes.Equal(got, original) {
+		t.Errorf("unchanged file = % x, want % x", got, original)
+	}
+
+	got := updateTestConfig(t, original, func(data []byte) []byte {
+		return append(data, "KEY 1 66 40045 0\n"...)
+	})
+	want := encodeUTF16LE("[Main]\r\nKEY 1 65 40044 0\r\nSCR 4 0 RS1 \"Script: Café.lua\" \"Café.lua\"\r\nKEY 1 66 40045 0\r\n")
+	if !bytes.Equal(got, want) {
+		t.Errorf("file = % x, want % x", got, want)
+	}
+}
@@ -0,0 +1,104 @@
+package scripts
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"time"
+)
+
+// REAPER's config files can be changed by several operations, plugin instances or
+// agents at once. Changes go through updateConfigFile, which serializes them with a
+// lock file next to the config file and rereads the file before replacing it, so a
+// change made meanwhile by REAPER, or anything else that ignores the lock, is never
+// overwritten.
+const (
+	configLockExt       = ".ori-lock"
+	configWriteAttempts = 3
+	configRetryDelay    = 200 * time.Millisecond
+)
+
+// ConfigLockTimeout is how long a config file change waits for another one to finish
+var ConfigLockTimeout = 30 * time.Second
+
+// errNoChange is returned by an update function to leave the config file as it is
+var errNoChange = errors.New("no change")
+
+// updateConfigFile replaces the content of the config file at path with what update
+// returns for its current content, which is nil for a missing file. update works on
+// text with "\n" line endings; the new content is written back with the encoding, byte
+// order mark and line endings of the original (see configFormat). operation describes
+// the change for anyone waiting on the lock. update may run again with fresh content if
+// the file changes before it's written, so it must only compute the new content. If it
+// returns errNoChange the file is left alone and nil is returned.
+func updateConfigFile(path, operation string, update func(data []byte) ([]byte, error)) error {
+	name := filepath.Base(path)
+	release, err := acquireLock(configFS, path+configLockExt, name, operation, ConfigLockTimeout)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	for attempt := 1; ; attempt++ {
+		data, err := readConfigFile(path)
+		if err != nil {
+			return err
+		}
+		text, format, err := decodeConfig(data)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		updated, err := update(text)
+		if errors.Is(err, errNoChange) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		// Anything that changed the file while update ran didn't take the lock
+		current, err := readConfigFile(path)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(current, data) {
+			if attempt == configWriteAttempts {
+				return fmt.Errorf("%s kept changing while it was being updated (is REAPER writing it?); try again", name)
+			}
+			time.Sleep(configRetryDelay)
+			continue
+		}
+
+		if err := writeAtomic(configFS, path, format.encode(updated)); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+		return nil
+	}
+}
+
+// backupConfigFile copies a config file to backupPath as it is on disk. A missing file
+// has nothing to back up.
+func backupConfigFile(path, backupPath string) error {
+	data, err := readConfigFile(path)
+	if err != nil || data == nil {
+		return err
+	}
+	if err := configFS.WriteFile(backupPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to back up %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// readConfigFile reads a config file, returning nil for a missing one
+func readConfigFile(path string) ([]byte, error) {
+	data, err := configFS.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+	}
+	return data, nil
+}
@@ -0,0 +1,198 @@
+package scripts
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/errcode"
+)
+
+// Extension describes a REAPER extension that scripts commonly depend on
+type Extension struct {
+	ID           string         `json:"id"`
+	Name         string         `json:"name"`
+	URL          string         `json:"url"` // Where to get it
+	filePrefix   string         // Lower-cased prefix of the extension binary in UserPlugins
+	usagePattern *regexp.Regexp // Matches script code that requires the extension
+	releaseRepo  string         // GitHub repository publishing plugin binaries as release assets, if any
+}
+
+// KnownExtensions lists the extensions detected in script content and UserPlugins
+var KnownExtensions = []Extension{
+	{
+		ID:           "sws",
+		Name:         "SWS/S&M Extension",
+		URL:          "https://www.sws-extension.org/",
+		filePrefix:   "reaper_sws",
+		releaseRepo:  "reaper-oss/sws",
+		usagePattern: regexp.MustCompile(`reaper\.(BR|CF|NF|SNM|SN|ULT|FNG|Xen)_\w+`),
+	},
+	{
+		ID:           "js_reascriptapi",
+		Name:         "js_ReaScriptAPI",
+		URL:          "https://forum.cockos.com/showthread.php?t=212174",
+		filePrefix:   "reaper_js_reascriptapi",
+		usagePattern: regexp.MustCompile(`reaper\.JS_\w+`),
+	},
+	{
+		ID:           "reaimgui",
+		Name:         "ReaImGui",
+		URL:          "https://forum.cockos.com/showthread.php?t=250419",
+		filePrefix:   "reaper_imgui",
+		releaseRepo:  "cfillion/reaimgui",
+		usagePattern: regexp.MustCompile(`reaper\.ImGui_\w+|require\s*\(?\s*['"]imgui['"]`),
+	},
+	{
+		ID:           "reapack",
+		Name:         "ReaPack",
+		URL:          "https://reapack.com/",
+		filePrefix:   "reaper_reapack",
+		releaseRepo:  "cfillion/reapack",
+		usagePattern: regexp.MustCompile(`reaper\.ReaPack_\w+`),
+	},
+}
+
+// DetectScriptDependencies returns the extensions a script's content calls into
+func DetectScriptDependencies(content string) []Extension {
+	var required []Extension
+	for _, ext := range KnownExtensions {
+		if ext.usagePattern.MatchString(content) {
+			required = append(required, ext)
+		}
+	}
+	return required
+}
+
+// GetUserPluginsPath returns the path to REAPER's UserPlugins directory
+func GetUserPluginsPath() (string, error) {
+	resourcePath, err := GetReaperResourcePath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(resourcePath, "UserPlugins"), nil
+}
+
+// InstalledExtensions scans UserPlugins and returns the IDs of known extensions found there
+func InstalledExtensions() (map[string]bool, error) {
+	pluginsDir, err := GetUserPluginsPath()
+	if err != nil {
+		return nil, err
+	}
+
+	installed := make(map[string]bool)
+	entries, err := os.ReadDir(pluginsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return installed, nil
+		}
+		return nil, fmt.Errorf("failed to read UserPlugins: %w", err)
+	}
+
+	for _, e := range entries {
+		name := strings.ToLower(e.Name())
+		for _, ext := range KnownExtensions {
+			if strings.HasPrefix(name, ext.filePrefix) {
+				installed[ext.ID] = true
+			}
+		}
+	}
+
+	return installed, nil
+}
+
+// MissingDependencies returns the extensions a script needs that are not installed
+func MissingDependencies(content string) ([]Extension, error) {
+	required := DetectScriptDependencies(content)
+	if len(required) == 0 {
+		return nil, nil
+	}
+
+	installed, err := InstalledExtensions()
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []Extension
+	for _, ext := range required {
+		if !installed[ext.ID] {
+			missing = append(missing, ext)
+		}
+	}
+	return missing, nil
+}
+
+// dependencyWarning returns a warning about missing extensions for the script content,
+// or "" if everything it needs is installed (or detection isn't possible)
+func dependencyWarning(content string) string {
+	missing, err := MissingDependencies(content)
+	if err != nil || len(missing) == 0 {
+		return ""
+	}
+
+	var result strings.Builder
+	result.WriteString("⚠️ This script needs REAPER extensions that are not installed:\n")
+	for _, ext := range missing {
+		result.WriteString(fmt.Sprintf("  - %s: %s\n", ext.Name, ext.URL))
+	}
+	result.WriteString("Install them into REAPER's UserPlugins folder and restart REAPER, or the script will fail when run.")
+	return result.String()
+}
+
+// CheckDependencies reports which extensions are installed and, if a script is given,
+// which extensions that script requires
+func (sm *ScriptManager) CheckDependencies(script string) (string, error) {
+	installed, err := InstalledExtensions()
+	if err != nil {
+		return "", err
+	}
+
+	var result strings.Builder
+	result.WriteString("REAPER extensions:\n")
+	for _, ext := range KnownExtensions {
+		status := "✗ not installed"
+		if installed[ext.ID] {
+			status = "✓ installed"
+		}
+		result.WriteString(fmt.Sprintf("  %-20s %s\n", ext.Name, status))
+	}
+
+	if strings.TrimSpace(script) == "" {
+		return result.String(), nil
+	}
+
+	path, err := sm.scriptFilePath(scriptFileName(script))
+	if err != nil {
+		return "", err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", errcode.Errorf(errcode.ScriptNotFound, "script not found: %s", script)
+	}
+
+	required := DetectScriptDependencies(string(content))
+	result.WriteString(fmt.Sprintf("\nScript '%s' ", script))
+	if len(required) == 0 {
+		result.WriteString("only uses the built-in ReaScript API.")
+		return result.String(), nil
+	}
+	result.WriteString("requires:\n")
+	for _, ext := range required {
+		status := "✓"
+		if !installed[ext.ID] {
+			status = "✗ missing - " + ext.URL
+		}
+		result.WriteString(fmt.Sprintf("  %s %s\n", ext.Name, status))
+	}
+	return result.String(), nil
+}
+
+// scriptFileName returns the script's file name, adding .lua when no script extension is given
+func scriptFileName(script string) string {
+	if isScriptFile(script) {
+		return script
+	}
+	return script + ".lua"
+}
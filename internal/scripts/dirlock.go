@@ -0,0 +1,247 @@
+package scripts
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/platform"
+)
+
+// The scripts directory may be a network share used by several workstations, so changes
+// to it are serialized by a lock file in the directory rather than an in-process mutex
+const (
+	lockFileName  = ".ori_scripts.lock"
+	tempFileExt   = ".ori-tmp" // Suffix of files being written by writeFileAtomic
+	lockRetryBase = 50 * time.Millisecond
+	lockRetryMax  = time.Second
+)
+
+var (
+	// DirLockTimeout is how long a change waits for another workstation's lock
+	DirLockTimeout = 15 * time.Second
+
+	// StaleLockAge is how old a lock or half-written file must be before it's assumed to
+	// be left over from a crashed workstation and removed. Holders refresh their lock
+	// every quarter of it, so long operations keep theirs.
+	StaleLockAge = 2 * time.Minute
+)
+
+// dirLock is the content of the lock file
+type dirLock struct {
+	Host      string    `json:"host"`
+	PID       int       `json:"pid"`
+	Operation string    `json:"operation"`
+	Acquired  time.Time `json:"acquired"`
+	Refreshed time.Time `json:"refreshed,omitempty"` // When the holder last showed it's still working
+	Token     string    `json:"token"`               // Identifies this holder, so release never removes someone else's lock
+}
+
+// lastSeen returns when the holder was last known to be working
+func (l dirLock) lastSeen() time.Time {
+	if l.Refreshed.After(l.Acquired) {
+		return l.Refreshed
+	}
+	return l.Acquired
+}
+
+// errDirLocked reports a lock held by someone else for longer than the lock timeout
+type errDirLocked struct {
+	holder dirLock
+	path   string
+	what   string // What the lock protects, e.g. "the scripts directory"
+}
+
+func (e *errDirLocked) Error() string {
+	if e.holder.Host == "" {
+		return fmt.Sprintf("%s is locked (%s); try again shortly", e.what, e.path)
+	}
+	return fmt.Sprintf("%s is locked by %s (pid %d, %s since %s); try again shortly, or delete %s if that workstation crashed",
+		e.what, e.holder.Host, e.holder.PID, e.holder.Operation, e.holder.Acquired.Local().Format("15:04:05"), e.path)
+}
+
+// withDirLock runs fn while holding the scripts directory lock. A missing scripts
+// directory isn't locked, so fn can report that itself.
+func (sm *ScriptManager) withDirLock(operation string, fn func() error) error {
+	release, err := sm.lockDir(operation)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fn()
+		}
+		return err
+	}
+	defer release()
+	return fn()
+}
+
+// lockDir locks the scripts directory and clears half-written files left behind by
+// crashed writers. It returns a function that releases the lock.
+func (sm *ScriptManager) lockDir(operation string) (func(), error) {
+	release, err := acquireLock(sm.backend.FS, filepath.Join(sm.scriptsDir, lockFileName), "the scripts directory", operation, DirLockTimeout)
+	if err != nil {
+		return nil, err
+	}
+	sm.removeStaleTempFiles()
+	return release, nil
+}
+
+// acquireLock creates the lock file at path, waiting up to timeout for another holder
+// and taking over locks not refreshed for StaleLockAge. The lock is refreshed until it's
+// released. what names what the lock protects in errors. It returns a function that
+// releases the lock.
+func acquireLock(fsys platform.FS, path, what, operation string, timeout time.Duration) (func(), error) {
+	token := make([]byte, 8)
+	if _, err := rand.Read(token); err != nil {
+		return nil, fmt.Errorf("failed to generate lock token: %w", err)
+	}
+	host, _ := os.Hostname()
+	lock := dirLock{Host: host, PID: os.Getpid(), Operation: operation, Acquired: time.Now(), Token: hex.EncodeToString(token)}
+	data, err := json.Marshal(lock)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal lock: %w", err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	wait := lockRetryBase
+	for {
+		err := platform.CreateExclusive(fsys, path, data, 0644)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil, err
+			}
+			return nil, fmt.Errorf("failed to lock %s: %w", what, err)
+		}
+
+		holder, stale := readLock(fsys, path)
+		if stale && takeOverLock(fsys, path, holder, lock.Token) {
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, &errDirLocked{holder: holder, path: path, what: what}
+		}
+		time.Sleep(wait)
+		wait = min(wait*2, lockRetryMax)
+	}
+
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go refreshLock(fsys, path, lock, stop, stopped)
+	return func() {
+		close(stop)
+		<-stopped
+		if current, _ := readLock(fsys, path); current.Token == lock.Token {
+			fsys.Remove(path)
+		}
+	}, nil
+}
+
+// takeOverLock clears the stale lock of holder at path, reporting whether the caller
+// should try to create its own. Other workstations may clear the same lock and create a
+// fresh one meanwhile, so the lock is renamed aside rather than removed: only the
+// renamed file's token shows whose lock was taken, and a fresh one is put back.
+func takeOverLock(fsys platform.FS, path string, holder dirLock, token string) bool {
+	aside := path + "." + token + ".stale"
+	if err := fsys.Rename(path, aside); err != nil {
+		// Already cleared by someone else, so compete for the lock again
+		return errors.Is(err, fs.ErrNotExist)
+	}
+	taken, stale := readLock(fsys, aside)
+	if taken.Token == holder.Token && stale {
+		fsys.Remove(aside)
+		return true
+	}
+	// Someone else's fresh lock: put it back unless yet another holder got in first
+	if data, err := fsys.ReadFile(aside); err == nil {
+		platform.CreateExclusive(fsys, path, data, 0644)
+	}
+	fsys.Remove(aside)
+	return false
+}
+
+// refreshLock rewrites lock at path every quarter of StaleLockAge until stop is closed,
+// so other workstations don't take it over while a long operation runs. It stops early if
+// the lock is no longer this holder's.
+func refreshLock(fsys platform.FS, path string, lock dirLock, stop <-chan struct{}, stopped chan<- struct{}) {
+	defer close(stopped)
+	ticker := time.NewTicker(StaleLockAge / 4)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		if current, _ := readLock(fsys, path); current.Token != lock.Token {
+			return
+		}
+		lock.Refreshed = time.Now()
+		if data, err := json.Marshal(lock); err == nil {
+			writeAtomic(fsys, path, data)
+		}
+	}
+}
+
+// readLock reads a lock file and reports whether it's stale. An unreadable lock,
+// e.g. one still being written, is judged by its modification time.
+func readLock(fsys platform.FS, path string) (dirLock, bool) {
+	var lock dirLock
+	data, err := fsys.ReadFile(path)
+	if err == nil && json.Unmarshal(data, &lock) == nil && !lock.Acquired.IsZero() {
+		return lock, time.Since(lock.lastSeen()) > StaleLockAge
+	}
+	info, err := fsys.Stat(path)
+	if err != nil {
+		return lock, false
+	}
+	return lock, time.Since(info.ModTime()) > StaleLockAge
+}
+
+// removeStaleTempFiles deletes files left behind by writes interrupted on any
+// workstation. It must be called with the directory lock held.
+func (sm *ScriptManager) removeStaleTempFiles() {
+	entries, err := sm.backend.FS.ReadDir(sm.scriptsDir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), tempFileExt) {
+			continue
+		}
+		if info, err := entry.Info(); err == nil && time.Since(info.ModTime()) > StaleLockAge {
+			sm.backend.FS.Remove(filepath.Join(sm.scriptsDir, entry.Name()))
+		}
+	}
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it into
+// place, so other workstations never see a half-written script
+func (sm *ScriptManager) writeFileAtomic(path string, data []byte) error {
+	return writeAtomic(sm.backend.FS, path, data)
+}
+
+// writeAtomic writes data to a temporary file next to path and renames it into place
+func writeAtomic(fsys platform.FS, path string, data []byte) error {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return fmt.Errorf("failed to generate temporary file name: %w", err)
+	}
+	temp := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+"."+hex.EncodeToString(suffix)+tempFileExt)
+	if err := fsys.WriteFile(temp, data, 0644); err != nil {
+		return err
+	}
+	if err := fsys.Rename(temp, path); err != nil {
+		fsys.Remove(temp)
+		return err
+	}
+	return nil
+}
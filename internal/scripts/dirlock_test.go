@@ -0,0 +1,87 @@
+package scripts
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/platform"
+)
+
+func TestAcquireLockContended(t *testing.T) {
+	staleLockAge := StaleLockAge
+	StaleLockAge = 200 * time.Millisecond
+	t.Cleanup(func() { StaleLockAge = staleLockAge })
+
+	path := filepath.Join(t.TempDir(), ".lock")
+	// Left over from a crashed workstation, so both lockers try to take it over at once
+	stale, err := json.Marshal(dirLock{Host: "crashed", Acquired: time.Now().Add(-time.Hour), Token: "crashed"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, stale, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var holders, acquired atomic.Int32
+	var wg sync.WaitGroup
+	for locker := range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 2 {
+				release, err := acquireLock(platform.OSFS{}, path, "test", "test", 10*time.Second)
+				if err != nil {
+					t.Errorf("locker %d: %v", locker, err)
+					return
+				}
+				if n := holders.Add(1); n != 1 {
+					t.Errorf("locker %d holds the lock with %d others", locker, n-1)
+				}
+				acquired.Add(1)
+				// Held for longer than StaleLockAge, so only refreshing keeps the other locker out
+				time.Sleep(2 * StaleLockAge)
+				holders.Add(-1)
+				release()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := acquired.Load(); n != 4 {
+		t.Errorf("lock acquired %d times, want 4", n)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("lock file left after release: %v", err)
+	}
+	if matches, _ := filepath.Glob(path + ".*"); len(matches) > 0 {
+		t.Errorf("files left beside the lock: %v", matches)
+	}
+}
+
+func TestTakeOverLockKeepsFreshLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".lock")
+	// Read as stale, but another workstation has since taken it over
+	staleHolder := dirLock{Host: "crashed", Acquired: time.Now().Add(-time.Hour), Token: "crashed"}
+	fresh, err := json.Marshal(dirLock{Host: "other", Acquired: time.Now(), Token: "other"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, fresh, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if takeOverLock(platform.OSFS{}, path, staleHolder, "mine") {
+		t.Error("takeOverLock cleared another workstation's fresh lock")
+	}
+	if current, _ := readLock(platform.OSFS{}, path); current.Token != "other" {
+		t.Errorf("lock token = %q after takeOverLock, want the fresh lock put back", current.Token)
+	}
+	if matches, _ := filepath.Glob(path + ".*"); len(matches) > 0 {
+		t.Errorf("files left beside the lock: %v", matches)
+	}
+}
@@ -0,0 +1,130 @@
+package scripts
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/errcode"
+)
+
+// disabledDirName is the folder, inside the scripts directory, that disabled scripts are
+// moved to. Scripts there aren't listed, run or registered, but keep their content,
+// tags and ratings until they're enabled again.
+const disabledDirName = "Disabled"
+
+// disabledDir returns the folder holding disabled scripts
+func (sm *ScriptManager) disabledDir() string {
+	return filepath.Join(sm.scriptsDir, disabledDirName)
+}
+
+// DisabledScripts returns the file names of the disabled scripts, sorted
+func (sm *ScriptManager) DisabledScripts() ([]string, error) {
+	entries, err := sm.backend.FS.ReadDir(sm.disabledDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read %s folder: %w", disabledDirName, err)
+	}
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && isScriptFile(entry.Name()) {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// disabledScriptFile returns the file name of the disabled script named script (a file
+// name or base name, case-insensitive), or "" if there is none
+func (sm *ScriptManager) disabledScriptFile(script string) string {
+	script = strings.TrimSpace(script)
+	names, _ := sm.DisabledScripts()
+	for _, name := range names {
+		if strings.EqualFold(name, script) || strings.EqualFold(strings.TrimSuffix(name, filepath.Ext(name)), script) {
+			return name
+		}
+	}
+	return ""
+}
+
+// DisableScript moves a script into the Disabled folder, hiding it from 'list',
+// 'run' and registration without deleting it
+func (sm *ScriptManager) DisableScript(script string) (string, error) {
+	if strings.TrimSpace(script) == "" {
+		return "", errors.New("script name is required for 'disable_script' operation")
+	}
+	if disabled := sm.disabledScriptFile(script); disabled != "" {
+		if _, err := sm.findScriptFile(script); err != nil {
+			return fmt.Sprintf("Script %s is already disabled", disabled), nil
+		}
+	}
+	file, err := sm.findScriptFile(script)
+	if err != nil {
+		return "", err
+	}
+
+	source, err := sm.scriptFilePath(file)
+	if err != nil {
+		return "", err
+	}
+	target := filepath.Join(sm.disabledDir(), file)
+	err = sm.withDirLock("disable "+file, func() error {
+		if err := sm.backend.FS.MkdirAll(sm.disabledDir(), 0755); err != nil {
+			return fmt.Errorf("failed to create %s folder: %w", disabledDirName, err)
+		}
+		if _, err := sm.backend.FS.Stat(target); err == nil {
+			return fmt.Errorf("a disabled script named %s already exists; enable or delete it first", file)
+		}
+		if err := sm.backend.FS.Rename(source, target); err != nil {
+			if os.IsNotExist(err) {
+				return fmt.Errorf("%s is already gone; it may have been disabled from another workstation", file)
+			}
+			return fmt.Errorf("failed to disable %s: %w", file, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	invalidateScriptCache(sm.scriptsDir)
+	return fmt.Sprintf("Disabled REAPER script: %s (moved to %s/; use 'enable_script' to turn it back on)", file, disabledDirName), nil
+}
+
+// EnableScript moves a disabled script back into the scripts directory
+func (sm *ScriptManager) EnableScript(script string) (string, error) {
+	if strings.TrimSpace(script) == "" {
+		return "", errors.New("script name is required for 'enable_script' operation")
+	}
+	file := sm.disabledScriptFile(script)
+	if file == "" {
+		return "", errcode.Errorf(errcode.ScriptNotFound, "script not found in %s/: %s", disabledDirName, script)
+	}
+
+	target, err := sm.scriptFilePath(file)
+	if err != nil {
+		return "", err
+	}
+	err = sm.withDirLock("enable "+file, func() error {
+		if _, err := sm.backend.FS.Stat(target); err == nil {
+			return fmt.Errorf("a script named %s already exists; delete or rename it first", file)
+		}
+		if err := sm.backend.FS.Rename(filepath.Join(sm.disabledDir(), file), target); err != nil {
+			if os.IsNotExist(err) {
+				return fmt.Errorf("%s is no longer disabled; it may have been enabled from another workstation", file)
+			}
+			return fmt.Errorf("failed to enable %s: %w", file, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	invalidateScriptCache(sm.scriptsDir)
+	return fmt.Sprintf("Enabled REAPER script: %s", file), nil
+}
@@ -1,13 +1,19 @@
 package scripts
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/johnjallday/ori-agent/pluginapi"
+	"github.com/johnjallday/ori-reaper-plugin/internal/errcode"
+	"github.com/johnjallday/ori-reaper-plugin/internal/metrics"
 )
 
 const (
@@ -39,20 +45,27 @@ type DownloadableScript struct {
 
 // ScriptDownloader handles fetching scripts from GitHub
 type ScriptDownloader struct {
-	apiURL string
+	apiURLs        []string     // GitHub contents API URLs of the script sources
+	trustedSources []string     // URL prefixes scripts may be downloaded from
+	progress       ProgressFunc // Receives progress of multi-file downloads, if set
+}
+
+// SetProgress sets a function to receive download progress
+func (sd *ScriptDownloader) SetProgress(progress ProgressFunc) {
+	sd.progress = progress
 }
 
 // NewScriptDownloader creates a new script downloader
 func NewScriptDownloader() *ScriptDownloader {
 	return &ScriptDownloader{
-		apiURL: GitHubAPIURL,
+		apiURLs: []string{GitHubAPIURL},
 	}
 }
 
 // ListAvailableScripts fetches and returns a list of downloadable scripts from GitHub
-func (sd *ScriptDownloader) ListAvailableScripts() (string, error) {
+func (sd *ScriptDownloader) ListAvailableScripts(ctx context.Context) (string, error) {
 	// Fetch files from GitHub API
-	files, err := sd.fetchGitHubFiles()
+	files, err := sd.fetchGitHubFiles(ctx)
 	if err != nil {
 		return "", fmt.Errorf("failed to fetch scripts from GitHub: %w", err)
 	}
@@ -125,13 +138,76 @@ func (sd *ScriptDownloader) ListAvailableScripts() (string, error) {
 	return result.ToJSON()
 }
 
-// fetchGitHubFiles fetches the file list from GitHub API
-func (sd *ScriptDownloader) fetchGitHubFiles() ([]GitHubFile, error) {
-	resp, err := http.Get(sd.apiURL)
+// Bounds for fetching several script sources at once
+const (
+	sourceWorkers = 4
+	sourceTimeout = 10 * time.Second
+)
+
+// SetSources sets the GitHub contents API URLs scripts are listed from.
+// An empty list keeps the official repository.
+func (sd *ScriptDownloader) SetSources(apiURLs []string) {
+	if len(apiURLs) > 0 {
+		sd.apiURLs = apiURLs
+	}
+}
+
+// fetchGitHubFiles fetches the file lists of all sources in parallel, with a timeout per
+// source so one slow repository doesn't hold up the others. Files from earlier sources
+// win when names collide. It only fails if every source fails.
+func (sd *ScriptDownloader) fetchGitHubFiles(ctx context.Context) ([]GitHubFile, error) {
+	lists := make([][]GitHubFile, len(sd.apiURLs))
+	errs := make([]error, len(sd.apiURLs))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < sourceWorkers && w < len(sd.apiURLs); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client := newHTTPClient(sourceTimeout)
+			for i := range jobs {
+				lists[i], errs[i] = fetchSourceFiles(ctx, client, sd.apiURLs[i])
+			}
+		}()
+	}
+	for i := range sd.apiURLs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	var files []GitHubFile
+	seen := make(map[string]bool)
+	failed := 0
+	for i, list := range lists {
+		if errs[i] != nil {
+			failed++
+			continue
+		}
+		for _, file := range list {
+			if !seen[file.Name] {
+				seen[file.Name] = true
+				files = append(files, file)
+			}
+		}
+	}
+
+	if failed == len(sd.apiURLs) {
+		return nil, errors.Join(errs...)
+	}
+	return files, nil
+}
+
+// fetchSourceFiles fetches the file list of one source from the GitHub API
+func fetchSourceFiles(ctx context.Context, client *http.Client, apiURL string) ([]GitHubFile, error) {
+	resp, err := getWithClient(ctx, client, apiURL)
 	if err != nil {
+		metrics.CountGitHub(true)
 		return nil, fmt.Errorf("HTTP request failed: %w", err)
 	}
 	defer resp.Body.Close()
+	metrics.CountGitHub(resp.StatusCode != http.StatusOK)
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
@@ -203,40 +279,68 @@ func getScriptDescription(filename string) string {
 }
 
 // DownloadScript downloads a specific script from GitHub and saves it to the scripts directory
-func (sd *ScriptDownloader) DownloadScript(filename, targetDir string) (string, error) {
+func (sd *ScriptDownloader) DownloadScript(ctx context.Context, filename, targetDir string) (string, error) {
 	// Fetch all files to get the download URL
-	files, err := sd.fetchGitHubFiles()
+	files, err := sd.fetchGitHubFiles(ctx)
 	if err != nil {
 		return "", fmt.Errorf("failed to fetch scripts from GitHub: %w", err)
 	}
 
 	// Find the requested file
-	var downloadURL string
-	for _, file := range files {
+	var found *GitHubFile
+	for i, file := range files {
 		if file.Name == filename {
-			downloadURL = file.DownloadURL
+			found = &files[i]
 			break
 		}
 	}
 
-	if downloadURL == "" {
-		return "", fmt.Errorf("script not found: %s", filename)
+	if found == nil || found.DownloadURL == "" {
+		return "", errcode.Errorf(errcode.ScriptNotFound, "script not found: %s", filename)
+	}
+	if err := sd.checkTrusted(found.DownloadURL); err != nil {
+		return "", err
 	}
 
-	// Download the file content
-	resp, err := http.Get(downloadURL)
+	result, err := installDownloadedScript(ctx, *found, targetDir, sd.progress)
 	if err != nil {
-		return "", fmt.Errorf("failed to download script: %w", err)
+		return "", err
+	}
+
+	// Append marketplace URL to the result
+	result += "\n\n🎵 Browse more scripts at the marketplace: http://localhost:8080/api/plugins/ori-reaper/pages/marketplace"
+	return result, nil
+}
+
+// downloadScriptContent fetches the content of a marketplace script,
+// reporting bytes received to progress if set
+func downloadScriptContent(ctx context.Context, file GitHubFile, progress ProgressFunc) ([]byte, error) {
+	resp, err := httpGet(ctx, file.DownloadURL)
+	if err != nil {
+		metrics.CountGitHub(true)
+		return nil, fmt.Errorf("failed to download script: %w", err)
 	}
 	defer resp.Body.Close()
+	metrics.CountGitHub(resp.StatusCode != http.StatusOK)
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("download failed with status: %d", resp.StatusCode)
+		return nil, fmt.Errorf("download failed with status: %d", resp.StatusCode)
+	}
+
+	content, err := io.ReadAll(newProgressReader(resp.Body, file.Name, resp.ContentLength, progress))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read script content: %w", err)
 	}
+	return content, nil
+}
 
-	content, err := io.ReadAll(resp.Body)
+// installDownloadedScript downloads one script, adds it to targetDir and records
+// the installed version, reporting bytes received to progress if set
+func installDownloadedScript(ctx context.Context, file GitHubFile, targetDir string, progress ProgressFunc) (string, error) {
+	filename := file.Name
+	content, err := downloadScriptContent(ctx, file, progress)
 	if err != nil {
-		return "", fmt.Errorf("failed to read script content: %w", err)
+		return "", err
 	}
 
 	// Use ScriptManager to add the script
@@ -264,8 +368,98 @@ func (sd *ScriptDownloader) DownloadScript(filename, targetDir string) (string,
 	if err != nil {
 		return "", err
 	}
-
-	// Append marketplace URL to the result
-	result += "\n\n🎵 Browse more scripts at the marketplace: http://localhost:8080/api/plugins/ori-reaper/pages/marketplace"
+	if err := sm.recordInstall(file); err != nil {
+		result += fmt.Sprintf("\n⚠️ Could not record the installed version: %v", err)
+	}
 	return result, nil
 }
+
+// downloadWorkers bounds how many scripts DownloadScripts fetches at once
+const downloadWorkers = 4
+
+// ScriptDownloadResult is the outcome of downloading one script in DownloadScripts
+type ScriptDownloadResult struct {
+	Filename string `json:"filename"`
+	Error    string `json:"error,omitempty"`
+}
+
+// DownloadScripts downloads several scripts concurrently. A single "all" entry downloads
+// every script in the repository. One failure doesn't stop the other downloads.
+func (sd *ScriptDownloader) DownloadScripts(ctx context.Context, filenames []string, targetDir string) ([]ScriptDownloadResult, error) {
+	if len(filenames) == 0 {
+		return nil, errors.New("filenames are required for 'download_scripts' operation")
+	}
+
+	files, err := sd.fetchGitHubFiles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch scripts from GitHub: %w", err)
+	}
+
+	byName := make(map[string]GitHubFile)
+	var available []string
+	for _, file := range files {
+		if file.Type == "file" && isScriptFile(file.Name) {
+			byName[file.Name] = file
+			available = append(available, file.Name)
+		}
+	}
+
+	if len(filenames) == 1 && strings.EqualFold(filenames[0], "all") {
+		filenames = available
+	}
+
+	results := make([]ScriptDownloadResult, len(filenames))
+	counter := &fileCounter{total: len(filenames), report: sd.progress}
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < downloadWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				filename := filenames[i]
+				results[i].Filename = filename
+				sd.downloadOne(ctx, &results[i], byName, targetDir)
+				counter.finished(filename)
+			}
+		}()
+	}
+	for i := range filenames {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results, nil
+}
+
+// downloadOne downloads and installs the script named in result, recording any error in it
+func (sd *ScriptDownloader) downloadOne(ctx context.Context, result *ScriptDownloadResult, byName map[string]GitHubFile, targetDir string) {
+	file, ok := byName[result.Filename]
+	if !ok {
+		result.Error = "script not found"
+		return
+	}
+	if err := sd.checkTrusted(file.DownloadURL); err != nil {
+		result.Error = err.Error()
+		return
+	}
+	if _, err := installDownloadedScript(ctx, file, targetDir, nil); err != nil {
+		result.Error = err.Error()
+	}
+}
+
+// FormatDownloadResults summarizes a bulk download
+func FormatDownloadResults(results []ScriptDownloadResult) string {
+	succeeded := 0
+	var b strings.Builder
+	for _, r := range results {
+		if r.Error == "" {
+			succeeded++
+			b.WriteString(fmt.Sprintf("  ✅ %s\n", r.Filename))
+		} else {
+			b.WriteString(fmt.Sprintf("  ❌ %s: %s\n", r.Filename, r.Error))
+		}
+	}
+	return fmt.Sprintf("Downloaded %d of %d scripts:\n", succeeded, len(results)) + b.String()
+}
@@ -0,0 +1,230 @@
+package scripts
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DuplicateGroup is a set of scripts with the same content
+type DuplicateGroup struct {
+	Files   []string `json:"files"`   // Script filenames; the first is normally the one kept when cleaning
+	Exact   bool     `json:"exact"`   // False if the files only match after normalizing whitespace and line endings
+	Removed []string `json:"removed"` // Files moved to the trash by cleaning
+}
+
+// DuplicateReport is the result of FindDuplicates
+type DuplicateReport struct {
+	Scanned      int              `json:"scanned"`
+	Groups       []DuplicateGroup `json:"groups"`
+	Cleaned      bool             `json:"cleaned"`
+	KBReferences int              `json:"kb_references"` // reaper-kb.ini entries repointed to the kept file
+}
+
+// normalizedContent drops differences that don't change what a script does:
+// line endings, trailing whitespace, indentation and blank lines
+func normalizedContent(content []byte) string {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// hashString returns the hex SHA-256 of s
+func hashString(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// FindDuplicates hashes every script in the scripts directory and groups identical files,
+// and files identical apart from whitespace. With clean set, all but one file per group is
+// moved to the trash and reaper-kb.ini entries for removed files are pointed at the kept one.
+// The kept file is a favorite if there is one, then one installed from the marketplace,
+// then the shortest name.
+func (sm *ScriptManager) FindDuplicates(clean bool) (*DuplicateReport, error) {
+	entries, err := sm.backend.FS.ReadDir(sm.scriptsDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scripts in %s: %w", sm.scriptsDir, err)
+	}
+
+	exact := make(map[string][]string)
+	near := make(map[string][]string)
+	report := &DuplicateReport{}
+	for _, entry := range entries {
+		if entry.IsDir() || !isScriptFile(entry.Name()) {
+			continue
+		}
+		content, err := sm.backend.FS.ReadFile(filepath.Join(sm.scriptsDir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", entry.Name(), err)
+		}
+		report.Scanned++
+		// Include the extension so a .lua and an .eel file never match
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		exactKey := ext + hashString(string(content))
+		nearKey := ext + hashString(normalizedContent(content))
+		exact[exactKey] = append(exact[exactKey], entry.Name())
+		near[nearKey] = append(near[nearKey], entry.Name())
+	}
+
+	metadata, err := sm.LoadMetadata()
+	if err != nil {
+		return nil, err
+	}
+
+	// Near-duplicate groups that are all exact copies are reported once, as exact
+	inExactGroup := make(map[string]bool)
+	for _, files := range exact {
+		if len(files) > 1 {
+			report.Groups = append(report.Groups, DuplicateGroup{Files: sm.orderForKeeping(files, metadata), Exact: true})
+			for _, f := range files {
+				inExactGroup[f] = true
+			}
+		}
+	}
+	for _, files := range near {
+		if len(files) < 2 {
+			continue
+		}
+		allExact := true
+		for _, f := range files {
+			allExact = allExact && inExactGroup[f]
+		}
+		if !allExact {
+			report.Groups = append(report.Groups, DuplicateGroup{Files: sm.orderForKeeping(files, metadata)})
+		}
+	}
+	sort.Slice(report.Groups, func(i, j int) bool { return report.Groups[i].Files[0] < report.Groups[j].Files[0] })
+
+	if !clean || len(report.Groups) == 0 {
+		return report, nil
+	}
+
+	// An exact group may overlap a near group; each file is removed once, and a group
+	// whose first file was already removed keeps the file that one was replaced by
+	removed := make(map[string]string)      // removed file -> kept file
+	replacements := make(map[string]string) // removed path -> kept path
+	for i := range report.Groups {
+		group := &report.Groups[i]
+		keep := group.Files[0]
+		if kept, ok := removed[keep]; ok {
+			keep = kept
+		}
+		for _, f := range group.Files {
+			if _, ok := removed[f]; ok || f == keep {
+				continue
+			}
+			path := filepath.Join(sm.scriptsDir, f)
+			if err := sm.moveToTrash(path); err != nil {
+				return nil, fmt.Errorf("failed to remove %s: %w", f, err)
+			}
+			removed[f] = keep
+			group.Removed = append(group.Removed, f)
+			replacements[path] = filepath.Join(sm.scriptsDir, keep)
+		}
+	}
+	report.Cleaned = true
+
+	count, err := repointKBScripts(replacements)
+	if err != nil {
+		return report, err
+	}
+	report.KBReferences = count
+	return report, nil
+}
+
+// orderForKeeping sorts duplicate files so the one to keep comes first
+func (sm *ScriptManager) orderForKeeping(files []string, metadata *ScriptMetadata) []string {
+	rank := func(f string) int {
+		switch {
+		case metadata.IsFavorite(strings.TrimSuffix(f, ".lua")):
+			return 0
+		case metadata.Installed[f].SHA != "":
+			return 1
+		default:
+			return 2
+		}
+	}
+	ordered := append([]string(nil), files...)
+	sort.Slice(ordered, func(i, j int) bool {
+		a, b := ordered[i], ordered[j]
+		if rank(a) != rank(b) {
+			return rank(a) < rank(b)
+		}
+		if len(a) != len(b) {
+			return len(a) < len(b)
+		}
+		return a < b
+	})
+	return ordered
+}
+
+// repointKBScripts rewrites SCR entries in reaper-kb.ini whose script path is a key of
+// replacements to use the mapped path instead, so shortcuts to removed duplicates keep working.
+// Returns the number of entries changed.
+func repointKBScripts(replacements map[string]string) (int, error) {
+	if len(replacements) == 0 {
+		return 0, nil
+	}
+	kbIniPath, err := GetReaperKBIniPath()
+	if err != nil {
+		return 0, err
+	}
+	changed := 0
+	err = updateConfigFile(kbIniPath, "repoint duplicate scripts", func(content []byte) ([]byte, error) {
+		lines := strings.Split(string(content), "\n")
+		changed = 0
+		for i, line := range lines {
+			if !strings.HasPrefix(line, "SCR ") {
+				continue
+			}
+			for oldPath, newPath := range replacements {
+				if quoted := `"` + oldPath + `"`; strings.Contains(line, quoted) {
+					lines[i] = strings.Replace(line, quoted, `"`+newPath+`"`, 1)
+					changed++
+					break
+				}
+			}
+		}
+		if changed == 0 {
+			return nil, errNoChange
+		}
+		return []byte(strings.Join(lines, "\n")), nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return changed, nil
+}
+
+// FormatDuplicateReport summarizes duplicate scripts and any cleanup done
+func FormatDuplicateReport(report *DuplicateReport) string {
+	if len(report.Groups) == 0 {
+		return fmt.Sprintf("No duplicate scripts found among %d scripts.", report.Scanned)
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Found %d group(s) of duplicate scripts among %d scripts:\n", len(report.Groups), report.Scanned))
+	for _, group := range report.Groups {
+		kind := "identical"
+		if !group.Exact {
+			kind = "identical apart from whitespace"
+		}
+		b.WriteString(fmt.Sprintf("\n• %s (%s)\n", strings.Join(group.Files, ", "), kind))
+		if len(group.Removed) > 0 {
+			b.WriteString(fmt.Sprintf("  moved to trash: %s\n", strings.Join(group.Removed, ", ")))
+		}
+	}
+	if report.Cleaned {
+		b.WriteString(fmt.Sprintf("\nUpdated %d reaper-kb.ini entries to point at the kept scripts.", report.KBReferences))
+	} else {
+		b.WriteString("\nRun 'find_duplicates' with dry_run=false to keep the first file of each group and move the rest to the trash.")
+	}
+	return b.String()
+}
@@ -0,0 +1,232 @@
+package scripts
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/bridge"
+)
+
+// rippleActions are REAPER's ripple editing actions, each selecting one mode
+var rippleActions = map[string]int{
+	"off":        40309, // Options: Ripple editing off
+	"per_track":  40310, // Options: Ripple editing per-track
+	"all_tracks": 40311, // Options: Ripple editing all tracks
+}
+
+const (
+	actionToggleSnap      = 1157  // Options: Toggle snapping
+	actionToggleGridLines = 40145 // Options: Toggle grid lines
+)
+
+// RippleModes lists the ripple editing modes
+var RippleModes = []string{"off", "per_track", "all_tracks"}
+
+// EditModes are the editing options that change what item edits and scripts do to the
+// rest of the project
+type EditModes struct {
+	Ripple       string  `json:"ripple"`        // One of RippleModes
+	Snap         bool    `json:"snap"`          // Snapping enabled
+	GridVisible  bool    `json:"grid_visible"`  // Grid lines shown
+	GridDivision float64 `json:"grid_division"` // Grid spacing as a fraction of a whole note (0.25 = quarter notes)
+	Grid         string  `json:"grid"`          // Grid spacing as a note value, e.g. "1/16" or "1/8T"
+	Swing        float64 `json:"swing"`         // Grid swing amount (-1.0 to 1.0), 0 when swing is off
+}
+
+// EditModesChange describes the edit modes to change; empty and nil fields are left alone
+type EditModesChange struct {
+	Ripple      string
+	Snap        *bool
+	GridVisible *bool
+	Grid        string   // Note value such as "1/16", "1/8T" or "1/4." (dotted), or a fraction of a whole note
+	Swing       *float64 // 0 turns swing off
+}
+
+// ParseRippleMode normalizes a ripple mode name to one of RippleModes
+func ParseRippleMode(mode string) (string, error) {
+	switch strings.NewReplacer(" ", "_", "-", "_").Replace(strings.ToLower(strings.TrimSpace(mode))) {
+	case "off", "none", "false":
+		return "off", nil
+	case "per_track", "track", "on", "true":
+		return "per_track", nil
+	case "all_tracks", "all":
+		return "all_tracks", nil
+	}
+	return "", fmt.Errorf("unsupported ripple mode: %s. Valid modes: %s", mode, strings.Join(RippleModes, ", "))
+}
+
+// ParseGridDivision converts a note value such as "1/16", "1/8T" (triplet) or "1/4."
+// (dotted), or a plain fraction of a whole note such as "0.25", to a grid division
+func ParseGridDivision(grid string) (float64, error) {
+	s := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(grid), " ", ""))
+	factor := 1.0
+	switch {
+	case strings.HasSuffix(s, "t"):
+		factor, s = 2.0/3.0, strings.TrimSuffix(s, "t")
+	case strings.HasSuffix(s, "."):
+		factor, s = 1.5, strings.TrimSuffix(s, ".")
+	}
+
+	var division float64
+	if numerator, denominator, ok := strings.Cut(s, "/"); ok {
+		n, err1 := strconv.ParseFloat(numerator, 64)
+		d, err2 := strconv.ParseFloat(denominator, 64)
+		if err1 != nil || err2 != nil || d == 0 {
+			return 0, fmt.Errorf("unsupported grid: %q. Use a note value such as 1/16, 1/8T or 1/4.", grid)
+		}
+		division = n / d
+	} else {
+		d, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, fmt.Errorf("unsupported grid: %q. Use a note value such as 1/16, 1/8T or 1/4.", grid)
+		}
+		division = d
+	}
+	division *= factor
+	if division <= 0 || division > 16 {
+		return 0, fmt.Errorf("grid must be between 1/256 and 16 whole notes, got %q", grid)
+	}
+	return division, nil
+}
+
+// gridLabel names a grid division as a note value where one fits, preferring triplets
+// for divisions of three (1/12 is shown as 1/8T)
+func gridLabel(division float64) string {
+	if division <= 0 {
+		return "off"
+	}
+	isWhole := func(x float64) bool { return x >= 1 && math.Abs(x-math.Round(x)) < 1e-6 }
+
+	if denominator := 1 / division; isWhole(denominator) {
+		n := int(math.Round(denominator))
+		if n%3 == 0 {
+			return fmt.Sprintf("1/%dT", n*2/3)
+		}
+		return fmt.Sprintf("1/%d", n)
+	}
+	if isWhole(division) {
+		return strconv.Itoa(int(math.Round(division)))
+	}
+	if denominator := 1.5 / division; isWhole(denominator) {
+		return fmt.Sprintf("1/%d.", int(math.Round(denominator)))
+	}
+	return strconv.FormatFloat(division, 'f', -1, 64)
+}
+
+// GetEditModes reads the ripple, snap and grid settings via the Lua bridge
+func GetEditModes(ctx context.Context) (*EditModes, error) {
+	rows, err := bridge.Run(ctx, "get_edit_modes", fmt.Sprintf(`local ripple = "off"
+if reaper.GetToggleCommandState(%d) == 1 then ripple = "per_track" end
+if reaper.GetToggleCommandState(%d) == 1 then ripple = "all_tracks" end
+local _, division, swing_mode, swing = reaper.GetSetProjectGrid(0, false)
+if swing_mode ~= 1 then swing = 0 end
+out(ripple, reaper.GetToggleCommandState(%d), reaper.GetToggleCommandState(%d), division, swing)
+`, rippleActions["per_track"], rippleActions["all_tracks"], actionToggleSnap, actionToggleGridLines))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read edit modes: %w", err)
+	}
+	if len(rows) < 1 || len(rows[0]) < 5 {
+		return nil, fmt.Errorf("unexpected output format: no edit mode data")
+	}
+
+	row := rows[0]
+	modes := &EditModes{
+		Ripple:      row[0],
+		Snap:        row[1] == "1",
+		GridVisible: row[2] == "1",
+	}
+	modes.GridDivision, _ = strconv.ParseFloat(row[3], 64)
+	modes.Swing, _ = strconv.ParseFloat(row[4], 64)
+	modes.Grid = gridLabel(modes.GridDivision)
+	return modes, nil
+}
+
+// SetEditModes changes the ripple, snap and grid settings via the Lua bridge. Toggles are
+// only flipped when they differ from the requested state.
+func SetEditModes(ctx context.Context, change EditModesChange) error {
+	var body strings.Builder
+	if change.Ripple != "" {
+		mode, err := ParseRippleMode(change.Ripple)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&body, "reaper.Main_OnCommand(%d, 0)\n", rippleActions[mode])
+	}
+	for _, toggle := range []struct {
+		want   *bool
+		action int
+	}{{change.Snap, actionToggleSnap}, {change.GridVisible, actionToggleGridLines}} {
+		if toggle.want == nil {
+			continue
+		}
+		state := 0
+		if *toggle.want {
+			state = 1
+		}
+		fmt.Fprintf(&body, "if reaper.GetToggleCommandState(%d) ~= %d then reaper.Main_OnCommand(%d, 0) end\n",
+			toggle.action, state, toggle.action)
+	}
+	if change.Grid != "" || change.Swing != nil {
+		division := "nil"
+		if change.Grid != "" {
+			value, err := ParseGridDivision(change.Grid)
+			if err != nil {
+				return err
+			}
+			division = strconv.FormatFloat(value, 'g', -1, 64)
+		}
+		swingMode, swing := "nil", "nil"
+		if change.Swing != nil {
+			if *change.Swing < -1 || *change.Swing > 1 {
+				return fmt.Errorf("swing must be between -1.0 and 1.0, got %g", *change.Swing)
+			}
+			swingMode = "0"
+			if *change.Swing != 0 {
+				swingMode = "1"
+			}
+			swing = strconv.FormatFloat(*change.Swing, 'g', -1, 64)
+		}
+		fmt.Fprintf(&body, `local _, division, swing_mode, swing = reaper.GetSetProjectGrid(0, false)
+reaper.GetSetProjectGrid(0, true, %s or division, %s or swing_mode, %s or swing)
+`, division, swingMode, swing)
+	}
+	if body.Len() == 0 {
+		return fmt.Errorf("nothing to change: set ripple, snap, grid_visible, grid or swing")
+	}
+
+	if _, err := bridge.Run(ctx, "set_edit_modes", body.String()); err != nil {
+		return fmt.Errorf("failed to set edit modes: %w", err)
+	}
+	return nil
+}
+
+// FormatEditModes formats the edit modes as readable text
+func FormatEditModes(modes *EditModes) string {
+	onOff := func(on bool) string {
+		if on {
+			return "on"
+		}
+		return "off"
+	}
+	ripple := strings.ReplaceAll(modes.Ripple, "_", " ")
+
+	var result strings.Builder
+	result.WriteString("Edit modes:\n")
+	result.WriteString(fmt.Sprintf("  Ripple editing: %s\n", ripple))
+	result.WriteString(fmt.Sprintf("  Snap: %s\n", onOff(modes.Snap)))
+	result.WriteString(fmt.Sprintf("  Grid: %s (lines %s)\n", modes.Grid, onOff(modes.GridVisible)))
+	if modes.Swing != 0 {
+		result.WriteString(fmt.Sprintf("  Swing: %.0f%%\n", modes.Swing*100))
+	}
+	if modes.Ripple != "off" {
+		result.WriteString("\nNote: with ripple editing on, moving, deleting or inserting items also shifts later items")
+		if modes.Ripple == "all_tracks" {
+			result.WriteString(" on every track")
+		}
+		result.WriteString(".\n")
+	}
+	return result.String()
+}
@@ -0,0 +1,116 @@
+package scripts
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/bridge"
+)
+
+// Envelope represents an automation envelope on a track
+type Envelope struct {
+	TrackIndex int    `json:"track_index"` // Track index (1-based)
+	TrackName  string `json:"track_name"`  // Track name
+	Name       string `json:"name"`        // Envelope name (e.g. "Volume", "Pan")
+	Visible    bool   `json:"visible"`     // Envelope lane is visible
+	Armed      bool   `json:"armed"`       // Envelope is armed for recording
+	Active     bool   `json:"active"`      // Envelope is active
+	Points     int    `json:"points"`      // Number of automation points
+}
+
+// GetEnvelopes lists the envelopes of a track (1-based index) via the Lua bridge
+// A track index of 0 lists the envelopes of every track
+func GetEnvelopes(ctx context.Context, trackIndex int) ([]Envelope, error) {
+	if trackIndex < 0 {
+		return nil, fmt.Errorf("track index must be 0 (all tracks) or greater")
+	}
+
+	rows, err := bridge.Run(ctx, "get_envelopes", fmt.Sprintf(`local wanted = %d
+local first, last = 0, reaper.CountTracks(0) - 1
+if wanted > 0 then
+    if wanted - 1 > last then
+        return fail("track " .. wanted .. " not found")
+    end
+    first, last = wanted - 1, wanted - 1
+end
+
+for i = first, last do
+    local track = reaper.GetTrack(0, i)
+    local _, track_name = reaper.GetTrackName(track)
+    for e = 0, reaper.CountTrackEnvelopes(track) - 1 do
+        local env = reaper.GetTrackEnvelope(track, e)
+        local _, name = reaper.GetEnvelopeName(env)
+        local _, chunk = reaper.GetEnvelopeStateChunk(env, "", false)
+        local visible = chunk:match("\nVIS (%%d)") or "0"
+        local armed = chunk:match("\nARM (%%d)") or "0"
+        local active = chunk:match("\nACT (%%d)") or "0"
+        out(i + 1, track_name, name, visible, armed, active, reaper.CountEnvelopePoints(env))
+    end
+end
+`, trackIndex))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read envelopes: %w", err)
+	}
+
+	envelopes := make([]Envelope, 0, len(rows))
+	for _, row := range rows {
+		if len(row) < 7 {
+			continue
+		}
+		index, _ := strconv.Atoi(row[0])
+		points, _ := strconv.Atoi(row[6])
+		envelopes = append(envelopes, Envelope{
+			TrackIndex: index,
+			TrackName:  row[1],
+			Name:       row[2],
+			Visible:    row[3] == "1",
+			Armed:      row[4] == "1",
+			Active:     row[5] == "1",
+			Points:     points,
+		})
+	}
+
+	return envelopes, nil
+}
+
+// FormatEnvelopesTable formats envelopes as a readable table
+func FormatEnvelopesTable(envelopes []Envelope) string {
+	if len(envelopes) == 0 {
+		return "No envelopes found"
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Found %d envelopes:\n\n", len(envelopes)))
+	result.WriteString("Track | Track Name           | Envelope             | Points | V | A | Active\n")
+	result.WriteString("------|----------------------|----------------------|--------|---|---|-------\n")
+
+	for _, env := range envelopes {
+		visibleFlag := " "
+		if env.Visible {
+			visibleFlag = "V"
+		}
+		armedFlag := " "
+		if env.Armed {
+			armedFlag = "A"
+		}
+		activeStr := "no"
+		if env.Active {
+			activeStr = "yes"
+		}
+
+		result.WriteString(fmt.Sprintf("%-5d | %-20s | %-20s | %6d | %s | %s | %s\n",
+			env.TrackIndex,
+			truncateString(env.TrackName, 20),
+			truncateString(env.Name, 20),
+			env.Points,
+			visibleFlag,
+			armedFlag,
+			activeStr,
+		))
+	}
+
+	result.WriteString("\nLegend: V=Visible, A=Armed")
+	return result.String()
+}
@@ -0,0 +1,223 @@
+package scripts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// githubRelease is the subset of the GitHub releases API response used for extension downloads
+type githubRelease struct {
+	TagName string `json:"tag_name"`
+	Assets  []struct {
+		Name               string `json:"name"`
+		Size               int    `json:"size"`
+		BrowserDownloadURL string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+// ExtensionDownload describes the plugin binary that would be installed for an extension
+type ExtensionDownload struct {
+	Extension Extension `json:"extension"`
+	Version   string    `json:"version"`
+	AssetName string    `json:"asset_name"`
+	Size      int       `json:"size"`
+	URL       string    `json:"url"`
+	Target    string    `json:"target"` // Destination path in UserPlugins
+}
+
+// FindExtension looks up a known extension by ID or name (case-insensitive)
+func FindExtension(id string) (Extension, error) {
+	id = strings.ToLower(strings.TrimSpace(id))
+	for _, ext := range KnownExtensions {
+		if ext.ID == id || strings.ToLower(ext.Name) == id {
+			return ext, nil
+		}
+	}
+	ids := make([]string, len(KnownExtensions))
+	for i, ext := range KnownExtensions {
+		ids[i] = ext.ID
+	}
+	return Extension{}, fmt.Errorf("unknown extension: %s. Known extensions: %s", id, strings.Join(ids, ", "))
+}
+
+// platformAssetSuffix returns the release asset suffix for the current OS and architecture
+func platformAssetSuffix() (string, error) {
+	switch runtime.GOOS + "/" + runtime.GOARCH {
+	case "darwin/arm64":
+		return "-arm64.dylib", nil
+	case "darwin/amd64":
+		return "-x86_64.dylib", nil
+	case "windows/amd64":
+		return "-x64.dll", nil
+	case "windows/386":
+		return "-x86.dll", nil
+	case "linux/amd64":
+		return "-x86_64.so", nil
+	case "linux/arm64":
+		return "-aarch64.so", nil
+	default:
+		return "", fmt.Errorf("no extension builds available for %s/%s", runtime.GOOS, runtime.GOARCH)
+	}
+}
+
+// PlanExtensionInstall finds the latest release binary of an extension for this platform
+func PlanExtensionInstall(ctx context.Context, ext Extension) (*ExtensionDownload, error) {
+	if ext.releaseRepo == "" {
+		return nil, fmt.Errorf("%s can't be installed automatically. Install it via ReaPack or from %s", ext.Name, ext.URL)
+	}
+
+	suffix, err := platformAssetSuffix()
+	if err != nil {
+		return nil, err
+	}
+
+	pluginsDir, err := GetUserPluginsPath()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := httpGet(ctx, fmt.Sprintf("https://api.github.com/repos/%s/releases/latest", ext.releaseRepo))
+	if err != nil {
+		return nil, fmt.Errorf("HTTP request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("GitHub API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var release githubRelease
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub release: %w", err)
+	}
+
+	for _, asset := range release.Assets {
+		name := strings.ToLower(asset.Name)
+		if strings.HasPrefix(name, ext.filePrefix) && strings.HasSuffix(name, suffix) {
+			return &ExtensionDownload{
+				Extension: ext,
+				Version:   release.TagName,
+				AssetName: asset.Name,
+				Size:      asset.Size,
+				URL:       asset.BrowserDownloadURL,
+				Target:    filepath.Join(pluginsDir, asset.Name),
+			}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no %s build found for %s/%s in release %s", ext.Name, runtime.GOOS, runtime.GOARCH, release.TagName)
+}
+
+// InstallExtension downloads a planned extension binary into UserPlugins,
+// reporting bytes received to progress if set
+func InstallExtension(ctx context.Context, download *ExtensionDownload, progress ProgressFunc) error {
+	if _, err := os.Stat(download.Target); err == nil {
+		return fmt.Errorf("%s already exists in UserPlugins", download.AssetName)
+	}
+	if err := os.MkdirAll(filepath.Dir(download.Target), 0755); err != nil {
+		return fmt.Errorf("failed to create UserPlugins: %w", err)
+	}
+
+	resp, err := httpGet(ctx, download.URL)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", download.AssetName, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download failed with status: %d", resp.StatusCode)
+	}
+
+	// Write to a temporary name so REAPER never sees a partial binary
+	tmpPath := download.Target + ".download"
+	out, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", tmpPath, err)
+	}
+	if _, err := io.Copy(out, newProgressReader(resp.Body, download.AssetName, resp.ContentLength, progress)); err != nil {
+		out.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write %s: %w", download.AssetName, err)
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write %s: %w", download.AssetName, err)
+	}
+
+	if err := os.Rename(tmpPath, download.Target); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to install %s: %w", download.AssetName, err)
+	}
+	return nil
+}
+
+// PlanExtensionOperation finds the download InstallExtensionOperation would make for
+// extension id, so it can be confirmed first. Returns nil when there's nothing to
+// install: no extension is given or it's already installed.
+func PlanExtensionOperation(ctx context.Context, id string) (*ExtensionDownload, error) {
+	if strings.TrimSpace(id) == "" {
+		return nil, nil
+	}
+	ext, err := FindExtension(id)
+	if err != nil {
+		return nil, err
+	}
+	installed, err := InstalledExtensions()
+	if err != nil {
+		return nil, err
+	}
+	if installed[ext.ID] {
+		return nil, nil
+	}
+	return PlanExtensionInstall(ctx, ext)
+}
+
+// InstallExtensionOperation installs an extension; callers confirm the download
+// PlanExtensionOperation describes first. With no extension given it lists the known
+// extensions that are missing.
+func InstallExtensionOperation(ctx context.Context, id string) (string, error) {
+	installed, err := InstalledExtensions()
+	if err != nil {
+		return "", err
+	}
+
+	if strings.TrimSpace(id) == "" {
+		var missing []string
+		for _, ext := range KnownExtensions {
+			if !installed[ext.ID] {
+				missing = append(missing, fmt.Sprintf("  - %s (%s)", ext.Name, ext.ID))
+			}
+		}
+		if len(missing) == 0 {
+			return "All known REAPER extensions are installed.", nil
+		}
+		return "Missing REAPER extensions:\n" + strings.Join(missing, "\n") +
+			"\n\nTo install one, use 'install_extension' with its id.", nil
+	}
+
+	ext, err := FindExtension(id)
+	if err != nil {
+		return "", err
+	}
+	if installed[ext.ID] {
+		return fmt.Sprintf("%s is already installed.", ext.Name), nil
+	}
+
+	download, err := PlanExtensionInstall(ctx, ext)
+	if err != nil {
+		return "", err
+	}
+
+	if err := InstallExtension(ctx, download, StderrProgress); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Installed %s %s to %s\nRestart REAPER to load the extension.", ext.Name, download.Version, download.Target), nil
+}
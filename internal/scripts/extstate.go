@@ -0,0 +1,166 @@
+package scripts
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// extStateValuePreview caps how much of each value is shown when a whole section is listed
+const extStateValuePreview = 120
+
+// ExtStateSection is a section of reaper-extstate.ini, usually named after the script
+// that wrote it
+type ExtStateSection struct {
+	Name string `json:"name"`
+	Keys int    `json:"keys"`
+}
+
+// ExtStateValue is one persistent ExtState key
+type ExtStateValue struct {
+	Section string `json:"section"`
+	Key     string `json:"key"`
+	Value   string `json:"value"`
+}
+
+// GetReaperExtStatePath returns the path of reaper-extstate.ini, where REAPER keeps the
+// ExtState that scripts save with persist=true
+func GetReaperExtStatePath() (string, error) {
+	basePath, err := GetReaperResourcePath()
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(basePath, "reaper-extstate.ini")
+	if _, err := configFS.Stat(path); os.IsNotExist(err) {
+		return "", fmt.Errorf("reaper-extstate.ini not found at %s (no script has saved persistent state yet)", path)
+	}
+	return path, nil
+}
+
+// readExtState returns every key of reaper-extstate.ini, in file order
+func readExtState() ([]ExtStateValue, error) {
+	path, err := GetReaperExtStatePath()
+	if err != nil {
+		return nil, err
+	}
+	file, err := configFS.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open reaper-extstate.ini: %w", err)
+	}
+	defer file.Close()
+
+	var values []ExtStateValue
+	section := ""
+	scanner := bufio.NewScanner(file)
+	// Scripts store serialized tables in single values, so lines can be long
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			section = trimmed[1 : len(trimmed)-1]
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || section == "" {
+			continue
+		}
+		values = append(values, ExtStateValue{Section: section, Key: strings.TrimSpace(key), Value: value})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading reaper-extstate.ini: %w", err)
+	}
+	return values, nil
+}
+
+// ListExtStateSections returns the sections of reaper-extstate.ini whose name contains
+// filter (case-insensitive), sorted by name
+func ListExtStateSections(filter string) ([]ExtStateSection, error) {
+	values, err := readExtState()
+	if err != nil {
+		return nil, err
+	}
+	filter = strings.ToLower(strings.TrimSpace(filter))
+	counts := make(map[string]int)
+	for _, v := range values {
+		if filter == "" || strings.Contains(strings.ToLower(v.Section), filter) {
+			counts[v.Section]++
+		}
+	}
+
+	sections := make([]ExtStateSection, 0, len(counts))
+	for name, keys := range counts {
+		sections = append(sections, ExtStateSection{Name: name, Keys: keys})
+	}
+	sort.Slice(sections, func(i, j int) bool {
+		return strings.ToLower(sections[i].Name) < strings.ToLower(sections[j].Name)
+	})
+	return sections, nil
+}
+
+// GetExtState returns the keys of a section of reaper-extstate.ini, or only key when it's
+// given. Section and key names are matched case-insensitively, as REAPER does.
+func GetExtState(section, key string) ([]ExtStateValue, error) {
+	section, key = strings.TrimSpace(section), strings.TrimSpace(key)
+	if section == "" {
+		return nil, fmt.Errorf("section is required; use 'list_extstate' to see the sections")
+	}
+	values, err := readExtState()
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []ExtStateValue
+	sectionFound := false
+	for _, v := range values {
+		if !strings.EqualFold(v.Section, section) {
+			continue
+		}
+		sectionFound = true
+		if key == "" || strings.EqualFold(v.Key, key) {
+			matched = append(matched, v)
+		}
+	}
+	switch {
+	case !sectionFound:
+		return nil, fmt.Errorf("no ExtState section %q in reaper-extstate.ini; use 'list_extstate' to see the sections", section)
+	case len(matched) == 0:
+		return nil, fmt.Errorf("no key %q in ExtState section %q", key, section)
+	}
+	return matched, nil
+}
+
+// FormatExtStateSections formats the ExtState sections as a list
+func FormatExtStateSections(sections []ExtStateSection, filter string) string {
+	if len(sections) == 0 {
+		if filter != "" {
+			return fmt.Sprintf("No ExtState sections matching '%s'", filter)
+		}
+		return "reaper-extstate.ini has no sections"
+	}
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%d ExtState section(s) in reaper-extstate.ini:\n\n", len(sections)))
+	for _, section := range sections {
+		b.WriteString(fmt.Sprintf("  - %s (%d keys)\n", section.Name, section.Keys))
+	}
+	b.WriteString("\nUse 'get_extstate' with section=<name> to read one.")
+	return b.String()
+}
+
+// FormatExtState formats ExtState keys as "key = value" lines. When a whole section is
+// shown, long values are shortened; a single key is shown in full.
+func FormatExtState(values []ExtStateValue, single bool) string {
+	if single && len(values) == 1 {
+		return fmt.Sprintf("[%s] %s = %s", values[0].Section, values[0].Key, values[0].Value)
+	}
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("[%s] (%d keys)\n", values[0].Section, len(values)))
+	for _, v := range values {
+		b.WriteString(fmt.Sprintf("  %s = %s\n", v.Key, truncateString(v.Value, extStateValuePreview)))
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,194 @@
+package scripts
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/bridge"
+)
+
+// GroupParams lists the track parameters whose group links are reported, in REAPER's
+// group membership names (each with a _LEAD and _FOLLOW variant)
+var GroupParams = []string{"VOLUME", "VOLUME_VCA", "PAN", "MUTE", "SOLO", "RECARM"}
+
+// GroupMembership is a track's part in one track group
+type GroupMembership struct {
+	Group  int      `json:"group"`            // Group number, 1-64
+	Name   string   `json:"name,omitempty"`   // Group name set in REAPER
+	Lead   []string `json:"lead,omitempty"`   // Parameters the track leads, e.g. "volume"
+	Follow []string `json:"follow,omitempty"` // Parameters the track follows
+}
+
+// TrackLayout is the folder structure and group links of a project's tracks, as read by
+// GetTrackLayout
+type TrackLayout struct {
+	FolderDepths []int               // Folder depth change per track (index = track number - 1): 1 opens a folder, -n closes n folders after the track
+	Groups       [][]GroupMembership // Group memberships per track (index = track number - 1)
+}
+
+// GetTrackLayout reads the folder structure and group memberships of every track via the Lua bridge
+func GetTrackLayout(ctx context.Context) (*TrackLayout, error) {
+	params := make([]string, len(GroupParams))
+	for i, param := range GroupParams {
+		params[i] = bridge.LuaString(param)
+	}
+	rows, err := bridge.Run(ctx, "get_track_layout", fmt.Sprintf(`local params = {%s}
+for g = 1, 64 do
+    local _, name = reaper.GetSetProjectInfo_String(0, "TRACK_GROUP_NAME:" .. g, "", false)
+    if name ~= "" then out("name", g, name) end
+end
+for i = 0, reaper.CountTracks(0) - 1 do
+    local track = reaper.GetTrack(0, i)
+    out("track", i + 1, math.floor(reaper.GetMediaTrackInfo_Value(track, "I_FOLDERDEPTH")))
+    for _, param in ipairs(params) do
+        for _, role in ipairs({"LEAD", "FOLLOW"}) do
+            local low = reaper.GetSetTrackGroupMembership(track, param .. "_" .. role, 0, 0)
+            local high = reaper.GetSetTrackGroupMembershipHigh(track, param .. "_" .. role, 0, 0)
+            if low ~= 0 or high ~= 0 then out("group", i + 1, param, role, low, high) end
+        end
+    end
+end
+`, strings.Join(params, ", ")))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read track layout: %w", err)
+	}
+	return parseTrackLayout(rows), nil
+}
+
+// parseTrackLayout builds a TrackLayout from the rows written by GetTrackLayout's script
+func parseTrackLayout(rows [][]string) *TrackLayout {
+	layout := &TrackLayout{}
+	names := make(map[int]string)
+	memberships := make(map[int]map[int]*GroupMembership) // Track -> group -> membership
+	for _, row := range rows {
+		switch {
+		case row[0] == "name" && len(row) >= 3:
+			group, _ := strconv.Atoi(row[1])
+			names[group] = row[2]
+		case row[0] == "track" && len(row) >= 3:
+			depth, _ := strconv.Atoi(row[2])
+			layout.FolderDepths = append(layout.FolderDepths, depth)
+		case row[0] == "group" && len(row) >= 6:
+			index, _ := strconv.Atoi(row[1])
+			low, _ := strconv.ParseUint(row[4], 10, 32)
+			high, _ := strconv.ParseUint(row[5], 10, 32)
+			param := strings.ToLower(row[2])
+			for bit := 0; bit < 64; bit++ {
+				var set bool
+				if bit < 32 {
+					set = low&(1<<bit) != 0
+				} else {
+					set = high&(1<<(bit-32)) != 0
+				}
+				if !set {
+					continue
+				}
+				if memberships[index] == nil {
+					memberships[index] = make(map[int]*GroupMembership)
+				}
+				membership := memberships[index][bit+1]
+				if membership == nil {
+					membership = &GroupMembership{Group: bit + 1}
+					memberships[index][bit+1] = membership
+				}
+				if row[3] == "LEAD" {
+					membership.Lead = append(membership.Lead, param)
+				} else {
+					membership.Follow = append(membership.Follow, param)
+				}
+			}
+		}
+	}
+
+	layout.Groups = make([][]GroupMembership, len(layout.FolderDepths))
+	for index, groups := range memberships {
+		if index < 1 || index > len(layout.Groups) {
+			continue
+		}
+		for group := 1; group <= 64; group++ {
+			if membership, ok := groups[group]; ok {
+				membership.Name = names[group]
+				layout.Groups[index-1] = append(layout.Groups[index-1], *membership)
+			}
+		}
+	}
+	return layout
+}
+
+// ApplyTrackLayout sets Depth, Parent, Folder and Groups of tracks from layout. The
+// master track (index 0) is left at the top level.
+func ApplyTrackLayout(tracks []Track, layout *TrackLayout) {
+	var parents []int // Enclosing folder track indexes, innermost last
+	for i := range tracks {
+		track := &tracks[i]
+		if track.Index < 1 || track.Index > len(layout.FolderDepths) {
+			continue
+		}
+		track.Depth = len(parents)
+		if len(parents) > 0 {
+			track.Parent = parents[len(parents)-1]
+		}
+		track.Groups = layout.Groups[track.Index-1]
+
+		change := layout.FolderDepths[track.Index-1]
+		switch {
+		case change > 0:
+			track.Folder = true
+			parents = append(parents, track.Index)
+		case change < 0:
+			parents = parents[:max(0, len(parents)+change)]
+		}
+	}
+}
+
+// FormatTrackGroups describes which tracks lead and follow in each group, or returns ""
+// when no track is grouped
+func FormatTrackGroups(tracks []Track) string {
+	type member struct {
+		name   string
+		params []string
+	}
+	leads := make(map[int][]member)
+	follows := make(map[int][]member)
+	names := make(map[int]string)
+	var groups []int
+	for _, track := range tracks {
+		for _, membership := range track.Groups {
+			if _, seen := names[membership.Group]; !seen {
+				groups = append(groups, membership.Group)
+			}
+			names[membership.Group] = membership.Name
+			label := fmt.Sprintf("%d %s", track.Index, track.Name)
+			if len(membership.Lead) > 0 {
+				leads[membership.Group] = append(leads[membership.Group], member{label, membership.Lead})
+			}
+			if len(membership.Follow) > 0 {
+				follows[membership.Group] = append(follows[membership.Group], member{label, membership.Follow})
+			}
+		}
+	}
+	if len(groups) == 0 {
+		return ""
+	}
+
+	sort.Ints(groups)
+	var result strings.Builder
+	result.WriteString("Track groups (changing a leader also changes its followers):\n")
+	for _, group := range groups {
+		title := fmt.Sprintf("Group %d", group)
+		if names[group] != "" {
+			title += " \"" + names[group] + "\""
+		}
+		result.WriteString("  " + title + "\n")
+		for _, m := range leads[group] {
+			result.WriteString(fmt.Sprintf("    lead:   %s (%s)\n", m.name, strings.Join(m.params, ", ")))
+		}
+		for _, m := range follows[group] {
+			result.WriteString(fmt.Sprintf("    follow: %s (%s)\n", m.name, strings.Join(m.params, ", ")))
+		}
+	}
+	return result.String()
+}
@@ -0,0 +1,309 @@
+package scripts
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/platform"
+)
+
+// gitTimeout bounds each git command, which can be slow on a network share
+const gitTimeout = 30 * time.Second
+
+// gitIgnore keeps the plugin's own bookkeeping files out of the scripts repository
+var gitIgnore = []string{lockFileName, "*" + tempFileExt, trashDirName + "/", ".write-test"}
+
+// GitOptions turns the scripts directory into a git repository that records every
+// change the plugin makes. Messages are templates where {script} is the file name and,
+// for rollbacks, {commit} the short commit ID rolled back to.
+type GitOptions struct {
+	Enabled         bool
+	AddMessage      string
+	UpdateMessage   string
+	DeleteMessage   string
+	RestoreMessage  string
+	RollbackMessage string
+}
+
+// withDefaults fills unset message templates
+func (o GitOptions) withDefaults() GitOptions {
+	defaults := map[*string]string{
+		&o.AddMessage:      "Add {script}",
+		&o.UpdateMessage:   "Update {script}",
+		&o.DeleteMessage:   "Delete {script}",
+		&o.RestoreMessage:  "Restore {script}",
+		&o.RollbackMessage: "Roll back {script} to {commit}",
+	}
+	for message, value := range defaults {
+		if strings.TrimSpace(*message) == "" {
+			*message = value
+		}
+	}
+	return o
+}
+
+// template returns the message template for action: add, update, delete, restore or rollback
+func (o GitOptions) template(action string) string {
+	switch action {
+	case "add":
+		return o.AddMessage
+	case "update":
+		return o.UpdateMessage
+	case "delete":
+		return o.DeleteMessage
+	case "restore":
+		return o.RestoreMessage
+	default:
+		return o.RollbackMessage
+	}
+}
+
+// Managers are created per call, so the git options are shared by all of them
+var (
+	gitMu      sync.RWMutex
+	gitOptions GitOptions
+)
+
+// ConfigureGit sets whether and how script changes are committed to git
+func ConfigureGit(opts GitOptions) {
+	gitMu.Lock()
+	defer gitMu.Unlock()
+	gitOptions = opts.withDefaults()
+}
+
+// currentGitOptions returns the options set by ConfigureGit
+func currentGitOptions() GitOptions {
+	gitMu.RLock()
+	defer gitMu.RUnlock()
+	return gitOptions
+}
+
+// ScriptRevision is one commit that changed a script
+type ScriptRevision struct {
+	Commit  string    `json:"commit"`
+	Short   string    `json:"short"`
+	Date    time.Time `json:"date"`
+	Author  string    `json:"author"`
+	Message string    `json:"message"`
+}
+
+// git runs a git command in the scripts directory and returns its trimmed output
+func (sm *ScriptManager) git(args ...string) (string, error) {
+	out, err := sm.gitRaw(args...)
+	return strings.TrimSpace(string(out)), err
+}
+
+// gitRaw runs a git command in the scripts directory and returns its output as is. A
+// commit identity is supplied when the user hasn't configured one.
+func (sm *ScriptManager) gitRaw(args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), gitTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = sm.scriptsDir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if os.Getenv("GIT_AUTHOR_NAME") == "" && os.Getenv("GIT_COMMITTER_NAME") == "" {
+		if name, _ := exec.CommandContext(ctx, "git", "-C", sm.scriptsDir, "config", "user.name").Output(); len(bytes.TrimSpace(name)) == 0 {
+			cmd.Env = append(cmd.Env,
+				"GIT_AUTHOR_NAME=Ori Agent", "GIT_AUTHOR_EMAIL=ori@localhost",
+				"GIT_COMMITTER_NAME=Ori Agent", "GIT_COMMITTER_EMAIL=ori@localhost")
+		}
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return out, nil
+}
+
+// gitAvailable reports why the scripts directory can't be versioned, or nil if it can
+func (sm *ScriptManager) gitAvailable() error {
+	if !platform.IsLocal(sm.backend.FS) {
+		return errors.New("git history is only available for a scripts directory on this machine's file system")
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		return errors.New("git is not installed or not on PATH")
+	}
+	return nil
+}
+
+// ensureGitRepo initializes the scripts directory as a git repository, committing the
+// scripts already in it, unless it already is one
+func (sm *ScriptManager) ensureGitRepo() error {
+	if _, err := sm.backend.FS.Stat(filepath.Join(sm.scriptsDir, ".git")); err == nil {
+		return nil
+	}
+	if _, err := sm.git("init", "-q"); err != nil {
+		return err
+	}
+	ignorePath := filepath.Join(sm.scriptsDir, ".gitignore")
+	if _, err := sm.backend.FS.Stat(ignorePath); os.IsNotExist(err) {
+		if err := sm.backend.FS.WriteFile(ignorePath, []byte(strings.Join(gitIgnore, "\n")+"\n"), 0644); err != nil {
+			return fmt.Errorf("failed to write .gitignore: %w", err)
+		}
+	}
+	if _, err := sm.git("add", "-A"); err != nil {
+		return err
+	}
+	if _, err := sm.git("commit", "-q", "--allow-empty", "-m", "Track scripts directory"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// commitScript commits the current state of file (a name in the scripts directory) with
+// the message template for action, when git is enabled; rollbacks are always committed.
+// Failures are returned as a note for the caller's result, since the change itself
+// already succeeded.
+func (sm *ScriptManager) commitScript(action, file, commit string) string {
+	opts := currentGitOptions()
+	if !opts.Enabled && action != "rollback" {
+		return ""
+	}
+	message := strings.NewReplacer("{script}", file, "{commit}", commit).Replace(opts.withDefaults().template(action))
+	err := sm.withDirLock("commit "+file, func() error {
+		if err := sm.gitAvailable(); err != nil {
+			return err
+		}
+		if err := sm.ensureGitRepo(); err != nil {
+			return err
+		}
+		if _, err := sm.git("add", "-A", "--", file); err != nil {
+			return err
+		}
+		if status, err := sm.git("status", "--porcelain", "--", file); err != nil || status == "" {
+			return err // Nothing changed
+		}
+		_, err := sm.git("commit", "-q", "-m", message, "--", file)
+		return err
+	})
+	if err != nil {
+		log.Printf("Failed to commit %s to the scripts repository: %v", file, err)
+		return fmt.Sprintf("⚠️ The change was not committed to git: %v", err)
+	}
+	return ""
+}
+
+// scriptFileInHistory returns the file name of script in the repository, trying the
+// script extensions when script has none, so deleted scripts can still be found
+func (sm *ScriptManager) scriptFileInHistory(script string) (string, error) {
+	script = strings.TrimSpace(script)
+	if script == "" {
+		return "", errors.New("script name is required")
+	}
+	candidates := []string{script}
+	if filepath.Ext(script) == "" {
+		if resolved, err := sm.ResolveScript(script); err == nil {
+			candidates = append([]string{resolved + ".lua"}, candidates...)
+		}
+		candidates = append(candidates, script+".lua", script+".eel", script+".py")
+	}
+	for _, file := range candidates {
+		if out, err := sm.git("log", "-1", "--format=%H", "--", file); err == nil && out != "" {
+			return file, nil
+		}
+	}
+	return "", fmt.Errorf("no git history for script %s", script)
+}
+
+// ScriptHistory returns the commits that changed a script, newest first
+func (sm *ScriptManager) ScriptHistory(script string) (string, []ScriptRevision, error) {
+	if err := sm.gitAvailable(); err != nil {
+		return "", nil, err
+	}
+	if _, err := sm.backend.FS.Stat(filepath.Join(sm.scriptsDir, ".git")); err != nil {
+		return "", nil, errors.New("the scripts directory is not a git repository; enable scripts_git in the settings to start recording history")
+	}
+	file, err := sm.scriptFileInHistory(script)
+	if err != nil {
+		return "", nil, err
+	}
+
+	out, err := sm.git("log", "--format=%H%x1f%h%x1f%aI%x1f%an%x1f%s", "--", file)
+	if err != nil {
+		return "", nil, err
+	}
+	var revisions []ScriptRevision
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Split(line, "\x1f")
+		if len(fields) < 5 {
+			continue
+		}
+		date, _ := time.Parse(time.RFC3339, fields[2])
+		revisions = append(revisions, ScriptRevision{Commit: fields[0], Short: fields[1], Date: date, Author: fields[3], Message: fields[4]})
+	}
+	return file, revisions, nil
+}
+
+// RollbackScript restores a script to its content at commit (an ID or prefix from
+// ScriptHistory) and commits the result. A script deleted since then is brought back.
+func (sm *ScriptManager) RollbackScript(script, commit string) (string, error) {
+	commit = strings.TrimSpace(commit)
+	if commit == "" {
+		return "", errors.New("version is required: the commit to roll back to, as listed by 'script_history'")
+	}
+	file, revisions, err := sm.ScriptHistory(script)
+	if err != nil {
+		return "", err
+	}
+	var target *ScriptRevision
+	for i := range revisions {
+		if strings.HasPrefix(revisions[i].Commit, commit) {
+			target = &revisions[i]
+			break
+		}
+	}
+	if target == nil {
+		return "", fmt.Errorf("commit %s didn't change %s; use 'script_history' to list its versions", commit, file)
+	}
+
+	content, err := sm.gitRaw("show", target.Commit+":"+file)
+	if err != nil {
+		return "", fmt.Errorf("%s doesn't exist at commit %s (it was deleted there); pick an earlier version", file, target.Short)
+	}
+	path, err := sm.scriptFilePath(file)
+	if err != nil {
+		return "", err
+	}
+	err = sm.withDirLock("roll back "+file, func() error {
+		return sm.writeFileAtomic(path, content)
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", file, err)
+	}
+	invalidateScriptCache(sm.scriptsDir)
+
+	result := fmt.Sprintf("Rolled back %s to %s (%s, %s)", file, target.Short, target.Message, target.Date.Local().Format("2006-01-02 15:04"))
+	if note := sm.commitScript("rollback", file, target.Short); note != "" {
+		result += "\n\n" + note
+	}
+	return result, nil
+}
+
+// FormatScriptHistory formats the commits that changed a script as a list
+func FormatScriptHistory(file string, revisions []ScriptRevision) string {
+	if len(revisions) == 0 {
+		return fmt.Sprintf("No history for %s", file)
+	}
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("History of %s (%d versions, newest first):\n", file, len(revisions)))
+	for _, rev := range revisions {
+		b.WriteString(fmt.Sprintf("  %s  %s  %s (%s)\n", rev.Short, rev.Date.Local().Format("2006-01-02 15:04"), rev.Message, rev.Author))
+	}
+	b.WriteString("\nUse 'rollback_script' with version=<commit> to restore one.")
+	return b.String()
+}
@@ -0,0 +1,180 @@
+package scripts
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// Defaults for HTTPOptions fields left at zero
+const (
+	DefaultWebRemoteTimeout    = 5 * time.Second
+	DefaultIdleConnTimeout     = 90 * time.Second
+	DefaultMaxIdleConnsPerHost = 8
+)
+
+// HTTPOptions configures outbound HTTP. Zero durations and counts use the defaults.
+type HTTPOptions struct {
+	Proxy               string        // Proxy URL; empty uses HTTP_PROXY/HTTPS_PROXY/NO_PROXY
+	CABundle            string        // PEM file of CA certificates trusted in addition to the system roots
+	WebRemoteTimeout    time.Duration // Limit per Web Remote request
+	DownloadTimeout     time.Duration // Limit per download request; 0 leaves it to the operation timeout
+	IdleConnTimeout     time.Duration // How long unused keep-alive connections stay open
+	MaxIdleConnsPerHost int           // Keep-alive connections kept per host
+}
+
+// withDefaults fills zero fields of o with the defaults
+func (o HTTPOptions) withDefaults() HTTPOptions {
+	if o.WebRemoteTimeout <= 0 {
+		o.WebRemoteTimeout = DefaultWebRemoteTimeout
+	}
+	if o.IdleConnTimeout <= 0 {
+		o.IdleConnTimeout = DefaultIdleConnTimeout
+	}
+	if o.MaxIdleConnsPerHost <= 0 {
+		o.MaxIdleConnsPerHost = DefaultMaxIdleConnsPerHost
+	}
+	return o
+}
+
+// Outbound HTTP for the downloader and Web Remote client shares one transport, so proxy
+// and CA settings apply everywhere and keep-alive connections are reused across calls.
+// Clients are pooled per timeout and replaced when the options change.
+var (
+	httpMu        sync.RWMutex
+	httpOptions   = HTTPOptions{}.withDefaults()
+	httpTransport = newTransport(nil, nil, httpOptions)
+	httpClients   = make(map[time.Duration]*http.Client)
+)
+
+// ConfigureHTTP applies opts to all outbound HTTP. Requests to this machine never go
+// through the proxy.
+func ConfigureHTTP(opts HTTPOptions) error {
+	opts = opts.withDefaults()
+	httpMu.RLock()
+	unchanged := opts == httpOptions
+	httpMu.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	var proxy *url.URL
+	if opts.Proxy != "" {
+		parsed, err := url.Parse(opts.Proxy)
+		if err != nil || parsed.Host == "" {
+			return fmt.Errorf("invalid proxy URL %q: expected e.g. http://proxy.example.com:3128", opts.Proxy)
+		}
+		proxy = parsed
+	}
+
+	var roots *x509.CertPool
+	if caBundle := opts.CABundle; caBundle != "" {
+		pem, err := os.ReadFile(caBundle)
+		if err != nil {
+			return fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		roots, err = x509.SystemCertPool()
+		if err != nil || roots == nil {
+			roots = x509.NewCertPool()
+		}
+		if !roots.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no PEM certificates found in CA bundle %s", caBundle)
+		}
+	}
+
+	httpMu.Lock()
+	defer httpMu.Unlock()
+	httpOptions = opts
+	// Let connections of the old transport close instead of lingering until their idle timeout
+	httpTransport.CloseIdleConnections()
+	httpTransport = newTransport(proxy, roots, opts)
+	httpClients = make(map[time.Duration]*http.Client)
+	return nil
+}
+
+// newTransport builds a transport using proxy (or the environment when nil), roots
+// (or the system roots when nil) and the keep-alive settings of opts
+func newTransport(proxy *url.URL, roots *x509.CertPool, opts HTTPOptions) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.IdleConnTimeout = opts.IdleConnTimeout
+	transport.MaxIdleConnsPerHost = opts.MaxIdleConnsPerHost
+	transport.Proxy = func(req *http.Request) (*url.URL, error) {
+		if isLoopbackHost(req.URL.Hostname()) {
+			return nil, nil
+		}
+		if proxy != nil {
+			return proxy, nil
+		}
+		return http.ProxyFromEnvironment(req)
+	}
+	if roots != nil {
+		transport.TLSClientConfig = &tls.Config{RootCAs: roots}
+	}
+	return transport
+}
+
+// isLoopbackHost reports whether host names this machine
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// newHTTPClient returns the pooled client with the given timeout, using the configured
+// transport; a zero timeout means none
+func newHTTPClient(timeout time.Duration) *http.Client {
+	httpMu.RLock()
+	client, ok := httpClients[timeout]
+	httpMu.RUnlock()
+	if ok {
+		return client
+	}
+
+	httpMu.Lock()
+	defer httpMu.Unlock()
+	if client, ok := httpClients[timeout]; ok {
+		return client
+	}
+	client = &http.Client{Timeout: timeout, Transport: httpTransport}
+	httpClients[timeout] = client
+	return client
+}
+
+// HTTPClient returns the pooled client with the given timeout, for outbound requests made
+// outside this package, so they go through the configured proxy and CA bundle too
+func HTTPClient(timeout time.Duration) *http.Client {
+	return newHTTPClient(timeout)
+}
+
+// webRemoteTimeout returns the configured limit per Web Remote request
+func webRemoteTimeout() time.Duration {
+	httpMu.RLock()
+	defer httpMu.RUnlock()
+	return httpOptions.WebRemoteTimeout
+}
+
+// httpGet is http.Get through the configured transport, canceled when ctx is done
+func httpGet(ctx context.Context, url string) (*http.Response, error) {
+	httpMu.RLock()
+	timeout := httpOptions.DownloadTimeout
+	httpMu.RUnlock()
+	return getWithClient(ctx, newHTTPClient(timeout), url)
+}
+
+// getWithClient sends a GET request with client, canceled when ctx is done
+func getWithClient(ctx context.Context, client *http.Client, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return client.Do(req)
+}
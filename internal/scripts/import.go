@@ -0,0 +1,236 @@
+package scripts
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"unicode/utf8"
+)
+
+// Limits on what import_scripts accepts, so a bad archive can't fill the disk
+const (
+	maxImportSize       = 50 << 20 // Archive or single script
+	maxImportScriptSize = 5 << 20  // Each extracted script
+	maxImportScripts    = 500
+)
+
+// ImportResult is the outcome of importing scripts from an archive or URL
+type ImportResult struct {
+	Installed  []string     `json:"installed"`
+	Registered []string     `json:"registered,omitempty"`
+	Skipped    []ImportSkip `json:"skipped,omitempty"`
+	Warnings   []string     `json:"warnings,omitempty"`
+	DryRun     bool         `json:"dry_run,omitempty"`
+}
+
+// ImportSkip is a file left out of an import and why
+type ImportSkip struct {
+	File   string `json:"file"`
+	Reason string `json:"reason"`
+}
+
+// importedScript is a validated script waiting to be installed
+type importedScript struct {
+	name    string
+	content []byte
+}
+
+// ImportScripts installs the scripts in a zip archive, or a single script file, from a
+// local path or URL into sm's scripts directory. URLs must come from a trusted source.
+// Existing scripts are never overwritten. With register, installed .lua scripts are also
+// added to REAPER's action list; with dryRun, nothing is installed.
+func (sd *ScriptDownloader) ImportScripts(ctx context.Context, sm *ScriptManager, source string, register, dryRun bool) (*ImportResult, error) {
+	source = strings.TrimSpace(source)
+	if source == "" {
+		return nil, fmt.Errorf("path is required: a local .zip or script file, or a URL to one")
+	}
+	data, name, err := sd.readImportSource(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ImportResult{Installed: []string{}, DryRun: dryRun}
+	var found []importedScript
+	if isScriptFile(name) {
+		if reason := validateImportedScript(data); reason != "" {
+			return nil, fmt.Errorf("%s can't be imported: %s", name, reason)
+		}
+		found = append(found, importedScript{name: name, content: data})
+	} else {
+		if found, err = extractScripts(data, result); err != nil {
+			return nil, err
+		}
+	}
+	if len(found) == 0 {
+		return nil, fmt.Errorf("no .lua, .eel or .py scripts found in %s", source)
+	}
+
+	for _, script := range found {
+		scriptPath, err := sm.scriptFilePath(script.name)
+		if err != nil {
+			result.Skipped = append(result.Skipped, ImportSkip{File: script.name, Reason: err.Error()})
+			continue
+		}
+		if _, err := sm.backend.FS.Stat(scriptPath); err == nil {
+			result.Skipped = append(result.Skipped, ImportSkip{File: script.name, Reason: "a script with this name is already installed"})
+			continue
+		}
+		if warning := dependencyWarning(string(script.content)); warning != "" {
+			result.Warnings = append(result.Warnings, script.name+": "+warning)
+		}
+		if dryRun {
+			result.Installed = append(result.Installed, script.name)
+			continue
+		}
+
+		ext := path.Ext(script.name)
+		if _, err := sm.AddScript(strings.TrimSuffix(script.name, ext), string(script.content), ext); err != nil {
+			result.Skipped = append(result.Skipped, ImportSkip{File: script.name, Reason: err.Error()})
+			continue
+		}
+		result.Installed = append(result.Installed, script.name)
+		if register && strings.EqualFold(ext, ".lua") {
+			if _, err := sm.RegisterScript(script.name); err != nil {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("%s was installed but not registered: %v", script.name, err))
+			} else {
+				result.Registered = append(result.Registered, script.name)
+			}
+		}
+	}
+	return result, nil
+}
+
+// readImportSource reads a local file or downloads a URL, returning its content and file name
+func (sd *ScriptDownloader) readImportSource(ctx context.Context, source string) ([]byte, string, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		info, err := os.Stat(source)
+		if err != nil {
+			return nil, "", fmt.Errorf("cannot read %s: %w", source, err)
+		}
+		if info.Size() > maxImportSize {
+			return nil, "", fmt.Errorf("%s is too large to import (%s, limit %s)", source, formatFileSize(int(info.Size())), formatFileSize(maxImportSize))
+		}
+		data, err := os.ReadFile(source)
+		if err != nil {
+			return nil, "", fmt.Errorf("cannot read %s: %w", source, err)
+		}
+		return data, path.Base(strings.ReplaceAll(source, "\\", "/")), nil
+	}
+
+	if err := sd.checkTrusted(source); err != nil {
+		return nil, "", err
+	}
+	resp, err := httpGet(ctx, source)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to download %s: %w", source, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("download of %s failed with status: %d", source, resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImportSize+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to download %s: %w", source, err)
+	}
+	if len(data) > maxImportSize {
+		return nil, "", fmt.Errorf("%s is too large to import (limit %s)", source, formatFileSize(maxImportSize))
+	}
+	name := path.Base(strings.SplitN(strings.SplitN(source, "?", 2)[0], "#", 2)[0])
+	return data, name, nil
+}
+
+// extractScripts returns the valid scripts in a zip archive, flattening its folders and
+// recording every script file it leaves out in result
+func extractScripts(data []byte, result *ImportResult) ([]importedScript, error) {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("not a zip archive or script file: %w", err)
+	}
+
+	var scripts []importedScript
+	seen := make(map[string]bool)
+	for _, file := range reader.File {
+		name := path.Base(strings.ReplaceAll(file.Name, "\\", "/"))
+		if file.FileInfo().IsDir() || !isScriptFile(name) || strings.HasPrefix(name, ".") || strings.HasPrefix(file.Name, "__MACOSX/") {
+			continue
+		}
+		if seen[strings.ToLower(name)] {
+			result.Skipped = append(result.Skipped, ImportSkip{File: file.Name, Reason: "another file in the archive has the same name"})
+			continue
+		}
+		if len(scripts) >= maxImportScripts {
+			return nil, fmt.Errorf("the archive holds more than %d scripts", maxImportScripts)
+		}
+		if file.UncompressedSize64 > maxImportScriptSize {
+			result.Skipped = append(result.Skipped, ImportSkip{File: file.Name, Reason: "larger than " + formatFileSize(maxImportScriptSize)})
+			continue
+		}
+
+		rc, err := file.Open()
+		if err != nil {
+			result.Skipped = append(result.Skipped, ImportSkip{File: file.Name, Reason: err.Error()})
+			continue
+		}
+		content, err := io.ReadAll(io.LimitReader(rc, maxImportScriptSize+1))
+		rc.Close()
+		if err != nil {
+			result.Skipped = append(result.Skipped, ImportSkip{File: file.Name, Reason: err.Error()})
+			continue
+		}
+		if reason := validateImportedScript(content); reason != "" {
+			result.Skipped = append(result.Skipped, ImportSkip{File: file.Name, Reason: reason})
+			continue
+		}
+		seen[strings.ToLower(name)] = true
+		scripts = append(scripts, importedScript{name: name, content: content})
+	}
+	return scripts, nil
+}
+
+// validateImportedScript returns why content isn't a usable script, or "" if it is
+func validateImportedScript(content []byte) string {
+	switch {
+	case len(bytes.TrimSpace(content)) == 0:
+		return "the file is empty"
+	case len(content) > maxImportScriptSize:
+		return "larger than " + formatFileSize(maxImportScriptSize)
+	case bytes.IndexByte(content, 0) >= 0 || !utf8.Valid(content):
+		return "not a text file"
+	}
+	return ""
+}
+
+// FormatImportResult formats the outcome of an import as a readable report
+func FormatImportResult(result *ImportResult) string {
+	var b strings.Builder
+	verb := "Imported"
+	if result.DryRun {
+		verb = "Would import"
+	}
+	b.WriteString(fmt.Sprintf("📥 %s %d script(s):\n", verb, len(result.Installed)))
+	for _, name := range result.Installed {
+		b.WriteString(fmt.Sprintf("  ✅ %s\n", name))
+	}
+	if len(result.Registered) > 0 {
+		b.WriteString(fmt.Sprintf("\nRegistered %d script(s) in REAPER's action list; restart REAPER to load them.\n", len(result.Registered)))
+	}
+	if len(result.Skipped) > 0 {
+		b.WriteString(fmt.Sprintf("\nSkipped %d file(s):\n", len(result.Skipped)))
+		for _, skip := range result.Skipped {
+			b.WriteString(fmt.Sprintf("  ⏭️ %s: %s\n", skip.File, skip.Reason))
+		}
+	}
+	for _, warning := range result.Warnings {
+		b.WriteString("\n⚠️ " + warning + "\n")
+	}
+	if result.DryRun && len(result.Installed) > 0 {
+		b.WriteString("\nRun again with dry_run=false to install them.")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
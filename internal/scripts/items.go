@@ -0,0 +1,135 @@
+package scripts
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/bridge"
+)
+
+// ItemEdits maps the edits edit_selected_items supports to the REAPER actions that do them
+var ItemEdits = map[string]int{
+	"split":     40757, // Item: Split items at edit cursor (no change selection)
+	"glue":      42432, // Item: Glue items
+	"normalize": 40108, // Item properties: Normalize items
+}
+
+// MediaItem is a media item in the project
+type MediaItem struct {
+	Track     int     `json:"track"` // Track number (1-based)
+	TrackName string  `json:"track_name"`
+	Position  float64 `json:"position"`        // Start (seconds)
+	Length    float64 `json:"length"`          // Length (seconds)
+	Name      string  `json:"name,omitempty"`  // Active take name, empty for empty items
+	Muted     bool    `json:"muted,omitempty"` // Item mute state
+}
+
+// SelectedItems is the current item selection and the edit cursor it's edited around
+type SelectedItems struct {
+	Count  int         `json:"count"`
+	Tracks []int       `json:"tracks"` // Track numbers holding selected items
+	Cursor float64     `json:"cursor"` // Edit cursor position (seconds)
+	Items  []MediaItem `json:"items"`
+}
+
+// GetSelectedItems reads the selected media items via the Lua bridge
+func GetSelectedItems(ctx context.Context) (*SelectedItems, error) {
+	rows, err := bridge.Run(ctx, "get_selected_items", `out("cursor", reaper.GetCursorPosition())
+for i = 0, reaper.CountSelectedMediaItems(0) - 1 do
+    local item = reaper.GetSelectedMediaItem(0, i)
+    local track = reaper.GetMediaItem_Track(item)
+    local _, track_name = reaper.GetTrackName(track)
+    local take = reaper.GetActiveTake(item)
+    local name = take and reaper.GetTakeName(take) or ""
+    out("item", math.floor(reaper.GetMediaTrackInfo_Value(track, "IP_TRACKNUMBER")), track_name,
+        reaper.GetMediaItemInfo_Value(item, "D_POSITION"), reaper.GetMediaItemInfo_Value(item, "D_LENGTH"),
+        name, math.floor(reaper.GetMediaItemInfo_Value(item, "B_MUTE")))
+end
+`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read selected items: %w", err)
+	}
+
+	selection := &SelectedItems{Items: []MediaItem{}, Tracks: []int{}}
+	seen := make(map[int]bool)
+	for _, row := range rows {
+		switch row[0] {
+		case "cursor":
+			if len(row) > 1 {
+				selection.Cursor, _ = strconv.ParseFloat(row[1], 64)
+			}
+		case "item":
+			if len(row) < 7 {
+				continue
+			}
+			track, _ := strconv.Atoi(row[1])
+			position, _ := strconv.ParseFloat(row[3], 64)
+			length, _ := strconv.ParseFloat(row[4], 64)
+			selection.Items = append(selection.Items, MediaItem{
+				Track:     track,
+				TrackName: row[2],
+				Position:  position,
+				Length:    length,
+				Name:      row[5],
+				Muted:     row[6] == "1",
+			})
+			if !seen[track] {
+				seen[track] = true
+				selection.Tracks = append(selection.Tracks, track)
+			}
+		}
+	}
+	sort.Ints(selection.Tracks)
+	selection.Count = len(selection.Items)
+	return selection, nil
+}
+
+// EditSelectedItems runs one of ItemEdits on the selected media items via the Lua bridge.
+// Each edit is its own undo step, named by REAPER.
+func EditSelectedItems(ctx context.Context, edit string) error {
+	edit = strings.ToLower(strings.TrimSpace(edit))
+	action, ok := ItemEdits[edit]
+	if !ok {
+		names := make([]string, 0, len(ItemEdits))
+		for name := range ItemEdits {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unsupported item edit: %s. Valid edits: %s", edit, strings.Join(names, ", "))
+	}
+
+	_, err := bridge.Run(ctx, "edit_selected_items", fmt.Sprintf(`if reaper.CountSelectedMediaItems(0) == 0 then
+    return fail("no media items are selected")
+end
+reaper.Main_OnCommand(%d, 0)
+reaper.UpdateArrange()
+`, action))
+	if err != nil {
+		return fmt.Errorf("failed to %s selected items: %w", edit, err)
+	}
+	return nil
+}
+
+// FormatSelectedItems formats the selected items as a readable table
+func FormatSelectedItems(selection *SelectedItems) string {
+	if selection.Count == 0 {
+		return fmt.Sprintf("No media items are selected (edit cursor at %.3fs)", selection.Cursor)
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("%d selected item(s), edit cursor at %.3fs:\n\n", selection.Count, selection.Cursor))
+	result.WriteString("Track | Track Name           | Start      | Length     | Take\n")
+	result.WriteString("------|----------------------|------------|------------|--------------------\n")
+	for _, item := range selection.Items {
+		name := item.Name
+		if item.Muted {
+			name += " (muted)"
+		}
+		result.WriteString(fmt.Sprintf("%-5d | %-20s | %9.3fs | %9.3fs | %s\n",
+			item.Track, truncateString(item.TrackName, 20), item.Position, item.Length, name))
+	}
+	return result.String()
+}
@@ -0,0 +1,127 @@
+package scripts
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/project"
+)
+
+// Entry types of reaper-kb.ini lines
+const (
+	kbKey    = "KEY" // KEY <flags> <key> <command> <section>
+	kbScript = "SCR" // SCR <flags> <section> [<id>] "<description>" "<path>"
+	kbAction = "ACT" // ACT <flags> <section> "<id>" "<description>" <command> ...
+)
+
+// kbFile is reaper-kb.ini split into lines. Lines are read without a length limit, since
+// custom actions with many steps can be very long, and are kept as read, so writing the
+// file back only changes the lines that were edited. Lines the parser doesn't know, such
+// as other sections or comments, are kept verbatim. Line endings are left to
+// updateConfigFile, which restores each unchanged line's own.
+type kbFile struct {
+	lines []kbLine
+}
+
+// kbLine is one line of reaper-kb.ini
+type kbLine struct {
+	text string // The line without its line ending
+}
+
+// parseKBIni reads reaper-kb.ini content with "\n" line endings line by line
+func parseKBIni(r io.Reader) (*kbFile, error) {
+	reader := bufio.NewReader(r)
+	f := &kbFile{}
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if line != "" {
+			f.lines = append(f.lines, kbLine{text: strings.TrimSuffix(line, "\n")})
+		}
+		if err == io.EOF {
+			return f, nil
+		}
+	}
+}
+
+// kind returns the entry type of the line (kbKey, kbScript or kbAction), or "" for
+// anything else
+func (l kbLine) kind() string {
+	kind, _, _ := strings.Cut(strings.TrimSpace(l.text), " ")
+	switch kind {
+	case kbKey, kbScript, kbAction:
+		return kind
+	}
+	return ""
+}
+
+// fields splits the line into its values, unquoting quoted ones
+func (l kbLine) fields() []string {
+	return project.Tokenize(strings.TrimSpace(l.text))
+}
+
+// scriptPath returns the path of a SCR entry, the last of its values
+func (l kbLine) scriptPath() string {
+	if l.kind() != kbScript {
+		return ""
+	}
+	fields := l.fields()
+	if len(fields) < 5 {
+		return ""
+	}
+	return fields[len(fields)-1]
+}
+
+// kbScriptPath resolves the path of a SCR entry: REAPER stores scripts inside its
+// Scripts folder relative to it, e.g. "ReaTeam Scripts/Items/script.lua"
+func kbScriptPath(path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	basePath, err := GetReaperResourcePath()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(basePath, "Scripts", path)
+}
+
+// insert adds a line before the i-th line; i == len(lines) appends
+func (f *kbFile) insert(i int, text string) {
+	f.lines = append(f.lines[:i], append([]kbLine{{text: text}}, f.lines[i:]...)...)
+}
+
+// append adds lines at the end of the file
+func (f *kbFile) append(texts ...string) {
+	for _, text := range texts {
+		f.insert(len(f.lines), text)
+	}
+}
+
+// removeIf removes the lines for which drop returns true, returning how many were removed
+func (f *kbFile) removeIf(drop func(kbLine) bool) int {
+	kept := f.lines[:0]
+	for _, line := range f.lines {
+		if !drop(line) {
+			kept = append(kept, line)
+		}
+	}
+	removed := len(f.lines) - len(kept)
+	f.lines = kept
+	return removed
+}
+
+// bytes returns the file content with "\n" line endings, identical to what was parsed
+// apart from edited lines
+func (f *kbFile) bytes() []byte {
+	var b bytes.Buffer
+	for _, line := range f.lines {
+		b.WriteString(line.text)
+		b.WriteByte('\n')
+	}
+	return b.Bytes()
+}
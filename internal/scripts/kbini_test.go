@@ -0,0 +1,63 @@
+package scripts
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// longAction is a custom action line longer than bufio.Scanner's default 64 KB limit
+var longAction = `ACT 0 0 "_long" "Custom: Long"` + strings.Repeat(" 40044", 20000)
+
+// updateKBIni runs edit on reaper-kb.ini content through updateConfigFile and returns the
+// file written
+func updateKBIni(t *testing.T, content string, edit func(*kbFile)) string {
+	t.Helper()
+	return string(updateTestConfig(t, []byte(content), func(data []byte) []byte {
+		kb, err := parseKBIni(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		edit(kb)
+		return kb.bytes()
+	}))
+}
+
+func TestKBIniRoundTripUnchanged(t *testing.T) {
+	for _, content := range []string{
+		"KEY 1 65 40044 0\r\nSCR 4 0 RS1 \"Script: a.lua\" \"a.lua\"\r\n",
+		"KEY 1 65 40044 0\nSCR 4 0 RS1 \"Script: a.lua\" \"a.lua\"\r\n[other]\n; comment",
+		longAction + "\r\nKEY 1 65 40044 0\r\n",
+	} {
+		if got := updateKBIni(t, content, func(*kbFile) {}); got != content {
+			t.Errorf("unchanged reaper-kb.ini = %.80q, want %.80q", got, content)
+		}
+	}
+}
+
+func TestKBIniLongLines(t *testing.T) {
+	content := "KEY 1 65 40044 0\r\n" + longAction + "\r\n"
+	got := updateKBIni(t, content, func(kb *kbFile) {
+		if len(kb.lines) != 2 || kb.lines[1].kind() != kbAction {
+			t.Errorf("parsed %d lines, want the KEY line and the long ACT line", len(kb.lines))
+		}
+		kb.append(`SCR 4 0 RS2 "Script: b.lua" "b.lua"`)
+	})
+	if want := content + "SCR 4 0 RS2 \"Script: b.lua\" \"b.lua\"\r\n"; got != want {
+		t.Errorf("reaper-kb.ini = %.80q..., want the long line kept and the new one added", got)
+	}
+}
+
+func TestSetIniValueLongLines(t *testing.T) {
+	long := "recent=" + strings.Repeat("x", 100*1024)
+	data := []byte("[reaper]\n" + long + "\n[other]\na=1\n")
+
+	updated, err := setIniValue(data, "reaper", "key", "value")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The final line ending is left to updateConfigFile
+	if want := "[reaper]\n" + long + "\nkey=value\n[other]\na=1"; string(updated) != want {
+		t.Errorf("setIniValue = %.80q..., want the long line kept and key=value added", updated)
+	}
+}
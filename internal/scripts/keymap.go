@@ -0,0 +1,209 @@
+package scripts
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// keymapExtension is the file extension REAPER uses for exported key maps
+const keymapExtension = ".ReaperKeyMap"
+
+// keymapEntryTypes are the line types found in reaper-kb.ini and .ReaperKeyMap files
+var keymapEntryTypes = []string{"KEY", "SCR", "ACT"}
+
+// defaultKeymapPath returns REAPER's KeyMaps folder path for a key map named name
+func defaultKeymapPath(name string) (string, error) {
+	basePath, err := GetReaperResourcePath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(basePath, "KeyMaps", name+keymapExtension), nil
+}
+
+// ExportKeymap writes the current key bindings, custom actions and script entries to a
+// .ReaperKeyMap file. An empty path exports to KeyMaps/ori-<timestamp>.ReaperKeyMap
+// in the REAPER resource folder. Returns the path written.
+func ExportKeymap(path string) (string, error) {
+	kbIniPath, err := GetReaperKBIniPath()
+	if err != nil {
+		return "", err
+	}
+
+	content, err := configFS.ReadFile(kbIniPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read reaper-kb.ini: %w", err)
+	}
+
+	if strings.TrimSpace(path) == "" {
+		path, err = defaultKeymapPath("ori-" + time.Now().Format("20060102-150405"))
+		if err != nil {
+			return "", err
+		}
+	} else if !strings.EqualFold(filepath.Ext(path), keymapExtension) {
+		path += keymapExtension
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return "", fmt.Errorf("failed to write key map: %w", err)
+	}
+	return path, nil
+}
+
+// ImportKeymap replaces reaper-kb.ini with the contents of a .ReaperKeyMap file,
+// backing up the current file first. Returns the backup path and the number of entries imported.
+// REAPER rewrites reaper-kb.ini when it exits, so import while REAPER is closed.
+func ImportKeymap(path string) (string, int, error) {
+	if strings.TrimSpace(path) == "" {
+		return "", 0, errors.New("path is required for 'import_keymap' operation")
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to read key map: %w", err)
+	}
+	if content, _, err = decodeConfig(content); err != nil {
+		return "", 0, fmt.Errorf("failed to read key map: %w", err)
+	}
+
+	entries := countKeymapEntries(string(content))
+	if entries == 0 {
+		return "", 0, fmt.Errorf("%s contains no KEY, SCR or ACT entries; is it a REAPER key map?", path)
+	}
+
+	kbIniPath, err := GetReaperKBIniPath()
+	if err != nil {
+		return "", 0, err
+	}
+
+	backupPath := fmt.Sprintf("%s.backup-%s", kbIniPath, time.Now().Format("20060102-150405"))
+	err = updateConfigFile(kbIniPath, "import key map", func([]byte) ([]byte, error) {
+		if err := backupConfigFile(kbIniPath, backupPath); err != nil {
+			return nil, err
+		}
+		return content, nil
+	})
+	if err != nil {
+		return "", 0, err
+	}
+	return backupPath, entries, nil
+}
+
+// countKeymapEntries counts the KEY, SCR and ACT lines in key map content
+func countKeymapEntries(content string) int {
+	count := 0
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		for _, entryType := range keymapEntryTypes {
+			if fields[0] == entryType {
+				count++
+				break
+			}
+		}
+	}
+	return count
+}
+
+// Modifier flags of KEY entries in reaper-kb.ini (the Windows accelerator flags)
+const (
+	keyFlagVirtKey = 1
+	keyFlagShift   = 4
+	keyFlagControl = 8
+	keyFlagAlt     = 16
+)
+
+// namedKeys maps key names to virtual key codes
+var namedKeys = map[string]int{
+	"backspace": 8, "tab": 9, "enter": 13, "return": 13, "escape": 27, "esc": 27, "space": 32,
+	"pageup": 33, "pagedown": 34, "end": 35, "home": 36,
+	"left": 37, "up": 38, "right": 39, "down": 40, "insert": 45, "delete": 46, "del": 46,
+}
+
+// ParseShortcut converts a shortcut like "Ctrl+Shift+P" or "Alt+F5" to the modifier flags
+// and key code of a reaper-kb.ini KEY entry. Cmd is treated as Ctrl, as REAPER does on macOS.
+func ParseShortcut(shortcut string) (flags, code int, err error) {
+	parts := strings.Split(shortcut, "+")
+	flags = keyFlagVirtKey
+	for _, mod := range parts[:len(parts)-1] {
+		switch strings.ToLower(strings.TrimSpace(mod)) {
+		case "ctrl", "control", "cmd", "command":
+			flags |= keyFlagControl
+		case "shift":
+			flags |= keyFlagShift
+		case "alt", "opt", "option":
+			flags |= keyFlagAlt
+		default:
+			return 0, 0, fmt.Errorf("unknown modifier %q in shortcut %q", mod, shortcut)
+		}
+	}
+
+	key := strings.TrimSpace(parts[len(parts)-1])
+	lower := strings.ToLower(key)
+	switch {
+	case len(key) == 1 && (key[0] >= 'a' && key[0] <= 'z' || key[0] >= 'A' && key[0] <= 'Z' || key[0] >= '0' && key[0] <= '9'):
+		code = int(strings.ToUpper(key)[0])
+	case len(lower) >= 2 && lower[0] == 'f':
+		n, convErr := strconv.Atoi(lower[1:])
+		if convErr != nil || n < 1 || n > 24 {
+			return 0, 0, fmt.Errorf("unknown key %q in shortcut %q", key, shortcut)
+		}
+		code = 111 + n // VK_F1 is 112
+	default:
+		var ok bool
+		if code, ok = namedKeys[lower]; !ok {
+			return 0, 0, fmt.Errorf("unknown key %q in shortcut %q", key, shortcut)
+		}
+	}
+	return flags, code, nil
+}
+
+// keyNames are display names of the key codes in namedKeys
+var keyNames = map[int]string{
+	8: "Backspace", 9: "Tab", 13: "Enter", 27: "Esc", 32: "Space",
+	33: "PageUp", 34: "PageDown", 35: "End", 36: "Home",
+	37: "Left", 38: "Up", 39: "Right", 40: "Down", 45: "Insert", 46: "Delete",
+}
+
+// FormatShortcut converts the modifier flags and key code of a reaper-kb.ini KEY entry to
+// a shortcut like "Ctrl+Shift+P", the reverse of ParseShortcut. Mouse wheel and other
+// special bindings, which REAPER marks with flags 255, return "".
+func FormatShortcut(flags, code int) string {
+	if flags == 255 || code <= 0 {
+		return ""
+	}
+	var key string
+	switch {
+	case flags&keyFlagVirtKey == 0:
+		key = strings.ToUpper(string(rune(code))) // A character rather than a virtual key
+	case code >= 'A' && code <= 'Z' || code >= '0' && code <= '9':
+		key = string(rune(code))
+	case code >= 112 && code <= 135:
+		key = fmt.Sprintf("F%d", code-111)
+	case keyNames[code] != "":
+		key = keyNames[code]
+	default:
+		key = fmt.Sprintf("Key%d", code)
+	}
+
+	var parts []string
+	if flags&keyFlagControl != 0 {
+		parts = append(parts, "Ctrl")
+	}
+	if flags&keyFlagAlt != 0 {
+		parts = append(parts, "Alt")
+	}
+	if flags&keyFlagShift != 0 {
+		parts = append(parts, "Shift")
+	}
+	return strings.Join(append(parts, key), "+")
+}
@@ -0,0 +1,96 @@
+package scripts
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/platform"
+)
+
+// LaunchPolicy limits how often RunScript launches scripts in REAPER, so a looping caller
+// can't start REAPER or a script dozens of times per second
+type LaunchPolicy struct {
+	MinInterval time.Duration // Minimum time between two launches; 0 disables rate limiting
+	Debounce    time.Duration // Repeat runs of the same script within this window are skipped; 0 disables
+	Queue       bool          // Wait for the next free slot instead of rejecting launches that come too fast
+	MaxQueued   int           // Launches allowed to wait at once when queueing; further ones are rejected
+}
+
+// DefaultLaunchPolicy allows a couple of launches per second and rejects the rest
+var DefaultLaunchPolicy = LaunchPolicy{
+	MinInterval: 500 * time.Millisecond,
+	Debounce:    time.Second,
+	MaxQueued:   5,
+}
+
+// Managers are created per call, so launch history is shared by all of them
+var (
+	launchMu     sync.Mutex
+	nextLaunch   time.Time                    // Earliest time the next launch may start
+	launchQueued int                          // Launches currently waiting for their slot
+	lastLaunchBy = make(map[string]time.Time) // Script -> time of its last launch
+)
+
+// errDebounced reports a repeat run skipped by the debounce window
+type errDebounced struct {
+	script string
+	since  time.Duration
+}
+
+func (e *errDebounced) Error() string {
+	return fmt.Sprintf("REAPER script %s was already launched %s ago; skipped the repeat run", e.script, e.since.Round(time.Millisecond))
+}
+
+// SetLaunchPolicy changes how script launches are rate limited and debounced
+func (sm *ScriptManager) SetLaunchPolicy(policy LaunchPolicy) {
+	sm.launchPolicy = policy
+}
+
+// waitForLaunch reserves a launch slot for script under policy. It returns an
+// *errDebounced for a repeat run inside the debounce window, an error when the launch
+// comes too fast and queueing is off or full, and ctx's error if it ends while waiting.
+func waitForLaunch(ctx context.Context, policy LaunchPolicy, script string) error {
+	launchMu.Lock()
+	now := time.Now()
+	if last, ok := lastLaunchBy[script]; ok && policy.Debounce > 0 && now.Sub(last) < policy.Debounce {
+		launchMu.Unlock()
+		return &errDebounced{script: script, since: now.Sub(last)}
+	}
+
+	slot := now
+	if nextLaunch.After(now) {
+		if !policy.Queue {
+			launchMu.Unlock()
+			return fmt.Errorf("script launches are limited to one every %s; try again in %s",
+				policy.MinInterval, nextLaunch.Sub(now).Round(time.Millisecond))
+		}
+		if launchQueued >= policy.MaxQueued {
+			launchMu.Unlock()
+			return fmt.Errorf("too many script launches waiting (%d); try again later", launchQueued)
+		}
+		slot = nextLaunch
+	}
+	nextLaunch = slot.Add(policy.MinInterval)
+	lastLaunchBy[script] = slot
+	for name, last := range lastLaunchBy {
+		if now.Sub(last) > policy.Debounce && name != script {
+			delete(lastLaunchBy, name)
+		}
+	}
+	wait := slot.Sub(now)
+	if wait > 0 {
+		launchQueued++
+	}
+	launchMu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+	err := platform.Sleep(ctx, wait)
+	launchMu.Lock()
+	launchQueued--
+	launchMu.Unlock()
+	return err
+}
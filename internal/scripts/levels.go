@@ -0,0 +1,113 @@
+package scripts
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ClipThresholdDB is the peak level at or above which a track counts as clipping
+const ClipThresholdDB = 0.0
+
+// MinLevelInterval is the shortest polling interval PollLevels accepts, so meters can't
+// flood REAPER with requests
+const MinLevelInterval = 50 * time.Millisecond
+
+// TrackLevel is the meter reading of one track
+type TrackLevel struct {
+	Index    int     `json:"index"`   // Track index (1-based, 0 is the master)
+	Name     string  `json:"name"`    // Track name
+	PeakDB   float64 `json:"peak_db"` // Last meter peak (dB), -150 for silence
+	Clipping bool    `json:"clipping,omitempty"`
+}
+
+// LevelSnapshot is the meter readings of all tracks at one moment
+type LevelSnapshot struct {
+	Time     time.Time    `json:"time"`
+	Tracks   []TrackLevel `json:"tracks"`
+	Clipping bool         `json:"clipping"`        // Whether any track is clipping
+	Error    string       `json:"error,omitempty"` // Set by PollLevels when a sample failed
+}
+
+// GetLevels samples the peak levels of all tracks
+func (wrc *WebRemoteClient) GetLevels(ctx context.Context) (*LevelSnapshot, error) {
+	tracks, err := wrc.GetTracks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &LevelSnapshot{Time: time.Now(), Tracks: make([]TrackLevel, len(tracks))}
+	for i, track := range tracks {
+		level := TrackLevel{Index: track.Index, Name: track.Name, PeakDB: track.PeakDB}
+		level.Clipping = level.PeakDB >= ClipThresholdDB
+		snapshot.Clipping = snapshot.Clipping || level.Clipping
+		snapshot.Tracks[i] = level
+	}
+	return snapshot, nil
+}
+
+// PollLevels samples the peak levels every interval until ctx is done, then closes the
+// returned channel. Failed samples are sent with Error set and polling continues. A slow
+// receiver gets the latest snapshot rather than a backlog.
+func (wrc *WebRemoteClient) PollLevels(ctx context.Context, interval time.Duration) <-chan LevelSnapshot {
+	interval = max(interval, MinLevelInterval)
+	levels := make(chan LevelSnapshot, 1)
+	go func() {
+		defer close(levels)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			snapshot, err := wrc.GetLevels(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				snapshot = &LevelSnapshot{Time: time.Now(), Error: err.Error()}
+			}
+			// Replace an unread snapshot instead of blocking
+			select {
+			case <-levels:
+			default:
+			}
+			levels <- *snapshot
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return levels
+}
+
+// FormatLevelsTable formats a level snapshot as a readable table, clipping tracks marked
+func FormatLevelsTable(snapshot *LevelSnapshot) string {
+	if len(snapshot.Tracks) == 0 {
+		return "No tracks found in REAPER project"
+	}
+
+	var result strings.Builder
+	if snapshot.Clipping {
+		result.WriteString("⚠️ Clipping detected\n\n")
+	} else {
+		result.WriteString("No track is clipping\n\n")
+	}
+	result.WriteString("Index | Name                    | Peak     | Clip\n")
+	result.WriteString("------|-------------------------|----------|-----\n")
+	for _, level := range snapshot.Tracks {
+		peak := "-inf dB"
+		if level.PeakDB > -150 {
+			peak = fmt.Sprintf("%.1f dB", level.PeakDB)
+		}
+		clip := ""
+		if level.Clipping {
+			clip = "CLIP"
+		}
+		result.WriteString(fmt.Sprintf("%-5d | %-23s | %8s | %s\n",
+			level.Index, truncateString(level.Name, 23), peak, clip))
+	}
+	result.WriteString("\nPeaks are REAPER's current meter readings; play the project to see levels.")
+	return result.String()
+}
@@ -0,0 +1,173 @@
+package scripts
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/bridge"
+)
+
+// soloInFrontAction is REAPER's "Options: Solo in front" toggle
+const soloInFrontAction = 40745
+
+// maxMasterVolumeDB is the highest level REAPER's master fader goes to
+const maxMasterVolumeDB = 12.0
+
+// SoloModes lists the global solo modes: in_place silences the unsoloed tracks, in_front
+// only dims them
+var SoloModes = []string{"in_place", "in_front"}
+
+// MasterState is the master fader, master mute and global solo mode
+type MasterState struct {
+	Volume   float64 `json:"volume"`    // Master volume (dB), -150 for -inf
+	Pan      float64 `json:"pan"`       // Master pan (-1.0 to 1.0)
+	Mute     bool    `json:"mute"`      // Master mute state
+	SoloMode string  `json:"solo_mode"` // One of SoloModes
+	Soloed   int     `json:"soloed"`    // Number of soloed tracks
+}
+
+// MasterChange describes the master settings to change; nil fields are left alone
+type MasterChange struct {
+	Volume *float64 // dB
+	Pan    *float64 // -1.0 to 1.0
+	Mute   *bool
+}
+
+// ParseSoloMode normalizes a solo mode name to one of SoloModes
+func ParseSoloMode(mode string) (string, error) {
+	switch strings.NewReplacer(" ", "_", "-", "_").Replace(strings.ToLower(strings.TrimSpace(mode))) {
+	case "in_place", "place", "normal", "mute":
+		return "in_place", nil
+	case "in_front", "front", "dim":
+		return "in_front", nil
+	}
+	return "", fmt.Errorf("unsupported solo mode: %s. Valid modes: %s", mode, strings.Join(SoloModes, ", "))
+}
+
+// GetMasterState reads the master track and global solo mode via the Lua bridge
+func GetMasterState(ctx context.Context) (*MasterState, error) {
+	rows, err := bridge.Run(ctx, "get_master", fmt.Sprintf(`local master = reaper.GetMasterTrack(0)
+local soloed = 0
+for i = 0, reaper.CountTracks(0) - 1 do
+    if reaper.GetMediaTrackInfo_Value(reaper.GetTrack(0, i), "I_SOLO") > 0 then soloed = soloed + 1 end
+end
+out(reaper.GetMediaTrackInfo_Value(master, "D_VOL"), reaper.GetMediaTrackInfo_Value(master, "D_PAN"),
+    math.floor(reaper.GetMediaTrackInfo_Value(master, "B_MUTE")), reaper.GetToggleCommandState(%d), soloed)
+`, soloInFrontAction))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read master track: %w", err)
+	}
+	if len(rows) < 1 || len(rows[0]) < 5 {
+		return nil, fmt.Errorf("unexpected output format: no master track data")
+	}
+
+	row := rows[0]
+	volume, _ := strconv.ParseFloat(row[0], 64)
+	pan, _ := strconv.ParseFloat(row[1], 64)
+	soloed, _ := strconv.Atoi(row[4])
+	state := &MasterState{
+		Volume:   -150.0, // -inf dB for 0 volume
+		Pan:      pan,
+		Mute:     row[2] == "1",
+		SoloMode: "in_place",
+		Soloed:   soloed,
+	}
+	if volume > 0 {
+		state.Volume = 20 * math.Log10(volume)
+	}
+	if row[3] == "1" {
+		state.SoloMode = "in_front"
+	}
+	return state, nil
+}
+
+// SetMasterState changes the master volume, pan and mute via the Lua bridge, as one undo step
+func SetMasterState(ctx context.Context, change MasterChange) error {
+	var body strings.Builder
+	if change.Volume != nil {
+		if *change.Volume > maxMasterVolumeDB {
+			return fmt.Errorf("master volume can't exceed +%.0f dB, got %.1f dB", maxMasterVolumeDB, *change.Volume)
+		}
+		volume := 0.0 // -inf
+		if *change.Volume > -150 {
+			volume = math.Pow(10, *change.Volume/20)
+		}
+		fmt.Fprintf(&body, "reaper.SetMediaTrackInfo_Value(master, \"D_VOL\", %g)\n", volume)
+	}
+	if change.Pan != nil {
+		if *change.Pan < -1 || *change.Pan > 1 {
+			return fmt.Errorf("master pan must be between -1.0 (left) and 1.0 (right), got %g", *change.Pan)
+		}
+		fmt.Fprintf(&body, "reaper.SetMediaTrackInfo_Value(master, \"D_PAN\", %g)\n", *change.Pan)
+	}
+	if change.Mute != nil {
+		mute := 0
+		if *change.Mute {
+			mute = 1
+		}
+		fmt.Fprintf(&body, "reaper.SetMediaTrackInfo_Value(master, \"B_MUTE\", %d)\n", mute)
+	}
+	if body.Len() == 0 {
+		return fmt.Errorf("nothing to change: set volume, pan or mute")
+	}
+
+	_, err := bridge.Run(ctx, "set_master", fmt.Sprintf(`local master = reaper.GetMasterTrack(0)
+reaper.Undo_BeginBlock()
+%sreaper.Undo_EndBlock("Ori: Set master track", -1)
+`, body.String()))
+	if err != nil {
+		return fmt.Errorf("failed to set master track: %w", err)
+	}
+	return nil
+}
+
+// SetSoloMode switches REAPER's global solo mode via the Lua bridge
+func SetSoloMode(ctx context.Context, mode string) error {
+	mode, err := ParseSoloMode(mode)
+	if err != nil {
+		return err
+	}
+	want := 0
+	if mode == "in_front" {
+		want = 1
+	}
+
+	_, err = bridge.Run(ctx, "set_solo_mode", fmt.Sprintf(`if reaper.GetToggleCommandState(%d) ~= %d then
+    reaper.Main_OnCommand(%d, 0)
+end
+`, soloInFrontAction, want, soloInFrontAction))
+	if err != nil {
+		return fmt.Errorf("failed to set solo mode: %w", err)
+	}
+	return nil
+}
+
+// FormatMasterState formats the master state as readable text
+func FormatMasterState(state *MasterState) string {
+	panStr := "Center"
+	if state.Pan < -0.01 {
+		panStr = fmt.Sprintf("L%.0f%%", -state.Pan*100)
+	} else if state.Pan > 0.01 {
+		panStr = fmt.Sprintf("R%.0f%%", state.Pan*100)
+	}
+	mute := "off"
+	if state.Mute {
+		mute = "on"
+	}
+	soloMode := "solo in place (unsoloed tracks are silenced)"
+	if state.SoloMode == "in_front" {
+		soloMode = "solo in front (unsoloed tracks are dimmed)"
+	}
+
+	var result strings.Builder
+	result.WriteString("Master track:\n")
+	result.WriteString(fmt.Sprintf("  Volume: %.1fdB\n", state.Volume))
+	result.WriteString(fmt.Sprintf("  Pan: %s\n", panStr))
+	result.WriteString(fmt.Sprintf("  Mute: %s\n", mute))
+	result.WriteString(fmt.Sprintf("  Solo mode: %s\n", soloMode))
+	result.WriteString(fmt.Sprintf("  Soloed tracks: %d\n", state.Soloed))
+	return result.String()
+}
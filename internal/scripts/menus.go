@@ -0,0 +1,394 @@
+package scripts
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultMenu is the reaper-menu.ini section menu items are added to when none is named
+const DefaultMenu = "Main actions"
+
+// Special item values in reaper-menu.ini menus
+const (
+	menuSeparator    = "-1"
+	menuSubmenuStart = "-2" // Followed by the submenu's label
+	menuSubmenuEnd   = "-3"
+)
+
+// menuBackupSuffix names the copy of reaper-menu.ini kept from before the last change
+const menuBackupSuffix = ".ori-backup"
+
+// MenuEntry is one entry of a customized menu, flattened with its submenu depth
+type MenuEntry struct {
+	Depth     int    `json:"depth"` // 0 for top-level entries
+	Label     string `json:"label,omitempty"`
+	Command   string `json:"command,omitempty"`
+	Submenu   bool   `json:"submenu,omitempty"`   // Label starts a submenu holding the following deeper entries
+	Separator bool   `json:"separator,omitempty"` // A divider line
+}
+
+// MenuItemRequest describes a menu item to add or remove
+type MenuItemRequest struct {
+	Menu    string // reaper-menu.ini section; defaults to DefaultMenu
+	Submenu string // Top-level submenu of Menu to put the item in, created when missing; empty for Menu itself
+	Script  string // Script to run; it's registered in reaper-kb.ini if needed
+	Command string // Command ID to run, used when Script is empty
+	Label   string // Item text; defaults to the script name or the command
+}
+
+// readMenuIni returns the lines of reaper-menu.ini; a missing file has no lines
+func readMenuIni() ([]string, error) {
+	menuPath, err := getReaperMenuIniPath()
+	if err != nil {
+		return nil, err
+	}
+	content, err := readConfigFile(menuPath)
+	if err != nil {
+		return nil, err
+	}
+	if content, _, err = decodeConfig(content); err != nil {
+		return nil, fmt.Errorf("failed to read reaper-menu.ini: %w", err)
+	}
+	return menuLines(content), nil
+}
+
+// menuLines splits reaper-menu.ini text into lines
+func menuLines(content []byte) []string {
+	text := strings.TrimRight(string(content), "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// updateMenuIni changes reaper-menu.ini through updateConfigFile: update gets its lines
+// and returns the new ones. The current file is copied to reaper-menu.ini.ori-backup
+// first. The new content is renamed into place, so REAPER never reads a half-written file.
+func updateMenuIni(operation string, update func(lines []string) ([]string, error)) error {
+	menuPath, err := getReaperMenuIniPath()
+	if err != nil {
+		return err
+	}
+	return updateConfigFile(menuPath, operation, func(content []byte) ([]byte, error) {
+		lines, err := update(menuLines(content))
+		if err != nil {
+			return nil, err
+		}
+		if err := backupConfigFile(menuPath, menuPath+menuBackupSuffix); err != nil {
+			return nil, err
+		}
+		return []byte(strings.Join(lines, "\n") + "\n"), nil
+	})
+}
+
+// isToolbarSection reports whether a reaper-menu.ini section is a toolbar rather than a menu
+func isToolbarSection(name string) bool {
+	return strings.Contains(strings.ToLower(name), "toolbar")
+}
+
+// ListMenus returns the customized menus in reaper-menu.ini, sorted. Menus REAPER still
+// shows with their defaults aren't in the file.
+func ListMenus() ([]string, error) {
+	lines, err := readMenuIni()
+	if err != nil {
+		return nil, err
+	}
+	var menus []string
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			if name := trimmed[1 : len(trimmed)-1]; !isToolbarSection(name) {
+				menus = append(menus, name)
+			}
+		}
+	}
+	sort.Strings(menus)
+	return menus, nil
+}
+
+// customizedMenu returns the section of a customized menu, case-insensitively. Menus that
+// were never customized can't be edited: writing the section would replace all of
+// REAPER's default entries with just the new ones.
+func customizedMenu(lines []string, menu string) (string, int, int, error) {
+	if menu = strings.TrimSpace(menu); menu == "" {
+		menu = DefaultMenu
+	}
+	if isToolbarSection(menu) {
+		return "", -1, -1, fmt.Errorf("%q is a toolbar; menus are sections such as %q", menu, DefaultMenu)
+	}
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") && strings.EqualFold(trimmed[1:len(trimmed)-1], menu) {
+			name := trimmed[1 : len(trimmed)-1]
+			start, end := menuSection(lines, name)
+			return name, start, end, nil
+		}
+	}
+	return "", -1, -1, fmt.Errorf("menu %q has not been customized yet. In REAPER, open Options > Customize menus/toolbars, choose it, click Save once, then try again", menu)
+}
+
+// parseMenuEntry converts an item value to a MenuEntry at depth
+func parseMenuEntry(value string, depth int) MenuEntry {
+	command, label, _ := strings.Cut(strings.TrimSpace(value), " ")
+	switch command {
+	case menuSeparator:
+		return MenuEntry{Depth: depth, Separator: true}
+	case menuSubmenuStart:
+		return MenuEntry{Depth: depth, Label: label, Submenu: true}
+	}
+	return MenuEntry{Depth: depth, Command: command, Label: label}
+}
+
+// GetMenu returns the entries of a customized menu in order
+func GetMenu(menu string) (string, []MenuEntry, error) {
+	lines, err := readMenuIni()
+	if err != nil {
+		return "", nil, err
+	}
+	name, start, end, err := customizedMenu(lines, menu)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var entries []MenuEntry
+	depth := 0
+	for _, item := range toolbarItems(lines[start+1 : end]) {
+		if item.value == "" {
+			continue
+		}
+		if strings.TrimSpace(item.value) == menuSubmenuEnd {
+			depth = max(depth-1, 0)
+			continue
+		}
+		entry := parseMenuEntry(item.value, depth)
+		entries = append(entries, entry)
+		if entry.Submenu {
+			depth++
+		}
+	}
+	return name, entries, nil
+}
+
+// submenuSpan returns the indexes of the start and end items of a top-level submenu
+// labeled label (case-insensitive), or -1 if there's none
+func submenuSpan(items []toolbarItem, label string) (int, int) {
+	depth := 0
+	start := -1
+	for i, item := range items {
+		value := strings.TrimSpace(item.value)
+		switch {
+		case value == menuSubmenuEnd:
+			depth--
+			if depth == 0 && start >= 0 {
+				return start, i
+			}
+		case strings.HasPrefix(value, menuSubmenuStart+" "):
+			if depth == 0 && strings.EqualFold(strings.TrimSpace(strings.TrimPrefix(value, menuSubmenuStart)), label) {
+				start = i
+			}
+			depth++
+		}
+	}
+	return -1, -1
+}
+
+// AddMenuItem adds a script or action to a customized menu, optionally inside a top-level
+// submenu that is created when missing. REAPER must be restarted to show the change.
+func (sm *ScriptManager) AddMenuItem(req MenuItemRequest) (string, error) {
+	req.Label = strings.TrimSpace(req.Label)
+	req.Submenu = strings.TrimSpace(req.Submenu)
+	if strings.ContainsAny(req.Label+req.Submenu, "\n\r") {
+		return "", errors.New("menu labels can't contain line breaks")
+	}
+
+	command := strings.TrimSpace(req.Command)
+	if strings.TrimSpace(req.Script) != "" {
+		file, err := sm.findScriptFile(req.Script)
+		if err != nil {
+			return "", err
+		}
+		scriptPath, err := sm.scriptFilePath(file)
+		if err != nil {
+			return "", err
+		}
+		kbPath, err := GetReaperKBIniPath()
+		if err != nil {
+			return "", err
+		}
+		// Register the script first, so the menu never points to an unknown command
+		err = updateConfigFile(kbPath, "register "+file, func(content []byte) ([]byte, error) {
+			kbLines := strings.Split(strings.TrimRight(string(content), "\n"), "\n")
+			before := strings.Join(kbLines, "\n")
+			var kbEntry string
+			var err error
+			if command, kbEntry, err = scriptCommand(kbLines, scriptPath); err != nil {
+				return nil, err
+			}
+			if kbEntry == "" && strings.Join(kbLines, "\n") == before {
+				return nil, errNoChange
+			}
+			if kbEntry != "" {
+				kbLines = append(kbLines, kbEntry)
+			}
+			return []byte(strings.Join(kbLines, "\n") + "\n"), nil
+		})
+		if err != nil {
+			return "", err
+		}
+		if req.Label == "" {
+			req.Label = ToTitleCase(strings.ReplaceAll(strings.TrimSuffix(file, filepath.Ext(file)), "_", " "))
+		}
+	} else if !isCommandID(command) {
+		return "", errors.New("script or command is required: a script name, or a command ID such as 40001 or _SWS_SAVESEL")
+	}
+	if req.Label == "" {
+		req.Label = command
+	}
+
+	var menu string
+	err := updateMenuIni("add menu item "+req.Label, func(lines []string) ([]string, error) {
+		var start, end int
+		var err error
+		menu, start, end, err = customizedMenu(lines, req.Menu)
+		if err != nil {
+			return nil, err
+		}
+		body := lines[start+1 : end]
+		items := toolbarItems(body)
+		newItem := toolbarItem{value: command + " " + req.Label}
+
+		insertAt := len(items)
+		if req.Submenu != "" {
+			subStart, subEnd := submenuSpan(items, req.Submenu)
+			if subStart < 0 {
+				items = append(items, toolbarItem{value: menuSubmenuStart + " " + req.Submenu}, toolbarItem{value: menuSubmenuEnd})
+				subStart, subEnd = len(items)-2, len(items)-1
+			}
+			for _, item := range items[subStart+1 : subEnd] {
+				if existing, _, _ := strings.Cut(strings.TrimSpace(item.value), " "); existing == command {
+					return nil, fmt.Errorf("%s is already in %s > %s", command, menu, req.Submenu)
+				}
+			}
+			insertAt = subEnd
+		} else {
+			for _, item := range items {
+				if existing, _, _ := strings.Cut(strings.TrimSpace(item.value), " "); existing == command {
+					return nil, fmt.Errorf("%s is already in %s", command, menu)
+				}
+			}
+		}
+		items = append(items[:insertAt], append([]toolbarItem{newItem}, items[insertAt:]...)...)
+		return replaceMenuSection(lines, start, end, rewriteToolbarSection(body, items)), nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	where := menu
+	if req.Submenu != "" {
+		where += " > " + req.Submenu
+	}
+	return fmt.Sprintf("Added '%s' (%s) to %s. Restart REAPER to see it.", req.Label, command, where), nil
+}
+
+// RemoveMenuItem removes the first item of a customized menu (or of one of its top-level
+// submenus) whose label or command is item. A submenu left empty is removed too.
+func RemoveMenuItem(menu, submenu, item string) (string, error) {
+	item, submenu = strings.TrimSpace(item), strings.TrimSpace(submenu)
+	if item == "" {
+		return "", errors.New("label or command of the menu item to remove is required")
+	}
+	var result string
+	err := updateMenuIni("remove menu item "+item, func(lines []string) ([]string, error) {
+		menu, start, end, err := customizedMenu(lines, menu)
+		if err != nil {
+			return nil, err
+		}
+		body := lines[start+1 : end]
+		items := toolbarItems(body)
+
+		from, to := 0, len(items)
+		if submenu != "" {
+			subStart, subEnd := submenuSpan(items, submenu)
+			if subStart < 0 {
+				return nil, fmt.Errorf("menu %s has no submenu %q", menu, submenu)
+			}
+			from, to = subStart+1, subEnd
+		}
+
+		removed := -1
+		var label string
+		for i := from; i < to; i++ {
+			entry := parseMenuEntry(items[i].value, 0)
+			if entry.Submenu || entry.Separator || entry.Command == menuSubmenuEnd {
+				continue
+			}
+			if entry.Command == item || strings.EqualFold(entry.Label, item) {
+				removed, label = i, entry.Label
+				break
+			}
+		}
+		if removed < 0 {
+			where := menu
+			if submenu != "" {
+				where += " > " + submenu
+			}
+			return nil, fmt.Errorf("no item %q in %s", item, where)
+		}
+		items = append(items[:removed], items[removed+1:]...)
+
+		result = fmt.Sprintf("Removed '%s' from %s", label, menu)
+		if submenu != "" {
+			result += " > " + submenu
+			if subStart, subEnd := submenuSpan(items, submenu); subStart >= 0 && subEnd == subStart+1 {
+				items = append(items[:subStart], items[subEnd+1:]...)
+				result += fmt.Sprintf(" (the empty submenu %s was removed)", submenu)
+			}
+		}
+		return replaceMenuSection(lines, start, end, rewriteToolbarSection(body, items)), nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return result + ". Restart REAPER to see the change.", nil
+}
+
+// FormatMenu formats the entries of a menu as an indented list
+func FormatMenu(menu string, entries []MenuEntry) string {
+	if len(entries) == 0 {
+		return fmt.Sprintf("Menu %s is empty", menu)
+	}
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Menu %s:\n\n", menu))
+	for _, entry := range entries {
+		indent := strings.Repeat("    ", entry.Depth+1)
+		switch {
+		case entry.Separator:
+			b.WriteString(indent + "──────\n")
+		case entry.Submenu:
+			b.WriteString(indent + "▸ " + entry.Label + "\n")
+		case entry.Label == "":
+			b.WriteString(indent + entry.Command + "\n")
+		default:
+			b.WriteString(fmt.Sprintf("%s%s  [%s]\n", indent, entry.Label, entry.Command))
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// FormatMenus formats the list of customized menus
+func FormatMenus(menus []string) string {
+	if len(menus) == 0 {
+		return "No menus have been customized. In REAPER, open Options > Customize menus/toolbars, choose a menu and click Save once to make it editable."
+	}
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%d customized menu(s) in reaper-menu.ini:\n\n", len(menus)))
+	for _, menu := range menus {
+		b.WriteString("  - " + menu + "\n")
+	}
+	b.WriteString("\nUse 'list_menus' with menu=<name> to see its items.")
+	return b.String()
+}
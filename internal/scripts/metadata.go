@@ -0,0 +1,266 @@
+package scripts
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/errcode"
+)
+
+// metadataFileName is the sidecar file in the scripts directory holding favorites, tags,
+// ratings and versions
+const metadataFileName = ".ori_scripts.json"
+
+// metadataMu serializes read-modify-write cycles of the sidecar, since bulk downloads
+// record installs from several goroutines
+var metadataMu sync.Mutex
+
+// ScriptMetadata is the user-managed information about scripts kept in the sidecar file
+type ScriptMetadata struct {
+	Favorites []string                   `json:"favorites,omitempty"` // Script names marked as favorites
+	Tags      map[string][]string        `json:"tags,omitempty"`      // Script name -> tags
+	Ratings   map[string]ScriptRating    `json:"ratings,omitempty"`   // Script name -> the user's rating and notes
+	Installed map[string]InstalledScript `json:"installed,omitempty"` // Script filename -> marketplace version installed
+	Pins      map[string]string          `json:"pins,omitempty"`      // Script filename -> pinned version SHA
+	Bundles   map[string]InstalledBundle `json:"bundles,omitempty"`   // Bundle name -> what installing it added
+}
+
+// MaxRating is the highest star rating a script can be given
+const MaxRating = 5
+
+// ScriptRating is the user's own star rating and notes for a script
+type ScriptRating struct {
+	Stars   int       `json:"stars,omitempty"` // 1 to MaxRating, 0 when only notes are kept
+	Notes   string    `json:"notes,omitempty"`
+	Updated time.Time `json:"updated"`
+}
+
+// IsFavorite reports whether script is marked as a favorite
+func (m *ScriptMetadata) IsFavorite(script string) bool {
+	for _, name := range m.Favorites {
+		if name == script {
+			return true
+		}
+	}
+	return false
+}
+
+// HasTag reports whether script is tagged with tag (case-insensitive)
+func (m *ScriptMetadata) HasTag(script, tag string) bool {
+	for _, t := range m.Tags[script] {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// metadataPath returns the sidecar file path
+func (sm *ScriptManager) metadataPath() string {
+	return filepath.Join(sm.scriptsDir, metadataFileName)
+}
+
+// LoadMetadata reads the favorites and tags sidecar, returning empty metadata if it doesn't exist
+func (sm *ScriptManager) LoadMetadata() (*ScriptMetadata, error) {
+	metadata := &ScriptMetadata{}
+
+	data, err := sm.backend.FS.ReadFile(sm.metadataPath())
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, metadata); err != nil {
+			return nil, errcode.Errorf(errcode.ConfigParseError, "failed to parse %s: %w", metadataFileName, err)
+		}
+	case !os.IsNotExist(err):
+		return nil, fmt.Errorf("failed to read %s: %w", metadataFileName, err)
+	}
+	if metadata.Tags == nil {
+		metadata.Tags = make(map[string][]string)
+	}
+	if metadata.Ratings == nil {
+		metadata.Ratings = make(map[string]ScriptRating)
+	}
+	if metadata.Installed == nil {
+		metadata.Installed = make(map[string]InstalledScript)
+	}
+	if metadata.Pins == nil {
+		metadata.Pins = make(map[string]string)
+	}
+	if metadata.Bundles == nil {
+		metadata.Bundles = make(map[string]InstalledBundle)
+	}
+	return metadata, nil
+}
+
+// updateMetadata loads the sidecar, applies update and saves it while holding metadataMu
+// and the scripts directory lock, which covers other workstations sharing the directory
+func (sm *ScriptManager) updateMetadata(update func(*ScriptMetadata) error) error {
+	metadataMu.Lock()
+	defer metadataMu.Unlock()
+
+	return sm.withDirLock("update "+metadataFileName, func() error {
+		metadata, err := sm.LoadMetadata()
+		if err != nil {
+			return err
+		}
+		if err := update(metadata); err != nil {
+			return err
+		}
+		return sm.saveMetadata(metadata)
+	})
+}
+
+// saveMetadata writes the favorites and tags sidecar
+func (sm *ScriptManager) saveMetadata(metadata *ScriptMetadata) error {
+	data, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal script metadata: %w", err)
+	}
+	if err := sm.writeFileAtomic(sm.metadataPath(), data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", metadataFileName, err)
+	}
+	return nil
+}
+
+// scriptExists checks that a .lua script with the given base name is in the scripts directory
+func (sm *ScriptManager) scriptExists(script string) error {
+	if strings.TrimSpace(script) == "" {
+		return errors.New("script name is required")
+	}
+	path, err := sm.scriptFilePath(script + ".lua")
+	if err != nil {
+		return err
+	}
+	if _, err := sm.backend.FS.Stat(path); err != nil {
+		return errcode.Errorf(errcode.ScriptNotFound, "script not found: %s", script)
+	}
+	return nil
+}
+
+// SetFavorite marks or unmarks a script as a favorite
+func (sm *ScriptManager) SetFavorite(script string, favorite bool) (string, error) {
+	script = strings.TrimSuffix(script, ".lua")
+	if err := sm.scriptExists(script); err != nil {
+		return "", err
+	}
+
+	err := sm.updateMetadata(func(metadata *ScriptMetadata) error {
+		var favorites []string
+		for _, name := range metadata.Favorites {
+			if name != script {
+				favorites = append(favorites, name)
+			}
+		}
+		if favorite {
+			favorites = append(favorites, script)
+			sort.Strings(favorites)
+		}
+		metadata.Favorites = favorites
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if favorite {
+		return fmt.Sprintf("Marked '%s' as a favorite", script), nil
+	}
+	return fmt.Sprintf("Removed '%s' from favorites", script), nil
+}
+
+// SetTags replaces the tags of a script; no tags clears them
+func (sm *ScriptManager) SetTags(script string, tags []string) (string, error) {
+	script = strings.TrimSuffix(script, ".lua")
+	if err := sm.scriptExists(script); err != nil {
+		return "", err
+	}
+
+	seen := make(map[string]bool)
+	var cleaned []string
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		cleaned = append(cleaned, tag)
+	}
+	sort.Strings(cleaned)
+
+	err := sm.updateMetadata(func(metadata *ScriptMetadata) error {
+		if len(cleaned) == 0 {
+			delete(metadata.Tags, script)
+		} else {
+			metadata.Tags[script] = cleaned
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(cleaned) == 0 {
+		return fmt.Sprintf("Cleared tags for '%s'", script), nil
+	}
+	return fmt.Sprintf("Tagged '%s': %s", script, strings.Join(cleaned, ", ")), nil
+}
+
+// SetRating sets the star rating and notes of a script. A nil field is left as it is;
+// a rating of 0 or empty notes clears them.
+func (sm *ScriptManager) SetRating(script string, stars *int, notes *string) (string, error) {
+	script = strings.TrimSuffix(script, ".lua")
+	if err := sm.scriptExists(script); err != nil {
+		return "", err
+	}
+	if stars == nil && notes == nil {
+		return "", fmt.Errorf("rating or notes is required")
+	}
+	if stars != nil && (*stars < 0 || *stars > MaxRating) {
+		return "", fmt.Errorf("rating must be between 0 and %d stars, got %d", MaxRating, *stars)
+	}
+
+	var rating ScriptRating
+	err := sm.updateMetadata(func(metadata *ScriptMetadata) error {
+		rating = metadata.Ratings[script]
+		if stars != nil {
+			rating.Stars = *stars
+		}
+		if notes != nil {
+			rating.Notes = strings.TrimSpace(*notes)
+		}
+		rating.Updated = time.Now()
+		if rating.Stars == 0 && rating.Notes == "" {
+			delete(metadata.Ratings, script)
+		} else {
+			metadata.Ratings[script] = rating
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if rating.Stars == 0 && rating.Notes == "" {
+		return fmt.Sprintf("Cleared the rating and notes for '%s'", script), nil
+	}
+	result := fmt.Sprintf("Rated '%s': %s", script, FormatStars(rating.Stars))
+	if rating.Stars == 0 {
+		result = fmt.Sprintf("Saved notes for '%s'", script)
+	}
+	if rating.Notes != "" {
+		result += "\nNotes: " + rating.Notes
+	}
+	return result, nil
+}
+
+// FormatStars shows a star rating as filled and empty stars, or "unrated"
+func FormatStars(stars int) string {
+	if stars <= 0 {
+		return "unrated"
+	}
+	stars = min(stars, MaxRating)
+	return strings.Repeat("★", stars) + strings.Repeat("☆", MaxRating-stars)
+}
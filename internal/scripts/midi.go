@@ -0,0 +1,332 @@
+package scripts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/bridge"
+)
+
+// Defaults for notes and pattern steps that leave them out
+const (
+	defaultMIDIVelocity  = 96
+	defaultNoteBeats     = 1.0 // Length of a single note in a pattern
+	defaultChordBeats    = 4.0 // Length of a chord in a pattern: a bar of 4/4
+	defaultChordOctave   = 4   // Chord roots are placed from C4 (middle C, MIDI 60) up
+	maxMIDINotesPerItem  = 10000
+	midiNoteNameExamples = "60, C4, F#3 or Bb2"
+)
+
+// noteOffsets are the semitones of the natural notes above C
+var noteOffsets = map[byte]int{'C': 0, 'D': 2, 'E': 4, 'F': 5, 'G': 7, 'A': 9, 'B': 11}
+
+// chordIntervals are the semitones above the root of each chord quality, by the suffix
+// written after the root, e.g. "m7" in "Am7"
+var chordIntervals = map[string][]int{
+	"":     {0, 4, 7},
+	"maj":  {0, 4, 7},
+	"m":    {0, 3, 7},
+	"min":  {0, 3, 7},
+	"dim":  {0, 3, 6},
+	"aug":  {0, 4, 8},
+	"+":    {0, 4, 8},
+	"m6":   {0, 3, 7, 9},
+	"7":    {0, 4, 7, 10},
+	"maj7": {0, 4, 7, 11},
+	"m7":   {0, 3, 7, 10},
+	"m7b5": {0, 3, 6, 10},
+	"dim7": {0, 3, 6, 9},
+	"9":    {0, 4, 7, 10, 14},
+	"maj9": {0, 4, 7, 11, 14},
+	"m9":   {0, 3, 7, 10, 14},
+	"add9": {0, 4, 7, 14},
+	"sus2": {0, 2, 7},
+	"sus4": {0, 5, 7},
+}
+
+// NotePitch is a MIDI note number. In JSON it may also be a note name such as "C4" or
+// "F#3", with C4 being middle C (60).
+type NotePitch int
+
+// UnmarshalJSON accepts a note number or a note name
+func (p *NotePitch) UnmarshalJSON(data []byte) error {
+	var number int
+	if err := json.Unmarshal(data, &number); err == nil {
+		*p = NotePitch(number)
+		return nil
+	}
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return fmt.Errorf("pitch must be a note number or name, e.g. %s", midiNoteNameExamples)
+	}
+	pitch, err := ParseNotePitch(name)
+	if err != nil {
+		return err
+	}
+	*p = pitch
+	return nil
+}
+
+// MIDINote is a note to insert. Start and length are in beats (quarter notes) from the
+// start of the item.
+type MIDINote struct {
+	Pitch    NotePitch `json:"pitch"`
+	Start    float64   `json:"start"`
+	Length   float64   `json:"length"`
+	Velocity int       `json:"velocity,omitempty"` // 1-127; defaults to 96
+}
+
+// MIDIInsertResult describes a MIDI item created by InsertMIDI
+type MIDIInsertResult struct {
+	Track    int     `json:"track"`    // Track number (1-based)
+	Position float64 `json:"position"` // Item start (seconds)
+	Length   float64 `json:"length"`   // Item length (seconds)
+	Beats    float64 `json:"beats"`    // Item length (quarter notes)
+	Notes    int     `json:"notes"`
+}
+
+// ParseNotePitch converts a note number ("60") or name ("C4", "F#3", "Bb2") to a MIDI
+// note number
+func ParseNotePitch(s string) (NotePitch, error) {
+	s = strings.TrimSpace(s)
+	if number, err := strconv.Atoi(s); err == nil {
+		return checkPitch(number, s)
+	}
+	root, rest, err := parseNoteRoot(s)
+	if err != nil || rest == "" {
+		return 0, fmt.Errorf("invalid note %q: use a note number or name, e.g. %s", s, midiNoteNameExamples)
+	}
+	octave, err := strconv.Atoi(rest)
+	if err != nil {
+		return 0, fmt.Errorf("invalid note %q: use a note number or name, e.g. %s", s, midiNoteNameExamples)
+	}
+	return checkPitch((octave+1)*12+root, s)
+}
+
+// checkPitch returns pitch if it's a valid MIDI note number
+func checkPitch(pitch int, s string) (NotePitch, error) {
+	if pitch < 0 || pitch > 127 {
+		return 0, fmt.Errorf("note %q is outside the MIDI range 0-127", s)
+	}
+	return NotePitch(pitch), nil
+}
+
+// parseNoteRoot reads a note letter with optional sharps (#) or flats (b) from the start
+// of s, returning its semitone within the octave (which may fall outside 0-11) and the rest of s
+func parseNoteRoot(s string) (int, string, error) {
+	if s == "" {
+		return 0, "", fmt.Errorf("missing note name")
+	}
+	offset, ok := noteOffsets[strings.ToUpper(s[:1])[0]]
+	if !ok {
+		return 0, "", fmt.Errorf("invalid note name %q", s)
+	}
+	i := 1
+	for ; i < len(s); i++ {
+		switch s[i] {
+		case '#':
+			offset++
+		case 'b':
+			offset--
+		default:
+			return offset, s[i:], nil
+		}
+	}
+	return offset, "", nil
+}
+
+// ParseMIDIPattern converts a pattern into notes. A pattern is a space-separated sequence
+// of steps played one after another:
+//   - a note with its octave, e.g. "C4" or "F#3", lasting 1 beat
+//   - a chord symbol, e.g. "C", "Am7" or "Gsus4", lasting 4 beats and voiced from octave 4
+//   - a rest, "-" or "r", lasting 1 beat
+//
+// A step's length in beats can be given after a colon, e.g. "Am:2" or "C4:0.5", and
+// notes of a step can be joined with "+" to sound together, e.g. "C4+E4+G4".
+func ParseMIDIPattern(pattern string, velocity int) ([]MIDINote, error) {
+	var notes []MIDINote
+	position := 0.0
+	for _, step := range strings.Fields(pattern) {
+		symbol, beatsText, hasBeats := strings.Cut(step, ":")
+		var pitches []int
+		beats := defaultNoteBeats
+		switch {
+		case symbol == "-" || strings.EqualFold(symbol, "r"):
+		case isNoteStep(symbol):
+			for _, name := range strings.Split(symbol, "+") {
+				pitch, err := ParseNotePitch(name)
+				if err != nil {
+					return nil, err
+				}
+				pitches = append(pitches, int(pitch))
+			}
+		default:
+			chord, err := chordPitches(symbol)
+			if err != nil {
+				return nil, err
+			}
+			pitches = chord
+			beats = defaultChordBeats
+		}
+		if hasBeats {
+			value, err := strconv.ParseFloat(beatsText, 64)
+			if err != nil || value <= 0 {
+				return nil, fmt.Errorf("invalid length in %q: use a number of beats, e.g. %s:2", step, symbol)
+			}
+			beats = value
+		}
+		for _, pitch := range pitches {
+			notes = append(notes, MIDINote{Pitch: NotePitch(pitch), Start: position, Length: beats, Velocity: velocity})
+		}
+		position += beats
+	}
+	if len(notes) == 0 {
+		return nil, fmt.Errorf("pattern has no notes: use chords such as 'C Am F G' or notes such as 'C4 E4 G4:2'")
+	}
+	return notes, nil
+}
+
+// isNoteStep reports whether a pattern step is notes with their octaves, such as "A4" or
+// "C4+E4", rather than a chord. "7" and "9" after a root make a chord ("A7"), not an octave.
+func isNoteStep(symbol string) bool {
+	if strings.Contains(symbol, "+") {
+		return true
+	}
+	_, rest, err := parseNoteRoot(symbol)
+	if err != nil || rest == "7" || rest == "9" {
+		return false
+	}
+	_, err = strconv.Atoi(rest)
+	return err == nil
+}
+
+// chordPitches returns the notes of a chord symbol voiced from octave 4
+func chordPitches(symbol string) ([]int, error) {
+	root, quality, err := parseNoteRoot(symbol)
+	if err != nil {
+		return nil, fmt.Errorf("invalid chord %q", symbol)
+	}
+	intervals, ok := chordIntervals[quality]
+	if !ok {
+		return nil, fmt.Errorf("unknown chord %q: supported qualities are major, m, dim, aug, m6, 7, maj7, m7, m7b5, dim7, 9, maj9, m9, add9, sus2 and sus4", symbol)
+	}
+	base := (defaultChordOctave+1)*12 + root
+	pitches := make([]int, len(intervals))
+	for i, interval := range intervals {
+		pitches[i] = base + interval
+	}
+	return pitches, nil
+}
+
+// InsertMIDI creates a MIDI item holding notes on a track via the Lua bridge, as one undo
+// step. trackIndex is 1-based; 0 uses the selected track. position is in seconds; nil
+// uses the edit cursor. The item spans the notes, rounded up to whole beats.
+func InsertMIDI(ctx context.Context, notes []MIDINote, trackIndex int, position *float64) (*MIDIInsertResult, error) {
+	if len(notes) == 0 {
+		return nil, fmt.Errorf("notes are required: a list of notes (pitch, start, length, velocity) or a pattern such as 'C Am F G'")
+	}
+	if len(notes) > maxMIDINotesPerItem {
+		return nil, fmt.Errorf("too many notes: %d (limit %d)", len(notes), maxMIDINotesPerItem)
+	}
+	if trackIndex < 0 {
+		return nil, fmt.Errorf("track index must be 0 (selected track) or greater")
+	}
+	pos := -1.0
+	if position != nil {
+		if *position < 0 {
+			return nil, fmt.Errorf("position must not be negative")
+		}
+		pos = *position
+	}
+
+	var table strings.Builder
+	beats := 0.0
+	for i, note := range notes {
+		if note.Pitch < 0 || note.Pitch > 127 {
+			return nil, fmt.Errorf("note %d: pitch %d is outside the MIDI range 0-127", i+1, note.Pitch)
+		}
+		if note.Start < 0 || note.Length <= 0 {
+			return nil, fmt.Errorf("note %d: start must not be negative and length must be positive (in beats)", i+1)
+		}
+		velocity := note.Velocity
+		if velocity == 0 {
+			velocity = defaultMIDIVelocity
+		}
+		if velocity < 1 || velocity > 127 {
+			return nil, fmt.Errorf("note %d: velocity must be between 1 and 127, got %d", i+1, velocity)
+		}
+		fmt.Fprintf(&table, "{%d, %s, %s, %d},\n", note.Pitch, formatBeats(note.Start), formatBeats(note.Length), velocity)
+		beats = max(beats, note.Start+note.Length)
+	}
+	if whole := float64(int(beats)); whole < beats {
+		beats = whole + 1
+	}
+
+	rows, err := bridge.Run(ctx, "insert_midi", fmt.Sprintf(`local notes = {
+%s}
+local track_index = %d
+local position = %s
+local beats = %s
+
+local track
+if track_index > 0 then
+    track = reaper.GetTrack(0, track_index - 1)
+    if not track then return fail("track " .. track_index .. " not found") end
+else
+    track = reaper.GetSelectedTrack(0, 0)
+    if not track then return fail("no track is selected; select one or give a track number") end
+end
+if position < 0 then position = reaper.GetCursorPosition() end
+
+reaper.Undo_BeginBlock()
+reaper.PreventUIRefresh(1)
+
+local start_qn = reaper.TimeMap2_timeToQN(0, position)
+local end_time = reaper.TimeMap2_QNToTime(0, start_qn + beats)
+local item = reaper.CreateNewMIDIItemInProj(track, position, end_time, false)
+local take = item and reaper.GetActiveTake(item)
+if not take then
+    reaper.PreventUIRefresh(-1)
+    reaper.Undo_EndBlock("Ori: Insert MIDI", -1)
+    return fail("REAPER could not create a MIDI item")
+end
+for _, note in ipairs(notes) do
+    local note_start = reaper.MIDI_GetPPQPosFromProjQN(take, start_qn + note[2])
+    local note_end = reaper.MIDI_GetPPQPosFromProjQN(take, start_qn + note[2] + note[3])
+    reaper.MIDI_InsertNote(take, false, false, note_start, note_end, 0, note[1], note[4], true)
+end
+reaper.MIDI_Sort(take)
+
+reaper.PreventUIRefresh(-1)
+reaper.Undo_EndBlock("Ori: Insert MIDI", -1)
+reaper.UpdateArrange()
+
+out(math.floor(reaper.GetMediaTrackInfo_Value(track, "IP_TRACKNUMBER")), position, end_time - position)
+`, table.String(), trackIndex, formatBeats(pos), formatBeats(beats)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert MIDI: %w", err)
+	}
+	if len(rows) == 0 || len(rows[0]) < 3 {
+		return nil, fmt.Errorf("failed to insert MIDI: unexpected response from REAPER")
+	}
+
+	result := &MIDIInsertResult{Beats: beats, Notes: len(notes)}
+	result.Track, _ = strconv.Atoi(rows[0][0])
+	result.Position, _ = strconv.ParseFloat(rows[0][1], 64)
+	result.Length, _ = strconv.ParseFloat(rows[0][2], 64)
+	return result, nil
+}
+
+// formatBeats formats a number for the generated Lua
+func formatBeats(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// FormatMIDIInsertResult describes an inserted MIDI item
+func FormatMIDIInsertResult(result *MIDIInsertResult) string {
+	return fmt.Sprintf("Inserted a MIDI item with %d note(s) on track %d at %.3fs (%s beats, %.3fs)",
+		result.Notes, result.Track, result.Position, formatBeats(result.Beats), result.Length)
+}
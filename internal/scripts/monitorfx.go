@@ -0,0 +1,141 @@
+package scripts
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/bridge"
+)
+
+// monitorFXOffset is added to an FX index on the master track to address the monitoring
+// FX chain instead of the master FX chain
+const monitorFXOffset = 0x1000000
+
+// MonitorFX is a plugin in REAPER's monitoring FX chain, which only affects what you hear
+// and isn't rendered or saved with the project
+type MonitorFX struct {
+	Index   int    `json:"index"` // Position in the chain (1-based)
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"` // False when bypassed
+}
+
+// ListMonitorFX reads the monitoring FX chain via the Lua bridge
+func ListMonitorFX(ctx context.Context) ([]MonitorFX, error) {
+	rows, err := bridge.Run(ctx, "list_monitor_fx", fmt.Sprintf(`local master = reaper.GetMasterTrack(0)
+for i = 0, reaper.TrackFX_GetRecCount(master) - 1 do
+    local _, name = reaper.TrackFX_GetFXName(master, %d + i, "")
+    out(i + 1, name, reaper.TrackFX_GetEnabled(master, %d + i) and 1 or 0)
+end
+`, monitorFXOffset, monitorFXOffset))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read monitoring FX: %w", err)
+	}
+
+	chain := make([]MonitorFX, 0, len(rows))
+	for _, row := range rows {
+		if len(row) < 3 {
+			continue
+		}
+		index, _ := strconv.Atoi(row[0])
+		chain = append(chain, MonitorFX{Index: index, Name: row[1], Enabled: row[2] == "1"})
+	}
+	return chain, nil
+}
+
+// FindMonitorFX picks the plugin in chain that fx refers to: its 1-based position, or a
+// case-insensitive part of its name that matches exactly one plugin
+func FindMonitorFX(chain []MonitorFX, fx string) (MonitorFX, error) {
+	fx = strings.TrimSpace(fx)
+	if fx == "" {
+		return MonitorFX{}, fmt.Errorf("fx is required: a position in the monitoring FX chain or part of a plugin name")
+	}
+	if index, err := strconv.Atoi(fx); err == nil {
+		if index < 1 || index > len(chain) {
+			return MonitorFX{}, fmt.Errorf("no monitoring FX at position %d; the chain has %d plugin(s)", index, len(chain))
+		}
+		return chain[index-1], nil
+	}
+
+	var matches []MonitorFX
+	for _, plugin := range chain {
+		if strings.EqualFold(plugin.Name, fx) {
+			return plugin, nil
+		}
+		if strings.Contains(strings.ToLower(plugin.Name), strings.ToLower(fx)) {
+			matches = append(matches, plugin)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return MonitorFX{}, fmt.Errorf("no monitoring FX matches %q; use 'list_monitor_fx' to see the chain", fx)
+	case 1:
+		return matches[0], nil
+	}
+	names := make([]string, len(matches))
+	for i, plugin := range matches {
+		names[i] = fmt.Sprintf("%d %s", plugin.Index, plugin.Name)
+	}
+	return MonitorFX{}, fmt.Errorf("%q matches several monitoring FX (%s); use the position instead", fx, strings.Join(names, ", "))
+}
+
+// AddMonitorFX adds a plugin (e.g. "VST3: Sonarworks SoundID Reference") to the end of the
+// monitoring FX chain via the Lua bridge and returns its 1-based position
+func AddMonitorFX(ctx context.Context, name string) (int, error) {
+	if strings.TrimSpace(name) == "" {
+		return 0, fmt.Errorf("name is required: the plugin to add, as shown in REAPER's FX browser")
+	}
+
+	rows, err := bridge.Run(ctx, "add_monitor_fx", fmt.Sprintf(`local index = reaper.TrackFX_AddByName(reaper.GetMasterTrack(0), %s, true, -1)
+if index < 0 then
+    return fail("plugin not found")
+end
+out(index - %d + 1)
+`, bridge.LuaString(name), monitorFXOffset))
+	if err != nil {
+		return 0, fmt.Errorf("failed to add monitoring FX %q: %w", name, err)
+	}
+	if len(rows) < 1 || len(rows[0]) < 1 {
+		return 0, fmt.Errorf("unexpected output format: no FX position")
+	}
+	return strconv.Atoi(rows[0][0])
+}
+
+// SetMonitorFXEnabled enables or bypasses the monitoring FX at a 1-based position via the Lua bridge
+func SetMonitorFXEnabled(ctx context.Context, index int, enabled bool) error {
+	if index < 1 {
+		return fmt.Errorf("monitoring FX position must be 1 or greater")
+	}
+
+	_, err := bridge.Run(ctx, "set_monitor_fx", fmt.Sprintf(`local master = reaper.GetMasterTrack(0)
+if %d > reaper.TrackFX_GetRecCount(master) then
+    return fail("monitoring FX %d not found")
+end
+reaper.TrackFX_SetEnabled(master, %d, %t)
+`, index, index, monitorFXOffset+index-1, enabled))
+	if err != nil {
+		return fmt.Errorf("failed to set monitoring FX: %w", err)
+	}
+	return nil
+}
+
+// FormatMonitorFX formats the monitoring FX chain as a readable table
+func FormatMonitorFX(chain []MonitorFX) string {
+	if len(chain) == 0 {
+		return "The monitoring FX chain is empty"
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Monitoring FX chain (%d plugins, heard but not rendered):\n\n", len(chain)))
+	result.WriteString("Index | Name                                     | State\n")
+	result.WriteString("------|------------------------------------------|---------\n")
+	for _, plugin := range chain {
+		state := "on"
+		if !plugin.Enabled {
+			state = "bypassed"
+		}
+		result.WriteString(fmt.Sprintf("%-5d | %-40s | %s\n", plugin.Index, truncateString(plugin.Name, 40), state))
+	}
+	return result.String()
+}
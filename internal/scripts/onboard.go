@@ -0,0 +1,132 @@
+package scripts
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/platform"
+	"github.com/johnjallday/ori-reaper-plugin/internal/types"
+)
+
+// StarterPack is the curated bundle offered to new users by 'onboard'
+var StarterPack = types.Bundle{
+	Name:        "Starter pack",
+	Description: "A few everyday helpers: track naming and coloring, markers, regions and loop mode",
+	Scripts: []string{
+		"auto_name_tracks.lua",
+		"auto_color_tracks.lua",
+		"auto_create_markers.lua",
+		"add_4_bar_region.lua",
+		"loop_mode.lua",
+	},
+}
+
+// SetupCheck is the result of one onboarding detection step
+type SetupCheck struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail"`
+	Fix    string `json:"fix,omitempty"` // What to do if the check failed
+}
+
+// SetupReport is what 'onboard' detected about the user's REAPER setup
+type SetupReport struct {
+	Checks           []SetupCheck `json:"checks"`
+	StarterInstalled bool         `json:"starter_installed"`
+	StarterMissing   []string     `json:"starter_missing,omitempty"` // Starter pack scripts not in the scripts directory
+	ReaperInstalled  bool         `json:"reaper_installed"`
+	WebRemoteEnabled bool         `json:"web_remote_enabled"`
+}
+
+// DetectSetup checks the REAPER installation, the scripts directory and the Web Remote
+func DetectSetup(scriptsDir string) *SetupReport {
+	report := &SetupReport{}
+
+	// REAPER install: the resource folder with reaper.ini is created on first launch
+	iniPath, err := GetReaperIniPath()
+	switch {
+	case err != nil:
+		report.Checks = append(report.Checks, SetupCheck{Name: "REAPER", Detail: err.Error(),
+			Fix: "Install REAPER from https://www.reaper.fm and launch it once"})
+	default:
+		if _, err := os.Stat(iniPath); err != nil {
+			report.Checks = append(report.Checks, SetupCheck{Name: "REAPER", Detail: "reaper.ini not found at " + iniPath,
+				Fix: "Install REAPER from https://www.reaper.fm and launch it once"})
+		} else {
+			report.ReaperInstalled = true
+			detail := "Found " + iniPath
+			if running, err := platform.IsReaperRunning(); err == nil && running {
+				detail += " (REAPER is running)"
+			}
+			report.Checks = append(report.Checks, SetupCheck{Name: "REAPER", OK: true, Detail: detail})
+		}
+	}
+
+	// Scripts directory
+	if info, err := os.Stat(scriptsDir); err != nil || !info.IsDir() {
+		report.Checks = append(report.Checks, SetupCheck{Name: "Scripts directory", Detail: scriptsDir + " does not exist",
+			Fix: "It will be created when the starter pack is installed"})
+	} else {
+		lua, _ := ListLuaScripts(scriptsDir)
+		report.Checks = append(report.Checks, SetupCheck{Name: "Scripts directory", OK: true,
+			Detail: fmt.Sprintf("%s (%d Lua scripts)", scriptsDir, len(lua))})
+	}
+
+	// Web Remote
+	if config, err := GetWebRemoteConfig(); err != nil {
+		report.Checks = append(report.Checks, SetupCheck{Name: "Web Remote", Detail: "Not configured",
+			Fix: "Run 'setup_web_remote' to enable it (needed for tracks, actions and transport)"})
+	} else if !config.Enabled {
+		report.Checks = append(report.Checks, SetupCheck{Name: "Web Remote", Detail: fmt.Sprintf("Disabled (port %d)", config.Port),
+			Fix: "Run 'setup_web_remote' to enable it (needed for tracks, actions and transport)"})
+	} else {
+		report.WebRemoteEnabled = true
+		report.Checks = append(report.Checks, SetupCheck{Name: "Web Remote", OK: true, Detail: fmt.Sprintf("Enabled on port %d", config.Port)})
+	}
+
+	// Starter pack
+	sm := NewScriptManager(scriptsDir)
+	if metadata, err := sm.LoadMetadata(); err == nil {
+		_, report.StarterInstalled = metadata.Bundles[StarterPack.Name]
+	}
+	for _, script := range StarterPack.Scripts {
+		if _, err := os.Stat(filepath.Join(scriptsDir, script)); err != nil {
+			report.StarterMissing = append(report.StarterMissing, script)
+		}
+	}
+
+	return report
+}
+
+// FormatSetupReport renders the detection results of 'onboard'
+func FormatSetupReport(report *SetupReport) string {
+	var b strings.Builder
+	b.WriteString("👋 Welcome to Ori REAPER! Here's what I found:\n\n")
+	for _, check := range report.Checks {
+		icon := "✅"
+		if !check.OK {
+			icon = "⚠️"
+		}
+		b.WriteString(fmt.Sprintf("%s %s: %s\n", icon, check.Name, check.Detail))
+		if !check.OK && check.Fix != "" {
+			b.WriteString(fmt.Sprintf("   → %s\n", check.Fix))
+		}
+	}
+
+	b.WriteString(fmt.Sprintf("\n📦 %s: %s\n", StarterPack.Name, StarterPack.Description))
+	if report.StarterInstalled {
+		b.WriteString("   Already installed.\n")
+	} else {
+		for _, script := range StarterPack.Scripts {
+			state := "will be downloaded"
+			if !slices.Contains(report.StarterMissing, script) {
+				state = "already present, will be registered"
+			}
+			b.WriteString(fmt.Sprintf("   • %s (%s)\n", script, state))
+		}
+	}
+	return b.String()
+}
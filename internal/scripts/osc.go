@@ -0,0 +1,222 @@
+package scripts
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/project"
+)
+
+// oscPatternExtension is the file extension of OSC pattern config files
+const oscPatternExtension = ".ReaperOSC"
+
+// Defaults for new OSC control surfaces, matching REAPER's dialog defaults
+const (
+	DefaultOSCLocalPort  = 8000
+	DefaultOSCRemotePort = 9000
+	oscMaxPacketSize     = 1024
+	oscWaitTimeMs        = 10
+)
+
+// OSC csurf modes: whether REAPER only listens or also sends feedback to a device
+const (
+	oscModeReceiveOnly = 1 // Local port only
+	oscModeDevice      = 3 // Device IP + device port + local port
+)
+
+// OSCConfig is an OSC control surface entry in reaper.ini, stored as:
+// csurf_N=OSC <flags> <mode> "<name>" <local port> "<device host>" <device port> <max packet> <wait ms> "<pattern>" ...
+type OSCConfig struct {
+	CSurfID    int    `json:"csurf_id"`
+	Name       string `json:"name"`
+	LocalPort  int    `json:"local_port"`            // Port REAPER listens on
+	Host       string `json:"host,omitempty"`        // Device to send feedback to
+	RemotePort int    `json:"remote_port,omitempty"` // Port on the device
+	Pattern    string `json:"pattern,omitempty"`     // .ReaperOSC pattern config name, empty for Default
+}
+
+// value renders the config as a reaper.ini csurf value
+func (c *OSCConfig) value() string {
+	mode := oscModeReceiveOnly
+	if c.Host != "" {
+		mode = oscModeDevice
+	}
+	return fmt.Sprintf(`OSC 0 %d %s %d %s %d %d %d %s 0 ""`,
+		mode, project.QuoteString(c.Name), c.LocalPort, project.QuoteString(c.Host), c.RemotePort,
+		oscMaxPacketSize, oscWaitTimeMs, project.QuoteString(c.Pattern))
+}
+
+// GetOSCConfigs returns every OSC control surface entry in reaper.ini
+func GetOSCConfigs() ([]OSCConfig, error) {
+	entries, err := GetAllCSurfEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	var configs []OSCConfig
+	for key, value := range entries {
+		id, err := strconv.Atoi(strings.TrimPrefix(key, "csurf_"))
+		if err != nil {
+			continue
+		}
+		fields := project.Tokenize(value)
+		if len(fields) < 7 || fields[0] != "OSC" {
+			continue
+		}
+		config := OSCConfig{CSurfID: id, Name: fields[3], Host: fields[5]}
+		config.LocalPort, _ = strconv.Atoi(fields[4])
+		config.RemotePort, _ = strconv.Atoi(fields[6])
+		if len(fields) > 9 {
+			config.Pattern = fields[9]
+		}
+		configs = append(configs, config)
+	}
+	return configs, nil
+}
+
+// ConfigureOSC creates an OSC control surface, or updates the one with the same name.
+// Zero ports fall back to the existing values or REAPER's defaults.
+// REAPER must be restarted (or the surface re-added) for changes to take effect.
+func ConfigureOSC(config OSCConfig) (*OSCConfig, error) {
+	config.Name = strings.TrimSpace(config.Name)
+	if config.Name == "" {
+		config.Name = "Ori OSC"
+	}
+	if strings.ContainsAny(config.Name+config.Host+config.Pattern, "\"'`\n") {
+		return nil, errors.New("OSC name, host and pattern can't contain quotes or newlines")
+	}
+	config.Pattern = strings.TrimSuffix(config.Pattern, oscPatternExtension)
+
+	if config.Pattern != "" {
+		patternPath, err := oscPatternPath(config.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(patternPath); err != nil {
+			return nil, fmt.Errorf("OSC pattern config not found: %s (install it with 'install_osc_pattern')", patternPath)
+		}
+	}
+
+	existing, err := GetOSCConfigs()
+	if err != nil {
+		return nil, err
+	}
+
+	var current *OSCConfig
+	for i := range existing {
+		if existing[i].Name == config.Name {
+			current = &existing[i]
+			break
+		}
+	}
+
+	if current != nil {
+		if config.LocalPort == 0 {
+			config.LocalPort = current.LocalPort
+		}
+		if config.RemotePort == 0 {
+			config.RemotePort = current.RemotePort
+		}
+		if config.Host == "" {
+			config.Host = current.Host
+		}
+		if config.Pattern == "" {
+			config.Pattern = current.Pattern
+		}
+	}
+	if config.LocalPort == 0 {
+		config.LocalPort = DefaultOSCLocalPort
+	}
+	if config.RemotePort == 0 {
+		config.RemotePort = DefaultOSCRemotePort
+	}
+	for _, port := range []int{config.LocalPort, config.RemotePort} {
+		if port < 1 || port > 65535 {
+			return nil, fmt.Errorf("invalid port %d: must be between 1 and 65535", port)
+		}
+	}
+
+	if current != nil {
+		config.CSurfID = current.CSurfID
+		if err := replaceCSurfEntry(config.CSurfID, config.value()); err != nil {
+			return nil, err
+		}
+		return &config, nil
+	}
+
+	id, err := addCSurfEntry(config.value())
+	if err != nil {
+		return nil, err
+	}
+	config.CSurfID = id
+	return &config, nil
+}
+
+// oscPatternPath returns the path of a pattern config in REAPER's OSC folder
+func oscPatternPath(name string) (string, error) {
+	basePath, err := GetReaperResourcePath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(basePath, "OSC", name+oscPatternExtension), nil
+}
+
+// InstallOSCPattern writes a .ReaperOSC pattern config to REAPER's OSC folder,
+// from content or by copying the file at sourcePath. Returns the installed path.
+func InstallOSCPattern(name, content, sourcePath string) (string, error) {
+	name = strings.TrimSuffix(strings.TrimSpace(name), oscPatternExtension)
+	if sourcePath != "" {
+		data, err := os.ReadFile(sourcePath)
+		if err != nil {
+			return "", fmt.Errorf("failed to read pattern file: %w", err)
+		}
+		content = string(data)
+		if name == "" {
+			name = strings.TrimSuffix(filepath.Base(sourcePath), filepath.Ext(sourcePath))
+		}
+	}
+
+	if name == "" {
+		return "", errors.New("name is required for 'install_osc_pattern' operation")
+	}
+	if strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("invalid pattern name: %s", name)
+	}
+	if strings.TrimSpace(content) == "" {
+		return "", errors.New("pattern content (or path) is required for 'install_osc_pattern' operation")
+	}
+
+	path, err := oscPatternPath(name)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create OSC folder: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return "", fmt.Errorf("failed to write pattern config: %w", err)
+	}
+	return path, nil
+}
+
+// FormatOSCConfig formats an OSC control surface entry
+func FormatOSCConfig(config *OSCConfig) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("OSC control surface '%s' (csurf_%d):\n", config.Name, config.CSurfID))
+	b.WriteString(fmt.Sprintf("  Listening on port: %d\n", config.LocalPort))
+	if config.Host != "" {
+		b.WriteString(fmt.Sprintf("  Sending to: %s:%d\n", config.Host, config.RemotePort))
+	} else {
+		b.WriteString("  Sending to: (receive only)\n")
+	}
+	pattern := config.Pattern
+	if pattern == "" {
+		pattern = "Default"
+	}
+	b.WriteString(fmt.Sprintf("  Pattern config: %s\n", pattern))
+	return b.String()
+}
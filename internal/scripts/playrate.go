@@ -0,0 +1,96 @@
+package scripts
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/bridge"
+)
+
+// preservePitchAction is REAPER's "Transport: Toggle preserve pitch in audio items when
+// changing master playrate"
+const preservePitchAction = 40671
+
+// Limits of REAPER's master playback rate
+const (
+	minPlayRate = 0.25
+	maxPlayRate = 4.0
+)
+
+// PlayRate is the master playback rate, used to slow down or speed up playback for
+// transcription and practice
+type PlayRate struct {
+	Rate          float64 `json:"rate"`           // 1.0 is normal speed
+	PreservePitch bool    `json:"preserve_pitch"` // Audio keeps its pitch when the rate changes
+}
+
+// PlayRateChange describes the playback settings to change; nil fields are left alone
+type PlayRateChange struct {
+	Rate          *float64
+	PreservePitch *bool
+}
+
+// GetPlayRate reads the master playback rate and preserve pitch option via the Lua bridge
+func GetPlayRate(ctx context.Context) (*PlayRate, error) {
+	rows, err := bridge.Run(ctx, "get_playrate", fmt.Sprintf(`out(reaper.Master_GetPlayRate(0), reaper.GetToggleCommandState(%d))
+`, preservePitchAction))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read playback rate: %w", err)
+	}
+	if len(rows) < 1 || len(rows[0]) < 2 {
+		return nil, fmt.Errorf("unexpected output format: no playback rate data")
+	}
+
+	rate, err := strconv.ParseFloat(rows[0][0], 64)
+	if err != nil {
+		return nil, fmt.Errorf("unexpected playback rate %q", rows[0][0])
+	}
+	return &PlayRate{Rate: rate, PreservePitch: rows[0][1] == "1"}, nil
+}
+
+// SetPlayRate changes the master playback rate and preserve pitch option via the Lua bridge
+func SetPlayRate(ctx context.Context, change PlayRateChange) error {
+	var body strings.Builder
+	if change.PreservePitch != nil {
+		want := 0
+		if *change.PreservePitch {
+			want = 1
+		}
+		fmt.Fprintf(&body, "if reaper.GetToggleCommandState(%d) ~= %d then reaper.Main_OnCommand(%d, 0) end\n",
+			preservePitchAction, want, preservePitchAction)
+	}
+	if change.Rate != nil {
+		if *change.Rate < minPlayRate || *change.Rate > maxPlayRate {
+			return fmt.Errorf("playback rate must be between %g and %g (1.0 is normal speed), got %g", minPlayRate, maxPlayRate, *change.Rate)
+		}
+		fmt.Fprintf(&body, "reaper.CSurf_OnPlayRateChange(%g)\n", *change.Rate)
+	}
+	if body.Len() == 0 {
+		return fmt.Errorf("nothing to change: set rate or preserve_pitch")
+	}
+
+	if _, err := bridge.Run(ctx, "set_playrate", body.String()); err != nil {
+		return fmt.Errorf("failed to set playback rate: %w", err)
+	}
+	return nil
+}
+
+// FormatPlayRate formats the playback rate as readable text
+func FormatPlayRate(rate *PlayRate) string {
+	var result strings.Builder
+	result.WriteString("Playback rate:\n")
+	result.WriteString(fmt.Sprintf("  Rate: %.2fx (%.0f%% speed)\n", rate.Rate, rate.Rate*100))
+	if rate.PreservePitch {
+		result.WriteString("  Preserve pitch: on (audio keeps its pitch)\n")
+	} else {
+		result.WriteString("  Preserve pitch: off")
+		if shift := 12 * math.Log2(rate.Rate); math.Abs(shift) >= 0.01 {
+			result.WriteString(fmt.Sprintf(" (audio sounds %+.2f semitones)", shift))
+		}
+		result.WriteString("\n")
+	}
+	return result.String()
+}
@@ -0,0 +1,160 @@
+package scripts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/bridge"
+	"github.com/johnjallday/ori-reaper-plugin/internal/errcode"
+)
+
+// profileTimeout is how long profile_script waits for the profiled script to finish
+const profileTimeout = 2 * time.Minute
+
+// profileHarness runs a script with every reaper.* function replaced by a timing proxy.
+// It reports the total wall time followed by one line per API function that was called.
+const profileHarness = `local stats = {}
+local proxy = setmetatable({}, { __index = function(t, name)
+    local fn = reaper[name]
+    if type(fn) ~= "function" then return fn end
+    local wrapped = function(...)
+        local start = reaper.time_precise()
+        local results = table.pack(fn(...))
+        local s = stats[name]
+        if not s then
+            s = { calls = 0, total = 0 }
+            stats[name] = s
+        end
+        s.calls = s.calls + 1
+        s.total = s.total + (reaper.time_precise() - start)
+        return table.unpack(results, 1, results.n)
+    end
+    rawset(t, name, wrapped)
+    return wrapped
+end })
+
+local env = setmetatable({ reaper = proxy }, { __index = _G, __newindex = _G })
+local chunk, load_err = loadfile(%s, "t", env)
+if not chunk then
+    fail(load_err)
+    return
+end
+
+local start = reaper.time_precise()
+local ok, err = xpcall(chunk, debug.traceback)
+local total = reaper.time_precise() - start
+if not ok then
+    fail(err)
+    return
+end
+
+out("total", total)
+for name, s in pairs(stats) do
+    out(name, s.calls, s.total)
+end
+`
+
+// APICallStats is the time spent in one REAPER API function during a profiled run
+type APICallStats struct {
+	Name  string        `json:"name"`
+	Calls int           `json:"calls"`
+	Total time.Duration `json:"total"`
+}
+
+// ProfileResult is the outcome of running a script under the profiler
+type ProfileResult struct {
+	Script    string         `json:"script"`
+	TotalTime time.Duration  `json:"total_time"`
+	APICalls  []APICallStats `json:"api_calls"` // Sorted by total time, slowest first
+}
+
+// ProfileScript runs a Lua script in REAPER and measures its wall time and the time
+// spent in each reaper.* API function. Work scheduled with reaper.defer is not measured.
+func (sm *ScriptManager) ProfileScript(ctx context.Context, script string) (*ProfileResult, error) {
+	if strings.TrimSpace(script) == "" {
+		return nil, errors.New("script name is required for 'profile_script' operation")
+	}
+
+	scriptPath, err := sm.scriptFilePath(strings.TrimSuffix(script, ".lua") + ".lua")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(scriptPath); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, errcode.Errorf(errcode.ScriptNotFound, "script not found: %s", scriptPath)
+		}
+		return nil, err
+	}
+
+	rows, err := bridge.RunWithTimeout(ctx, "profile_script", fmt.Sprintf(profileHarness, bridge.LuaString(scriptPath)), profileTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to profile %s: %w", script, err)
+	}
+
+	result := &ProfileResult{Script: script}
+	for _, row := range rows {
+		if row[0] == "total" && len(row) >= 2 {
+			result.TotalTime = parseSeconds(row[1])
+			continue
+		}
+		if len(row) < 3 {
+			continue
+		}
+		calls, _ := strconv.Atoi(row[1])
+		result.APICalls = append(result.APICalls, APICallStats{
+			Name:  row[0],
+			Calls: calls,
+			Total: parseSeconds(row[2]),
+		})
+	}
+
+	sort.Slice(result.APICalls, func(i, j int) bool {
+		return result.APICalls[i].Total > result.APICalls[j].Total
+	})
+	return result, nil
+}
+
+// parseSeconds converts a Lua number of seconds to a Duration
+func parseSeconds(s string) time.Duration {
+	seconds, _ := strconv.ParseFloat(s, 64)
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// FormatProfileResult formats a profile as a table of the slowest API functions
+func FormatProfileResult(result *ProfileResult) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Profile of %s\n", result.Script))
+	b.WriteString(fmt.Sprintf("Total wall time: %s\n", result.TotalTime.Round(time.Microsecond)))
+
+	if len(result.APICalls) == 0 {
+		b.WriteString("\nNo REAPER API calls were made.\n")
+		return b.String()
+	}
+
+	var apiTotal time.Duration
+	for _, call := range result.APICalls {
+		apiTotal += call.Total
+	}
+	b.WriteString(fmt.Sprintf("Time in REAPER API: %s\n\n", apiTotal.Round(time.Microsecond)))
+
+	b.WriteString("Function                             | Calls   | Total        | Per call\n")
+	b.WriteString("-------------------------------------|---------|--------------|-------------\n")
+	for _, call := range result.APICalls {
+		perCall := time.Duration(0)
+		if call.Calls > 0 {
+			perCall = call.Total / time.Duration(call.Calls)
+		}
+		b.WriteString(fmt.Sprintf("%-36s | %7d | %12s | %s\n",
+			truncateString(call.Name, 36),
+			call.Calls,
+			call.Total.Round(time.Microsecond),
+			perCall.Round(time.Microsecond)))
+	}
+	return b.String()
+}
@@ -0,0 +1,105 @@
+package scripts
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Progress describes how far a download has got
+type Progress struct {
+	File       string // File currently downloading
+	Bytes      int64  // Bytes of File received so far
+	TotalBytes int64  // Size of File, or -1 if unknown
+	FilesDone  int    // Files finished, for multi-file downloads
+	FilesTotal int    // Files in the whole download
+}
+
+// Percent returns overall completion, counting the current file's bytes when its size is known
+func (p Progress) Percent() int {
+	if p.FilesTotal == 0 {
+		return 0
+	}
+	done := float64(p.FilesDone)
+	if p.TotalBytes > 0 && p.Bytes < p.TotalBytes {
+		done += float64(p.Bytes) / float64(p.TotalBytes)
+	}
+	return int(done * 100 / float64(p.FilesTotal))
+}
+
+// String formats progress as a single status line
+func (p Progress) String() string {
+	if p.FilesTotal > 1 {
+		return fmt.Sprintf("%3d%% (%d/%d files) %s", p.Percent(), p.FilesDone, p.FilesTotal, p.File)
+	}
+	if p.TotalBytes > 0 {
+		return fmt.Sprintf("%3d%% %s (%s of %s)", p.Percent(), p.File, formatFileSize(int(p.Bytes)), formatFileSize(int(p.TotalBytes)))
+	}
+	return fmt.Sprintf("%s (%s)", p.File, formatFileSize(int(p.Bytes)))
+}
+
+// ProgressFunc receives progress updates; it may be called from several goroutines
+type ProgressFunc func(Progress)
+
+// StderrProgress writes progress lines to stderr, which the agent host records in its
+// log while a long download runs. Tool results themselves can't be streamed.
+func StderrProgress(p Progress) {
+	fmt.Fprintf(os.Stderr, "[ori-reaper] downloading %s\n", p)
+}
+
+// progressReportStep is the percentage change between byte-level progress reports
+const progressReportStep = 10
+
+// progressReader reports bytes read from a download body
+type progressReader struct {
+	reader   io.Reader
+	progress Progress
+	report   ProgressFunc
+	last     int
+}
+
+// newProgressReader wraps r so reads are reported to report every progressReportStep percent
+func newProgressReader(r io.Reader, file string, size int64, report ProgressFunc) io.Reader {
+	if report == nil {
+		return r
+	}
+	return &progressReader{
+		reader:   r,
+		progress: Progress{File: file, TotalBytes: size, FilesTotal: 1},
+		report:   report,
+		last:     -progressReportStep,
+	}
+}
+
+func (pr *progressReader) Read(p []byte) (int, error) {
+	n, err := pr.reader.Read(p)
+	pr.progress.Bytes += int64(n)
+	if pr.progress.TotalBytes > 0 {
+		if percent := pr.progress.Percent(); percent >= pr.last+progressReportStep || err == io.EOF {
+			pr.last = percent
+			pr.report(pr.progress)
+		}
+	}
+	return n, err
+}
+
+// fileCounter reports per-file completion of a multi-file download
+type fileCounter struct {
+	mu     sync.Mutex
+	done   int
+	total  int
+	report ProgressFunc
+}
+
+// finished records that file has completed
+func (fc *fileCounter) finished(file string) {
+	if fc.report == nil {
+		return
+	}
+	fc.mu.Lock()
+	fc.done++
+	p := Progress{File: file, FilesDone: fc.done, FilesTotal: fc.total}
+	fc.mu.Unlock()
+	fc.report(p)
+}
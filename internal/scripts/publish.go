@@ -0,0 +1,347 @@
+package scripts
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultGitHubAPI is the API of github.com; GitHub Enterprise servers use their own
+const DefaultGitHubAPI = "https://api.github.com"
+
+// repositoryPattern matches an "owner/name" repository
+var repositoryPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+/[A-Za-z0-9_.-]+$`)
+
+// PublishOptions is the GitHub repository scripts are published to. Message is a template
+// where {script} is the file name and {action} is "Add" or "Update".
+type PublishOptions struct {
+	Repository  string // "owner/name"
+	Token       string // Token with write access to the repository's contents (and pull requests)
+	Branch      string // Branch to publish to, or to open pull requests against; empty uses the default branch
+	Directory   string // Folder in the repository holding scripts
+	APIURL      string
+	Message     string
+	PullRequest bool // Publish on a new branch and open a pull request instead of committing to Branch
+}
+
+// withDefaults fills unset fields
+func (o PublishOptions) withDefaults() PublishOptions {
+	if strings.TrimSpace(o.Directory) == "" {
+		o.Directory = "reascripts"
+	}
+	if strings.TrimSpace(o.APIURL) == "" {
+		o.APIURL = DefaultGitHubAPI
+	}
+	if strings.TrimSpace(o.Message) == "" {
+		o.Message = "{action} {script}"
+	}
+	o.Directory = strings.Trim(strings.ReplaceAll(o.Directory, "\\", "/"), "/")
+	o.APIURL = strings.TrimRight(o.APIURL, "/")
+	return o
+}
+
+// PublishResult describes a script published to GitHub
+type PublishResult struct {
+	File        string `json:"file"`
+	Repository  string `json:"repository"`
+	Path        string `json:"path"`   // Path of the script in the repository
+	Branch      string `json:"branch"` // Branch the commit was made on
+	Created     bool   `json:"created"`
+	Unchanged   bool   `json:"unchanged,omitempty"` // The repository already had this content; nothing was committed
+	Commit      string `json:"commit,omitempty"`
+	URL         string `json:"url,omitempty"`          // The script on GitHub
+	PullRequest string `json:"pull_request,omitempty"` // URL of the pull request opened, if any
+}
+
+// githubError is the error body of the GitHub API
+type githubError struct {
+	Message string `json:"message"`
+}
+
+// PublishScript commits a script from the scripts directory to the GitHub repository in
+// opts, overwriting the file there. message overrides the commit message template. With
+// opts.PullRequest, the commit goes on a new branch and a pull request is opened.
+func (sm *ScriptManager) PublishScript(ctx context.Context, opts PublishOptions, script, message string) (*PublishResult, error) {
+	opts = opts.withDefaults()
+	if !repositoryPattern.MatchString(opts.Repository) {
+		return nil, errors.New("no repository to publish to: set script_publish.repository (\"owner/name\") in the plugin settings")
+	}
+	if strings.TrimSpace(opts.Token) == "" {
+		return nil, errors.New("no GitHub token: set script_publish.token in the plugin settings or ORI_REAPER_SCRIPT_PUBLISH_TOKEN in the environment")
+	}
+
+	file, err := sm.findScriptFile(script)
+	if err != nil {
+		return nil, err
+	}
+	scriptPath, err := sm.scriptFilePath(file)
+	if err != nil {
+		return nil, err
+	}
+	content, err := sm.backend.FS.ReadFile(scriptPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read script %s: %w", file, err)
+	}
+
+	gh := &githubClient{api: opts.APIURL, repo: opts.Repository, token: opts.Token}
+	base := opts.Branch
+	if base == "" {
+		if base, err = gh.defaultBranch(ctx); err != nil {
+			return nil, err
+		}
+	}
+	result := &PublishResult{File: file, Repository: opts.Repository, Path: path.Join(opts.Directory, file), Branch: base}
+
+	existing, err := gh.fileSHA(ctx, result.Path, base)
+	if err != nil {
+		return nil, err
+	}
+	result.Created = existing == ""
+	if existing == gitBlobSHA(content) {
+		result.Unchanged = true
+		return result, nil
+	}
+
+	action := "Update"
+	if result.Created {
+		action = "Add"
+	}
+	if strings.TrimSpace(message) == "" {
+		message = strings.NewReplacer("{script}", file, "{action}", action).Replace(opts.Message)
+	}
+
+	if opts.PullRequest {
+		result.Branch = fmt.Sprintf("publish/%s-%s", strings.TrimSuffix(file, path.Ext(file)), time.Now().Format("20060102-150405"))
+		if err := gh.createBranch(ctx, result.Branch, base); err != nil {
+			return nil, err
+		}
+	}
+	if result.Commit, result.URL, err = gh.putFile(ctx, result.Path, result.Branch, message, content, existing); err != nil {
+		return nil, err
+	}
+	if opts.PullRequest {
+		body := fmt.Sprintf("%s `%s` from the REAPER scripts directory.", action, file)
+		if result.PullRequest, err = gh.openPullRequest(ctx, message, body, result.Branch, base); err != nil {
+			return result, fmt.Errorf("%s was committed to branch %s, but the pull request failed: %w", file, result.Branch, err)
+		}
+	}
+	return result, nil
+}
+
+// findScriptFile finds the file name of a script by file name, base name, or the
+// partial and fuzzy names ResolveScript accepts
+func (sm *ScriptManager) findScriptFile(script string) (string, error) {
+	metadata, err := sm.LoadMetadata()
+	if err != nil {
+		return "", err
+	}
+	if file, err := sm.resolveScriptFile(metadata, script); err == nil {
+		return file, nil
+	}
+	resolved, err := sm.ResolveScript(script)
+	if err != nil {
+		return "", err
+	}
+	return resolved + ".lua", nil
+}
+
+// gitBlobSHA is the SHA git (and the GitHub contents API) gives a file with content
+func gitBlobSHA(content []byte) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "blob %d\x00", len(content))
+	h.Write(content)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// githubClient calls the GitHub REST API for one repository with a token
+type githubClient struct {
+	api   string
+	repo  string
+	token string
+}
+
+// do sends a request with an optional JSON body and decodes a JSON response into out.
+// It returns the status code, and an error for statuses other than 2xx and allowed ones.
+func (gh *githubClient) do(ctx context.Context, method, endpoint string, body, out interface{}, allowed ...int) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, gh.api+"/repos/"+gh.repo+endpoint, reader)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Authorization", "Bearer "+gh.token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	httpMu.RLock()
+	timeout := httpOptions.DownloadTimeout
+	httpMu.RUnlock()
+	resp, err := newHTTPClient(timeout).Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("GitHub request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	for _, status := range allowed {
+		if resp.StatusCode == status {
+			return status, nil
+		}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var ghErr githubError
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		if json.Unmarshal(data, &ghErr) != nil || ghErr.Message == "" {
+			ghErr.Message = strings.TrimSpace(string(data))
+		}
+		switch resp.StatusCode {
+		case http.StatusUnauthorized:
+			return resp.StatusCode, fmt.Errorf("GitHub rejected the token (%s); check script_publish.token", ghErr.Message)
+		case http.StatusForbidden, http.StatusNotFound:
+			return resp.StatusCode, fmt.Errorf("GitHub returned status %d for %s (%s); check that the repository exists and the token can write to it",
+				resp.StatusCode, gh.repo, ghErr.Message)
+		}
+		return resp.StatusCode, fmt.Errorf("GitHub API returned status %d: %s", resp.StatusCode, ghErr.Message)
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp.StatusCode, fmt.Errorf("failed to parse GitHub API response: %w", err)
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+// defaultBranch returns the repository's default branch
+func (gh *githubClient) defaultBranch(ctx context.Context) (string, error) {
+	var repo struct {
+		DefaultBranch string `json:"default_branch"`
+	}
+	if _, err := gh.do(ctx, http.MethodGet, "", nil, &repo); err != nil {
+		return "", err
+	}
+	if repo.DefaultBranch == "" {
+		return "", fmt.Errorf("repository %s has no default branch; set script_publish.branch", gh.repo)
+	}
+	return repo.DefaultBranch, nil
+}
+
+// fileSHA returns the blob SHA of a file on branch, or "" if it doesn't exist
+func (gh *githubClient) fileSHA(ctx context.Context, file, branch string) (string, error) {
+	var existing GitHubFile
+	status, err := gh.do(ctx, http.MethodGet, "/contents/"+escapePath(file)+"?ref="+url.QueryEscape(branch), nil, &existing, http.StatusNotFound)
+	if err != nil || status == http.StatusNotFound {
+		return "", err
+	}
+	if existing.Type != "file" {
+		return "", fmt.Errorf("%s in %s is a %s, not a file", file, gh.repo, existing.Type)
+	}
+	return existing.SHA, nil
+}
+
+// createBranch creates branch at the head of base
+func (gh *githubClient) createBranch(ctx context.Context, branch, base string) error {
+	var ref struct {
+		Object struct {
+			SHA string `json:"sha"`
+		} `json:"object"`
+	}
+	if _, err := gh.do(ctx, http.MethodGet, "/git/ref/heads/"+escapePath(base), nil, &ref); err != nil {
+		return fmt.Errorf("failed to read branch %s: %w", base, err)
+	}
+	body := map[string]string{"ref": "refs/heads/" + branch, "sha": ref.Object.SHA}
+	if _, err := gh.do(ctx, http.MethodPost, "/git/refs", body, nil); err != nil {
+		return fmt.Errorf("failed to create branch %s: %w", branch, err)
+	}
+	return nil
+}
+
+// putFile creates or replaces file on branch, returning the commit SHA and the file's URL.
+// sha is the blob being replaced, empty for a new file.
+func (gh *githubClient) putFile(ctx context.Context, file, branch, message string, content []byte, sha string) (string, string, error) {
+	body := map[string]string{
+		"message": message,
+		"content": base64.StdEncoding.EncodeToString(content),
+		"branch":  branch,
+	}
+	if sha != "" {
+		body["sha"] = sha
+	}
+	var result struct {
+		Content GitHubFile `json:"content"`
+		Commit  struct {
+			SHA string `json:"sha"`
+		} `json:"commit"`
+	}
+	status, err := gh.do(ctx, http.MethodPut, "/contents/"+escapePath(file), body, &result, http.StatusConflict)
+	if status == http.StatusConflict {
+		return "", "", fmt.Errorf("%s changed on branch %s while publishing; try again", file, branch)
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to commit %s: %w", file, err)
+	}
+	return result.Commit.SHA, result.Content.HTMLURL, nil
+}
+
+// openPullRequest opens a pull request from head into base and returns its URL
+func (gh *githubClient) openPullRequest(ctx context.Context, title, body, head, base string) (string, error) {
+	var pr struct {
+		HTMLURL string `json:"html_url"`
+	}
+	request := map[string]string{"title": title, "body": body, "head": head, "base": base}
+	if _, err := gh.do(ctx, http.MethodPost, "/pulls", request, &pr); err != nil {
+		return "", err
+	}
+	return pr.HTMLURL, nil
+}
+
+// escapePath escapes each segment of a repository path for use in a URL
+func escapePath(p string) string {
+	segments := strings.Split(p, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// FormatPublishResult formats a published script as readable text
+func FormatPublishResult(result *PublishResult) string {
+	if result.Unchanged {
+		return fmt.Sprintf("%s is already up to date in %s (%s on %s); nothing to publish", result.File, result.Repository, result.Path, result.Branch)
+	}
+	verb := "Updated"
+	if result.Created {
+		verb = "Added"
+	}
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("📤 %s %s in %s on branch %s", verb, result.Path, result.Repository, result.Branch))
+	if len(result.Commit) >= 7 {
+		b.WriteString(fmt.Sprintf(" (commit %s)", result.Commit[:7]))
+	}
+	if result.URL != "" {
+		b.WriteString("\n" + result.URL)
+	}
+	if result.PullRequest != "" {
+		b.WriteString("\n\nOpened pull request: " + result.PullRequest)
+	}
+	return b.String()
+}
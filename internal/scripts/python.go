@@ -0,0 +1,85 @@
+package scripts
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// reaper.ini keys for the ReaScript Python settings (Preferences > Plug-ins > ReaScript)
+const (
+	pythonLibPathKey = "pythonlibpath64" // Custom directory to search for the Python library
+	pythonLibDLLKey  = "pythonlibdll64"  // Specific Python library file to load
+)
+
+// PythonStatus describes whether REAPER is likely able to run .py ReaScripts
+type PythonStatus struct {
+	CanRun        bool   `json:"can_run"`
+	Interpreter   string `json:"interpreter,omitempty"`     // Python found on the system PATH
+	Version       string `json:"version,omitempty"`         // Version reported by that interpreter
+	CustomLibPath string `json:"custom_lib_path,omitempty"` // pythonlibpath64 from reaper.ini
+	CustomLibDLL  string `json:"custom_lib_dll,omitempty"`  // pythonlibdll64 from reaper.ini
+	Reason        string `json:"reason,omitempty"`          // Why .py scripts probably won't run
+}
+
+// GetPythonStatus checks reaper.ini and the system for a usable Python installation.
+// REAPER only loads Python when "Enable Python for use with ReaScript" is checked, which
+// can't be read reliably from outside REAPER, so CanRun is a best-effort answer.
+func GetPythonStatus() *PythonStatus {
+	status := &PythonStatus{}
+
+	if value, found, err := GetReaperIniValue("REAPER", pythonLibPathKey); err == nil && found {
+		status.CustomLibPath = strings.TrimSpace(value)
+	}
+	if value, found, err := GetReaperIniValue("REAPER", pythonLibDLLKey); err == nil && found {
+		status.CustomLibDLL = strings.TrimSpace(value)
+	}
+
+	for _, name := range []string{"python3", "python"} {
+		path, err := exec.LookPath(name)
+		if err != nil {
+			continue
+		}
+		output, err := exec.Command(path, "--version").CombinedOutput()
+		if err != nil {
+			continue
+		}
+		status.Interpreter = path
+		status.Version = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(string(output)), "Python"))
+		break
+	}
+
+	// A forced library that doesn't exist stops REAPER from loading Python at all
+	if status.CustomLibDLL != "" {
+		dll := status.CustomLibDLL
+		if !filepath.IsAbs(dll) && status.CustomLibPath != "" {
+			dll = filepath.Join(status.CustomLibPath, dll)
+		}
+		if filepath.IsAbs(dll) {
+			if _, err := os.Stat(dll); err != nil {
+				status.Reason = "reaper.ini forces Python library " + dll + ", which does not exist"
+				return status
+			}
+			status.CanRun = true
+			return status
+		}
+	}
+
+	if status.Interpreter == "" {
+		status.Reason = "no Python installation found; install Python 3 and enable it in REAPER Preferences > Plug-ins > ReaScript"
+		return status
+	}
+
+	status.CanRun = true
+	return status
+}
+
+// pythonWarning returns a warning for .py scripts when Python doesn't look usable, or ""
+func pythonWarning() string {
+	status := GetPythonStatus()
+	if status.CanRun {
+		return ""
+	}
+	return "⚠️ REAPER may not be able to run Python scripts: " + status.Reason + ". REAPER ignores .py scripts silently when Python isn't available."
+}
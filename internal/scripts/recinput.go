@@ -0,0 +1,316 @@
+package scripts
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/bridge"
+)
+
+// I_RECINPUT bits
+const (
+	recInputStereo       = 1024
+	recInputMultichannel = 2048
+	recInputMIDI         = 4096
+	recInputMIDIAll      = 63 // Device value meaning all MIDI inputs
+	recInputMIDIVKB      = 62 // Device value meaning the virtual MIDI keyboard
+)
+
+// MonitorModes lists record monitoring modes in REAPER's I_RECMON order
+var MonitorModes = []string{"off", "on", "tape"}
+
+// RecordInput is a track's record input
+type RecordInput struct {
+	Type         string `json:"type"`                   // "none", "audio" or "midi"
+	Channel      int    `json:"channel,omitempty"`      // Audio: first hardware input (1-based). MIDI: channel 1-16, 0 for all
+	Stereo       bool   `json:"stereo,omitempty"`       // Audio: a stereo pair starting at Channel
+	Multichannel bool   `json:"multichannel,omitempty"` // Audio: as many inputs as the track has channels
+	Device       string `json:"device,omitempty"`       // MIDI: device number (0-based, as in REAPER's preferences), "all" or "vkb"
+	Name         string `json:"name,omitempty"`         // Input channel or MIDI device name reported by REAPER
+}
+
+// TrackRecordSettings is the record input, monitoring mode and arm state of a track
+type TrackRecordSettings struct {
+	Index      int         `json:"index"` // Track index (1-based)
+	Name       string      `json:"name"`
+	Input      RecordInput `json:"input"`
+	Monitoring string      `json:"monitoring"` // One of MonitorModes
+	Armed      bool        `json:"armed"`
+}
+
+// String returns the input in the syntax ParseRecordInput accepts
+func (in RecordInput) String() string {
+	switch in.Type {
+	case "audio":
+		switch {
+		case in.Multichannel:
+			return fmt.Sprintf("%d+ (multichannel)", in.Channel)
+		case in.Stereo:
+			return fmt.Sprintf("%d-%d", in.Channel, in.Channel+1)
+		default:
+			return strconv.Itoa(in.Channel)
+		}
+	case "midi":
+		s := "midi:" + in.Device
+		if in.Channel > 0 {
+			s += fmt.Sprintf(":%d", in.Channel)
+		}
+		return s
+	default:
+		return "none"
+	}
+}
+
+// ParseRecordInput parses a record input: "none", a hardware input such as "2", a stereo
+// pair such as "1-2", or MIDI as "midi", "midi:<device|all|vkb>" or "midi:<device>:<channel>"
+func ParseRecordInput(input string) (RecordInput, error) {
+	s := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(input), " ", ""))
+	invalid := fmt.Errorf("unsupported record input: %q. Use none, an input number (e.g. 2), a stereo pair (e.g. 1-2) or midi[:device|all|vkb[:channel]]", input)
+
+	switch {
+	case s == "none" || s == "off":
+		return RecordInput{Type: "none"}, nil
+	case s == "vkb":
+		return RecordInput{Type: "midi", Device: "vkb"}, nil
+	case strings.HasPrefix(s, "midi"):
+		parts := strings.Split(strings.TrimPrefix(strings.TrimPrefix(s, "midi"), ":"), ":")
+		in := RecordInput{Type: "midi", Device: "all"}
+		if parts[0] != "" {
+			in.Device = parts[0]
+			if n, err := strconv.Atoi(parts[0]); (err != nil || n < 0 || n >= recInputMIDIVKB) && in.Device != "all" && in.Device != "vkb" {
+				return RecordInput{}, invalid
+			}
+		}
+		if len(parts) > 1 {
+			channel, err := strconv.Atoi(strings.TrimPrefix(parts[1], "ch"))
+			if err != nil || channel < 0 || channel > 16 || len(parts) > 2 {
+				return RecordInput{}, fmt.Errorf("MIDI channel must be 1-16, or 0 for all: %q", input)
+			}
+			in.Channel = channel
+		}
+		return in, nil
+	}
+
+	first, second, pair := strings.Cut(strings.TrimPrefix(strings.TrimPrefix(s, "input"), "in"), "-")
+	channel, err := strconv.Atoi(first)
+	if err != nil || channel < 1 || channel > 512 {
+		return RecordInput{}, invalid
+	}
+	in := RecordInput{Type: "audio", Channel: channel}
+	if pair {
+		if next, err := strconv.Atoi(second); err != nil || next != channel+1 {
+			return RecordInput{}, fmt.Errorf("a stereo input must be two adjacent inputs such as 1-2, got %q", input)
+		}
+		in.Stereo = true
+	}
+	return in, nil
+}
+
+// recInputValue encodes in as REAPER's I_RECINPUT value
+func recInputValue(in RecordInput) int {
+	switch in.Type {
+	case "audio":
+		value := in.Channel - 1
+		if in.Multichannel {
+			value |= recInputMultichannel
+		} else if in.Stereo {
+			value |= recInputStereo
+		}
+		return value
+	case "midi":
+		device := recInputMIDIAll
+		switch in.Device {
+		case "vkb":
+			device = recInputMIDIVKB
+		case "all", "":
+		default:
+			device, _ = strconv.Atoi(in.Device)
+		}
+		return recInputMIDI | device<<5 | in.Channel
+	default:
+		return -1
+	}
+}
+
+// recordInputFromValue decodes an I_RECINPUT value
+func recordInputFromValue(value int) RecordInput {
+	switch {
+	case value < 0:
+		return RecordInput{Type: "none"}
+	case value&recInputMIDI != 0:
+		in := RecordInput{Type: "midi", Channel: value & 31}
+		switch device := (value >> 5) & 63; device {
+		case recInputMIDIAll:
+			in.Device = "all"
+		case recInputMIDIVKB:
+			in.Device = "vkb"
+		default:
+			in.Device = strconv.Itoa(device)
+		}
+		return in
+	default:
+		return RecordInput{
+			Type:         "audio",
+			Channel:      value&1023 + 1,
+			Stereo:       value&recInputStereo != 0,
+			Multichannel: value&recInputMultichannel != 0,
+		}
+	}
+}
+
+// parseMonitorMode converts a monitoring mode name to REAPER's I_RECMON value
+func parseMonitorMode(mode string) (int, error) {
+	switch strings.ReplaceAll(strings.ToLower(strings.TrimSpace(mode)), " ", "_") {
+	case "off", "false":
+		return 0, nil
+	case "on", "normal", "true":
+		return 1, nil
+	case "tape", "auto", "not_when_playing":
+		return 2, nil
+	}
+	return 0, fmt.Errorf("unsupported monitoring mode: %s. Valid modes: off, on, tape (not when playing)", mode)
+}
+
+// monitorModeName converts an I_RECMON value to its mode name
+func monitorModeName(value int) string {
+	if value >= 0 && value < len(MonitorModes) {
+		return MonitorModes[value]
+	}
+	return "unknown"
+}
+
+// GetRecordSettings reads the record input, monitoring and arm state of a track (1-based
+// index) via the Lua bridge. A track index of 0 reads every track.
+func GetRecordSettings(ctx context.Context, trackIndex int) ([]TrackRecordSettings, error) {
+	if trackIndex < 0 {
+		return nil, fmt.Errorf("track index must be 0 (all tracks) or greater")
+	}
+
+	rows, err := bridge.Run(ctx, "get_record_inputs", fmt.Sprintf(`local wanted = %d
+local first, last = 0, reaper.CountTracks(0) - 1
+if wanted > 0 then
+    if wanted - 1 > last then
+        return fail("track " .. wanted .. " not found")
+    end
+    first, last = wanted - 1, wanted - 1
+end
+
+for i = first, last do
+    local track = reaper.GetTrack(0, i)
+    local _, name = reaper.GetTrackName(track)
+    local input = math.floor(reaper.GetMediaTrackInfo_Value(track, "I_RECINPUT"))
+    local input_name = ""
+    if input >= 4096 then
+        local device = (input >> 5) & 63
+        if device < 62 then
+            local _, device_name = reaper.GetMIDIInputName(device, "")
+            input_name = device_name or ""
+        end
+    elseif input >= 0 then
+        input_name = reaper.GetInputChannelName(input & 1023) or ""
+    end
+    out(i + 1, name, input, math.floor(reaper.GetMediaTrackInfo_Value(track, "I_RECMON")),
+        math.floor(reaper.GetMediaTrackInfo_Value(track, "I_RECARM")), input_name)
+end
+`, trackIndex))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read record inputs: %w", err)
+	}
+
+	settings := make([]TrackRecordSettings, 0, len(rows))
+	for _, row := range rows {
+		if len(row) < 5 {
+			continue
+		}
+		index, _ := strconv.Atoi(row[0])
+		input, _ := strconv.Atoi(row[2])
+		monitor, _ := strconv.Atoi(row[3])
+		track := TrackRecordSettings{
+			Index:      index,
+			Name:       row[1],
+			Input:      recordInputFromValue(input),
+			Monitoring: monitorModeName(monitor),
+			Armed:      row[4] == "1",
+		}
+		if len(row) > 5 {
+			track.Input.Name = row[5]
+		}
+		settings = append(settings, track)
+	}
+	return settings, nil
+}
+
+// RecordChange describes the record settings to change on a track; nil fields are left alone
+type RecordChange struct {
+	Input      *RecordInput
+	Monitoring string // Empty leaves monitoring unchanged
+	Arm        *bool
+}
+
+// SetRecordSettings changes the record input, monitoring and arm state of a track (1-based
+// index) via the Lua bridge, as one undo step
+func SetRecordSettings(ctx context.Context, trackIndex int, change RecordChange) error {
+	if trackIndex < 1 {
+		return fmt.Errorf("track index must be 1 or greater")
+	}
+
+	var body strings.Builder
+	if change.Input != nil {
+		fmt.Fprintf(&body, "reaper.SetMediaTrackInfo_Value(track, \"I_RECINPUT\", %d)\n", recInputValue(*change.Input))
+	}
+	if change.Monitoring != "" {
+		mode, err := parseMonitorMode(change.Monitoring)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&body, "reaper.SetMediaTrackInfo_Value(track, \"I_RECMON\", %d)\n", mode)
+	}
+	if change.Arm != nil {
+		arm := 0
+		if *change.Arm {
+			arm = 1
+		}
+		fmt.Fprintf(&body, "reaper.SetMediaTrackInfo_Value(track, \"I_RECARM\", %d)\n", arm)
+	}
+	if body.Len() == 0 {
+		return fmt.Errorf("nothing to change: set input, monitoring or arm")
+	}
+
+	_, err := bridge.Run(ctx, "set_record_input", fmt.Sprintf(`local track = reaper.GetTrack(0, %d)
+if not track then
+    return fail("track %d not found")
+end
+reaper.Undo_BeginBlock()
+%sreaper.Undo_EndBlock("Ori: Set track record input", -1)
+`, trackIndex-1, trackIndex, body.String()))
+	if err != nil {
+		return fmt.Errorf("failed to set record input: %w", err)
+	}
+	return nil
+}
+
+// FormatRecordSettings formats track record settings as a readable table
+func FormatRecordSettings(settings []TrackRecordSettings) string {
+	if len(settings) == 0 {
+		return "No tracks found"
+	}
+
+	var result strings.Builder
+	result.WriteString("Index | Name                    | Input                | Monitoring | Armed\n")
+	result.WriteString("------|-------------------------|----------------------|------------|------\n")
+	for _, track := range settings {
+		input := track.Input.String()
+		if track.Input.Name != "" {
+			input += " (" + track.Input.Name + ")"
+		}
+		armed := ""
+		if track.Armed {
+			armed = "yes"
+		}
+		result.WriteString(fmt.Sprintf("%-5d | %-23s | %-20s | %-10s | %s\n",
+			track.Index, truncateString(track.Name, 23), truncateString(input, 20), track.Monitoring, armed))
+	}
+	return result.String()
+}
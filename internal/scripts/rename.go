@@ -0,0 +1,190 @@
+package scripts
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/bridge"
+)
+
+// numberToken matches {n} and {n:width} in a rename template
+var numberToken = regexp.MustCompile(`\{n(?::(\d+))?\}`)
+
+// RenameRule describes a batch rename. Tracks are picked by Tracks if set, otherwise by
+// Pattern across all tracks if set, otherwise the selected tracks are renamed.
+type RenameRule struct {
+	Tracks      []int  // Track numbers (1-based) to rename
+	Pattern     string // Regular expression a name must match; with Replacement, the part replaced
+	Replacement string // New name, or replacement for Pattern's match. {name} is the old name, {n} or {n:2} a running number, $1 a regex group
+	Prefix      string // Added in front of the name unless it's already there
+	Suffix      string // Added after the name unless it's already there
+	Start       int    // First number for {n}; defaults to 1
+}
+
+// TrackRename is one planned or applied track name change
+type TrackRename struct {
+	Index   int    `json:"index"` // Track index (1-based)
+	OldName string `json:"old_name"`
+	NewName string `json:"new_name"`
+}
+
+// trackName is a track's raw name and selection state as read by readTrackNames
+type trackName struct {
+	index    int
+	name     string
+	selected bool
+}
+
+// readTrackNames reads every track's name (empty for unnamed tracks) and selection via the Lua bridge
+func readTrackNames(ctx context.Context) ([]trackName, error) {
+	rows, err := bridge.Run(ctx, "get_track_names", `for i = 0, reaper.CountTracks(0) - 1 do
+    local track = reaper.GetTrack(0, i)
+    local _, name = reaper.GetSetMediaTrackInfo_String(track, "P_NAME", "", false)
+    out(i + 1, name, reaper.IsTrackSelected(track) and 1 or 0)
+end
+`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read track names: %w", err)
+	}
+
+	tracks := make([]trackName, 0, len(rows))
+	for _, row := range rows {
+		if len(row) < 3 {
+			continue
+		}
+		index, _ := strconv.Atoi(row[0])
+		tracks = append(tracks, trackName{index: index, name: row[1], selected: row[2] == "1"})
+	}
+	return tracks, nil
+}
+
+// PlanRenames works out the new names rule gives tracks, leaving out unchanged names
+func PlanRenames(ctx context.Context, rule RenameRule) ([]TrackRename, error) {
+	if rule.Replacement == "" && rule.Prefix == "" && rule.Suffix == "" {
+		return nil, fmt.Errorf("nothing to rename: set replacement, prefix or suffix")
+	}
+	var pattern *regexp.Regexp
+	if rule.Pattern != "" {
+		var err error
+		if pattern, err = regexp.Compile(rule.Pattern); err != nil {
+			return nil, fmt.Errorf("invalid pattern: %w", err)
+		}
+	}
+
+	tracks, err := readTrackNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+	wanted := make(map[int]bool, len(rule.Tracks))
+	for _, index := range rule.Tracks {
+		if index < 1 || index > len(tracks) {
+			return nil, fmt.Errorf("no track with number %d in the project; use 'get_tracks' to see the track numbers", index)
+		}
+		wanted[index] = true
+	}
+	if len(wanted) == 0 && pattern == nil && !hasSelection(tracks) {
+		return nil, fmt.Errorf("no tracks are selected; select tracks first or pass 'tracks' or 'pattern'")
+	}
+
+	number := rule.Start
+	if number == 0 {
+		number = 1
+	}
+	var renames []TrackRename
+	for _, track := range tracks {
+		switch {
+		case len(wanted) > 0 && !wanted[track.index]:
+			continue
+		case len(wanted) == 0 && pattern == nil && !track.selected:
+			continue
+		case pattern != nil && !pattern.MatchString(track.name):
+			continue
+		}
+
+		name := applyRenameRule(rule, pattern, track.name, number)
+		number++
+		if name != track.name {
+			renames = append(renames, TrackRename{Index: track.index, OldName: track.name, NewName: name})
+		}
+	}
+	return renames, nil
+}
+
+// applyRenameRule returns the new name of a track called name, numbered number
+func applyRenameRule(rule RenameRule, pattern *regexp.Regexp, name string, number int) string {
+	if rule.Replacement != "" {
+		template := expandNumber(rule.Replacement, number)
+		if pattern != nil {
+			// {name} goes through regex expansion, so escape its $ signs
+			name = pattern.ReplaceAllString(name, strings.ReplaceAll(template, "{name}", strings.ReplaceAll(name, "$", "$$")))
+		} else {
+			name = strings.ReplaceAll(template, "{name}", name)
+		}
+	}
+	if prefix := expandNumber(rule.Prefix, number); !strings.HasPrefix(name, prefix) {
+		name = prefix + name
+	}
+	if suffix := expandNumber(rule.Suffix, number); !strings.HasSuffix(name, suffix) {
+		name += suffix
+	}
+	return name
+}
+
+// expandNumber replaces {n} and {n:width} in s with number, zero padded to width
+func expandNumber(s string, number int) string {
+	return numberToken.ReplaceAllStringFunc(s, func(token string) string {
+		width := 0
+		if match := numberToken.FindStringSubmatch(token); match[1] != "" {
+			width, _ = strconv.Atoi(match[1])
+		}
+		return fmt.Sprintf("%0*d", width, number)
+	})
+}
+
+// hasSelection reports whether any track is selected
+func hasSelection(tracks []trackName) bool {
+	for _, track := range tracks {
+		if track.selected {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyRenames renames tracks via a generated Lua script, as one undo step
+func ApplyRenames(ctx context.Context, renames []TrackRename) error {
+	if len(renames) == 0 {
+		return nil
+	}
+
+	var body strings.Builder
+	body.WriteString("reaper.Undo_BeginBlock()\n")
+	for _, rename := range renames {
+		fmt.Fprintf(&body, "reaper.GetSetMediaTrackInfo_String(reaper.GetTrack(0, %d), \"P_NAME\", %s, true)\n",
+			rename.Index-1, bridge.LuaString(rename.NewName))
+	}
+	fmt.Fprintf(&body, "reaper.Undo_EndBlock(\"Ori: Rename %d tracks\", -1)\n", len(renames))
+
+	if _, err := bridge.Run(ctx, "batch_rename_tracks", body.String()); err != nil {
+		return fmt.Errorf("failed to rename tracks: %w", err)
+	}
+	return nil
+}
+
+// FormatRenames formats planned or applied renames as a readable table
+func FormatRenames(renames []TrackRename) string {
+	var result strings.Builder
+	result.WriteString("Index | Old Name                | New Name\n")
+	result.WriteString("------|-------------------------|-------------------------\n")
+	for _, rename := range renames {
+		oldName := rename.OldName
+		if oldName == "" {
+			oldName = "(unnamed)"
+		}
+		result.WriteString(fmt.Sprintf("%-5d | %-23s | %s\n", rename.Index, truncateString(oldName, 23), rename.NewName))
+	}
+	return result.String()
+}
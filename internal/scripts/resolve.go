@@ -0,0 +1,154 @@
+package scripts
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/errcode"
+)
+
+// maxSuggestions caps the "did you mean" list when a script name doesn't match
+const maxSuggestions = 3
+
+// SetAliases sets user-defined alternative names for scripts (alias -> script base name)
+func (sm *ScriptManager) SetAliases(aliases map[string]string) {
+	sm.aliases = aliases
+}
+
+// scriptFilePath returns the path of a file in the scripts directory, rejecting names
+// that would lead outside it, such as "../other.lua", absolute paths, or on Windows
+// "C:other.lua" and reserved names like "CON". Backslashes and colons are rejected on
+// every platform, so a name that is only safe outside Windows can't reach a Windows
+// workstation through shared settings or a bundle.
+func (sm *ScriptManager) scriptFilePath(file string) (string, error) {
+	if !filepath.IsLocal(file) || strings.ContainsAny(file, `\:`) {
+		return "", fmt.Errorf("invalid script name %q: scripts must be inside the scripts directory", file)
+	}
+	return filepath.Join(sm.scriptsDir, file), nil
+}
+
+// normalizeScriptName folds case and treats spaces, hyphens and underscores alike,
+// so "normalize selected items" matches Normalize_Selected_Items
+func normalizeScriptName(name string) string {
+	name = strings.TrimSuffix(strings.TrimSpace(name), ".lua")
+	name = strings.ToLower(name)
+	return strings.Join(strings.FieldsFunc(name, func(r rune) bool {
+		return r == ' ' || r == '_' || r == '-'
+	}), "_")
+}
+
+// ResolveScript finds the .lua script a user means by name, returning its base name.
+// It tries, in order: the exact name, an alias, a case/separator-insensitive match, and a
+// unique partial match. On a miss the error suggests the closest names.
+func (sm *ScriptManager) ResolveScript(name string) (string, error) {
+	name = strings.TrimSuffix(strings.TrimSpace(name), ".lua")
+	if name == "" {
+		return "", errors.New("script name is required")
+	}
+	path, err := sm.scriptFilePath(name + ".lua")
+	if err != nil {
+		return "", err
+	}
+	if _, err := sm.backend.FS.Stat(path); err == nil {
+		return name, nil
+	}
+
+	for alias, target := range sm.aliases {
+		if normalizeScriptName(alias) == normalizeScriptName(name) {
+			target = strings.TrimSuffix(target, ".lua")
+			path, err := sm.scriptFilePath(target + ".lua")
+			if err != nil {
+				return "", fmt.Errorf("alias '%s': %w", alias, err)
+			}
+			if _, err := sm.backend.FS.Stat(path); err != nil {
+				return "", errcode.Errorf(errcode.ScriptNotFound, "alias '%s' points to '%s', which was not found", alias, target)
+			}
+			return target, nil
+		}
+	}
+
+	scripts, err := sm.luaScripts()
+	if err != nil {
+		return "", fmt.Errorf("failed to list scripts in %s: %w", sm.scriptsDir, err)
+	}
+
+	query := normalizeScriptName(name)
+	var partial []string
+	for _, script := range scripts {
+		normalized := normalizeScriptName(script)
+		if normalized == query {
+			return script, nil
+		}
+		if strings.Contains(normalized, query) {
+			partial = append(partial, script)
+		}
+	}
+	switch len(partial) {
+	case 1:
+		return partial[0], nil
+	case 0:
+	default:
+		sort.Strings(partial)
+		return "", fmt.Errorf("'%s' matches several scripts: %s", name, strings.Join(partial, ", "))
+	}
+
+	if disabled := sm.disabledScriptFile(name); disabled != "" {
+		return "", fmt.Errorf("script %s is disabled; use 'enable_script' to turn it back on", disabled)
+	}
+	if suggestions := closestScripts(query, scripts); len(suggestions) > 0 {
+		return "", errcode.Errorf(errcode.ScriptNotFound, "script not found: %s. Did you mean: %s?", name, strings.Join(suggestions, ", "))
+	}
+	return "", errcode.Errorf(errcode.ScriptNotFound, "script not found: %s", name)
+}
+
+// closestScripts returns up to maxSuggestions scripts within a small edit distance of query
+func closestScripts(query string, scripts []string) []string {
+	type candidate struct {
+		name     string
+		distance int
+	}
+	limit := len(query)/3 + 2
+	var candidates []candidate
+	for _, script := range scripts {
+		if d := levenshtein(query, normalizeScriptName(script)); d <= limit {
+			candidates = append(candidates, candidate{script, d})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return candidates[i].name < candidates[j].name
+	})
+
+	var names []string
+	for i := 0; i < len(candidates) && i < maxSuggestions; i++ {
+		names = append(names, candidates[i].name)
+	}
+	return names
+}
+
+// levenshtein returns the edit distance between a and b
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,57 @@
+package scripts
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestScriptFilePath(t *testing.T) {
+	dir := t.TempDir()
+	sm := NewScriptManager(dir)
+
+	tests := []struct {
+		name string
+		file string
+		want string // Path relative to the scripts directory, or "" when the name is rejected
+	}{
+		{"plain name", "Normalize.lua", "Normalize.lua"},
+		{"subfolder", "Utilities/Normalize.lua", "Utilities/Normalize.lua"},
+		{"dot dot that stays inside", "a/../Normalize.lua", "Normalize.lua"},
+		{"parent directory", "../../other", ""},
+		{"parent directory through a folder", "a/../../b", ""},
+		{"windows parent directory", `..\..\x`, ""},
+		{"windows absolute path", `C:\x`, ""},
+		{"windows forward slash absolute path", "C:/x.lua", ""},
+		{"drive relative path", "C:x.lua", ""},
+		{"drive letter inside a name", "scripts/D:evil.lua", ""},
+		{"UNC share", `\\server\share`, ""},
+		{"absolute path", "/etc/passwd", ""},
+		{"empty name", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := sm.scriptFilePath(tt.file)
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("scriptFilePath(%q) = %q, want an error", tt.file, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("scriptFilePath(%q) returned error: %v", tt.file, err)
+			}
+			if want := filepath.Join(dir, filepath.FromSlash(tt.want)); got != want {
+				t.Errorf("scriptFilePath(%q) = %q, want %q", tt.file, got, want)
+			}
+		})
+	}
+}
+
+func TestResolveScriptRejectsPathsOutsideScriptsDir(t *testing.T) {
+	sm := NewScriptManager(t.TempDir())
+	for _, name := range []string{"../../other", `..\..\other`, `C:\other`} {
+		if resolved, err := sm.ResolveScript(name); err == nil {
+			t.Errorf("ResolveScript(%q) = %q, want an error", name, resolved)
+		}
+	}
+}
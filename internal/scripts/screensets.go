@@ -0,0 +1,251 @@
+package scripts
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/bridge"
+)
+
+// maxScreensetSlot is the number of screenset slots REAPER offers for each kind
+const maxScreensetSlot = 10
+
+// ScreensetKinds lists the kinds of screenset: window layouts and track views
+var ScreensetKinds = []string{"window", "track_view"}
+
+// screensetLoadActions are REAPER's "Screenset: Load ..." action names, by kind. The slot
+// is appended as "#01" to "#10".
+var screensetLoadActions = map[string]string{
+	"window":     "Screenset: Load window set",
+	"track_view": "Screenset: Load track view",
+}
+
+// windowSetLoadAction is the command ID of "Screenset: Load window set #01"; the other
+// window sets follow it
+const windowSetLoadAction = 40454
+
+// screensetSectionPattern splits a reaper-screensets.ini section name into a prefix
+// naming the kind and the slot number it ends with
+var screensetSectionPattern = regexp.MustCompile(`^(.*?)[ _#-]*(\d+)$`)
+
+// Screenset is a saved window layout or track view
+type Screenset struct {
+	Kind string `json:"kind"` // One of ScreensetKinds
+	Slot int    `json:"slot"` // 1 to 10
+	Name string `json:"name,omitempty"`
+}
+
+// Label names a screenset for display, e.g. "Window set #3 (Mixing)"
+func (s Screenset) Label() string {
+	label := fmt.Sprintf("Window set #%d", s.Slot)
+	if s.Kind == "track_view" {
+		label = fmt.Sprintf("Track view #%d", s.Slot)
+	}
+	if s.Name != "" {
+		label += " (" + s.Name + ")"
+	}
+	return label
+}
+
+// GetReaperScreensetsPath returns the path of reaper-screensets.ini
+func GetReaperScreensetsPath() (string, error) {
+	basePath, err := GetReaperResourcePath()
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(basePath, "reaper-screensets.ini")
+	if _, err := configFS.Stat(path); os.IsNotExist(err) {
+		return "", fmt.Errorf("reaper-screensets.ini not found at %s (no screensets have been saved yet; use View > Screensets/Layouts in REAPER)", path)
+	}
+	return path, nil
+}
+
+// screensetKind maps a section name prefix to a screenset kind, or "" for sections that
+// aren't screensets
+func screensetKind(prefix string) string {
+	prefix = strings.ToLower(prefix)
+	switch {
+	case strings.Contains(prefix, "track") || strings.Contains(prefix, "view"):
+		return "track_view"
+	case strings.Contains(prefix, "layout"):
+		return "" // Theme layouts are saved with the theme, not recalled as screensets
+	case strings.Contains(prefix, "win") || strings.Contains(prefix, "screen"):
+		return "window"
+	}
+	return ""
+}
+
+// ListScreensets returns the screensets saved in reaper-screensets.ini, window sets first.
+// Each saved slot is a section whose name ends with its number; sections of REAPER
+// versions that name them differently are recognized by the words in the name.
+func ListScreensets() ([]Screenset, error) {
+	path, err := GetReaperScreensetsPath()
+	if err != nil {
+		return nil, err
+	}
+	file, err := configFS.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open reaper-screensets.ini: %w", err)
+	}
+	defer file.Close()
+
+	found := make(map[string]*Screenset)
+	var current *Screenset
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		trimmed := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			current = nil
+			match := screensetSectionPattern.FindStringSubmatch(trimmed[1 : len(trimmed)-1])
+			if match == nil {
+				continue
+			}
+			kind := screensetKind(match[1])
+			slot, _ := strconv.Atoi(match[2])
+			if kind == "" || slot < 1 || slot > maxScreensetSlot {
+				continue
+			}
+			id := kind + match[2]
+			if found[id] == nil {
+				found[id] = &Screenset{Kind: kind, Slot: slot}
+			}
+			current = found[id]
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		key, value, ok := strings.Cut(trimmed, "=")
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "name", "desc", "description":
+			if current.Name == "" {
+				current.Name = strings.TrimSpace(value)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading reaper-screensets.ini: %w", err)
+	}
+
+	screensets := make([]Screenset, 0, len(found))
+	for _, s := range found {
+		screensets = append(screensets, *s)
+	}
+	sort.Slice(screensets, func(i, j int) bool {
+		if screensets[i].Kind != screensets[j].Kind {
+			return screensets[i].Kind == "window"
+		}
+		return screensets[i].Slot < screensets[j].Slot
+	})
+	return screensets, nil
+}
+
+// FindScreenset finds a saved screenset of kind (default "window") by slot number or by
+// name (case-insensitive, a unique part is enough)
+func FindScreenset(screensets []Screenset, kind, query string) (Screenset, error) {
+	kind = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(kind)), " ", "_")
+	switch kind {
+	case "", "window", "windows", "window_set":
+		kind = "window"
+	case "track_view", "track_views", "track", "view":
+		kind = "track_view"
+	default:
+		return Screenset{}, fmt.Errorf("unsupported screenset kind: %s. Valid kinds: %s", kind, strings.Join(ScreensetKinds, ", "))
+	}
+	query = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(query), "#"))
+	if query == "" {
+		return Screenset{}, fmt.Errorf("name is required: the screenset's name or slot number (1-%d)", maxScreensetSlot)
+	}
+
+	if slot, err := strconv.Atoi(query); err == nil {
+		if slot < 1 || slot > maxScreensetSlot {
+			return Screenset{}, fmt.Errorf("screenset slot must be between 1 and %d, got %d", maxScreensetSlot, slot)
+		}
+		for _, s := range screensets {
+			if s.Kind == kind && s.Slot == slot {
+				return s, nil
+			}
+		}
+		// The slot may be saved in a way ListScreensets doesn't recognize; let REAPER decide
+		return Screenset{Kind: kind, Slot: slot}, nil
+	}
+
+	var matches []Screenset
+	for _, s := range screensets {
+		if s.Kind != kind || s.Name == "" {
+			continue
+		}
+		if strings.EqualFold(s.Name, query) {
+			return s, nil
+		}
+		if strings.Contains(strings.ToLower(s.Name), strings.ToLower(query)) {
+			matches = append(matches, s)
+		}
+	}
+	switch len(matches) {
+	case 1:
+		return matches[0], nil
+	case 0:
+		noun := "window set"
+		if kind == "track_view" {
+			noun = "track view"
+		}
+		return Screenset{}, fmt.Errorf("no saved %s named '%s'; use 'list_screensets' to see them", noun, query)
+	}
+	labels := make([]string, len(matches))
+	for i, s := range matches {
+		labels[i] = s.Label()
+	}
+	return Screenset{}, fmt.Errorf("'%s' matches several screensets: %s", query, strings.Join(labels, ", "))
+}
+
+// LoadScreenset recalls a screenset by running its "Screenset: Load ..." action via the
+// Lua bridge. The action is found by name, so it works across REAPER versions.
+func LoadScreenset(ctx context.Context, screenset Screenset) error {
+	action := fmt.Sprintf("%s #%02d", screensetLoadActions[screenset.Kind], screenset.Slot)
+	preferred := 0
+	if screenset.Kind == "window" {
+		preferred = windowSetLoadAction + screenset.Slot - 1
+	}
+	_, err := bridge.Run(ctx, "load_screenset", fmt.Sprintf(`local name = %s
+local id = %d
+if id == 0 or reaper.kbd_getTextFromCmd(id, 0) ~= name then
+    id = 0
+    for cmd = 40000, 42999 do
+        if reaper.kbd_getTextFromCmd(cmd, 0) == name then id = cmd break end
+    end
+end
+if id == 0 then return fail("action not found: " .. name) end
+reaper.Main_OnCommand(id, 0)
+`, bridge.LuaString(action), preferred))
+	if err != nil {
+		return fmt.Errorf("failed to load %s: %w", screenset.Label(), err)
+	}
+	return nil
+}
+
+// FormatScreensets formats the saved screensets as a list
+func FormatScreensets(screensets []Screenset) string {
+	if len(screensets) == 0 {
+		return "No screensets are saved. Save one in REAPER with View > Screensets/Layouts."
+	}
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%d saved screenset(s):\n\n", len(screensets)))
+	for _, s := range screensets {
+		b.WriteString("  - " + s.Label() + "\n")
+	}
+	b.WriteString("\nUse 'load_screenset' with name=<name or slot> to recall one.")
+	return b.String()
+}
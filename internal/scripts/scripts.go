@@ -1,22 +1,33 @@
 package scripts
 
 import (
-	"bufio"
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
-	"runtime"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/johnjallday/ori-reaper-plugin/internal/bridge"
+	"github.com/johnjallday/ori-reaper-plugin/internal/errcode"
+	"github.com/johnjallday/ori-reaper-plugin/internal/i18n"
 	"github.com/johnjallday/ori-reaper-plugin/internal/platform"
+	"github.com/johnjallday/ori-reaper-plugin/internal/progress"
 	"github.com/johnjallday/ori-reaper-plugin/internal/types"
 )
 
 // ListLuaScripts lists all .lua script files in the given directory
 func ListLuaScripts(dir string) ([]string, error) {
-	entries, err := os.ReadDir(dir)
+	return listLuaScripts(platform.OSFS{}, dir)
+}
+
+// listLuaScripts lists the .lua script files in dir on fsys
+func listLuaScripts(fsys platform.FS, dir string) ([]string, error) {
+	entries, err := fsys.ReadDir(dir)
 	if err != nil {
 		return nil, err
 	}
@@ -46,36 +57,179 @@ func ToTitleCase(s string) string {
 
 // ScriptManager handles script operations
 type ScriptManager struct {
-	scriptsDir string
+	scriptsDir     string
+	trashRetention time.Duration     // How long deleted scripts stay in the trash; 0 keeps them
+	aliases        map[string]string // User-defined alias -> script base name
+	backend        platform.Backend  // File system and REAPER process access
+	launchPolicy   LaunchPolicy      // Rate limit and debounce for RunScript
 }
 
 // NewScriptManager creates a new script manager with the given scripts directory
 func NewScriptManager(scriptsDir string) *ScriptManager {
-	return &ScriptManager{scriptsDir: scriptsDir}
+	return &ScriptManager{scriptsDir: scriptsDir, backend: platform.DefaultBackend(), launchPolicy: DefaultLaunchPolicy}
+}
+
+// SetBackend replaces the file system and process access, e.g. with fakes in tests or a
+// remote file system. Unset fields keep the local default.
+func (sm *ScriptManager) SetBackend(b platform.Backend) {
+	sm.backend = b.WithDefaults()
+}
+
+// scriptInfos lists the scripts with their headers. The shared cache only watches the
+// local file system, so other backends are read directly.
+func (sm *ScriptManager) scriptInfos() ([]ScriptInfo, error) {
+	if platform.IsLocal(sm.backend.FS) {
+		return CachedScripts(sm.scriptsDir)
+	}
+	return readScriptInfos(sm.backend.FS, sm.scriptsDir)
+}
+
+// Scripts returns the .lua scripts in the scripts directory with their metadata headers
+func (sm *ScriptManager) Scripts() ([]ScriptInfo, error) {
+	return sm.scriptInfos()
+}
+
+// ScriptsDir returns the directory the manager works in
+func (sm *ScriptManager) ScriptsDir() string {
+	return sm.scriptsDir
+}
+
+// CheckScriptsDir verifies that the scripts directory exists, is a directory and is
+// writable. With create set, a missing directory is created first.
+func (sm *ScriptManager) CheckScriptsDir(create bool) error {
+	info, err := sm.backend.FS.Stat(sm.scriptsDir)
+	switch {
+	case errors.Is(err, os.ErrNotExist) && create:
+		if err := sm.CreateScriptsDir(); err != nil {
+			return err
+		}
+	case errors.Is(err, os.ErrNotExist):
+		return fmt.Errorf("scripts directory %s does not exist; create it or enable create_if_missing", sm.scriptsDir)
+	case err != nil:
+		return fmt.Errorf("cannot access scripts directory %s: %w", sm.scriptsDir, err)
+	case !info.IsDir():
+		return fmt.Errorf("scripts directory %s is not a directory", sm.scriptsDir)
+	}
+
+	probe := filepath.Join(sm.scriptsDir, ".write-test")
+	if err := sm.backend.FS.WriteFile(probe, nil, 0644); err != nil {
+		return fmt.Errorf("scripts directory %s is not writable: %w", sm.scriptsDir, err)
+	}
+	sm.backend.FS.Remove(probe)
+	return nil
+}
+
+// CreateScriptsDir creates the scripts directory and its parents if they don't exist
+func (sm *ScriptManager) CreateScriptsDir() error {
+	if err := sm.backend.FS.MkdirAll(sm.scriptsDir, 0755); err != nil {
+		return fmt.Errorf("failed to create scripts directory %s: %w", sm.scriptsDir, err)
+	}
+	return nil
 }
 
-// ListScripts returns a structured list of available scripts
-func (sm *ScriptManager) ListScripts() (string, error) {
-	// Get fresh list of scripts from the directory
-	scripts, err := ListLuaScripts(sm.scriptsDir)
+// luaScripts returns the base names of the .lua scripts, like scriptInfos
+func (sm *ScriptManager) luaScripts() ([]string, error) {
+	if platform.IsLocal(sm.backend.FS) {
+		return CachedLuaScripts(sm.scriptsDir)
+	}
+	return listLuaScripts(sm.backend.FS, sm.scriptsDir)
+}
+
+// ListOptions narrows and pages the output of ListScripts
+type ListOptions struct {
+	Tag    string // Only scripts with this tag
+	Filter string // Only scripts whose name contains this text (case, spaces and underscores ignored)
+	Offset int    // Number of matching scripts to skip
+	Limit  int    // Maximum number of scripts to return; 0 returns all
+}
+
+// ListScripts returns a structured list of available scripts, favorites first,
+// narrowed and paged by opts. The result includes the total number of matches.
+func (sm *ScriptManager) ListScripts(opts ListOptions) (string, error) {
+	// The list is re-read only when the directory changed since the last call
+	infos, err := sm.scriptInfos()
 	if err != nil {
 		return "", fmt.Errorf("failed to list scripts in %s: %w", sm.scriptsDir, err)
 	}
+	headers := make(map[string]ScriptInfo, len(infos))
+	scripts := make([]string, len(infos))
+	for i, info := range infos {
+		headers[info.Name] = info
+		scripts[i] = info.Name
+	}
 
 	if len(scripts) == 0 {
 		return fmt.Sprintf("No ReaScripts (.lua files) found in: %s", sm.scriptsDir), nil
 	}
 
+	metadata, err := sm.LoadMetadata()
+	if err != nil {
+		return "", err
+	}
+
+	if tag := strings.TrimSpace(opts.Tag); tag != "" {
+		var tagged []string
+		for _, script := range scripts {
+			if metadata.HasTag(script, tag) {
+				tagged = append(tagged, script)
+			}
+		}
+		if len(tagged) == 0 {
+			return fmt.Sprintf("No ReaScripts tagged '%s' found in: %s", tag, sm.scriptsDir), nil
+		}
+		scripts = tagged
+	}
+
+	if filter := normalizeScriptName(opts.Filter); filter != "" {
+		var matched []string
+		for _, script := range scripts {
+			if strings.Contains(normalizeScriptName(script), filter) {
+				matched = append(matched, script)
+			}
+		}
+		if len(matched) == 0 {
+			return fmt.Sprintf("No ReaScripts matching '%s' found in: %s", opts.Filter, sm.scriptsDir), nil
+		}
+		scripts = matched
+	}
+
+	// Favorites first, then higher rated scripts
+	sort.SliceStable(scripts, func(i, j int) bool {
+		if fi, fj := metadata.IsFavorite(scripts[i]), metadata.IsFavorite(scripts[j]); fi != fj {
+			return fi
+		}
+		return metadata.Ratings[scripts[i]].Stars > metadata.Ratings[scripts[j]].Stars
+	})
+
+	total := len(scripts)
+	offset := max(opts.Offset, 0)
+	if offset >= total {
+		return fmt.Sprintf("Offset %d is past the end of the list (%d matching scripts)", offset, total), nil
+	}
+	scripts = scripts[offset:]
+	if opts.Limit > 0 && opts.Limit < len(scripts) {
+		scripts = scripts[:opts.Limit]
+	}
+
 	var scriptItems []types.ScriptItem
 	for i, script := range scripts {
 		displayName := strings.ReplaceAll(script, "_", " ")
 		displayName = ToTitleCase(displayName)
 
+		_, pinned := metadata.Pins[script+".lua"]
 		scriptItems = append(scriptItems, types.ScriptItem{
-			Index:       i + 1,
+			Index:       offset + i + 1,
 			Name:        script,
 			DisplayName: displayName,
 			Action:      script,
+			Favorite:    metadata.IsFavorite(script),
+			Tags:        metadata.Tags[script],
+			Description: headers[script].Description,
+			Author:      headers[script].Author,
+			Version:     scriptVersion(metadata, headers[script]),
+			Pinned:      pinned,
+			Rating:      metadata.Ratings[script].Stars,
+			Notes:       metadata.Ratings[script].Notes,
 		})
 	}
 
@@ -83,10 +237,21 @@ func (sm *ScriptManager) ListScripts() (string, error) {
 		Type:        "reaper_script_list",
 		Title:       "🎵 Available REAPER Scripts",
 		Count:       len(scripts),
+		Total:       total,
+		Offset:      offset,
+		HasMore:     offset+len(scripts) < total,
 		Location:    sm.scriptsDir,
 		Scripts:     scriptItems,
 		Instruction: "To run a script, say: \"Run the [script_name] script\"",
 	}
+	if result.HasMore {
+		result.Instruction += fmt.Sprintf(". Showing %d-%d of %d; list with offset=%d for more.",
+			offset+1, offset+len(scripts), total, offset+len(scripts))
+	}
+	if disabled, _ := sm.DisabledScripts(); len(disabled) > 0 {
+		result.Instruction += fmt.Sprintf(". %d disabled script(s) not listed: %s (use 'enable_script' to turn one back on)",
+			len(disabled), strings.Join(disabled, ", "))
+	}
 
 	// Return as JSON string with special prefix to indicate structured data
 	jsonData, err := json.Marshal(result)
@@ -98,6 +263,15 @@ func (sm *ScriptManager) ListScripts() (string, error) {
 	return "STRUCTURED_DATA:" + string(jsonData), nil
 }
 
+// scriptVersion returns the installed marketplace version of a script,
+// or the @version from its header if it wasn't installed from the marketplace
+func scriptVersion(metadata *ScriptMetadata, info ScriptInfo) string {
+	if sha := metadata.Installed[info.Name+".lua"].SHA; sha != "" {
+		return shortSHA(sha)
+	}
+	return info.Version
+}
+
 // listScriptsMarkdown returns a markdown-formatted list of scripts
 func (sm *ScriptManager) listScriptsMarkdown(scripts []string) (string, error) {
 	// Fallback markdown format
@@ -118,24 +292,52 @@ func (sm *ScriptManager) listScriptsMarkdown(scripts []string) (string, error) {
 }
 
 // RunScript launches a script in REAPER
-func (sm *ScriptManager) RunScript(script string) (string, error) {
+func (sm *ScriptManager) RunScript(ctx context.Context, script string) (string, error) {
 	if strings.TrimSpace(script) == "" {
 		return "", errors.New("script name is required for 'run' operation")
 	}
+	script, err := sm.ResolveScript(script)
+	if err != nil {
+		return "", err
+	}
 
-	running, err := platform.IsReaperRunning()
+	running, err := sm.backend.Processes.IsReaperRunning()
 	if err != nil {
 		return "", fmt.Errorf("could not check for REAPER process: %w", err)
 	}
 	if !running {
 		// Not an error for the model; return a friendly message.
-		return "REAPER is not running. Please start REAPER first, then try running the script again.", nil
+		return i18n.T("run.reaper_not_running"), nil
+	}
+
+	scriptPath, err := sm.scriptFilePath(script + ".lua")
+	if err != nil {
+		return "", err
+	}
+	if _, err := sm.backend.FS.Stat(scriptPath); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", errcode.Errorf(errcode.ScriptNotFound, "script not found: %s", scriptPath)
+		}
+		return "", err
 	}
 
-	if err := platform.LaunchScript(sm.scriptsDir, script); err != nil {
+	var debounced *errDebounced
+	if err := waitForLaunch(ctx, sm.launchPolicy, script); errors.As(err, &debounced) {
+		return debounced.Error(), nil
+	} else if err != nil {
 		return "", err
 	}
-	return fmt.Sprintf("Successfully launched REAPER script: %s", script), nil
+
+	// Run through the error capture harness so failures come back with a stack trace.
+	// Scripts that are still running when the wait expires (dialogs, deferred loops)
+	// are reported as launched; the execution history records how they end.
+	if err := bridge.RunFile(ctx, "run_script", scriptPath, bridge.DefaultTimeout); err != nil {
+		if errors.Is(err, bridge.ErrTimeout) {
+			return fmt.Sprintf("Launched REAPER script: %s (still running; use 'list_executions' to see how it ends)", script), nil
+		}
+		return "", fmt.Errorf("script %s failed: %w", script, err)
+	}
+	return fmt.Sprintf("Successfully ran REAPER script: %s", script), nil
 }
 
 // DeleteScript deletes a script file from the scripts directory
@@ -143,6 +345,10 @@ func (sm *ScriptManager) DeleteScript(script string) (string, error) {
 	if strings.TrimSpace(script) == "" {
 		return "", errors.New("script name is required for 'delete' operation")
 	}
+	script, err := sm.ResolveScript(script)
+	if err != nil {
+		return "", err
+	}
 
 	// Add .lua extension if not present
 	scriptFile := script
@@ -151,19 +357,25 @@ func (sm *ScriptManager) DeleteScript(script string) (string, error) {
 	}
 
 	// Construct full path
-	scriptPath := fmt.Sprintf("%s/%s", sm.scriptsDir, scriptFile)
+	scriptPath, err := sm.scriptFilePath(scriptFile)
+	if err != nil {
+		return "", err
+	}
 
 	// Check if file exists
-	if _, err := os.Stat(scriptPath); os.IsNotExist(err) {
-		return "", fmt.Errorf("script not found: %s", script)
+	if _, err := sm.backend.FS.Stat(scriptPath); os.IsNotExist(err) {
+		return "", errcode.Errorf(errcode.ScriptNotFound, "script not found: %s", script)
 	}
 
-	// Delete the file
-	if err := os.Remove(scriptPath); err != nil {
+	// Move the file to the trash so it can be restored
+	if err := sm.moveToTrash(scriptPath); err != nil {
 		return "", fmt.Errorf("failed to delete script %s: %w", script, err)
 	}
 
-	return fmt.Sprintf("Successfully deleted REAPER script: %s", script), nil
+	// Purge anything past the retention period
+	sm.ListTrash()
+
+	return fmt.Sprintf("Successfully deleted REAPER script: %s (moved to trash; use 'restore_script' to undo)", script), nil
 }
 
 // AddScript adds a new script file to the scripts directory
@@ -199,61 +411,53 @@ func (sm *ScriptManager) AddScript(scriptName, content, scriptType string) (stri
 	scriptFile := scriptName + extension
 
 	// Construct full path
-	scriptPath := fmt.Sprintf("%s/%s", sm.scriptsDir, scriptFile)
-
-	// Check if file already exists
-	if _, err := os.Stat(scriptPath); err == nil {
-		return "", fmt.Errorf("script already exists: %s", scriptFile)
+	scriptPath, err := sm.scriptFilePath(scriptFile)
+	if err != nil {
+		return "", err
 	}
 
-	// Write the file
-	if err := os.WriteFile(scriptPath, []byte(content), 0644); err != nil {
-		return "", fmt.Errorf("failed to write script %s: %w", scriptFile, err)
+	// Check and write under the directory lock, since another workstation sharing the
+	// scripts directory may add the same script at the same time
+	err = sm.withDirLock("add "+scriptFile, func() error {
+		if _, err := sm.backend.FS.Stat(scriptPath); err == nil {
+			return fmt.Errorf("script already exists: %s", scriptFile)
+		}
+		if err := sm.writeFileAtomic(scriptPath, []byte(content)); err != nil {
+			return fmt.Errorf("failed to write script %s: %w", scriptFile, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
 	}
+	invalidateScriptCache(sm.scriptsDir)
 
-	return fmt.Sprintf("Successfully added REAPER script: %s", scriptFile), nil
+	result := fmt.Sprintf("Successfully added REAPER script: %s", scriptFile)
+	if note := sm.commitScript("add", scriptFile, ""); note != "" {
+		result += "\n\n" + note
+	}
+	if warning := dependencyWarning(content); warning != "" {
+		result += "\n\n" + warning
+	}
+	if extension == ".py" {
+		if warning := pythonWarning(); warning != "" {
+			result += "\n\n" + warning
+		}
+	}
+	return result, nil
 }
 
 // GetReaperKBIniPath returns the platform-specific path to reaper-kb.ini
 func GetReaperKBIniPath() (string, error) {
-	var basePath string
-
-	switch runtime.GOOS {
-	case "darwin": // macOS
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return "", fmt.Errorf("failed to get home directory: %w", err)
-		}
-		basePath = filepath.Join(homeDir, "Library", "Application Support", "REAPER")
-
-	case "windows":
-		appData := os.Getenv("APPDATA")
-		if appData == "" {
-			return "", errors.New("APPDATA environment variable not set")
-		}
-		basePath = filepath.Join(appData, "REAPER")
-
-	case "linux":
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return "", fmt.Errorf("failed to get home directory: %w", err)
-		}
-		// Try common Linux paths
-		xdgConfig := os.Getenv("XDG_CONFIG_HOME")
-		if xdgConfig != "" {
-			basePath = filepath.Join(xdgConfig, "REAPER")
-		} else {
-			basePath = filepath.Join(homeDir, ".config", "REAPER")
-		}
-
-	default:
-		return "", fmt.Errorf("unsupported platform: %s", runtime.GOOS)
+	basePath, err := GetReaperResourcePath()
+	if err != nil {
+		return "", err
 	}
 
 	kbIniPath := filepath.Join(basePath, "reaper-kb.ini")
 
 	// Check if the file exists
-	if _, err := os.Stat(kbIniPath); os.IsNotExist(err) {
+	if _, err := configFS.Stat(kbIniPath); os.IsNotExist(err) {
 		return "", fmt.Errorf("reaper-kb.ini not found at %s (is REAPER installed?)", kbIniPath)
 	}
 
@@ -273,81 +477,95 @@ func (sm *ScriptManager) RegisterScript(scriptName string) (string, error) {
 	}
 
 	// Construct full path to the script
-	scriptPath := filepath.Join(sm.scriptsDir, scriptFile)
+	scriptPath, err := sm.scriptFilePath(scriptFile)
+	if err != nil {
+		return "", err
+	}
 
 	// Check if script exists
-	if _, err := os.Stat(scriptPath); os.IsNotExist(err) {
-		return "", fmt.Errorf("script not found: %s", scriptName)
+	if _, err := sm.backend.FS.Stat(scriptPath); os.IsNotExist(err) {
+		return "", errcode.Errorf(errcode.ScriptNotFound, "script not found: %s", scriptName)
 	}
 
-	// Get reaper-kb.ini path
-	kbIniPath, err := GetReaperKBIniPath()
+	added, err := registerScripts("register "+scriptFile, []scriptRegistration{{name: scriptName, path: scriptPath}})
 	if err != nil {
 		return "", err
 	}
-
-	// Read existing reaper-kb.ini file
-	file, err := os.Open(kbIniPath)
-	if err != nil {
-		return "", fmt.Errorf("failed to open reaper-kb.ini: %w", err)
+	if len(added) == 0 {
+		return fmt.Sprintf("Script '%s' is already registered in REAPER", scriptName), nil
 	}
-	defer file.Close()
-
-	var lines []string
-	scanner := bufio.NewScanner(file)
-	scriptAlreadyRegistered := false
 
-	for scanner.Scan() {
-		line := scanner.Text()
-		lines = append(lines, line)
-
-		// Check if script is already registered
-		if strings.Contains(line, scriptPath) {
-			scriptAlreadyRegistered = true
-		}
-	}
+	return fmt.Sprintf("Successfully registered script '%s' in REAPER keyboard shortcuts", scriptName), nil
+}
 
-	if err := scanner.Err(); err != nil {
-		return "", fmt.Errorf("failed to read reaper-kb.ini: %w", err)
-	}
+// scriptRegistration is a script to register in reaper-kb.ini
+type scriptRegistration struct {
+	name string // Shown as "Script: <name>" in the action list
+	path string
+}
 
-	// If already registered, return early
-	if scriptAlreadyRegistered {
-		return fmt.Sprintf("Script '%s' is already registered in REAPER", scriptName), nil
+// registerScripts adds SCR entries to reaper-kb.ini for the scripts that aren't registered
+// yet, reading and writing the file once. It returns the names of the scripts added; the
+// others were already registered.
+func registerScripts(operation string, scripts []scriptRegistration) ([]string, error) {
+	kbIniPath, err := GetReaperKBIniPath()
+	if err != nil {
+		return nil, err
 	}
 
-	// Find the [Main] section and add the script
-	// REAPER format: SCR 4 0 "Script: scriptname" "path/to/script.lua"
-	scriptEntry := fmt.Sprintf(`SCR 4 0 "Script: %s" "%s"`, scriptName, scriptPath)
+	var added []string
+	err = updateConfigFile(kbIniPath, operation, func(data []byte) ([]byte, error) {
+		kb, err := parseKBIni(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read reaper-kb.ini: %w", err)
+		}
 
-	// Find where to insert (after [Main] section header)
-	inserted := false
-	for i, line := range lines {
-		if strings.HasPrefix(line, "[Main]") {
-			// Insert after [Main] line
-			lines = append(lines[:i+1], append([]string{scriptEntry}, lines[i+1:]...)...)
-			inserted = true
-			break
+		registered := make(map[string]bool)
+		for _, line := range kb.lines {
+			if path := kbScriptPath(line.scriptPath()); path != "" {
+				registered[path] = true
+			}
+		}
+		added = nil
+		var entries []string
+		for _, script := range scripts {
+			if registered[script.path] {
+				continue
+			}
+			registered[script.path] = true
+			// REAPER format: SCR 4 0 "Script: scriptname" "path/to/script.lua"
+			entries = append(entries, fmt.Sprintf(`SCR 4 0 "Script: %s" "%s"`, script.name, script.path))
+			added = append(added, script.name)
 		}
-	}
 
-	// If [Main] section not found, append to end
-	if !inserted {
-		lines = append(lines, "", "[Main]", scriptEntry)
-	}
+		// If everything is registered, leave the file alone
+		if len(entries) == 0 {
+			return nil, errNoChange
+		}
 
-	// Write back to file
-	content := strings.Join(lines, "\n")
-	if err := os.WriteFile(kbIniPath, []byte(content), 0644); err != nil {
-		return "", fmt.Errorf("failed to write reaper-kb.ini: %w", err)
+		// Insert after the [Main] section header, or append the section if not found
+		for i, line := range kb.lines {
+			if strings.HasPrefix(line.text, "[Main]") {
+				for j, entry := range entries {
+					kb.insert(i+1+j, entry)
+				}
+				return kb.bytes(), nil
+			}
+		}
+		kb.append(append([]string{"", "[Main]"}, entries...)...)
+		return kb.bytes(), nil
+	})
+	if err != nil {
+		return nil, err
 	}
-
-	return fmt.Sprintf("Successfully registered script '%s' in REAPER keyboard shortcuts", scriptName), nil
+	return added, nil
 }
 
-// RegisterAllScripts registers all scripts in the scripts directory to reaper-kb.ini
-func (sm *ScriptManager) RegisterAllScripts() (string, error) {
-	scripts, err := ListLuaScripts(sm.scriptsDir)
+// RegisterAllScripts registers all scripts in the scripts directory to reaper-kb.ini,
+// with a single write, and lists what happened to each. Progress is reported to the
+// reporter in ctx, if any.
+func (sm *ScriptManager) RegisterAllScripts(ctx context.Context) (string, error) {
+	scripts, err := listLuaScripts(sm.backend.FS, sm.scriptsDir)
 	if err != nil {
 		return "", fmt.Errorf("failed to list scripts: %w", err)
 	}
@@ -355,31 +573,57 @@ func (sm *ScriptManager) RegisterAllScripts() (string, error) {
 	if len(scripts) == 0 {
 		return "No scripts found to register", nil
 	}
+	sort.Strings(scripts)
 
-	registered := 0
-	alreadyRegistered := 0
-	failed := 0
-
-	for _, script := range scripts {
-		result, err := sm.RegisterScript(script)
+	// One step per script checked, then one for the write
+	steps := len(scripts) + 1
+	var toRegister []scriptRegistration
+	var failed []string
+	for i, script := range scripts {
+		progress.Report(ctx, "Checking "+script, i, steps)
+		scriptPath, err := sm.scriptFilePath(script + ".lua")
 		if err != nil {
-			failed++
+			failed = append(failed, fmt.Sprintf("%s (%v)", script, err))
 			continue
 		}
+		toRegister = append(toRegister, scriptRegistration{name: script, path: scriptPath})
+	}
 
-		if strings.Contains(result, "already registered") {
-			alreadyRegistered++
-		} else {
-			registered++
+	progress.Report(ctx, fmt.Sprintf("Writing %d script(s) to reaper-kb.ini", len(toRegister)), len(scripts), steps)
+	added, err := registerScripts("register all scripts", toRegister)
+	if err != nil {
+		return "", err
+	}
+	isAdded := make(map[string]bool, len(added))
+	for _, name := range added {
+		isAdded[name] = true
+	}
+	var already []string
+	for _, script := range toRegister {
+		if !isAdded[script.name] {
+			already = append(already, script.name)
 		}
 	}
 
-	summary := fmt.Sprintf("Registration complete: %d newly registered, %d already registered", registered, alreadyRegistered)
-	if failed > 0 {
-		summary += fmt.Sprintf(", %d failed", failed)
+	var summary strings.Builder
+	summary.WriteString(fmt.Sprintf("Registration complete: %d newly registered, %d already registered", len(added), len(already)))
+	if len(failed) > 0 {
+		summary.WriteString(fmt.Sprintf(", %d failed", len(failed)))
+	}
+	for _, group := range []struct {
+		title   string
+		scripts []string
+	}{{"Newly registered", added}, {"Already registered", already}, {"Failed", failed}} {
+		if len(group.scripts) == 0 {
+			continue
+		}
+		summary.WriteString(fmt.Sprintf("\n\n%s:", group.title))
+		for _, script := range group.scripts {
+			summary.WriteString("\n  • " + script)
+		}
 	}
 
-	return summary, nil
+	return summary.String(), nil
 }
 
 // CleanScripts removes script entries from reaper-kb.ini where the script files no longer exist
@@ -390,56 +634,37 @@ func (sm *ScriptManager) CleanScripts() (string, error) {
 		return "", err
 	}
 
-	// Read existing reaper-kb.ini file
-	file, err := os.Open(kbIniPath)
-	if err != nil {
-		return "", fmt.Errorf("failed to open reaper-kb.ini: %w", err)
-	}
-	defer file.Close()
-
-	var lines []string
 	var removedCount int
-	scanner := bufio.NewScanner(file)
-
-	for scanner.Scan() {
-		line := scanner.Text()
-
-		// Check if this is a script entry line
-		if strings.HasPrefix(strings.TrimSpace(line), "SCR ") {
-			// Extract the script path from the line
-			// Format: SCR 4 0 "Script: name" "path/to/script.lua"
-			parts := strings.Split(line, "\"")
-			if len(parts) >= 4 {
-				scriptPath := parts[3] // The path is in the 4th quoted section
-
-				// Check if the script file exists
-				if _, err := os.Stat(scriptPath); os.IsNotExist(err) {
-					// Script file doesn't exist, skip this line (don't add to lines)
-					removedCount++
-					continue
-				}
-			}
+	err = updateConfigFile(kbIniPath, "clean scripts", func(data []byte) ([]byte, error) {
+		kb, err := parseKBIni(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read reaper-kb.ini: %w", err)
 		}
 
-		// Keep this line
-		lines = append(lines, line)
-	}
+		// Drop script entries whose file no longer exists
+		removedCount = kb.removeIf(func(line kbLine) bool {
+			scriptPath := kbScriptPath(line.scriptPath())
+			if scriptPath == "" {
+				return false
+			}
+			_, err := sm.backend.FS.Stat(scriptPath)
+			return os.IsNotExist(err)
+		})
 
-	if err := scanner.Err(); err != nil {
-		return "", fmt.Errorf("failed to read reaper-kb.ini: %w", err)
-	}
+		// If no changes, leave the file alone
+		if removedCount == 0 {
+			return nil, errNoChange
+		}
 
-	// If no changes, return early
+		return kb.bytes(), nil
+	})
+	if err != nil {
+		return "", err
+	}
 	if removedCount == 0 {
 		return "No missing scripts found in reaper-kb.ini. All script paths are valid.", nil
 	}
 
-	// Write back to file
-	content := strings.Join(lines, "\n")
-	if err := os.WriteFile(kbIniPath, []byte(content), 0644); err != nil {
-		return "", fmt.Errorf("failed to write reaper-kb.ini: %w", err)
-	}
-
 	return fmt.Sprintf("Cleaned %d missing script(s) from reaper-kb.ini", removedCount), nil
 }
 
@@ -0,0 +1,124 @@
+package scripts
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/errcode"
+	"github.com/johnjallday/ori-reaper-plugin/internal/i18n"
+	"github.com/johnjallday/ori-reaper-plugin/internal/platform"
+)
+
+// memFS is an in-memory platform.FS for tests. Paths are absolute and slash-separated.
+type memFS struct {
+	files fstest.MapFS
+}
+
+func newMemFS(files map[string]string) *memFS {
+	m := &memFS{files: fstest.MapFS{}}
+	for name, content := range files {
+		m.files[m.key(name)] = &fstest.MapFile{Data: []byte(content), Mode: 0644, ModTime: time.Now()}
+	}
+	return m
+}
+
+// key converts a path to the unrooted form fstest.MapFS uses
+func (m *memFS) key(name string) string {
+	name = strings.TrimPrefix(filepath.ToSlash(name), "/")
+	if name == "" {
+		return "."
+	}
+	return name
+}
+
+func (m *memFS) Open(name string) (fs.File, error)          { return m.files.Open(m.key(name)) }
+func (m *memFS) ReadFile(name string) ([]byte, error)       { return m.files.ReadFile(m.key(name)) }
+func (m *memFS) Stat(name string) (fs.FileInfo, error)      { return m.files.Stat(m.key(name)) }
+func (m *memFS) ReadDir(name string) ([]fs.DirEntry, error) { return m.files.ReadDir(m.key(name)) }
+func (m *memFS) MkdirAll(string, fs.FileMode) error         { return nil }
+
+func (m *memFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	m.files[m.key(name)] = &fstest.MapFile{Data: append([]byte(nil), data...), Mode: perm, ModTime: time.Now()}
+	return nil
+}
+
+func (m *memFS) Rename(oldpath, newpath string) error {
+	file, ok := m.files[m.key(oldpath)]
+	if !ok {
+		return &fs.PathError{Op: "rename", Path: oldpath, Err: fs.ErrNotExist}
+	}
+	delete(m.files, m.key(oldpath))
+	m.files[m.key(newpath)] = file
+	return nil
+}
+
+func (m *memFS) Remove(name string) error {
+	if _, ok := m.files[m.key(name)]; !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	delete(m.files, m.key(name))
+	return nil
+}
+
+// fakeProcesses reports REAPER as running or not, and fails the test if anything is launched
+type fakeProcesses struct {
+	t       *testing.T
+	running bool
+}
+
+func (f fakeProcesses) IsReaperRunning() (bool, error) { return f.running, nil }
+
+func (f fakeProcesses) ExecuteScript(ctx context.Context, scriptPath string) error {
+	f.t.Errorf("launched %s in REAPER", scriptPath)
+	return errors.New("no REAPER in tests")
+}
+
+func (f fakeProcesses) LaunchReaper() error {
+	f.t.Error("launched REAPER")
+	return errors.New("no REAPER in tests")
+}
+
+func (f fakeProcesses) QuitReaper(context.Context, time.Duration) error { return nil }
+
+// newFakeScriptManager returns a script manager for /scripts on an in-memory file system
+func newFakeScriptManager(t *testing.T, running bool, files map[string]string) *ScriptManager {
+	sm := NewScriptManager("/scripts")
+	processes := fakeProcesses{t: t, running: running}
+	sm.SetBackend(platform.Backend{FS: newMemFS(files), Processes: processes, Launcher: processes})
+	return sm
+}
+
+func TestRunScriptWithoutReaper(t *testing.T) {
+	sm := newFakeScriptManager(t, false, map[string]string{
+		"/scripts/Normalize.lua": "reaper.ShowConsoleMsg('hi')\n",
+	})
+
+	text, err := sm.RunScript(context.Background(), "normalize")
+	if err != nil {
+		t.Fatalf("RunScript returned error: %v", err)
+	}
+	if want := i18n.T("run.reaper_not_running"); text != want {
+		t.Errorf("RunScript = %q, want %q", text, want)
+	}
+}
+
+func TestRunScriptNotFound(t *testing.T) {
+	sm := newFakeScriptManager(t, true, map[string]string{
+		"/scripts/Normalize.lua": "",
+		"/scripts/Readme.txt":    "",
+	})
+
+	_, err := sm.RunScript(context.Background(), "Normalise")
+	if !errors.Is(err, errcode.ScriptNotFound) {
+		t.Fatalf("RunScript error = %v, want %s", err, errcode.ScriptNotFound)
+	}
+	if !strings.Contains(err.Error(), "Did you mean: Normalize?") {
+		t.Errorf("RunScript error = %q, want a suggestion read from the fake file system", err)
+	}
+}
@@ -0,0 +1,200 @@
+package scripts
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/bridge"
+)
+
+// TemplateKinds lists the kinds of template: project templates and track templates
+var TemplateKinds = []string{"project", "track"}
+
+// templateFolders are the resource folders templates are saved in, by kind
+var templateFolders = map[string]string{
+	"project": "ProjectTemplates",
+	"track":   "TrackTemplates",
+}
+
+// templateExtensions are the file extensions of templates, by kind
+var templateExtensions = map[string]string{
+	"project": ".rpp",
+	"track":   ".rtracktemplate",
+}
+
+// newProjectTabAction is the command ID of "New project tab"
+const newProjectTabAction = 40859
+
+// Template is a saved project or track template
+type Template struct {
+	Kind     string    `json:"kind"` // One of TemplateKinds
+	Name     string    `json:"name"` // Path within the template folder, without the extension, e.g. "Band/Rock"
+	Path     string    `json:"path"`
+	Modified time.Time `json:"modified"`
+}
+
+// Label names a template for display, e.g. "Project template Band/Rock"
+func (t Template) Label() string {
+	if t.Kind == "track" {
+		return "Track template " + t.Name
+	}
+	return "Project template " + t.Name
+}
+
+// GetTemplatesDir returns REAPER's folder for templates of kind
+func GetTemplatesDir(kind string) (string, error) {
+	basePath, err := GetReaperResourcePath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(basePath, templateFolders[kind]), nil
+}
+
+// ListTemplates returns the project and track templates in REAPER's ProjectTemplates and
+// TrackTemplates folders, including their subfolders, project templates first
+func ListTemplates() ([]Template, error) {
+	var templates []Template
+	for _, kind := range TemplateKinds {
+		dir, err := GetTemplatesDir(kind)
+		if err != nil {
+			return nil, err
+		}
+		err = filepath.WalkDir(dir, func(file string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) && file == dir {
+					return filepath.SkipDir // No templates of this kind have been saved yet
+				}
+				return err
+			}
+			if entry.IsDir() || strings.ToLower(filepath.Ext(file)) != templateExtensions[kind] {
+				return nil
+			}
+			rel, err := filepath.Rel(dir, file)
+			if err != nil {
+				return err
+			}
+			template := Template{
+				Kind: kind,
+				Name: filepath.ToSlash(strings.TrimSuffix(rel, filepath.Ext(rel))),
+				Path: file,
+			}
+			if info, err := entry.Info(); err == nil {
+				template.Modified = info.ModTime()
+			}
+			templates = append(templates, template)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", templateFolders[kind], err)
+		}
+	}
+
+	sort.SliceStable(templates, func(i, j int) bool {
+		if templates[i].Kind != templates[j].Kind {
+			return templates[i].Kind == "project"
+		}
+		return strings.ToLower(templates[i].Name) < strings.ToLower(templates[j].Name)
+	})
+	return templates, nil
+}
+
+// FindTemplate finds a template of kind by name (case-insensitive, a unique part is enough)
+func FindTemplate(templates []Template, kind, query string) (Template, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return Template{}, fmt.Errorf("name is required: the %s template's name", kind)
+	}
+	query = filepath.ToSlash(query)
+	if strings.ToLower(path.Ext(query)) == templateExtensions[kind] {
+		query = strings.TrimSuffix(query, path.Ext(query))
+	}
+
+	var matches []Template
+	for _, t := range templates {
+		if t.Kind != kind {
+			continue
+		}
+		if strings.EqualFold(t.Name, query) || strings.EqualFold(path.Base(t.Name), query) {
+			return t, nil
+		}
+		if strings.Contains(strings.ToLower(t.Name), strings.ToLower(query)) {
+			matches = append(matches, t)
+		}
+	}
+	switch len(matches) {
+	case 1:
+		return matches[0], nil
+	case 0:
+		return Template{}, fmt.Errorf("no %s template named '%s'; use 'list_templates' to see them", kind, query)
+	}
+	names := make([]string, len(matches))
+	for i, t := range matches {
+		names[i] = t.Name
+	}
+	return Template{}, fmt.Errorf("'%s' matches several %s templates: %s", query, kind, strings.Join(names, ", "))
+}
+
+// CreateProjectFromTemplate opens a project template via the Lua bridge as a new, untitled
+// project in a new project tab, so the project currently open is left as it is
+func CreateProjectFromTemplate(ctx context.Context, template Template) error {
+	_, err := bridge.Run(ctx, "create_project_from_template", fmt.Sprintf(`local path = %s
+reaper.Main_OnCommand(%d, 0)
+reaper.Main_openProject("template:" .. path)
+`, bridge.LuaString(template.Path), newProjectTabAction))
+	if err != nil {
+		return fmt.Errorf("failed to create a project from %s: %w", template.Label(), err)
+	}
+	return nil
+}
+
+// InsertTrackTemplate inserts a track template into the current project via the Lua
+// bridge. Returns the number of tracks it added.
+func InsertTrackTemplate(ctx context.Context, template Template) (int, error) {
+	rows, err := bridge.Run(ctx, "insert_track_template", fmt.Sprintf(`local path = %s
+local before = reaper.CountTracks(0)
+-- Opening a track template inserts its tracks after the selected track
+reaper.Main_openProject(path)
+out(reaper.CountTracks(0) - before)
+`, bridge.LuaString(template.Path)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert %s: %w", template.Label(), err)
+	}
+	added := 0
+	if len(rows) > 0 && len(rows[0]) > 0 {
+		added, _ = strconv.Atoi(rows[0][0])
+	}
+	return added, nil
+}
+
+// FormatTemplates formats the saved templates as a list
+func FormatTemplates(templates []Template) string {
+	if len(templates) == 0 {
+		return "No templates are saved. Save one in REAPER with File > Project templates > Save project as template, or Track > Save tracks as track template."
+	}
+	var b strings.Builder
+	kind := ""
+	for _, t := range templates {
+		if t.Kind != kind {
+			kind = t.Kind
+			if b.Len() > 0 {
+				b.WriteString("\n")
+			}
+			if kind == "track" {
+				b.WriteString("Track templates:\n")
+			} else {
+				b.WriteString("Project templates:\n")
+			}
+		}
+		b.WriteString("  - " + t.Name + "\n")
+	}
+	b.WriteString("\nUse 'create_project_from_template' or 'insert_track_template' with name=<template> to use one.")
+	return b.String()
+}
@@ -0,0 +1,171 @@
+package scripts
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// DefaultBundleToolbar is the toolbar bundle buttons go on when none is named
+const DefaultBundleToolbar = "Floating toolbar 16"
+
+// ToolbarButton is one button added to a toolbar in reaper-menu.ini
+type ToolbarButton struct {
+	Toolbar string `json:"toolbar"` // reaper-menu.ini section, e.g. "Floating toolbar 16"
+	Value   string `json:"value"`   // item value: "<command> <label>"
+}
+
+// getReaperMenuIniPath returns the path to reaper-menu.ini, which holds customized menus and toolbars
+func getReaperMenuIniPath() (string, error) {
+	basePath, err := GetReaperResourcePath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(basePath, "reaper-menu.ini"), nil
+}
+
+// menuSection locates a section in reaper-menu.ini lines, returning the index of its header
+// and the index just past its last line, or -1 if it doesn't exist
+func menuSection(lines []string, name string) (int, int) {
+	header := "[" + name + "]"
+	for i, line := range lines {
+		if strings.TrimSpace(line) != header {
+			continue
+		}
+		end := i + 1
+		for end < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[end]), "[") {
+			end++
+		}
+		return i, end
+	}
+	return -1, -1
+}
+
+// toolbarItem is one item_N entry of a toolbar section with its icon_N, if any
+type toolbarItem struct {
+	value string
+	icon  string
+}
+
+// toolbarItems returns the items of a toolbar section body in order
+func toolbarItems(body []string) []toolbarItem {
+	var items []toolbarItem
+	for _, line := range body {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok {
+			continue
+		}
+		prefix, number, ok := strings.Cut(key, "_")
+		if !ok || (prefix != "item" && prefix != "icon") {
+			continue
+		}
+		index, err := strconv.Atoi(number)
+		if err != nil || index < 0 {
+			continue
+		}
+		for len(items) <= index {
+			items = append(items, toolbarItem{})
+		}
+		if prefix == "item" {
+			items[index].value = value
+		} else {
+			items[index].icon = value
+		}
+	}
+	return items
+}
+
+// rewriteToolbarSection replaces the item_N and icon_N lines of a section body with items,
+// keeping other keys such as title
+func rewriteToolbarSection(body []string, items []toolbarItem) []string {
+	var kept []string
+	for _, line := range body {
+		key, _, _ := strings.Cut(strings.TrimSpace(line), "=")
+		if strings.HasPrefix(key, "item_") || strings.HasPrefix(key, "icon_") || strings.TrimSpace(line) == "" {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	for i, item := range items {
+		if item.icon != "" {
+			kept = append(kept, fmt.Sprintf("icon_%d=%s", i, item.icon))
+		}
+		kept = append(kept, fmt.Sprintf("item_%d=%s", i, item.value))
+	}
+	return kept
+}
+
+// replaceMenuSection replaces the body of the section spanning lines[start:end],
+// keeping a blank line before the next section
+func replaceMenuSection(lines []string, start, end int, body []string) []string {
+	section := append([]string{lines[start]}, body...)
+	if end < len(lines) {
+		section = append(section, "")
+	}
+	return append(lines[:start], append(section, lines[end:]...)...)
+}
+
+// AddToolbarButtons appends buttons to toolbars in reaper-menu.ini. A floating toolbar that
+// has never been customized is created; other missing toolbars are an error, since creating
+// them would replace REAPER's default buttons. REAPER must be restarted to show the changes.
+func AddToolbarButtons(buttons []ToolbarButton) error {
+	if len(buttons) == 0 {
+		return nil
+	}
+	return updateMenuIni("add toolbar buttons", func(lines []string) ([]string, error) {
+		for _, button := range buttons {
+			start, end := menuSection(lines, button.Toolbar)
+			if start < 0 {
+				if !strings.HasPrefix(strings.ToLower(button.Toolbar), "floating toolbar") {
+					return nil, fmt.Errorf("toolbar %q has not been customized yet; customize it once in REAPER or use a floating toolbar", button.Toolbar)
+				}
+				if len(lines) > 0 {
+					lines = append(lines, "")
+				}
+				lines = append(lines, "["+button.Toolbar+"]", "title="+button.Toolbar)
+				start, end = menuSection(lines, button.Toolbar)
+			}
+			body := lines[start+1 : end]
+			items := append(toolbarItems(body), toolbarItem{value: button.Value})
+			lines = replaceMenuSection(lines, start, end, rewriteToolbarSection(body, items))
+		}
+		return lines, nil
+	})
+}
+
+// RemoveToolbarButtons removes buttons previously added with AddToolbarButtons,
+// renumbering the remaining items. Buttons that are no longer there are ignored.
+func RemoveToolbarButtons(buttons []ToolbarButton) error {
+	if len(buttons) == 0 {
+		return nil
+	}
+	return updateMenuIni("remove toolbar buttons", func(lines []string) ([]string, error) {
+		changed := false
+		for _, button := range buttons {
+			start, end := menuSection(lines, button.Toolbar)
+			if start < 0 {
+				continue
+			}
+			body := lines[start+1 : end]
+			var items []toolbarItem
+			removed := false
+			for _, item := range toolbarItems(body) {
+				if !removed && item.value == button.Value {
+					removed = true
+					continue
+				}
+				items = append(items, item)
+			}
+			if !removed {
+				continue
+			}
+			lines = replaceMenuSection(lines, start, end, rewriteToolbarSection(body, items))
+			changed = true
+		}
+		if !changed {
+			return nil, errNoChange
+		}
+		return lines, nil
+	})
+}
@@ -0,0 +1,157 @@
+package scripts
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/errcode"
+)
+
+// trashDirName is the plugin-managed folder, inside the scripts directory, that deleted scripts move to
+const trashDirName = ".ori_trash"
+
+// trashTimeFormat prefixes trashed file names so repeated deletes of a script don't collide
+const trashTimeFormat = "20060102-150405"
+
+// TrashedScript is a deleted script kept in the trash
+type TrashedScript struct {
+	Name      string    `json:"name"`       // Original file name
+	DeletedAt time.Time `json:"deleted_at"` // When it was moved to the trash
+	path      string
+}
+
+// SetTrashRetention sets how long deleted scripts are kept before being purged; 0 keeps them forever
+func (sm *ScriptManager) SetTrashRetention(retention time.Duration) {
+	sm.trashRetention = retention
+}
+
+// trashDir returns the trash folder for the scripts directory
+func (sm *ScriptManager) trashDir() string {
+	return filepath.Join(sm.scriptsDir, trashDirName)
+}
+
+// moveToTrash moves a script file into the trash
+func (sm *ScriptManager) moveToTrash(scriptPath string) error {
+	if err := sm.backend.FS.MkdirAll(sm.trashDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create trash folder: %w", err)
+	}
+	trashed := filepath.Join(sm.trashDir(), time.Now().Format(trashTimeFormat)+"_"+filepath.Base(scriptPath))
+	err := sm.withDirLock("delete "+filepath.Base(scriptPath), func() error {
+		if err := sm.backend.FS.Rename(scriptPath, trashed); err != nil {
+			if os.IsNotExist(err) {
+				return fmt.Errorf("%s is already gone; it may have been deleted from another workstation", filepath.Base(scriptPath))
+			}
+			return fmt.Errorf("failed to move script to trash: %w", err)
+		}
+		return nil
+	})
+	invalidateScriptCache(sm.scriptsDir)
+	if err == nil {
+		sm.commitScript("delete", filepath.Base(scriptPath), "")
+	}
+	return err
+}
+
+// ListTrash returns the scripts in the trash, newest first, after purging expired ones
+func (sm *ScriptManager) ListTrash() ([]TrashedScript, error) {
+	entries, err := sm.backend.FS.ReadDir(sm.trashDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read trash folder: %w", err)
+	}
+
+	var trashed []TrashedScript
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		stamp, name, ok := strings.Cut(entry.Name(), "_")
+		if !ok {
+			continue
+		}
+		deletedAt, err := time.ParseInLocation(trashTimeFormat, stamp, time.Local)
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(sm.trashDir(), entry.Name())
+
+		if sm.trashRetention > 0 && time.Since(deletedAt) > sm.trashRetention {
+			sm.backend.FS.Remove(path)
+			continue
+		}
+		trashed = append(trashed, TrashedScript{Name: name, DeletedAt: deletedAt, path: path})
+	}
+
+	sort.Slice(trashed, func(i, j int) bool {
+		return trashed[i].DeletedAt.After(trashed[j].DeletedAt)
+	})
+	return trashed, nil
+}
+
+// RestoreScript moves the most recently deleted copy of a script back into the scripts directory
+func (sm *ScriptManager) RestoreScript(script string) (string, error) {
+	if strings.TrimSpace(script) == "" {
+		return "", errors.New("script name is required for 'restore_script' operation")
+	}
+
+	trashed, err := sm.ListTrash()
+	if err != nil {
+		return "", err
+	}
+
+	for _, item := range trashed {
+		if item.Name != script && strings.TrimSuffix(item.Name, filepath.Ext(item.Name)) != script {
+			continue
+		}
+
+		target, err := sm.scriptFilePath(item.Name)
+		if err != nil {
+			return "", err
+		}
+		err = sm.withDirLock("restore "+item.Name, func() error {
+			if _, err := sm.backend.FS.Stat(target); err == nil {
+				return fmt.Errorf("a script named %s already exists; delete or rename it first", item.Name)
+			}
+			if err := sm.backend.FS.Rename(item.path, target); err != nil {
+				if os.IsNotExist(err) {
+					return fmt.Errorf("%s is no longer in the trash; it may have been restored from another workstation", item.Name)
+				}
+				return fmt.Errorf("failed to restore %s: %w", item.Name, err)
+			}
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+		invalidateScriptCache(sm.scriptsDir)
+		result := fmt.Sprintf("Restored REAPER script: %s (deleted %s)", item.Name, item.DeletedAt.Format("2006-01-02 15:04"))
+		if note := sm.commitScript("restore", item.Name, ""); note != "" {
+			result += "\n\n" + note
+		}
+		return result, nil
+	}
+
+	return "", errcode.Errorf(errcode.ScriptNotFound, "script not found in trash: %s", script)
+}
+
+// FormatTrash formats the trash contents as a list
+func FormatTrash(trashed []TrashedScript) string {
+	if len(trashed) == 0 {
+		return "Trash is empty"
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Found %d deleted scripts:\n\n", len(trashed)))
+	for _, item := range trashed {
+		result.WriteString(fmt.Sprintf("  - %s (deleted %s)\n", item.Name, item.DeletedAt.Format("2006-01-02 15:04")))
+	}
+	result.WriteString("\nUse 'restore_script' with the script name to restore one.")
+	return result.String()
+}
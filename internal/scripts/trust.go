@@ -0,0 +1,92 @@
+package scripts
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DefaultTrustedSources are the script sources allowed when settings don't list any
+var DefaultTrustedSources = []string{
+	"https://raw.githubusercontent.com/johnjallday/ori-reaper/",
+}
+
+// SetTrustedSources limits downloads to URLs starting with one of sources.
+// An empty list falls back to DefaultTrustedSources.
+func (sd *ScriptDownloader) SetTrustedSources(sources []string) {
+	sd.trustedSources = sources
+}
+
+// checkTrusted returns an error unless downloadURL comes from a trusted source
+func (sd *ScriptDownloader) checkTrusted(downloadURL string) error {
+	sources := sd.trustedSources
+	if len(sources) == 0 {
+		sources = DefaultTrustedSources
+	}
+	for _, source := range sources {
+		if strings.HasPrefix(downloadURL, source) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is not from a trusted source; add its source to trusted_sources in the plugin settings to allow it", downloadURL)
+}
+
+// PreviewScripts fetches scripts without installing them and returns their content for review.
+// Installed Lua runs with the user's full privileges, so review mode shows the code first.
+func (sd *ScriptDownloader) PreviewScripts(ctx context.Context, filenames []string) (string, error) {
+	if len(filenames) == 0 {
+		return "", fmt.Errorf("filenames are required for 'download_scripts' operation")
+	}
+
+	files, err := sd.fetchGitHubFiles(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch scripts from GitHub: %w", err)
+	}
+
+	urls := make(map[string]string)
+	var available []string
+	for _, file := range files {
+		if file.Type == "file" && isScriptFile(file.Name) {
+			urls[file.Name] = file.DownloadURL
+			available = append(available, file.Name)
+		}
+	}
+	if len(filenames) == 1 && strings.EqualFold(filenames[0], "all") {
+		filenames = available
+	}
+
+	var b strings.Builder
+	b.WriteString("Review these scripts before installing them:\n")
+	for _, filename := range filenames {
+		downloadURL, ok := urls[filename]
+		if !ok {
+			b.WriteString(fmt.Sprintf("\n### %s\n❌ script not found\n", filename))
+			continue
+		}
+		if err := sd.checkTrusted(downloadURL); err != nil {
+			b.WriteString(fmt.Sprintf("\n### %s\n❌ %v\n", filename, err))
+			continue
+		}
+
+		resp, err := httpGet(ctx, downloadURL)
+		if err != nil {
+			b.WriteString(fmt.Sprintf("\n### %s\n❌ failed to download: %v\n", filename, err))
+			continue
+		}
+		content, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil || resp.StatusCode != http.StatusOK {
+			b.WriteString(fmt.Sprintf("\n### %s\n❌ download failed with status: %d\n", filename, resp.StatusCode))
+			continue
+		}
+
+		language := strings.TrimPrefix(strings.ToLower(filename[strings.LastIndex(filename, ".")+1:]), ".")
+		b.WriteString(fmt.Sprintf("\n### %s\nSource: %s\n```%s\n%s\n```\n", filename, downloadURL, language, strings.TrimRight(string(content), "\n")))
+		if warning := dependencyWarning(string(content)); warning != "" {
+			b.WriteString(warning + "\n")
+		}
+	}
+	return b.String(), nil
+}
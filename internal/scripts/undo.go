@@ -0,0 +1,110 @@
+package scripts
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/bridge"
+)
+
+const (
+	// ActionUndo is REAPER's "Edit: Undo" command ID
+	ActionUndo = "40029"
+	// ActionRedo is REAPER's "Edit: Redo" command ID
+	ActionRedo = "40030"
+)
+
+// UndoState describes what REAPER would undo or redo next
+type UndoState struct {
+	CanUndo string `json:"can_undo,omitempty"` // Description of the next undo point, empty if nothing to undo
+	CanRedo string `json:"can_redo,omitempty"` // Description of the next redo point, empty if nothing to redo
+}
+
+// Undo triggers REAPER's undo action via Web Remote
+func (wrc *WebRemoteClient) Undo(ctx context.Context) error {
+	return wrc.RunAction(ctx, ActionUndo)
+}
+
+// Redo triggers REAPER's redo action via Web Remote
+func (wrc *WebRemoteClient) Redo(ctx context.Context) error {
+	return wrc.RunAction(ctx, ActionRedo)
+}
+
+// GetUndoState reads the current undo/redo descriptions via the Lua bridge
+func GetUndoState(ctx context.Context) (*UndoState, error) {
+	rows, err := bridge.Run(ctx, "get_undo_history", `out(reaper.Undo_CanUndo2(0) or "", reaper.Undo_CanRedo2(0) or "")
+`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read undo state: %w", err)
+	}
+
+	state := &UndoState{}
+	if len(rows) > 0 {
+		state.CanUndo = rows[0][0]
+		if len(rows[0]) > 1 {
+			state.CanRedo = rows[0][1]
+		}
+	}
+	return state, nil
+}
+
+// FormatUndoState formats the undo state as a readable summary
+func FormatUndoState(state *UndoState) string {
+	var result strings.Builder
+	result.WriteString("REAPER Undo History:\n")
+
+	if state.CanUndo != "" {
+		result.WriteString(fmt.Sprintf("  Next undo: %s\n", state.CanUndo))
+	} else {
+		result.WriteString("  Next undo: (nothing to undo)\n")
+	}
+
+	if state.CanRedo != "" {
+		result.WriteString(fmt.Sprintf("  Next redo: %s\n", state.CanRedo))
+	} else {
+		result.WriteString("  Next redo: (nothing to redo)\n")
+	}
+
+	return result.String()
+}
+
+// UndoLabel derives an undo point description from a script name
+// (e.g. "normalize_selected-items.lua" becomes "Normalize selected items")
+func UndoLabel(scriptName string) string {
+	name := strings.TrimSuffix(scriptName, filepath.Ext(scriptName))
+	name = strings.Join(strings.FieldsFunc(name, func(r rune) bool {
+		return r == '_' || r == '-' || r == ' '
+	}), " ")
+	if name == "" {
+		return "Script"
+	}
+	first, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToUpper(first)) + name[size:]
+}
+
+// WrapUndoBlock wraps script content in Undo_BeginBlock/Undo_EndBlock so everything
+// it changes shows up as a single undo point labelled label. Content that already
+// manages its own undo block is returned unchanged.
+func WrapUndoBlock(content, scriptType, label string) (string, error) {
+	if strings.Contains(content, "Undo_BeginBlock") {
+		return content, nil
+	}
+
+	switch strings.TrimPrefix(strings.ToLower(scriptType), ".") {
+	case "lua":
+		// Run the body in a function so a top-level return still reaches Undo_EndBlock
+		return fmt.Sprintf("reaper.Undo_BeginBlock()\n\nlocal function main()\n%s\nend\n\nmain()\n\nreaper.Undo_EndBlock(%s, -1)\n",
+			content, bridge.LuaString(label)), nil
+	case "eel":
+		return fmt.Sprintf("Undo_BeginBlock();\n\n%s\n\nUndo_EndBlock(%s, -1);\n", content, strconv.Quote(label)), nil
+	case "py", "python":
+		return fmt.Sprintf("RPR_Undo_BeginBlock()\n\n%s\n\nRPR_Undo_EndBlock(%s, -1)\n", content, strconv.Quote(label)), nil
+	default:
+		return "", fmt.Errorf("unsupported script type: %s. Supported types: lua, eel, py", scriptType)
+	}
+}
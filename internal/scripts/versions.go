@@ -0,0 +1,292 @@
+package scripts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/errcode"
+)
+
+// InstalledScript records which marketplace version of a script is installed
+type InstalledScript struct {
+	SHA         string    `json:"sha"`          // Git blob SHA of the installed file
+	Source      string    `json:"source"`       // URL the script was downloaded from
+	InstalledAt time.Time `json:"installed_at"` // When this version was installed
+}
+
+// shortSHA abbreviates a version SHA for display
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+// recordInstall stores the marketplace version of a script just installed
+func (sm *ScriptManager) recordInstall(file GitHubFile) error {
+	return sm.updateMetadata(func(metadata *ScriptMetadata) error {
+		metadata.Installed[file.Name] = InstalledScript{
+			SHA:         file.SHA,
+			Source:      file.DownloadURL,
+			InstalledAt: time.Now(),
+		}
+		return nil
+	})
+}
+
+// resolveScriptFile finds the filename of a script by filename or base name,
+// preferring scripts installed from the marketplace
+func (sm *ScriptManager) resolveScriptFile(metadata *ScriptMetadata, script string) (string, error) {
+	script = strings.TrimSpace(script)
+	if script == "" {
+		return "", errors.New("script name is required")
+	}
+	if _, err := sm.scriptFilePath(script); err != nil {
+		return "", err
+	}
+	if _, ok := metadata.Installed[script]; ok {
+		return script, nil
+	}
+	for _, ext := range []string{".lua", ".eel", ".py"} {
+		if _, ok := metadata.Installed[script+ext]; ok {
+			return script + ext, nil
+		}
+	}
+	for _, name := range []string{script, script + ".lua", script + ".eel", script + ".py"} {
+		if isScriptFile(name) {
+			if path, err := sm.scriptFilePath(name); err == nil {
+				if _, err := sm.backend.FS.Stat(path); err == nil {
+					return name, nil
+				}
+			}
+		}
+	}
+	return "", errcode.Errorf(errcode.ScriptNotFound, "script not found: %s", script)
+}
+
+// PinScript pins a marketplace script at its installed version so updates skip it.
+// If version is given it must match the installed SHA (a prefix is enough).
+func (sm *ScriptManager) PinScript(script, version string) (string, error) {
+	var filename, sha string
+	err := sm.updateMetadata(func(metadata *ScriptMetadata) error {
+		var err error
+		filename, err = sm.resolveScriptFile(metadata, script)
+		if err != nil {
+			return err
+		}
+		installed, ok := metadata.Installed[filename]
+		if !ok || installed.SHA == "" {
+			return fmt.Errorf("'%s' wasn't installed from the marketplace, so there is no version to pin", filename)
+		}
+		version = strings.ToLower(strings.TrimSpace(version))
+		if version != "" && !strings.HasPrefix(installed.SHA, version) {
+			return fmt.Errorf("'%s' is installed at version %s, not %s; update or reinstall it at that version before pinning",
+				filename, shortSHA(installed.SHA), version)
+		}
+		sha = installed.SHA
+		metadata.Pins[filename] = sha
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Pinned '%s' at version %s. Updates will skip it until it is unpinned.", filename, shortSHA(sha)), nil
+}
+
+// UnpinScript removes the pin from a script so updates include it again
+func (sm *ScriptManager) UnpinScript(script string) (string, error) {
+	var filename string
+	err := sm.updateMetadata(func(metadata *ScriptMetadata) error {
+		var err error
+		filename, err = sm.resolveScriptFile(metadata, script)
+		if err != nil {
+			return err
+		}
+		if _, ok := metadata.Pins[filename]; !ok {
+			return fmt.Errorf("'%s' is not pinned", filename)
+		}
+		delete(metadata.Pins, filename)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Unpinned '%s'", filename), nil
+}
+
+// ScriptUpdateResult is the outcome of updating one script in UpdateScripts
+type ScriptUpdateResult struct {
+	Filename string `json:"filename"`
+	Status   string `json:"status"` // updated, up_to_date, pinned or failed
+	From     string `json:"from,omitempty"`
+	To       string `json:"to,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// UpdateScripts replaces installed scripts with their latest marketplace version.
+// With no names (or a single "all") every script installed from the marketplace is checked.
+// Pinned scripts are skipped.
+func (sd *ScriptDownloader) UpdateScripts(ctx context.Context, names []string, targetDir string) ([]ScriptUpdateResult, error) {
+	sm := NewScriptManager(targetDir)
+	metadata, err := sm.LoadMetadata()
+	if err != nil {
+		return nil, err
+	}
+	filenames, err := sm.updateTargets(metadata, names)
+	if err != nil {
+		return nil, err
+	}
+	latest, err := sd.latestVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]ScriptUpdateResult, len(filenames))
+	counter := &fileCounter{total: len(filenames), report: sd.progress}
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < downloadWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				filename := filenames[i]
+				results[i] = sd.updateOne(ctx, sm, metadata, filename, latest)
+				counter.finished(filename)
+			}
+		}()
+	}
+	for i := range filenames {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results, nil
+}
+
+// updateTargets resolves the scripts an update covers: the named ones, or every
+// script installed from the marketplace when names is empty or "all"
+func (sm *ScriptManager) updateTargets(metadata *ScriptMetadata, names []string) ([]string, error) {
+	var filenames []string
+	if len(names) == 0 || (len(names) == 1 && strings.EqualFold(names[0], "all")) {
+		for filename := range metadata.Installed {
+			filenames = append(filenames, filename)
+		}
+		sort.Strings(filenames)
+		if len(filenames) == 0 {
+			return nil, errors.New("no scripts installed from the marketplace to update")
+		}
+		return filenames, nil
+	}
+	for _, name := range names {
+		filename, err := sm.resolveScriptFile(metadata, name)
+		if err != nil {
+			return nil, err
+		}
+		filenames = append(filenames, filename)
+	}
+	return filenames, nil
+}
+
+// latestVersions fetches the marketplace scripts keyed by filename
+func (sd *ScriptDownloader) latestVersions(ctx context.Context) (map[string]GitHubFile, error) {
+	files, err := sd.fetchGitHubFiles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch scripts from GitHub: %w", err)
+	}
+	latest := make(map[string]GitHubFile)
+	for _, file := range files {
+		if file.Type == "file" && isScriptFile(file.Name) {
+			latest[file.Name] = file
+		}
+	}
+	return latest, nil
+}
+
+// updateOne brings a single script up to the marketplace version in latest
+func (sd *ScriptDownloader) updateOne(ctx context.Context, sm *ScriptManager, metadata *ScriptMetadata, filename string, latest map[string]GitHubFile) ScriptUpdateResult {
+	result := ScriptUpdateResult{Filename: filename, From: shortSHA(metadata.Installed[filename].SHA)}
+
+	if pinned, ok := metadata.Pins[filename]; ok {
+		result.Status = "pinned"
+		result.From = shortSHA(pinned)
+		return result
+	}
+
+	file, ok := latest[filename]
+	if !ok {
+		result.Status = "failed"
+		result.Error = "no longer available in the marketplace"
+		return result
+	}
+	result.To = shortSHA(file.SHA)
+	if installed, ok := metadata.Installed[filename]; ok && installed.SHA == file.SHA {
+		result.Status = "up_to_date"
+		return result
+	}
+
+	if err := sd.checkTrusted(file.DownloadURL); err != nil {
+		result.Status = "failed"
+		result.Error = err.Error()
+		return result
+	}
+	content, err := downloadScriptContent(ctx, file, sd.progress)
+	if err != nil {
+		result.Status = "failed"
+		result.Error = err.Error()
+		return result
+	}
+	path, err := sm.scriptFilePath(filename)
+	if err != nil {
+		result.Status = "failed"
+		result.Error = err.Error()
+		return result
+	}
+	err = sm.withDirLock("update "+filename, func() error {
+		return sm.writeFileAtomic(path, content)
+	})
+	if err != nil {
+		result.Status = "failed"
+		result.Error = fmt.Sprintf("failed to write script: %v", err)
+		return result
+	}
+	invalidateScriptCache(sm.scriptsDir)
+	sm.commitScript("update", filename, "")
+	if err := sm.recordInstall(file); err != nil {
+		result.Status = "failed"
+		result.Error = err.Error()
+		return result
+	}
+	result.Status = "updated"
+	return result
+}
+
+// FormatUpdateResults summarizes an update run
+func FormatUpdateResults(results []ScriptUpdateResult) string {
+	updated := 0
+	var b strings.Builder
+	for _, r := range results {
+		switch r.Status {
+		case "updated":
+			updated++
+			if r.From == "" {
+				b.WriteString(fmt.Sprintf("  ✅ %s: installed version %s\n", r.Filename, r.To))
+			} else {
+				b.WriteString(fmt.Sprintf("  ✅ %s: %s → %s\n", r.Filename, r.From, r.To))
+			}
+		case "up_to_date":
+			b.WriteString(fmt.Sprintf("  ➖ %s: up to date (%s)\n", r.Filename, r.To))
+		case "pinned":
+			b.WriteString(fmt.Sprintf("  📌 %s: pinned at %s, skipped\n", r.Filename, r.From))
+		default:
+			b.WriteString(fmt.Sprintf("  ❌ %s: %s\n", r.Filename, r.Error))
+		}
+	}
+	return fmt.Sprintf("Updated %d of %d scripts:\n", updated, len(results)) + b.String()
+}
@@ -0,0 +1,199 @@
+package scripts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// WebInterfacesAPIURL lists the custom Web Remote interfaces published in the marketplace repository
+const WebInterfacesAPIURL = "https://api.github.com/repos/johnjallday/ori-reaper/contents/web_interfaces?ref=dev"
+
+// WebInterfaces describes the installed and available Web Remote interface pages
+type WebInterfaces struct {
+	WWWRoot   string   `json:"www_root"`
+	Current   string   `json:"current,omitempty"`   // Default page of the HTTP csurf entry
+	Installed []string `json:"installed"`           // Pages in reaper_www_root
+	Available []string `json:"available,omitempty"` // Pages in the marketplace
+}
+
+// GetWebWWWRoot returns REAPER's folder for user Web Remote interfaces
+func GetWebWWWRoot() (string, error) {
+	basePath, err := GetReaperResourcePath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(basePath, "reaper_www_root"), nil
+}
+
+// isWebInterfaceFile checks if a filename is a Web Remote interface page
+func isWebInterfaceFile(filename string) bool {
+	lower := strings.ToLower(filename)
+	return strings.HasSuffix(lower, ".html") || strings.HasSuffix(lower, ".htm")
+}
+
+// fetchWebInterfaceFiles lists the interface pages in the marketplace repository
+func fetchWebInterfaceFiles(ctx context.Context) ([]GitHubFile, error) {
+	resp, err := httpGet(ctx, WebInterfacesAPIURL)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("GitHub API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var files []GitHubFile
+	if err := json.NewDecoder(resp.Body).Decode(&files); err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub API response: %w", err)
+	}
+
+	var pages []GitHubFile
+	for _, file := range files {
+		if file.Type == "file" && isWebInterfaceFile(file.Name) {
+			pages = append(pages, file)
+		}
+	}
+	return pages, nil
+}
+
+// ListWebInterfaces reports the interface the Web Remote serves, the pages installed in
+// reaper_www_root and, if the marketplace is reachable, the pages available to install
+func ListWebInterfaces(ctx context.Context) (*WebInterfaces, error) {
+	root, err := GetWebWWWRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &WebInterfaces{WWWRoot: root}
+	if config, err := GetWebRemoteConfig(); err == nil {
+		result.Current = config.DefaultPage
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read reaper_www_root: %w", err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() && isWebInterfaceFile(entry.Name()) {
+			result.Installed = append(result.Installed, entry.Name())
+		}
+	}
+	sort.Strings(result.Installed)
+
+	// The marketplace is optional; list what's installed even when offline
+	if files, err := fetchWebInterfaceFiles(ctx); err == nil {
+		for _, file := range files {
+			result.Available = append(result.Available, file.Name)
+		}
+	}
+	return result, nil
+}
+
+// InstallWebInterface installs a Web Remote interface page into reaper_www_root,
+// either from the marketplace by filename or from a local file at sourcePath.
+// Pages from the marketplace must come from a trusted source, since they run in the
+// browser with access to REAPER. Returns the installed path.
+func (sd *ScriptDownloader) InstallWebInterface(ctx context.Context, filename, sourcePath string) (string, error) {
+	var content []byte
+
+	switch {
+	case sourcePath != "":
+		data, err := os.ReadFile(sourcePath)
+		if err != nil {
+			return "", fmt.Errorf("failed to read interface file: %w", err)
+		}
+		content = data
+		if filename == "" {
+			filename = filepath.Base(sourcePath)
+		}
+	case filename != "":
+		files, err := fetchWebInterfaceFiles(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to fetch web interfaces from GitHub: %w", err)
+		}
+		var downloadURL string
+		for _, file := range files {
+			if file.Name == filename {
+				downloadURL = file.DownloadURL
+				break
+			}
+		}
+		if downloadURL == "" {
+			return "", fmt.Errorf("web interface not found: %s", filename)
+		}
+		if err := sd.checkTrusted(downloadURL); err != nil {
+			return "", err
+		}
+
+		resp, err := httpGet(ctx, downloadURL)
+		if err != nil {
+			return "", fmt.Errorf("failed to download web interface: %w", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("download failed with status: %d", resp.StatusCode)
+		}
+		content, err = io.ReadAll(resp.Body)
+		if err != nil {
+			return "", fmt.Errorf("failed to read web interface content: %w", err)
+		}
+	default:
+		return "", errors.New("filename or path is required for 'install_web_interface' operation")
+	}
+
+	filename = filepath.Base(filename)
+	if !isWebInterfaceFile(filename) {
+		return "", fmt.Errorf("web interfaces must be .html files: %s", filename)
+	}
+
+	root, err := GetWebWWWRoot()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return "", fmt.Errorf("failed to create reaper_www_root: %w", err)
+	}
+
+	target := filepath.Join(root, filename)
+	if err := os.WriteFile(target, content, 0644); err != nil {
+		return "", fmt.Errorf("failed to write web interface: %w", err)
+	}
+	return target, nil
+}
+
+// FormatWebInterfaces formats the Web Remote interface listing
+func FormatWebInterfaces(interfaces *WebInterfaces) string {
+	var b strings.Builder
+	current := interfaces.Current
+	if current == "" {
+		current = "(unknown; no HTTP Web Remote entry found)"
+	}
+	b.WriteString(fmt.Sprintf("Web Remote default page: %s\n", current))
+	b.WriteString(fmt.Sprintf("Interface folder: %s\n\n", interfaces.WWWRoot))
+
+	b.WriteString("Installed interfaces:\n")
+	if len(interfaces.Installed) == 0 {
+		b.WriteString("  (none)\n")
+	}
+	for _, name := range interfaces.Installed {
+		b.WriteString(fmt.Sprintf("  - %s\n", name))
+	}
+
+	if len(interfaces.Available) > 0 {
+		b.WriteString("\nAvailable in the marketplace:\n")
+		for _, name := range interfaces.Available {
+			b.WriteString(fmt.Sprintf("  - %s\n", name))
+		}
+	}
+	return b.String()
+}
@@ -1,13 +1,21 @@
 package scripts
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"math"
+	"net"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
-	"time"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/errcode"
+	"github.com/johnjallday/ori-reaper-plugin/internal/i18n"
+	"github.com/johnjallday/ori-reaper-plugin/internal/metrics"
+	"github.com/johnjallday/ori-reaper-plugin/internal/platform"
 )
 
 // Track represents a REAPER track with its properties
@@ -21,12 +29,23 @@ type Track struct {
 	RecArm    bool    `json:"rec_arm,omitempty"`    // Record arm state
 	Selected  bool    `json:"selected,omitempty"`   // Selection state
 	FXEnabled bool    `json:"fx_enabled,omitempty"` // FX enabled state
+	Folder    bool    `json:"folder,omitempty"`     // Track is a folder (bus) containing the tracks below it
+	Depth     int     `json:"depth,omitempty"`      // Nesting level, 0 for top-level tracks; set by ApplyTrackLayout
+	Parent    int     `json:"parent,omitempty"`     // Index of the enclosing folder track, 0 for top-level tracks; set by ApplyTrackLayout
+	PeakDB    float64 `json:"peak_db"`              // Last meter peak (dB), -150 for silence
+
+	Groups []GroupMembership `json:"groups,omitempty"` // Track groups linking this track's faders to others; set by ApplyTrackLayout
+
+	AutomationMode string `json:"automation_mode,omitempty"` // Automation mode (trim/read/touch/write/latch)
 }
 
 // WebRemoteClient handles communication with REAPER's Web Remote interface
 type WebRemoteClient struct {
-	baseURL string
-	client  *http.Client
+	baseURL  string
+	client   *http.Client
+	username string // Basic auth credentials, empty when the Web Remote is open
+	password string
+	retry    RetryPolicy
 }
 
 // NewWebRemoteClient creates a new Web Remote client
@@ -41,19 +60,152 @@ func NewWebRemoteClient(port int) (*WebRemoteClient, error) {
 		port = detectedPort
 	}
 
+	client := NewWebRemoteClientAt("localhost", port)
+
+	// Use the credentials from the csurf entry by default; settings can override them
+	if config, err := GetWebRemoteConfig(); err == nil && config.Port == port && config.HasAuth() {
+		username, password, _ := strings.Cut(config.AuthString, ":")
+		client.SetCredentials(username, password)
+	}
+
+	return client, nil
+}
+
+// NewWebRemoteClientAt creates a Web Remote client for REAPER running on host,
+// which may be another machine on the network
+func NewWebRemoteClientAt(host string, port int) *WebRemoteClient {
 	return &WebRemoteClient{
-		baseURL: fmt.Sprintf("http://localhost:%d", port),
-		client: &http.Client{
-			Timeout: 5 * time.Second,
-		},
-	}, nil
+		baseURL: "http://" + net.JoinHostPort(host, strconv.Itoa(port)),
+		client:  newHTTPClient(webRemoteTimeout()),
+		retry:   DefaultRetryPolicy,
+	}
+}
+
+// SetRetryPolicy changes how connection failures are retried
+func (wrc *WebRemoteClient) SetRetryPolicy(policy RetryPolicy) {
+	wrc.retry = policy
+}
+
+// BaseURL returns the Web Remote address the client talks to
+func (wrc *WebRemoteClient) BaseURL() string {
+	return wrc.baseURL
+}
+
+// ParseWebRemoteHost validates a web_remote_host setting such as "studio.local",
+// "192.168.1.20:8080" or "http://studio.local:8080". A port in the value overrides defaultPort.
+func ParseWebRemoteHost(value string, defaultPort int) (string, int, error) {
+	value = strings.TrimSpace(value)
+	value = strings.TrimPrefix(value, "http://")
+	value = strings.TrimSuffix(value, "/")
+	if value == "" {
+		return "localhost", defaultPort, nil
+	}
+	if strings.ContainsAny(value, "/ \t?#@") {
+		return "", 0, fmt.Errorf("invalid web_remote_host %q: expected a host name or IP, optionally with :port", value)
+	}
+
+	host, port := value, defaultPort
+	if h, p, err := net.SplitHostPort(value); err == nil {
+		parsed, err := strconv.Atoi(p)
+		if err != nil || parsed < 1 || parsed > 65535 {
+			return "", 0, fmt.Errorf("invalid port in web_remote_host %q", value)
+		}
+		host, port = h, parsed
+	} else if strings.Count(value, ":") == 1 {
+		return "", 0, fmt.Errorf("invalid web_remote_host %q: %w", value, err)
+	}
+
+	host = strings.Trim(host, "[]")
+	if host == "" {
+		return "", 0, fmt.Errorf("invalid web_remote_host %q: missing host", value)
+	}
+	return host, port, nil
+}
+
+// IsLocalHost reports whether host refers to this machine
+func IsLocalHost(host string) bool {
+	return host == "" || isLoopbackHost(strings.ToLower(host))
+}
+
+// SetCredentials sets the username and password sent with every request
+func (wrc *WebRemoteClient) SetCredentials(username, password string) {
+	wrc.username = username
+	wrc.password = password
+}
+
+// get sends a GET request to the Web Remote, with credentials if set.
+// The request and any retries stop when ctx is done.
+func (wrc *WebRemoteClient) get(ctx context.Context, url string) (resp *http.Response, err error) {
+	defer func() {
+		// Requests the caller gave up on are neither successes nor failures
+		if err == nil || ctx.Err() == nil {
+			metrics.CountWebRemote(err != nil || resp.StatusCode >= http.StatusBadRequest)
+		}
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if wrc.username != "" || wrc.password != "" {
+		req.SetBasicAuth(wrc.username, wrc.password)
+	}
+
+	// Fail fast while a recent attempt found REAPER unreachable
+	if err := checkCircuit(wrc.baseURL); err != nil {
+		return nil, err
+	}
+
+	backoff := wrc.retry.InitialBackoff
+	for attempt := 0; ; attempt++ {
+		resp, err = wrc.client.Do(req)
+		if err == nil {
+			break
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		// Only a request that never reached REAPER is safe to send again: commands run
+		// actions, and a timeout or reset after sending may come after the action ran
+		if !isDialError(err) {
+			return nil, errcode.New(errcode.WebRemoteUnreachable, err)
+		}
+		if attempt >= wrc.retry.MaxRetries {
+			openCircuit(wrc.baseURL, err)
+			return nil, errcode.New(errcode.WebRemoteUnreachable, i18n.Errorf("error.web_remote_unreachable", attempt+1, err))
+		}
+		if err := platform.Sleep(ctx, backoff); err != nil {
+			return nil, err
+		}
+		backoff *= 2
+		if backoff > wrc.retry.MaxBackoff {
+			backoff = wrc.retry.MaxBackoff
+		}
+	}
+	closeCircuit(wrc.baseURL)
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		resp.Body.Close()
+		if wrc.username == "" {
+			return nil, errcode.New(errcode.WebRemoteAuthFailed, i18n.Errorf("error.web_remote_credentials_required"))
+		}
+		return nil, errcode.New(errcode.WebRemoteAuthFailed, i18n.Errorf("error.web_remote_credentials_rejected"))
+	}
+	return resp, nil
+}
+
+// isDialError reports whether err is a failure to connect, such as a refused connection,
+// which means the request was never sent
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
 }
 
 // GetTracks retrieves all tracks from REAPER via Web Remote API
-func (wrc *WebRemoteClient) GetTracks() ([]Track, error) {
+func (wrc *WebRemoteClient) GetTracks(ctx context.Context) ([]Track, error) {
 	url := wrc.baseURL + "/_/TRACK"
 
-	resp, err := wrc.client.Get(url)
+	resp, err := wrc.get(ctx, url)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to REAPER Web Remote at %s: %w (is REAPER running?)", url, err)
 	}
@@ -76,9 +228,42 @@ func (wrc *WebRemoteClient) GetTracks() ([]Track, error) {
 	return tracks, nil
 }
 
+// SendCommand sends one or more Web Remote commands (action IDs or API commands such as TRANSPORT)
+// and returns the raw response body
+func (wrc *WebRemoteClient) SendCommand(ctx context.Context, commands ...string) (string, error) {
+	url := wrc.baseURL + "/_/" + strings.Join(commands, ";")
+
+	resp, err := wrc.get(ctx, url)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to REAPER Web Remote at %s: %w (is REAPER running?)", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("REAPER Web Remote returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+
+	return string(body), nil
+}
+
+// RunAction triggers a REAPER action by its command ID (e.g. "40029" or "_SWS_ABOUT")
+func (wrc *WebRemoteClient) RunAction(ctx context.Context, commandID string) error {
+	commandID = strings.TrimSpace(commandID)
+	if commandID == "" {
+		return fmt.Errorf("command ID is required")
+	}
+	_, err := wrc.SendCommand(ctx, commandID)
+	return err
+}
+
 // GetTrackNames retrieves just the track names (simplified)
-func (wrc *WebRemoteClient) GetTrackNames() ([]string, error) {
-	tracks, err := wrc.GetTracks()
+func (wrc *WebRemoteClient) GetTrackNames(ctx context.Context) ([]string, error) {
+	tracks, err := wrc.GetTracks(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -91,10 +276,20 @@ func (wrc *WebRemoteClient) GetTrackNames() ([]string, error) {
 	return names, nil
 }
 
+// Track flags reported in field 3 of a Web Remote TRACK line
+const (
+	trackFlagFolder   = 1
+	trackFlagSelected = 2
+	trackFlagMuted    = 8
+	trackFlagSoloed   = 16
+	trackFlagRecArmed = 64
+)
+
 // parseTrackData parses the REAPER Web Remote TRACK response
 // Actual format from REAPER Web Remote API (tab-delimited):
-// TRACK\t{index}\t{name}\t{color}\t{volume_mult}\t{pan}\t{?}\t{?}\t{?}\t{?}\t{mute}\t{solo}\t{recarm}\t{?}
+// TRACK\t{index}\t{name}\t{flags}\t{volume_mult}\t{pan}\t{last_meter_peak}\t{last_meter_pos}\t{width}\t{panmode}\t{sendcnt}\t{recvcnt}\t{hwoutcnt}\t{color}
 // Example: TRACK	1	Tame Impala - Breathe Deeper	8	1.000000	0.000000	-1500	-1500	1.000000	3	0	0	0	0
+// Index 0 is the master track.
 func parseTrackData(data string) ([]Track, error) {
 	lines := strings.Split(strings.TrimSpace(data), "\n")
 	tracks := make([]Track, 0, len(lines))
@@ -127,8 +322,14 @@ func parseTrackData(data string) ([]Track, error) {
 		// Field 2: Track name
 		track.Name = fields[2]
 
-		// Field 3: Unknown (color?)
-		// Skip
+		// Field 3: Flags bitmask (folder, selected, has FX, muted, soloed, ..., record armed)
+		if flags, err := strconv.Atoi(fields[3]); err == nil {
+			track.Folder = flags&trackFlagFolder != 0
+			track.Selected = flags&trackFlagSelected != 0
+			track.Mute = flags&trackFlagMuted != 0
+			track.Solo = flags&trackFlagSoloed != 0
+			track.RecArm = flags&trackFlagRecArmed != 0
+		}
 
 		// Field 4: Volume multiplier (convert to dB)
 		if volMult, err := strconv.ParseFloat(fields[4], 64); err == nil {
@@ -144,16 +345,12 @@ func parseTrackData(data string) ([]Track, error) {
 			track.Pan = pan
 		}
 
-		// Fields 6-9: Unknown (skip)
-
-		// Field 10: Mute (0 or 1)
-		track.Mute = (fields[10] == "1")
-
-		// Field 11: Solo (0, 1, or 2)
-		track.Solo = (fields[11] == "1" || fields[11] == "2")
+		// Field 6: Last meter peak in tenths of a dB
+		if peak, err := strconv.Atoi(fields[6]); err == nil {
+			track.PeakDB = float64(peak) / 10
+		}
 
-		// Field 12: Record arm (0 or 1)
-		track.RecArm = (fields[12] == "1")
+		// Fields 7-13: Meter position, width, pan mode, routing counts and color (skip)
 
 		// Always add the track (even if name is empty)
 		tracks = append(tracks, track)
@@ -162,24 +359,79 @@ func parseTrackData(data string) ([]Track, error) {
 	return tracks, nil
 }
 
+// GetSelectedTracks returns the tracks currently selected in REAPER
+func (wrc *WebRemoteClient) GetSelectedTracks(ctx context.Context) ([]Track, error) {
+	tracks, err := wrc.GetTracks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var selected []Track
+	for _, track := range tracks {
+		if track.Selected {
+			selected = append(selected, track)
+		}
+	}
+	return selected, nil
+}
+
+// SelectTracks selects the tracks with the given indexes (1-based, 0 being the master).
+// Unless add is set, every other track is deselected. Returns the tracks selected afterwards.
+func (wrc *WebRemoteClient) SelectTracks(ctx context.Context, indexes []int, add bool) ([]Track, error) {
+	tracks, err := wrc.GetTracks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[int]bool, len(indexes))
+	for _, index := range indexes {
+		wanted[index] = true
+	}
+	var commands []string
+	for _, track := range tracks {
+		switch {
+		case wanted[track.Index] && !track.Selected:
+			commands = append(commands, fmt.Sprintf("SET/TRACK/%d/SEL/1", track.Index))
+		case !wanted[track.Index] && track.Selected && !add:
+			commands = append(commands, fmt.Sprintf("SET/TRACK/%d/SEL/0", track.Index))
+		}
+		delete(wanted, track.Index)
+	}
+	if len(wanted) > 0 {
+		missing := make([]int, 0, len(wanted))
+		for index := range wanted {
+			missing = append(missing, index)
+		}
+		sort.Ints(missing)
+		return nil, fmt.Errorf("no track with number %v in the project; use 'get_tracks' to see the track numbers", missing)
+	}
+
+	if len(commands) > 0 {
+		if _, err := wrc.SendCommand(ctx, commands...); err != nil {
+			return nil, fmt.Errorf("failed to change the track selection: %w", err)
+		}
+	}
+	return wrc.GetSelectedTracks(ctx)
+}
+
 // GetTracksFromREAPER is a convenience function that auto-detects the port and retrieves tracks
-func GetTracksFromREAPER() ([]Track, error) {
+func GetTracksFromREAPER(ctx context.Context) ([]Track, error) {
 	client, err := NewWebRemoteClient(0) // 0 = auto-detect
 	if err != nil {
 		return nil, err
 	}
 
-	return client.GetTracks()
+	return client.GetTracks(ctx)
 }
 
 // GetTrackNamesFromREAPER is a convenience function that auto-detects the port and retrieves track names
-func GetTrackNamesFromREAPER() ([]string, error) {
+func GetTrackNamesFromREAPER(ctx context.Context) ([]string, error) {
 	client, err := NewWebRemoteClient(0) // 0 = auto-detect
 	if err != nil {
 		return nil, err
 	}
 
-	return client.GetTrackNames()
+	return client.GetTrackNames(ctx)
 }
 
 // FormatTracksTable formats tracks as a readable table
@@ -190,8 +442,8 @@ func FormatTracksTable(tracks []Track) string {
 
 	var result strings.Builder
 	result.WriteString(fmt.Sprintf("Found %d tracks:\n\n", len(tracks)))
-	result.WriteString("Index | Name                    | Volume  | Pan    | M | S | R\n")
-	result.WriteString("------|-------------------------|---------|--------|---|---|---\n")
+	result.WriteString("Index | Name                    | Volume  | Pan    | M | S | R | Sel\n")
+	result.WriteString("------|-------------------------|---------|--------|---|---|---|----\n")
 
 	for _, track := range tracks {
 		// Format flags
@@ -207,6 +459,16 @@ func FormatTracksTable(tracks []Track) string {
 		if track.RecArm {
 			recFlag = "R"
 		}
+		selFlag := " "
+		if track.Selected {
+			selFlag = "*"
+		}
+
+		// Indent tracks inside folders and mark folders like directories
+		name := strings.Repeat("  ", track.Depth) + track.Name
+		if track.Folder {
+			name += "/"
+		}
 
 		// Format pan
 		panStr := "Center"
@@ -216,18 +478,22 @@ func FormatTracksTable(tracks []Track) string {
 			panStr = fmt.Sprintf("R%.0f%%", track.Pan*100)
 		}
 
-		result.WriteString(fmt.Sprintf("%-5d | %-23s | %6.1fdB | %-6s | %s | %s | %s\n",
+		result.WriteString(fmt.Sprintf("%-5d | %-23s | %6.1fdB | %-6s | %s | %s | %s | %s\n",
 			track.Index,
-			truncateString(track.Name, 23),
+			truncateString(name, 23),
 			track.Volume,
 			panStr,
 			muteFlag,
 			soloFlag,
 			recFlag,
+			selFlag,
 		))
 	}
 
-	result.WriteString("\nLegend: M=Muted, S=Solo, R=Record Armed")
+	result.WriteString("\nLegend: M=Muted, S=Solo, R=Record Armed, *=Selected, Name/=Folder")
+	if groups := FormatTrackGroups(tracks); groups != "" {
+		result.WriteString("\n\n" + strings.TrimSuffix(groups, "\n"))
+	}
 	return result.String()
 }
 
@@ -259,10 +525,10 @@ func truncateString(s string, maxLen int) string {
 }
 
 // GetProjectInfo retrieves general project information from REAPER
-func (wrc *WebRemoteClient) GetProjectInfo() (map[string]string, error) {
+func (wrc *WebRemoteClient) GetProjectInfo(ctx context.Context) (map[string]string, error) {
 	url := wrc.baseURL + "/_"
 
-	resp, err := wrc.client.Get(url)
+	resp, err := wrc.get(ctx, url)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to REAPER Web Remote: %w", err)
 	}
@@ -290,14 +556,14 @@ func (wrc *WebRemoteClient) GetProjectInfo() (map[string]string, error) {
 }
 
 // IsWebRemoteRunning checks if REAPER Web Remote is accessible
-func IsWebRemoteRunning() bool {
+func IsWebRemoteRunning(ctx context.Context) bool {
 	client, err := NewWebRemoteClient(0)
 	if err != nil {
 		return false
 	}
 
 	url := client.baseURL + "/_"
-	resp, err := client.client.Get(url)
+	resp, err := client.get(ctx, url)
 	if err != nil {
 		return false
 	}
@@ -0,0 +1,141 @@
+package scripts
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/platform"
+)
+
+// Timeouts for the REAPER restart done by SetupWebRemote
+const (
+	reaperQuitTimeout  = 60 * time.Second
+	reaperStartTimeout = 30 * time.Second
+)
+
+// WebRemoteSetupResult reports what SetupWebRemote found and changed
+type WebRemoteSetupResult struct {
+	Config    *WebRemoteConfig `json:"config"`
+	Steps     []string         `json:"steps"`     // What was done, in order
+	Reachable bool             `json:"reachable"` // Whether the Web Remote answered at the end
+}
+
+// Ping checks that the Web Remote answers a transport query
+func (wrc *WebRemoteClient) Ping(ctx context.Context) error {
+	resp, err := wrc.get(ctx, wrc.baseURL+"/_/TRANSPORT")
+	if err != nil {
+		return fmt.Errorf("HTTP request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	// Read the body so the keep-alive connection can be reused
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("web remote returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// SetupWebRemote makes sure REAPER's Web Remote is configured and enabled on port
+// (0 keeps the existing port, or uses the default for a new entry), optionally
+// restarting REAPER, then checks that it responds.
+// REAPER rewrites reaper.ini when it exits, so with restart set REAPER is quit
+// before the file is changed.
+func SetupWebRemote(ctx context.Context, port int, restart bool) (*WebRemoteSetupResult, error) {
+	result := &WebRemoteSetupResult{}
+
+	existing, err := GetWebRemoteConfig()
+	switch {
+	case err != nil:
+		result.Steps = append(result.Steps, "No Web Remote entry found in reaper.ini")
+	case existing.Enabled && (port == 0 || port == existing.Port):
+		result.Steps = append(result.Steps, fmt.Sprintf("Found enabled Web Remote on port %d (csurf_%d)", existing.Port, existing.CSurfID))
+		result.Config = existing
+	default:
+		state := "disabled"
+		if existing.Enabled {
+			state = "enabled"
+		}
+		result.Steps = append(result.Steps, fmt.Sprintf("Found %s Web Remote on port %d (csurf_%d)", state, existing.Port, existing.CSurfID))
+	}
+
+	running, err := platform.IsReaperRunning()
+	if err != nil {
+		return nil, fmt.Errorf("could not check for REAPER process: %w", err)
+	}
+
+	needsChange := result.Config == nil
+	if needsChange && running && restart {
+		if err := platform.QuitReaper(ctx, reaperQuitTimeout); err != nil {
+			return nil, err
+		}
+		result.Steps = append(result.Steps, "Quit REAPER")
+		running = false
+	}
+
+	if needsChange {
+		enabled := true
+		config, err := ConfigureWebRemote(&enabled, port)
+		if err != nil {
+			return nil, err
+		}
+		result.Config = config
+		result.Steps = append(result.Steps, fmt.Sprintf("Configured Web Remote on port %d (csurf_%d)", config.Port, config.CSurfID))
+		if running {
+			result.Steps = append(result.Steps, "⚠️ REAPER is running and may overwrite reaper.ini when it exits; quit REAPER and run this again with restart, or re-apply the change while REAPER is closed")
+		}
+	}
+
+	if restart && !running {
+		if err := platform.LaunchReaper(); err != nil {
+			return nil, err
+		}
+		result.Steps = append(result.Steps, "Started REAPER")
+	}
+
+	client, err := NewWebRemoteClient(result.Config.Port)
+	if err != nil {
+		return nil, err
+	}
+	// Poll directly: the loop below does its own waiting between attempts
+	client.SetRetryPolicy(RetryPolicy{})
+
+	wait := time.Duration(0)
+	if restart {
+		wait = reaperStartTimeout
+	}
+	deadline := time.Now().Add(wait)
+	for {
+		closeCircuit(client.baseURL)
+		if err := client.Ping(ctx); err == nil {
+			result.Reachable = true
+			break
+		}
+		if time.Now().After(deadline) {
+			break
+		}
+		if err := platform.Sleep(ctx, time.Second); err != nil {
+			return nil, err
+		}
+	}
+
+	if result.Reachable {
+		result.Steps = append(result.Steps, fmt.Sprintf("Web Remote is reachable at http://localhost:%d", result.Config.Port))
+	} else {
+		result.Steps = append(result.Steps, fmt.Sprintf("Web Remote is not reachable at http://localhost:%d yet; restart REAPER to apply the configuration", result.Config.Port))
+	}
+	return result, nil
+}
+
+// FormatWebRemoteSetup formats the steps taken by SetupWebRemote
+func FormatWebRemoteSetup(result *WebRemoteSetupResult) string {
+	var b strings.Builder
+	b.WriteString("Web Remote setup:\n")
+	for i, step := range result.Steps {
+		b.WriteString(fmt.Sprintf("  %d. %s\n", i+1, step))
+	}
+	return b.String()
+}
@@ -0,0 +1,201 @@
+package scripts
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/errcode"
+	"github.com/johnjallday/ori-reaper-plugin/pkg/webremote/mockserver"
+)
+
+// fastRetries retries quickly so tests don't wait out the default backoff
+var fastRetries = RetryPolicy{MaxRetries: 2, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+// newTestWebRemoteClient returns a client for the server at addr ("host:port")
+func newTestWebRemoteClient(t *testing.T, addr string) *WebRemoteClient {
+	t.Helper()
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	portNumber, err := net.LookupPort("tcp", port)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewWebRemoteClientAt(host, portNumber)
+	client.SetRetryPolicy(fastRetries)
+	t.Cleanup(func() { closeCircuit(client.BaseURL()) })
+	return client
+}
+
+// startMockWebRemote serves mock on a free port for the rest of the test and returns a client for it
+func startMockWebRemote(t *testing.T, mock *mockserver.Server) *WebRemoteClient {
+	t.Helper()
+	server, addr, err := mock.Start("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.Close() })
+	return newTestWebRemoteClient(t, addr)
+}
+
+func TestWebRemoteGetTracks(t *testing.T) {
+	client := startMockWebRemote(t, mockserver.New(
+		mockserver.Track{Name: "Drums", Volume: 1, HasFX: true, Peak: 1},
+		mockserver.Track{Name: "Bass", Volume: 0.5, Pan: -0.25, Mute: true},
+		mockserver.Track{Name: "Vocals", Volume: 1, Solo: true, RecArm: true, Selected: true},
+	))
+
+	tracks, err := client.GetTracks(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := make(map[string]Track)
+	for _, track := range tracks {
+		byName[track.Name] = track
+	}
+	for _, name := range []string{"Drums", "Bass", "Vocals"} {
+		if _, ok := byName[name]; !ok {
+			t.Fatalf("GetTracks = %+v, want a track named %s", tracks, name)
+		}
+	}
+
+	if drums := byName["Drums"]; drums.Index != 1 || drums.Volume != 0 || drums.PeakDB != 0 {
+		t.Errorf("Drums = %+v, want index 1 at 0 dB peaking at 0 dB", drums)
+	}
+	if bass := byName["Bass"]; bass.Index != 2 || math.Abs(bass.Volume+6.02) > 0.01 || bass.Pan != -0.25 || !bass.Mute || bass.Solo {
+		t.Errorf("Bass = %+v, want index 2 at -6.02 dB, panned -0.25 and muted", bass)
+	}
+	if vocals := byName["Vocals"]; vocals.Index != 3 || !vocals.Solo || !vocals.RecArm || !vocals.Selected || vocals.Mute {
+		t.Errorf("Vocals = %+v, want index 3 soloed, armed and selected", vocals)
+	}
+	if silent := byName["Bass"].PeakDB; silent != -150 {
+		t.Errorf("silent track peak = %v dB, want -150", silent)
+	}
+}
+
+func TestWebRemoteSendCommand(t *testing.T) {
+	mock := mockserver.New(mockserver.Track{Name: "Drums", Volume: 1})
+	client := startMockWebRemote(t, mock)
+
+	response, err := client.SendCommand(context.Background(), mockserver.ActionPlay, "TRANSPORT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mock.PlayState() != mockserver.Playing {
+		t.Errorf("play state = %d after the play action, want %d", mock.PlayState(), mockserver.Playing)
+	}
+	if !strings.HasPrefix(response, "TRANSPORT\t1\t") {
+		t.Errorf("SendCommand response = %q, want the TRANSPORT line of a playing transport", response)
+	}
+
+	if err := client.RunAction(context.Background(), mockserver.ActionInsertTrack); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(mock.Tracks()); got != 2 {
+		t.Errorf("%d tracks after inserting one, want 2", got)
+	}
+	if got, want := mock.Commands(), []string{mockserver.ActionPlay, "TRANSPORT", mockserver.ActionInsertTrack}; !slices.Equal(got, want) {
+		t.Errorf("commands received = %q, want %q", got, want)
+	}
+}
+
+func TestWebRemoteCredentials(t *testing.T) {
+	mock := mockserver.New()
+	mock.SetCredentials("ori", "secret")
+	client := startMockWebRemote(t, mock)
+
+	if _, err := client.SendCommand(context.Background(), "TRANSPORT"); !errors.Is(err, errcode.WebRemoteAuthFailed) {
+		t.Errorf("SendCommand without credentials = %v, want %s", err, errcode.WebRemoteAuthFailed)
+	}
+	client.SetCredentials("ori", "wrong")
+	if _, err := client.SendCommand(context.Background(), "TRANSPORT"); !errors.Is(err, errcode.WebRemoteAuthFailed) {
+		t.Errorf("SendCommand with the wrong password = %v, want %s", err, errcode.WebRemoteAuthFailed)
+	}
+	client.SetCredentials("ori", "secret")
+	if _, err := client.SendCommand(context.Background(), "TRANSPORT"); err != nil {
+		t.Errorf("SendCommand with credentials = %v, want success", err)
+	}
+}
+
+func TestWebRemoteCircuitOpensWhileUnreachable(t *testing.T) {
+	mock := mockserver.New()
+	server, addr, err := mock.Start("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := newTestWebRemoteClient(t, addr)
+	server.Close() // REAPER quit
+
+	if _, err := client.SendCommand(context.Background(), "TRANSPORT"); !errors.Is(err, errcode.WebRemoteUnreachable) {
+		t.Fatalf("SendCommand while REAPER is down = %v, want %s", err, errcode.WebRemoteUnreachable)
+	}
+
+	// REAPER is back, but the circuit keeps failing fast until it closes
+	server, _, err = mock.Start(addr)
+	if err != nil {
+		t.Skipf("can't restart the mock on %s: %v", addr, err)
+	}
+	defer server.Close()
+	if _, err := client.SendCommand(context.Background(), "TRANSPORT"); !errors.Is(err, errcode.WebRemoteUnreachable) {
+		t.Errorf("SendCommand with the circuit open = %v, want %s", err, errcode.WebRemoteUnreachable)
+	}
+	if got := mock.Commands(); len(got) != 0 {
+		t.Errorf("REAPER received %q with the circuit open, want nothing", got)
+	}
+
+	closeCircuit(client.BaseURL())
+	if _, err := client.SendCommand(context.Background(), "TRANSPORT"); err != nil {
+		t.Errorf("SendCommand after the circuit closed = %v, want success", err)
+	}
+}
+
+func TestWebRemoteRetriesRefusedConnections(t *testing.T) {
+	client := newTestWebRemoteClient(t, "127.0.0.1:9")
+	var dials atomic.Int32
+	client.client = &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			dials.Add(1)
+			return nil, &net.OpError{Op: "dial", Net: network, Err: syscall.ECONNREFUSED}
+		},
+	}}
+
+	_, err := client.SendCommand(context.Background(), "40001")
+	if !errors.Is(err, errcode.WebRemoteUnreachable) {
+		t.Fatalf("SendCommand error = %v, want %s", err, errcode.WebRemoteUnreachable)
+	}
+	if got, want := dials.Load(), int32(fastRetries.MaxRetries+1); got != want {
+		t.Errorf("connection attempts = %d, want %d", got, want)
+	}
+}
+
+func TestWebRemoteDoesNotResendAfterConnectionDrop(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		// REAPER got the command but the connection dropped before it answered
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			conn.Close()
+		}
+	}))
+	defer server.Close()
+	client := newTestWebRemoteClient(t, server.Listener.Addr().String())
+
+	if _, err := client.SendCommand(context.Background(), "40001"); err == nil {
+		t.Fatal("SendCommand succeeded, want the dropped connection's error")
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("REAPER received the command %d times, want 1", got)
+	}
+}
@@ -0,0 +1,74 @@
+package settings
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// DataDirEnv names the environment variable the agent can set to tell the plugin where
+// its data directory (the one holding agents.json) is
+const DataDirEnv = "ORI_AGENT_DATA_DIR"
+
+// SetDataDir sets the agent data directory explicitly, e.g. from the agent's
+// initialization config. DataDirEnv still takes precedence.
+func (sm *Manager) SetDataDir(dir string) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if dir == sm.dataDir {
+		return
+	}
+	sm.dataDir = dir
+	sm.settings = nil
+	if sm.watcher != nil {
+		sm.watcher.Add(sm.resolveDataDir())
+		sm.watchAgentDir()
+	}
+}
+
+// DataDir returns the agent data directory
+func (sm *Manager) DataDir() string {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	return sm.resolveDataDir()
+}
+
+// resolveDataDir finds the agent data directory. In order: DataDirEnv, the directory set
+// with SetDataDir, the working directory if it has agents.json, the nearest directory
+// above the plugin executable that has agents.json, and the user config directory.
+// Falls back to the working directory. Must be called with mu held.
+func (sm *Manager) resolveDataDir() string {
+	if dir := os.Getenv(DataDirEnv); dir != "" {
+		return dir
+	}
+	if sm.dataDir != "" {
+		return sm.dataDir
+	}
+	if hasAgentsFile(".") {
+		return "."
+	}
+	// Plugins are usually installed inside the agent's data directory
+	if exe, err := os.Executable(); err == nil {
+		if exe, err := filepath.EvalSymlinks(exe); err == nil {
+			for dir := filepath.Dir(exe); ; dir = filepath.Dir(dir) {
+				if hasAgentsFile(dir) {
+					return dir
+				}
+				if filepath.Dir(dir) == dir {
+					break
+				}
+			}
+		}
+	}
+	if config, err := os.UserConfigDir(); err == nil {
+		if dir := filepath.Join(config, "ori-agent"); hasAgentsFile(dir) {
+			return dir
+		}
+	}
+	return "."
+}
+
+// hasAgentsFile reports whether dir contains agents.json
+func hasAgentsFile(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, agentsFileName))
+	return err == nil
+}
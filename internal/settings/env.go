@@ -0,0 +1,124 @@
+package settings
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/types"
+)
+
+// envPrefix starts the name of every environment variable that overrides a setting. The
+// rest of the name is the setting's JSON key in upper case, with nested keys joined by an
+// underscore: ORI_REAPER_SCRIPTS_DIR, ORI_REAPER_WEB_REMOTE_PORT, ORI_REAPER_REST_API_TOKEN.
+const envPrefix = "ORI_REAPER_"
+
+// applyEnvOverrides sets the fields of settings that have an environment variable and
+// returns the indexes of the top-level fields it changed. Lists are comma separated.
+// Maps and lists of objects can't be overridden.
+func applyEnvOverrides(settings *types.Settings) []int {
+	var overridden []int
+	v := reflect.ValueOf(settings).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if applyEnvField(v.Field(i), v.Type().Field(i), envPrefix) {
+			overridden = append(overridden, i)
+		}
+	}
+	return overridden
+}
+
+// applyEnvField sets field from the environment variable named after prefix and its JSON
+// key, recursing into struct pointers. Values that don't parse are logged and ignored.
+func applyEnvField(field reflect.Value, sf reflect.StructField, prefix string) bool {
+	key, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+	if key == "" || key == "-" {
+		return false
+	}
+	name := prefix + strings.ToUpper(key)
+
+	if field.Kind() == reflect.Pointer && field.Type().Elem().Kind() == reflect.Struct {
+		// Work on a copy so the settings as read from the file stay untouched
+		copied := reflect.New(field.Type().Elem())
+		if !field.IsNil() {
+			copied.Elem().Set(field.Elem())
+		}
+		changed := false
+		for i := 0; i < copied.Elem().NumField(); i++ {
+			if applyEnvField(copied.Elem().Field(i), copied.Elem().Type().Field(i), name+"_") {
+				changed = true
+			}
+		}
+		if changed {
+			field.Set(copied)
+		}
+		return changed
+	}
+
+	raw, ok := os.LookupEnv(name)
+	if !ok {
+		return false
+	}
+	if err := setFromString(field, raw); err != nil {
+		log.Printf("Ignoring %s: %v", name, err)
+		return false
+	}
+	return true
+}
+
+// setFromString parses raw into field according to its type
+func setFromString(field reflect.Value, raw string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Int:
+		n, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil {
+			return fmt.Errorf("expected a whole number, got %q", raw)
+		}
+		field.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(raw))
+		if err != nil {
+			return fmt.Errorf("expected true or false, got %q", raw)
+		}
+		field.SetBool(b)
+	case reflect.Pointer:
+		value := reflect.New(field.Type().Elem())
+		if err := setFromString(value.Elem(), raw); err != nil {
+			return err
+		}
+		field.Set(value)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("this setting can't be set from the environment")
+		}
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("this setting can't be set from the environment")
+	}
+	return nil
+}
+
+// withoutEnvOverrides returns a copy of settings with the overridden fields set back to
+// their values in base, so saving doesn't write environment values to the file
+func withoutEnvOverrides(settings, base *types.Settings, overridden []int) *types.Settings {
+	restored := *settings
+	if base == nil {
+		return &restored
+	}
+	v := reflect.ValueOf(&restored).Elem()
+	b := reflect.ValueOf(base).Elem()
+	for _, i := range overridden {
+		v.Field(i).Set(b.Field(i))
+	}
+	return &restored
+}
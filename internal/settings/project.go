@@ -0,0 +1,65 @@
+package settings
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/errcode"
+	"github.com/johnjallday/ori-reaper-plugin/internal/types"
+)
+
+// ProjectSettingsFile is the name of the file in a project directory that overrides
+// settings while that project is open
+const ProjectSettingsFile = ".dolphin-reaper.json"
+
+// SetProjectDir loads the project overrides of the REAPER project in dir, or clears them
+// when dir is "" or has no override file. Environment overrides still take precedence.
+func (sm *Manager) SetProjectDir(dir string) error {
+	var project *types.ProjectSettings
+	if dir != "" {
+		path := filepath.Join(dir, ProjectSettingsFile)
+		data, err := os.ReadFile(path)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+		case err != nil:
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		default:
+			project = &types.ProjectSettings{}
+			if err := json.Unmarshal(data, project); err != nil {
+				return errcode.Errorf(errcode.ConfigParseError, "invalid %s: %w", path, err)
+			}
+			if project.ScriptsDir != "" && !filepath.IsAbs(project.ScriptsDir) {
+				project.ScriptsDir = filepath.Join(dir, project.ScriptsDir)
+			}
+		}
+	}
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.projectDir = dir
+	sm.project = project
+	return nil
+}
+
+// GetProjectSettings returns the overrides of the current project and the directory they
+// were read from, or nil when none apply
+func (sm *Manager) GetProjectSettings() (*types.ProjectSettings, string) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if sm.project == nil {
+		return nil, ""
+	}
+	return sm.project, sm.projectDir
+}
+
+// envOverridden reports whether an environment variable overrides the setting with the
+// given JSON key
+func envOverridden(key string) bool {
+	_, ok := os.LookupEnv(envPrefix + strings.ToUpper(key))
+	return ok
+}
@@ -3,17 +3,50 @@ package settings
 import (
 	"encoding/json"
 	"fmt"
+	"log"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/fsnotify/fsnotify"
+	"github.com/johnjallday/ori-reaper-plugin/internal/errcode"
 	"github.com/johnjallday/ori-reaper-plugin/internal/platform"
 	"github.com/johnjallday/ori-reaper-plugin/internal/scripts"
 	"github.com/johnjallday/ori-reaper-plugin/internal/types"
 )
 
+// Files the agent keeps its configuration in, relative to its working directory
+const (
+	agentsFileName   = "agents.json"
+	settingsFileName = "ori-reaper_settings.json"
+)
+
+// agentSettingsPath returns the plugin settings file of agent in the data directory
+func agentSettingsPath(dataDir, agent string) string {
+	return filepath.Join(dataDir, "agents", agent, settingsFileName)
+}
+
 // Manager manages plugin settings
 type Manager struct {
-	settings *types.Settings
+	mu       sync.Mutex      // Guards settings, which the file watcher resets
+	settings *types.Settings // Never changed once current; updates swap in a new value
+	watcher  *fsnotify.Watcher
+
+	// fileSettings holds the values replaced by environment overrides, and overridden the
+	// indexes of those fields in types.Settings
+	fileSettings *types.Settings
+	overridden   []int
+
+	// project holds the overrides of the open REAPER project, read from projectDir
+	project    *types.ProjectSettings
+	projectDir string
+
+	// dataDir is the agent data directory set with SetDataDir
+	dataDir string
 }
 
 // NewManager creates a new settings manager
@@ -25,9 +58,11 @@ func NewManager() *Manager {
 func (sm *Manager) SetSettings(settingsJSON string) error {
 	var settings types.Settings
 	if err := json.Unmarshal([]byte(settingsJSON), &settings); err != nil {
-		return fmt.Errorf("failed to unmarshal settings: %w", err)
+		return errcode.Errorf(errcode.ConfigParseError, "failed to unmarshal settings: %w", err)
 	}
-	sm.settings = &settings
+	sm.mu.Lock()
+	sm.useSettings(&settings)
+	sm.mu.Unlock()
 	return nil
 }
 
@@ -41,34 +76,73 @@ func (sm *Manager) GetDefaultSettings() *types.Settings {
 
 // GetCurrentSettings returns current settings, initializing if needed
 func (sm *Manager) GetCurrentSettings() *types.Settings {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
 	if sm.settings == nil {
-		sm.settings = sm.GetDefaultSettings()
+		sm.useSettings(sm.GetDefaultSettings())
 	}
 	return sm.settings
 }
 
-// GetCurrentScriptsDir returns the current scripts directory from settings
-func (sm *Manager) GetCurrentScriptsDir() string {
-	// Try to load settings from file if not already loaded
+// loadCurrentSettings returns current settings, loading them from the agent settings file if not already loaded
+func (sm *Manager) loadCurrentSettings() *types.Settings {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	return sm.loadLocked()
+}
+
+// loadLocked is loadCurrentSettings for callers holding mu
+func (sm *Manager) loadLocked() *types.Settings {
 	if sm.settings == nil {
 		if loadedSettings, err := sm.loadSettingsFromAPI(); err == nil {
-			sm.settings = loadedSettings
+			sm.useSettings(loadedSettings)
+		} else {
+			sm.useSettings(sm.GetDefaultSettings())
 		}
 	}
-	settings := sm.GetCurrentSettings()
+	return sm.settings
+}
+
+// useSettings makes a copy of settings with the environment overrides applied current,
+// leaving settings as read from the file. Must be called with mu held.
+func (sm *Manager) useSettings(settings *types.Settings) {
+	current := *settings
+	sm.overridden = applyEnvOverrides(&current)
+	sm.fileSettings = settings
+	sm.settings = &current
+}
+
+// updateSettings makes a changed copy of the current settings current, so callers still
+// holding the previous settings never see them change. change must replace maps and
+// slices rather than modify them. Returns the settings to save, without the environment
+// overrides.
+func (sm *Manager) updateSettings(change func(*types.Settings)) *types.Settings {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	updated := *sm.loadLocked()
+	change(&updated)
+	sm.settings = &updated
+	return withoutEnvOverrides(&updated, sm.fileSettings, sm.overridden)
+}
+
+// GetCurrentScriptsDir returns the current scripts directory from the open project's overrides or settings
+func (sm *Manager) GetCurrentScriptsDir() string {
+	if project, _ := sm.GetProjectSettings(); project != nil && project.ScriptsDir != "" && !envOverridden("scripts_dir") {
+		return project.ScriptsDir
+	}
+	settings := sm.loadCurrentSettings()
 	return settings.ScriptsDir
 }
 
+// GetCreateIfMissing reports whether a missing scripts directory should be created
+func (sm *Manager) GetCreateIfMissing() bool {
+	return sm.loadCurrentSettings().CreateIfMissing
+}
+
 // GetWebRemotePort returns the configured web remote port from settings
 // Falls back to auto-detection from reaper.ini if not configured
 func (sm *Manager) GetWebRemotePort() int {
-	// Try to load settings from file if not already loaded
-	if sm.settings == nil {
-		if loadedSettings, err := sm.loadSettingsFromAPI(); err == nil {
-			sm.settings = loadedSettings
-		}
-	}
-	settings := sm.GetCurrentSettings()
+	settings := sm.loadCurrentSettings()
 
 	// If port is configured in settings, use it
 	if settings.WebRemotePort != 0 {
@@ -81,6 +155,263 @@ func (sm *Manager) GetWebRemotePort() int {
 	return sm.getAutoDetectedPort()
 }
 
+// GetWebRemoteHost returns the configured web remote host, or "" for this machine
+func (sm *Manager) GetWebRemoteHost() string {
+	return sm.loadCurrentSettings().WebRemoteHost
+}
+
+// GetWebRemoteRetryPolicy returns the web remote retry policy, applying the configured retry count
+func (sm *Manager) GetWebRemoteRetryPolicy() scripts.RetryPolicy {
+	policy := scripts.DefaultRetryPolicy
+	if retries := sm.loadCurrentSettings().WebRemoteRetry; retries != nil && *retries >= 0 {
+		policy.MaxRetries = *retries
+	}
+	return policy
+}
+
+// GetWebRemoteCredentials returns the web remote username and password from settings, if set
+func (sm *Manager) GetWebRemoteCredentials() (string, string) {
+	settings := sm.loadCurrentSettings()
+	return settings.WebRemoteUser, settings.WebRemotePass
+}
+
+// SetWebRemotePort changes the web remote port and saves it to the agent settings file
+func (sm *Manager) SetWebRemotePort(port int) error {
+	return sm.saveSettings(sm.updateSettings(func(settings *types.Settings) {
+		settings.WebRemotePort = port
+	}))
+}
+
+// GetScriptAliases returns the user-defined script aliases (alias -> script base name)
+func (sm *Manager) GetScriptAliases() map[string]string {
+	return sm.loadCurrentSettings().ScriptAliases
+}
+
+// SetScriptAlias saves an alias for a script, or removes the alias if script is empty
+func (sm *Manager) SetScriptAlias(alias, script string) error {
+	return sm.saveSettings(sm.updateSettings(func(settings *types.Settings) {
+		aliases := maps.Clone(settings.ScriptAliases)
+		if script == "" {
+			delete(aliases, alias)
+		} else {
+			if aliases == nil {
+				aliases = make(map[string]string)
+			}
+			aliases[alias] = script
+		}
+		settings.ScriptAliases = aliases
+	}))
+}
+
+// saveSettings writes settings to the agent-specific settings file. Without a current
+// agent the settings are only kept in memory.
+func (sm *Manager) saveSettings(toSave *types.Settings) error {
+	dataDir := sm.DataDir()
+	currentAgent, err := sm.getCurrentAgentFromFile(dataDir)
+	if err != nil {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(toSave, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal settings: %w", err)
+	}
+
+	settingsPath := agentSettingsPath(dataDir, currentAgent)
+	if err := os.MkdirAll(filepath.Dir(settingsPath), 0755); err != nil {
+		return fmt.Errorf("failed to create settings directory: %w", err)
+	}
+	if err := os.WriteFile(settingsPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write settings: %w", err)
+	}
+	return nil
+}
+
+// GetTrashRetention returns how long deleted scripts are kept in the trash, 0 meaning forever
+func (sm *Manager) GetTrashRetention() time.Duration {
+	return time.Duration(sm.loadCurrentSettings().TrashRetentionDays) * 24 * time.Hour
+}
+
+// GetExecutionHistory returns how many generated scripts are kept in the execution history,
+// 0 meaning the default and a negative number none
+func (sm *Manager) GetExecutionHistory() int {
+	return sm.loadCurrentSettings().ExecutionHistory
+}
+
+// GetKeepBridgeFiles returns whether generated scripts and their output are left in the temp directory
+func (sm *Manager) GetKeepBridgeFiles() bool {
+	return sm.loadCurrentSettings().KeepBridgeFiles
+}
+
+// GetMetrics returns whether operation and request metrics are collected
+func (sm *Manager) GetMetrics() bool {
+	return sm.loadCurrentSettings().Metrics
+}
+
+// GetLocale returns the locale messages are shown in, as set; see i18n.Normalize
+func (sm *Manager) GetLocale() string {
+	return sm.loadCurrentSettings().Locale
+}
+
+// ApplyHTTPSettings configures the proxy, CA bundle, timeouts and keep-alives used for outbound HTTP
+func (sm *Manager) ApplyHTTPSettings() error {
+	settings := sm.loadCurrentSettings()
+	return scripts.ConfigureHTTP(scripts.HTTPOptions{
+		Proxy:               settings.HTTPProxy,
+		CABundle:            settings.CABundle,
+		WebRemoteTimeout:    time.Duration(settings.WebRemoteTimeout) * time.Second,
+		DownloadTimeout:     time.Duration(settings.DownloadTimeout) * time.Second,
+		IdleConnTimeout:     time.Duration(settings.HTTPIdleTimeout) * time.Second,
+		MaxIdleConnsPerHost: settings.HTTPMaxIdlePerHost,
+	})
+}
+
+// GetScriptSources returns the GitHub contents API URLs scripts are listed from
+func (sm *Manager) GetScriptSources() []string {
+	if project, _ := sm.GetProjectSettings(); project != nil && len(project.ScriptSources) > 0 && !envOverridden("script_sources") {
+		return project.ScriptSources
+	}
+	return sm.loadCurrentSettings().ScriptSources
+}
+
+// GetTrustedSources returns the URL prefixes scripts may be downloaded from. Project
+// folders are shared, so the open project's trusted sources only apply where they fall
+// within the user's; they can narrow the list but never widen it.
+func (sm *Manager) GetTrustedSources() []string {
+	trusted := sm.loadCurrentSettings().TrustedSources
+	project, dir := sm.GetProjectSettings()
+	if project == nil || len(project.TrustedSources) == 0 {
+		return trusted
+	}
+	return narrowTrustedSources(trusted, project.TrustedSources, dir)
+}
+
+// narrowTrustedSources returns the sources in project that start with one of trusted (or
+// of scripts.DefaultTrustedSources if trusted is empty). If none do, trusted is returned
+// unchanged.
+func narrowTrustedSources(trusted, project []string, dir string) []string {
+	allowed := trusted
+	if len(allowed) == 0 {
+		allowed = scripts.DefaultTrustedSources
+	}
+	var narrowed []string
+	for _, source := range project {
+		if slices.ContainsFunc(allowed, func(prefix string) bool { return strings.HasPrefix(source, prefix) }) {
+			narrowed = append(narrowed, source)
+		} else {
+			log.Printf("Ignoring trusted source %s from %s: it isn't within the trusted sources in the plugin settings", source, filepath.Join(dir, ProjectSettingsFile))
+		}
+	}
+	if len(narrowed) == 0 {
+		return trusted
+	}
+	return narrowed
+}
+
+// GetReviewBeforeInstall reports whether downloaded scripts must be reviewed before installing
+func (sm *Manager) GetReviewBeforeInstall() bool {
+	return sm.loadCurrentSettings().ReviewBeforeInstall
+}
+
+// NewScriptDownloader creates a script downloader using the configured sources and trust policy
+func (sm *Manager) NewScriptDownloader() *scripts.ScriptDownloader {
+	downloader := scripts.NewScriptDownloader()
+	downloader.SetSources(sm.GetScriptSources())
+	downloader.SetTrustedSources(sm.GetTrustedSources())
+	return downloader
+}
+
+// GetMacros returns the macros configured in settings
+func (sm *Manager) GetMacros() []types.Macro {
+	return sm.loadCurrentSettings().Macros
+}
+
+// GetBundles returns the script bundles configured in settings
+func (sm *Manager) GetBundles() []types.Bundle {
+	return sm.loadCurrentSettings().Bundles
+}
+
+// GetPostRenderHooks returns the post-render hooks configured in settings
+func (sm *Manager) GetPostRenderHooks() []types.PostRenderHook {
+	return sm.loadCurrentSettings().PostRenderHooks
+}
+
+// GetRESTAPI returns the REST API server settings; the server is disabled if they're missing
+func (sm *Manager) GetRESTAPI() types.RESTAPI {
+	if api := sm.loadCurrentSettings().RESTAPI; api != nil {
+		return *api
+	}
+	return types.RESTAPI{}
+}
+
+// GetScriptLaunchPolicy returns the rate limit for running scripts, applying the configured values
+func (sm *Manager) GetScriptLaunchPolicy() scripts.LaunchPolicy {
+	policy := scripts.DefaultLaunchPolicy
+	launch := sm.loadCurrentSettings().ScriptLaunch
+	if launch == nil {
+		return policy
+	}
+	switch {
+	case launch.MinIntervalMs < 0:
+		policy.MinInterval = 0
+	case launch.MinIntervalMs > 0:
+		policy.MinInterval = time.Duration(launch.MinIntervalMs) * time.Millisecond
+	}
+	switch {
+	case launch.DebounceMs < 0:
+		policy.Debounce = 0
+	case launch.DebounceMs > 0:
+		policy.Debounce = time.Duration(launch.DebounceMs) * time.Millisecond
+	}
+	policy.Queue = strings.EqualFold(launch.Policy, "queue")
+	if launch.MaxQueued > 0 {
+		policy.MaxQueued = launch.MaxQueued
+	}
+	return policy
+}
+
+// GetScriptsGit returns whether and how script changes are committed to git
+func (sm *Manager) GetScriptsGit() scripts.GitOptions {
+	git := sm.loadCurrentSettings().ScriptsGit
+	if git == nil {
+		return scripts.GitOptions{}
+	}
+	return scripts.GitOptions{
+		Enabled:         git.Enabled,
+		AddMessage:      git.AddMessage,
+		UpdateMessage:   git.UpdateMessage,
+		DeleteMessage:   git.DeleteMessage,
+		RestoreMessage:  git.RestoreMessage,
+		RollbackMessage: git.RollbackMessage,
+	}
+}
+
+// GetScriptPublish returns the GitHub repository scripts are published to
+func (sm *Manager) GetScriptPublish() scripts.PublishOptions {
+	publish := sm.loadCurrentSettings().ScriptPublish
+	if publish == nil {
+		return scripts.PublishOptions{}
+	}
+	return scripts.PublishOptions{
+		Repository:  publish.Repository,
+		Token:       publish.Token,
+		Branch:      publish.Branch,
+		Directory:   publish.Directory,
+		APIURL:      publish.APIURL,
+		Message:     publish.Message,
+		PullRequest: publish.PullRequest,
+	}
+}
+
+// GetOperationTimeout returns the configured time limit for an operation, or 0 if none is set
+func (sm *Manager) GetOperationTimeout(operation string) time.Duration {
+	settings := sm.loadCurrentSettings()
+	if seconds := settings.OperationTimeouts[operation]; seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return time.Duration(settings.OperationTimeout) * time.Second
+}
+
 // getAutoDetectedPort attempts to detect the port from reaper.ini
 func (sm *Manager) getAutoDetectedPort() int {
 	// Try to auto-detect from reaper.ini
@@ -101,17 +432,19 @@ func (sm *Manager) GetDefaultSettingsJSON() (string, error) {
 	return string(data), nil
 }
 
-// loadSettingsFromAPI loads settings from agent-specific settings file
+// loadSettingsFromAPI loads settings from agent-specific settings file. Must be called
+// with mu held.
 func (sm *Manager) loadSettingsFromAPI() (*types.Settings, error) {
 	// Get current agent from agents.json file
-	currentAgent, err := sm.getCurrentAgentFromFile()
+	dataDir := sm.resolveDataDir()
+	currentAgent, err := sm.getCurrentAgentFromFile(dataDir)
 	if err != nil {
 		// Fall back to default settings if no agent file or error reading it
 		return sm.GetDefaultSettings(), nil
 	}
 
 	// Try to load settings from the agent-specific file
-	settingsPath := filepath.Join(".", "agents", currentAgent, "ori-reaper_settings.json")
+	settingsPath := agentSettingsPath(dataDir, currentAgent)
 	if data, err := os.ReadFile(settingsPath); err == nil {
 		var settings types.Settings
 		if err := json.Unmarshal(data, &settings); err == nil {
@@ -123,9 +456,9 @@ func (sm *Manager) loadSettingsFromAPI() (*types.Settings, error) {
 	return sm.GetDefaultSettings(), nil
 }
 
-// getCurrentAgentFromFile reads the current agent from agents.json
-func (sm *Manager) getCurrentAgentFromFile() (string, error) {
-	agentsFilePath := filepath.Join(".", "agents.json")
+// getCurrentAgentFromFile reads the current agent from agents.json in dataDir
+func (sm *Manager) getCurrentAgentFromFile(dataDir string) (string, error) {
+	agentsFilePath := filepath.Join(dataDir, agentsFileName)
 	data, err := os.ReadFile(agentsFilePath)
 	if err != nil {
 		return "", fmt.Errorf("failed to read agents.json: %w", err)
@@ -0,0 +1,169 @@
+package settings
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/scripts"
+	"github.com/johnjallday/ori-reaper-plugin/internal/types"
+)
+
+// useTestDataDir points the manager at a temporary data directory with a current agent
+// and returns the agent's settings file
+func useTestDataDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv(DataDirEnv, dir)
+	if err := os.WriteFile(filepath.Join(dir, agentsFileName), []byte(`{"current": "test"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	path := agentSettingsPath(dir, "test")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(`{"scripts_dir": "/scripts", "web_remote_port": 8080}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSetScriptAliasLeavesEarlierSettingsUnchanged(t *testing.T) {
+	path := useTestDataDir(t)
+	t.Setenv(envPrefix+"WEB_REMOTE_HOST", "studio.local")
+	sm := NewManager()
+
+	if err := sm.SetScriptAlias("norm", "Normalize"); err != nil {
+		t.Fatal(err)
+	}
+	before := sm.GetScriptAliases()
+	if err := sm.SetScriptAlias("fade", "Fade In"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := before["fade"]; ok {
+		t.Errorf("aliases read before SetScriptAlias changed to %v", before)
+	}
+	if got := sm.GetScriptAliases(); got["norm"] != "Normalize" || got["fade"] != "Fade In" {
+		t.Errorf("GetScriptAliases = %v, want norm and fade", got)
+	}
+	if host := sm.GetWebRemoteHost(); host != "studio.local" {
+		t.Errorf("GetWebRemoteHost = %q, want the environment override", host)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved types.Settings
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if saved.WebRemoteHost != "" {
+		t.Errorf("saved web_remote_host = %q, want the environment override left out", saved.WebRemoteHost)
+	}
+	if saved.ScriptAliases["fade"] != "Fade In" || saved.ScriptsDir != "/scripts" {
+		t.Errorf("saved settings = %+v, want the file's settings with the new alias", saved)
+	}
+}
+
+func TestSettingsConcurrentAccess(t *testing.T) {
+	path := useTestDataDir(t)
+	t.Setenv(envPrefix+"WEB_REMOTE_HOST", "studio.local")
+	sm := NewManager()
+	if err := sm.Watch(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				for alias, script := range sm.GetScriptAliases() {
+					_ = alias + script
+				}
+				if host := sm.GetWebRemoteHost(); host != "studio.local" {
+					t.Errorf("GetWebRemoteHost = %q, want the environment override", host)
+					return
+				}
+				_ = sm.GetWebRemotePort()
+			}
+		}()
+	}
+
+	for i := range 50 {
+		if err := sm.SetScriptAlias(fmt.Sprintf("alias%d", i), "Normalize"); err != nil {
+			t.Error(err)
+		}
+		if err := sm.SetWebRemotePort(8080 + i); err != nil {
+			t.Error(err)
+		}
+		if i%10 == 0 {
+			// Agent UI edits the file, and the agent pushes settings
+			if err := os.WriteFile(path, []byte(`{"web_remote_port": 8080}`), 0644); err != nil {
+				t.Error(err)
+			}
+			if err := sm.SetSettings(`{"web_remote_port": 8081}`); err != nil {
+				t.Error(err)
+			}
+		}
+	}
+	close(done)
+	wg.Wait()
+}
+
+func TestProjectTrustedSourcesOnlyNarrow(t *testing.T) {
+	useTestDataDir(t)
+	const official = "https://raw.githubusercontent.com/johnjallday/ori-reaper/"
+	tests := []struct {
+		name     string
+		project  []string
+		rejected string // A URL the project's trusted sources must not allow
+	}{
+		{"widening", []string{"https://evil.example/"}, "https://evil.example/payload.lua"},
+		{"widening alongside a narrower source", []string{official + "main/", "https://evil.example/"}, "https://evil.example/payload.lua"},
+		{"narrowing", []string{official + "main/"}, official + "dev/Normalize.lua"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projectDir := t.TempDir()
+			data, err := json.Marshal(types.ProjectSettings{TrustedSources: tt.project})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(projectDir, ProjectSettingsFile), data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			sm := NewManager()
+			if err := sm.SetSettings(`{"trusted_sources": ["` + official + `"]}`); err != nil {
+				t.Fatal(err)
+			}
+			if err := sm.SetProjectDir(projectDir); err != nil {
+				t.Fatal(err)
+			}
+
+			// The trust check runs before anything is downloaded
+			_, err = sm.NewScriptDownloader().ImportScripts(context.Background(), scripts.NewScriptManager(t.TempDir()), tt.rejected, false, true)
+			if err == nil || !strings.Contains(err.Error(), "not from a trusted source") {
+				t.Errorf("importing %s = %v, want it rejected as untrusted", tt.rejected, err)
+			}
+			for _, source := range sm.GetTrustedSources() {
+				if !strings.HasPrefix(source, official) {
+					t.Errorf("GetTrustedSources includes %s, outside the user's trusted sources", source)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package settings
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Watch reloads the settings whenever the agent settings file or agents.json changes, so
+// edits made in the agent UI take effect without restarting the plugin. onReload, if not
+// nil, is called after each reload. Calling Watch again has no effect.
+func (sm *Manager) Watch(onReload func()) error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if sm.watcher != nil {
+		return nil
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to watch settings: %w", err)
+	}
+	// Watch directories rather than files, since editors often replace a file on save
+	if err := watcher.Add(sm.resolveDataDir()); err != nil {
+		watcher.Close()
+		return fmt.Errorf("failed to watch settings: %w", err)
+	}
+	sm.watcher = watcher
+	sm.watchAgentDir()
+	go sm.runWatcher(onReload)
+	return nil
+}
+
+// watchAgentDir adds the current agent's directory to the watcher. The directory may not
+// exist yet, in which case it's picked up once agents.json changes. Must be called with
+// mu held.
+func (sm *Manager) watchAgentDir() {
+	dataDir := sm.resolveDataDir()
+	if agent, err := sm.getCurrentAgentFromFile(dataDir); err == nil {
+		sm.watcher.Add(filepath.Dir(agentSettingsPath(dataDir, agent)))
+	}
+}
+
+// runWatcher drops the loaded settings as change events arrive, so the next read loads
+// the file again
+func (sm *Manager) runWatcher(onReload func()) {
+	for {
+		select {
+		case event, ok := <-sm.watcher.Events:
+			if !ok {
+				return
+			}
+			name := filepath.Base(event.Name)
+			if name != agentsFileName && name != settingsFileName {
+				continue
+			}
+
+			sm.mu.Lock()
+			if name == agentsFileName {
+				// The current agent may have changed
+				sm.watchAgentDir()
+			}
+			sm.settings = nil
+			sm.mu.Unlock()
+
+			if onReload != nil {
+				onReload()
+			}
+		case err, ok := <-sm.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("settings watcher: %v", err)
+		}
+	}
+}
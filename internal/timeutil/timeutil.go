@@ -0,0 +1,307 @@
+// Package timeutil converts project positions between seconds, samples, bars.beats,
+// h:m:s and h:m:s:f timecode, given the project's tempo, time signature, frame rate and
+// sample rate.
+package timeutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Formats a position can be written in
+const (
+	Seconds  = "seconds"  // 83.5
+	Samples  = "samples"  // 4008000
+	Bars     = "bars"     // 42.3.50: bar 42, beat 3, half way to beat 4
+	HMS      = "hms"      // 1:23.500 or 1:01:23.500
+	Timecode = "timecode" // 00:01:23:15 (hours:minutes:seconds:frames)
+)
+
+// Formats lists the position formats in the order they're shown
+var Formats = []string{Seconds, Samples, Bars, HMS, Timecode}
+
+// Timing is what converting between the formats depends on. Bars and beats assume the
+// tempo and time signature don't change.
+type Timing struct {
+	Tempo       float64 `json:"tempo"`         // Beats per minute
+	BeatsPerBar int     `json:"beats_per_bar"` // Time signature numerator
+	BeatUnit    int     `json:"beat_unit"`     // Time signature denominator
+	FrameRate   float64 `json:"frame_rate"`    // Timecode frames per second
+	SampleRate  int     `json:"sample_rate"`   // Samples per second
+}
+
+// DefaultTiming is REAPER's default for a new project: 120 BPM in 4/4, 30 fps, 48 kHz
+func DefaultTiming() Timing {
+	return Timing{Tempo: 120, BeatsPerBar: 4, BeatUnit: 4, FrameRate: 30, SampleRate: 48000}
+}
+
+// withDefaults fills in unset or invalid fields from DefaultTiming
+func (t Timing) withDefaults() Timing {
+	d := DefaultTiming()
+	if t.Tempo <= 0 {
+		t.Tempo = d.Tempo
+	}
+	if t.BeatsPerBar <= 0 {
+		t.BeatsPerBar = d.BeatsPerBar
+	}
+	if t.BeatUnit <= 0 {
+		t.BeatUnit = d.BeatUnit
+	}
+	if t.FrameRate <= 0 {
+		t.FrameRate = d.FrameRate
+	}
+	if t.SampleRate <= 0 {
+		t.SampleRate = d.SampleRate
+	}
+	return t
+}
+
+// Value is a position as given by the user: a number of seconds, or a string in any of
+// the Formats. In JSON it may be a number or a string.
+type Value string
+
+// UnmarshalJSON accepts a number or a string
+func (v *Value) UnmarshalJSON(data []byte) error {
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err == nil {
+		*v = Value(number.String())
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return fmt.Errorf("a position must be a number of seconds or a string such as '1:23.5', '42.3.00' or '00:01:23:15'")
+	}
+	*v = Value(text)
+	return nil
+}
+
+// IsSeconds reports whether v is a plain number of seconds, which needs no timing to convert
+func (v Value) IsSeconds() bool {
+	format, err := Detect(string(v))
+	return err == nil && format == Seconds
+}
+
+// Detect guesses the format of a position: "12.5" is seconds, "4008000smp" or
+// "4008000 samples" samples, "42.3" or "42.3.50" (with a trailing "bar", or two dots)
+// bars.beats, "1:23.5" h:m:s and "00:01:23:15" timecode
+func Detect(s string) (string, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch {
+	case s == "":
+		return "", fmt.Errorf("time is required")
+	case strings.HasSuffix(s, "smp") || strings.HasSuffix(s, "samples"):
+		return Samples, nil
+	case strings.HasPrefix(s, "bar") || strings.HasSuffix(s, "bar") || strings.HasSuffix(s, "bars") || strings.Count(s, ".") == 2:
+		return Bars, nil
+	case strings.Count(s, ":") == 3 || strings.Count(s, ";") == 1:
+		return Timecode, nil
+	case strings.Contains(s, ":"):
+		return HMS, nil
+	case strings.HasSuffix(s, "s"):
+		return Seconds, nil
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return Seconds, nil
+	}
+	return "", fmt.Errorf("unrecognized time %q: use seconds (83.5), samples (4008000smp), bars.beats (42.3.00), h:m:s (1:23.5) or timecode (00:01:23:15)", s)
+}
+
+// Parse converts a position written in format (detected when empty) to seconds
+func Parse(s, format string, t Timing) (float64, error) {
+	t = t.withDefaults()
+	s = strings.TrimSpace(s)
+	if format == "" {
+		var err error
+		if format, err = Detect(s); err != nil {
+			return 0, err
+		}
+	}
+	lower := strings.ToLower(s)
+
+	var seconds float64
+	var err error
+	switch format {
+	case Seconds:
+		seconds, err = parseNumber(strings.TrimSuffix(lower, "s"), s)
+	case Samples:
+		lower = strings.TrimSpace(strings.TrimSuffix(strings.TrimSuffix(lower, "samples"), "smp"))
+		var samples float64
+		if samples, err = parseNumber(lower, s); err == nil {
+			seconds = samples / float64(t.SampleRate)
+		}
+	case Bars:
+		seconds, err = parseBars(lower, s, t)
+	case HMS:
+		seconds, err = parseHMS(lower, s)
+	case Timecode:
+		seconds, err = parseTimecode(lower, s, t)
+	default:
+		return 0, fmt.Errorf("unsupported time format: %s. Valid formats: %s", format, strings.Join(Formats, ", "))
+	}
+	if err != nil {
+		return 0, err
+	}
+	if seconds < 0 {
+		return 0, fmt.Errorf("time %q is before the project start", s)
+	}
+	return seconds, nil
+}
+
+// parseNumber parses a non-negative number, reporting original in errors
+func parseNumber(s, original string) (float64, error) {
+	value, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, fmt.Errorf("invalid time %q", original)
+	}
+	return value, nil
+}
+
+// parseBars parses "bar.beat.percent", where beat and percent (hundredths of a beat) are
+// optional and bars and beats count from 1, as in REAPER's ruler
+func parseBars(s, original string, t Timing) (float64, error) {
+	s = strings.TrimSpace(strings.TrimPrefix(strings.TrimSuffix(strings.TrimSuffix(s, "bars"), "bar"), "bar"))
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("invalid bars.beats %q: use bar.beat or bar.beat.hundredths, e.g. 42.3.50", original)
+	}
+	values := make([]float64, 3)
+	values[1] = 1
+	for i, part := range parts {
+		value, err := strconv.ParseFloat(part, 64)
+		if err != nil || value < 0 {
+			return 0, fmt.Errorf("invalid bars.beats %q: use bar.beat or bar.beat.hundredths, e.g. 42.3.50", original)
+		}
+		values[i] = value
+	}
+	bar, beat, hundredths := values[0], values[1], values[2]
+	if bar < 1 || beat < 1 || beat > float64(t.BeatsPerBar) || hundredths >= 100 {
+		return 0, fmt.Errorf("invalid bars.beats %q: bars count from 1 and beats from 1 to %d", original, t.BeatsPerBar)
+	}
+	beats := (bar-1)*float64(t.BeatsPerBar) + (beat - 1) + hundredths/100
+	return beats * 60 / t.Tempo, nil
+}
+
+// parseHMS parses "m:ss", "h:mm:ss", with optional fractions of a second
+func parseHMS(s, original string) (float64, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("invalid h:m:s %q", original)
+	}
+	seconds := 0.0
+	for _, part := range parts {
+		value, err := strconv.ParseFloat(part, 64)
+		if err != nil || value < 0 {
+			return 0, fmt.Errorf("invalid h:m:s %q", original)
+		}
+		seconds = seconds*60 + value
+	}
+	return seconds, nil
+}
+
+// parseTimecode parses "hh:mm:ss:ff"; ";" before the frames, as drop-frame timecode is
+// written, is accepted but frames are counted as non-drop
+func parseTimecode(s, original string, t Timing) (float64, error) {
+	parts := strings.Split(strings.ReplaceAll(s, ";", ":"), ":")
+	if len(parts) != 4 {
+		return 0, fmt.Errorf("invalid timecode %q: use hh:mm:ss:ff", original)
+	}
+	values := make([]int, 4)
+	for i, part := range parts {
+		value, err := strconv.Atoi(part)
+		if err != nil || value < 0 {
+			return 0, fmt.Errorf("invalid timecode %q: use hh:mm:ss:ff", original)
+		}
+		values[i] = value
+	}
+	if fps := int(math.Round(t.FrameRate)); values[3] >= fps {
+		return 0, fmt.Errorf("invalid timecode %q: frames must be below %d at %g fps", original, fps, t.FrameRate)
+	}
+	return float64(values[0]*3600+values[1]*60+values[2]) + float64(values[3])/t.FrameRate, nil
+}
+
+// Format writes a position in seconds in format
+func Format(seconds float64, format string, t Timing) (string, error) {
+	t = t.withDefaults()
+	switch format {
+	case Seconds:
+		return strconv.FormatFloat(math.Round(seconds*1000)/1000, 'f', -1, 64), nil
+	case Samples:
+		return strconv.FormatInt(int64(math.Round(seconds*float64(t.SampleRate))), 10), nil
+	case Bars:
+		return FormatBars(seconds, t), nil
+	case HMS:
+		return FormatHMS(seconds), nil
+	case Timecode:
+		return FormatTimecode(seconds, t.FrameRate), nil
+	}
+	return "", fmt.Errorf("unsupported time format: %s. Valid formats: %s", format, strings.Join(Formats, ", "))
+}
+
+// FormatBars writes seconds as bar.beat.hundredths, as REAPER's ruler shows it
+func FormatBars(seconds float64, t Timing) string {
+	t = t.withDefaults()
+	hundredths := int64(math.Round(seconds * t.Tempo / 60 * 100))
+	beats := hundredths / 100
+	perBar := int64(t.BeatsPerBar)
+	return fmt.Sprintf("%d.%d.%02d", beats/perBar+1, beats%perBar+1, hundredths%100)
+}
+
+// FormatHMS writes seconds as m:ss.mmm, or h:mm:ss.mmm from an hour on
+func FormatHMS(seconds float64) string {
+	millis := int64(math.Round(seconds * 1000))
+	h, m := millis/3600000, (millis%3600000)/60000
+	s, ms := (millis%60000)/1000, millis%1000
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d.%03d", h, m, s, ms)
+	}
+	return fmt.Sprintf("%d:%02d.%03d", m, s, ms)
+}
+
+// FormatTimecode writes seconds as HH:MM:SS:FF at frameRate (non-drop)
+func FormatTimecode(seconds, frameRate float64) string {
+	if frameRate <= 0 {
+		frameRate = DefaultTiming().FrameRate
+	}
+	fps := int64(math.Round(frameRate))
+	totalFrames := int64(math.Round(seconds * frameRate))
+	frames := totalFrames % fps
+	totalSeconds := totalFrames / fps
+	return fmt.Sprintf("%02d:%02d:%02d:%02d", totalSeconds/3600, (totalSeconds%3600)/60, totalSeconds%60, frames)
+}
+
+// Conversion is a position written in every format
+type Conversion struct {
+	Seconds  float64 `json:"seconds"`
+	Samples  int64   `json:"samples"`
+	Bars     string  `json:"bars"`
+	HMS      string  `json:"hms"`
+	Timecode string  `json:"timecode"`
+	Timing   Timing  `json:"timing"`
+}
+
+// Convert writes a position in seconds in every format
+func Convert(seconds float64, t Timing) Conversion {
+	t = t.withDefaults()
+	return Conversion{
+		Seconds:  math.Round(seconds*1e6) / 1e6,
+		Samples:  int64(math.Round(seconds * float64(t.SampleRate))),
+		Bars:     FormatBars(seconds, t),
+		HMS:      FormatHMS(seconds),
+		Timecode: FormatTimecode(seconds, t.FrameRate),
+		Timing:   t,
+	}
+}
+
+// FormatConversion formats a position in every format as readable text
+func FormatConversion(c Conversion) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Seconds:   %s\n", strconv.FormatFloat(c.Seconds, 'f', -1, 64)))
+	b.WriteString(fmt.Sprintf("Samples:   %d (at %d Hz)\n", c.Samples, c.Timing.SampleRate))
+	b.WriteString(fmt.Sprintf("Bars:      %s (at %g BPM, %d/%d)\n", c.Bars, c.Timing.Tempo, c.Timing.BeatsPerBar, c.Timing.BeatUnit))
+	b.WriteString(fmt.Sprintf("H:M:S:     %s\n", c.HMS))
+	b.WriteString(fmt.Sprintf("Timecode:  %s (at %g fps)", c.Timecode, c.Timing.FrameRate))
+	return b.String()
+}
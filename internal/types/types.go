@@ -2,8 +2,134 @@ package types
 
 // Settings represents the REAPER plugin configuration
 type Settings struct {
-	ScriptsDir    string `json:"scripts_dir"`
-	WebRemotePort int    `json:"web_remote_port"`
+	ScriptsDir          string            `json:"scripts_dir"`
+	CreateIfMissing     bool              `json:"create_if_missing,omitempty"` // Create the scripts directory when it doesn't exist
+	WebRemotePort       int               `json:"web_remote_port"`
+	WebRemoteHost       string            `json:"web_remote_host,omitempty"`     // Machine running REAPER, e.g. "studio.local" or "192.168.1.20:8080"; defaults to this machine
+	WebRemoteUser       string            `json:"web_remote_username,omitempty"` // Overrides the credentials in REAPER's Web Remote entry
+	WebRemotePass       string            `json:"web_remote_password,omitempty"`
+	WebRemoteRetry      *int              `json:"web_remote_retries,omitempty"`         // Connection retries per Web Remote request; defaults to 2
+	TrashRetentionDays  int               `json:"trash_retention_days,omitempty"`       // Days deleted scripts stay in the trash; 0 keeps them
+	HTTPProxy           string            `json:"http_proxy,omitempty"`                 // Proxy for downloads and a remote Web Remote; defaults to HTTP_PROXY/HTTPS_PROXY
+	CABundle            string            `json:"ca_bundle,omitempty"`                  // PEM file of extra CA certificates, e.g. for a TLS-inspecting proxy
+	WebRemoteTimeout    int               `json:"web_remote_timeout_seconds,omitempty"` // Limit per Web Remote request; defaults to 5
+	DownloadTimeout     int               `json:"download_timeout_seconds,omitempty"`   // Limit per download request; defaults to the operation timeout
+	HTTPIdleTimeout     int               `json:"http_idle_timeout_seconds,omitempty"`  // How long idle keep-alive connections stay open; defaults to 90
+	HTTPMaxIdlePerHost  int               `json:"http_max_idle_per_host,omitempty"`     // Keep-alive connections kept per host; defaults to 8
+	ScriptLaunch        *ScriptLaunch     `json:"script_launch,omitempty"`              // Rate limit for running scripts; defaults to one launch per 500ms, rejecting the rest
+	ScriptsGit          *ScriptsGit       `json:"scripts_git,omitempty"`                // Keep the scripts directory in git, committing every change
+	ScriptPublish       *ScriptPublish    `json:"script_publish,omitempty"`             // GitHub repository 'publish_script' pushes scripts to
+	ScriptSources       []string          `json:"script_sources,omitempty"`             // GitHub contents API URLs listing scripts; defaults to the official repository
+	TrustedSources      []string          `json:"trusted_sources,omitempty"`            // URL prefixes scripts may be downloaded from; defaults to the official repository
+	ReviewBeforeInstall bool              `json:"review_before_install,omitempty"`      // Show downloaded script content for confirmation before installing
+	Macros              []Macro           `json:"macros,omitempty"`
+	ScriptAliases       map[string]string `json:"script_aliases,omitempty"` // Alternative names for scripts: alias -> script base name
+	Bundles             []Bundle          `json:"bundles,omitempty"`
+	PostRenderHooks     []PostRenderHook  `json:"post_render_hooks,omitempty"`
+	RESTAPI             *RESTAPI          `json:"rest_api,omitempty"`
+	OperationTimeout    int               `json:"operation_timeout_seconds,omitempty"` // Limit for operations without their own entry in OperationTimeouts
+	OperationTimeouts   map[string]int    `json:"operation_timeouts,omitempty"`        // Per-operation limits in seconds, e.g. {"render_project": 3600}
+	ExecutionHistory    int               `json:"execution_history,omitempty"`         // Generated scripts kept in the execution history; defaults to 200, -1 turns it off
+	KeepBridgeFiles     bool              `json:"keep_bridge_files,omitempty"`         // Leave generated scripts and their output in the temp directory for debugging
+	Metrics             bool              `json:"metrics,omitempty"`                   // Count operations, latencies and request errors for 'get_metrics' and the REST API's /metrics
+	Locale              string            `json:"locale,omitempty"`                    // Language of confirmations and errors: en (default), ko, ja or de
+}
+
+// ProjectSettings overrides settings for one REAPER project. It's read from
+// .dolphin-reaper.json in the project directory.
+type ProjectSettings struct {
+	ScriptsDir     string   `json:"scripts_dir,omitempty"` // Relative paths are resolved against the project directory
+	ScriptSources  []string `json:"script_sources,omitempty"`
+	TrustedSources []string `json:"trusted_sources,omitempty"` // Can only narrow the user's trusted sources, since project folders are shared
+}
+
+// ScriptLaunch limits how often scripts are launched in REAPER
+type ScriptLaunch struct {
+	MinIntervalMs int    `json:"min_interval_ms,omitempty"` // Minimum time between launches; defaults to 500, -1 disables
+	DebounceMs    int    `json:"debounce_ms,omitempty"`     // Repeat runs of the same script within this window are skipped; defaults to 1000, -1 disables
+	Policy        string `json:"policy,omitempty"`          // "reject" (default) fails launches that come too fast, "queue" delays them
+	MaxQueued     int    `json:"max_queued,omitempty"`      // Launches allowed to wait at once with "queue"; defaults to 5
+}
+
+// ScriptsGit makes the scripts directory a git repository with a commit for every script
+// added, updated, deleted or restored. Messages may use {script}; the rollback message
+// also {commit}.
+type ScriptsGit struct {
+	Enabled         bool   `json:"enabled"`
+	AddMessage      string `json:"add_message,omitempty"`      // Defaults to "Add {script}"
+	UpdateMessage   string `json:"update_message,omitempty"`   // Defaults to "Update {script}"
+	DeleteMessage   string `json:"delete_message,omitempty"`   // Defaults to "Delete {script}"
+	RestoreMessage  string `json:"restore_message,omitempty"`  // Defaults to "Restore {script}"
+	RollbackMessage string `json:"rollback_message,omitempty"` // Defaults to "Roll back {script} to {commit}"
+}
+
+// ScriptPublish is the GitHub repository scripts are shared to by 'publish_script'. The
+// message may use {script} and {action} ("Add" or "Update").
+type ScriptPublish struct {
+	Repository  string `json:"repository"`             // "owner/name"
+	Token       string `json:"token"`                  // Token with contents (and pull request) write access
+	Branch      string `json:"branch,omitempty"`       // Defaults to the repository's default branch
+	Directory   string `json:"directory,omitempty"`    // Folder holding scripts; defaults to "reascripts"
+	APIURL      string `json:"api_url,omitempty"`      // For GitHub Enterprise; defaults to https://api.github.com
+	Message     string `json:"message,omitempty"`      // Defaults to "{action} {script}"
+	PullRequest bool   `json:"pull_request,omitempty"` // Commit to a new branch and open a pull request
+}
+
+// RESTAPI configures the optional HTTP server exposing the operations as REST endpoints
+type RESTAPI struct {
+	Enabled     bool   `json:"enabled"`
+	Port        int    `json:"port,omitempty"`         // Defaults to 7878
+	Token       string `json:"token"`                  // Required bearer token, at least 16 characters
+	AllowRemote bool   `json:"allow_remote,omitempty"` // Listen on all interfaces instead of localhost only
+}
+
+// PostRenderHook is an action run on each file produced by 'render_project'
+type PostRenderHook struct {
+	Type    string `json:"type"`              // "command", "move" or "webhook"
+	Command string `json:"command,omitempty"` // Shell command for "command"; {file} is replaced with the rendered file path
+	Folder  string `json:"folder,omitempty"`  // Destination folder for "move"
+	URL     string `json:"url,omitempty"`     // Endpoint for "webhook"; receives a JSON POST per file
+}
+
+// Macro is a named sequence of steps run in order by 'run_macro'
+type Macro struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	Steps       []MacroStep `json:"steps"`
+}
+
+// Bundle is a named set of marketplace scripts installed together by 'install_bundle',
+// with optional shortcuts and toolbar buttons for them
+type Bundle struct {
+	Name        string              `json:"name"`
+	Description string              `json:"description,omitempty"`
+	Scripts     []string            `json:"scripts"`             // Marketplace script filenames
+	Shortcuts   []BundleShortcut    `json:"shortcuts,omitempty"` // Key bindings added to reaper-kb.ini
+	Toolbar     []BundleToolbarItem `json:"toolbar,omitempty"`   // Buttons added to reaper-menu.ini
+}
+
+// BundleShortcut binds a key to one of the bundle's scripts or to a REAPER command
+type BundleShortcut struct {
+	Key     string `json:"key"`               // e.g. "Ctrl+Shift+P"
+	Script  string `json:"script,omitempty"`  // Script filename from the bundle
+	Command string `json:"command,omitempty"` // Command ID, used when Script is empty
+}
+
+// BundleToolbarItem adds a toolbar button for one of the bundle's scripts or a REAPER command
+type BundleToolbarItem struct {
+	Toolbar string `json:"toolbar,omitempty"` // reaper-menu.ini section; defaults to "Floating toolbar 16"
+	Script  string `json:"script,omitempty"`  // Script filename from the bundle
+	Command string `json:"command,omitempty"` // Command ID, used when Script is empty
+	Label   string `json:"label,omitempty"`   // Button text; defaults to the script name
+}
+
+// MacroStep is one step of a macro: a plugin operation, a script, or a REAPER action.
+// Exactly one of Operation, Script or Action should be set.
+type MacroStep struct {
+	Operation string                 `json:"operation,omitempty"` // Plugin operation, e.g. "render_project"
+	Params    map[string]interface{} `json:"params,omitempty"`    // Parameters for Operation
+	Script    string                 `json:"script,omitempty"`    // Script to run, same as operation "run"
+	Action    string                 `json:"action,omitempty"`    // REAPER command ID to trigger via Web Remote
 }
 
 // AgentsConfig represents the agents.json file structure
@@ -13,17 +139,28 @@ type AgentsConfig struct {
 
 // ScriptItem represents a single script in the list
 type ScriptItem struct {
-	Index       int    `json:"index"`
-	Name        string `json:"name"`
-	DisplayName string `json:"displayName"`
-	Action      string `json:"action"`
+	Index       int      `json:"index"`
+	Name        string   `json:"name"`
+	DisplayName string   `json:"displayName"`
+	Action      string   `json:"action"`
+	Favorite    bool     `json:"favorite,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Description string   `json:"description,omitempty"` // From the script's @description header
+	Author      string   `json:"author,omitempty"`      // From the script's @author header
+	Version     string   `json:"version,omitempty"`     // Installed marketplace version (short SHA), else the @version header
+	Pinned      bool     `json:"pinned,omitempty"`
+	Rating      int      `json:"rating,omitempty"` // The user's star rating, 1-5
+	Notes       string   `json:"notes,omitempty"`  // The user's notes on the script
 }
 
 // ScriptList represents a structured list of scripts
 type ScriptList struct {
 	Type        string       `json:"type"`
 	Title       string       `json:"title"`
-	Count       int          `json:"count"`
+	Count       int          `json:"count"`  // Scripts in this page
+	Total       int          `json:"total"`  // Scripts matching the tag and filter
+	Offset      int          `json:"offset"` // Matching scripts skipped before this page
+	HasMore     bool         `json:"hasMore"`
 	Location    string       `json:"location"`
 	Scripts     []ScriptItem `json:"scripts"`
 	Instruction string       `json:"instruction"`
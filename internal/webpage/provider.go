@@ -1,13 +1,22 @@
 package webpage
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"html"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/johnjallday/ori-reaper-plugin/internal/scripts"
 	"github.com/johnjallday/ori-reaper-plugin/internal/settings"
 )
 
+// pageTimeout bounds the marketplace listing, since page requests carry no context
+const pageTimeout = 30 * time.Second
+
 // Provider handles web page serving for the ori-reaper plugin
 type Provider struct {
 	settingsManager *settings.Manager
@@ -22,7 +31,7 @@ func NewProvider(settingsManager *settings.Manager) *Provider {
 
 // GetPages returns the list of available web pages
 func (p *Provider) GetPages() []string {
-	return []string{"marketplace"}
+	return []string{"marketplace", "templates"}
 }
 
 // ServePage serves the requested web page
@@ -30,6 +39,8 @@ func (p *Provider) ServePage(path string, query map[string]string) (string, stri
 	switch path {
 	case "marketplace":
 		return p.serveMarketplace()
+	case "templates":
+		return p.serveTemplates()
 	default:
 		return "", "", fmt.Errorf("page not found: %s", path)
 	}
@@ -38,8 +49,13 @@ func (p *Provider) ServePage(path string, query map[string]string) (string, stri
 // serveMarketplace generates the script marketplace HTML page
 func (p *Provider) serveMarketplace() (string, string, error) {
 	// Get available scripts from repository
-	downloader := scripts.NewScriptDownloader()
-	scriptsJSON, err := downloader.ListAvailableScripts()
+	if err := p.settingsManager.ApplyHTTPSettings(); err != nil {
+		return "", "", err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), pageTimeout)
+	defer cancel()
+	downloader := p.settingsManager.NewScriptDownloader()
+	scriptsJSON, err := downloader.ListAvailableScripts(ctx)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to list available scripts: %w", err)
 	}
@@ -57,20 +73,26 @@ func (p *Provider) serveMarketplace() (string, string, error) {
 
 	// Get currently installed scripts
 	scriptsDir := p.settingsManager.GetCurrentScriptsDir()
-	installedScripts, _ := scripts.ListLuaScripts(scriptsDir)
+	installedScripts, _ := scripts.CachedLuaScripts(scriptsDir)
 	installedMap := make(map[string]bool)
 	for _, name := range installedScripts {
 		installedMap[name] = true
 	}
 
+	// The user's ratings and notes, keyed by script base name
+	var ratings map[string]scripts.ScriptRating
+	if metadata, err := scripts.NewScriptManager(scriptsDir).LoadMetadata(); err == nil {
+		ratings = metadata.Ratings
+	}
+
 	// Generate HTML using template
-	html := generateMarketplaceHTML(scriptsList, installedMap)
-	return html, "text/html; charset=utf-8", nil
+	page := generateMarketplaceHTML(scriptsList, installedMap, ratings)
+	return page, "text/html; charset=utf-8", nil
 }
 
 // generateMarketplaceHTML creates the marketplace HTML from script data
-func generateMarketplaceHTML(scriptsList []map[string]interface{}, installedMap map[string]bool) string {
-	html := getMarketplaceTemplate()
+func generateMarketplaceHTML(scriptsList []map[string]interface{}, installedMap map[string]bool, ratings map[string]scripts.ScriptRating) string {
+	page := getMarketplaceTemplate()
 
 	// Add script cards
 	for _, script := range scriptsList {
@@ -81,7 +103,7 @@ func generateMarketplaceHTML(scriptsList []map[string]interface{}, installedMap
 
 		installed := installedMap[name]
 
-		html += fmt.Sprintf(`
+		page += fmt.Sprintf(`
             <div class="script-card" data-name="%s" data-description="%s">
                 <div class="script-name">%s</div>
                 <div class="script-description">%s</div>
@@ -91,17 +113,90 @@ func generateMarketplaceHTML(scriptsList []map[string]interface{}, installedMap
                 </div>`,
 			name, description, name, description, filename, scriptType)
 
+		if rating, ok := ratings[strings.TrimSuffix(filename, path.Ext(filename))]; ok {
+			if rating.Stars > 0 {
+				page += fmt.Sprintf(`<div class="script-rating" title="Your rating">%s</div>`, scripts.FormatStars(rating.Stars))
+			}
+			if rating.Notes != "" {
+				page += fmt.Sprintf(`<div class="script-notes">📝 %s</div>`, html.EscapeString(rating.Notes))
+			}
+		}
+
 		if installed {
-			html += `<div class="installed-badge">✓ Installed</div>`
+			page += `<div class="installed-badge">✓ Installed</div>`
 		} else {
-			html += fmt.Sprintf(`<button class="install-btn" onclick="installScript('%s')">Install Script</button>`, filename)
+			page += fmt.Sprintf(`<button class="install-btn" onclick="installScript('%s')">Install Script</button>`, filename)
 		}
 
-		html += `</div>`
+		page += `</div>`
 	}
 
 	// Close HTML
-	html += getMarketplaceFooter()
+	page += getMarketplaceFooter()
+
+	return page
+}
+
+// serveTemplates generates the project and track templates HTML page
+func (p *Provider) serveTemplates() (string, string, error) {
+	templates, err := scripts.ListTemplates()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to list templates: %w", err)
+	}
+	return generateTemplatesHTML(templates), "text/html; charset=utf-8", nil
+}
+
+// generateTemplatesHTML creates the templates page HTML, a section for each kind of template
+func generateTemplatesHTML(templates []scripts.Template) string {
+	page := getTemplatesPageTemplate()
+
+	sections := []struct {
+		kind, title, action, empty string
+	}{
+		{"project", "Project Templates", "Create Project", "No project templates yet. Save one in REAPER with File → Project templates → Save project as template."},
+		{"track", "Track Templates", "Insert Tracks", "No track templates yet. Save one in REAPER with Track → Save tracks as track template."},
+	}
+	for _, section := range sections {
+		page += fmt.Sprintf(`
+        <div class="section-title">%s</div>
+        <div class="scripts-grid">`, section.title)
+
+		count := 0
+		for _, template := range templates {
+			if template.Kind != section.kind {
+				continue
+			}
+			count++
+			name := html.EscapeString(template.Name)
+			page += fmt.Sprintf(`
+            <div class="script-card" data-name="%s">
+                <div class="script-name">%s</div>
+                <div class="script-meta">
+                    <span class="meta-badge">📄 %s</span>`,
+				name, html.EscapeString(path.Base(template.Name)), html.EscapeString(filepath.Base(template.Path)))
+			if folder := path.Dir(template.Name); folder != "." {
+				page += fmt.Sprintf(`
+                    <span class="meta-badge">📁 %s</span>`, html.EscapeString(folder))
+			}
+			if !template.Modified.IsZero() {
+				page += fmt.Sprintf(`
+                    <span class="meta-badge">🕒 %s</span>`, template.Modified.Format("2006-01-02"))
+			}
+			page += fmt.Sprintf(`
+                </div>
+                <button class="install-btn" data-kind="%s" onclick="useTemplate(this)">%s</button>
+            </div>`, section.kind, section.action)
+		}
+		if count == 0 {
+			page += fmt.Sprintf(`
+            <div class="script-description" style="color: white;">%s</div>`, section.empty)
+		}
+
+		page += `
+        </div>`
+	}
+
+	page += getTemplatesPageFooter()
 
-	return html
+	return page
 }
@@ -1,14 +1,8 @@
 package webpage
 
-// getMarketplaceTemplate returns the HTML header and styles for the marketplace
-func getMarketplaceTemplate() string {
-	return `<!DOCTYPE html>
-<html lang="en">
-<head>
-    <meta charset="UTF-8">
-    <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <title>REAPER Script Marketplace</title>
-    <style>
+// getPageStyles returns the styles shared by the plugin's pages
+func getPageStyles() string {
+	return `    <style>
         body {
             font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, 'Helvetica Neue', Arial, sans-serif;
             margin: 0;
@@ -86,6 +80,20 @@ func getMarketplaceTemplate() string {
             font-size: 0.85em;
             color: #666;
         }
+        .script-rating {
+            color: #f5a623;
+            font-size: 1.1em;
+            margin-bottom: 8px;
+        }
+        .script-notes {
+            background: #fffbea;
+            border-left: 3px solid #f5a623;
+            padding: 6px 10px;
+            margin-bottom: 15px;
+            font-size: 0.9em;
+            color: #555;
+            white-space: pre-wrap;
+        }
         .install-btn {
             background: linear-gradient(135deg, #667eea 0%, #764ba2 100%);
             color: white;
@@ -113,6 +121,11 @@ func getMarketplaceTemplate() string {
             text-align: center;
             font-weight: 600;
         }
+        .section-title {
+            color: white;
+            font-size: 1.5em;
+            margin: 30px 0 15px;
+        }
         .no-results {
             text-align: center;
             color: white;
@@ -120,7 +133,18 @@ func getMarketplaceTemplate() string {
             margin-top: 50px;
         }
     </style>
-</head>
+`
+}
+
+// getMarketplaceTemplate returns the HTML header and styles for the marketplace
+func getMarketplaceTemplate() string {
+	return `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>REAPER Script Marketplace</title>
+` + getPageStyles() + `</head>
 <body>
     <div class="container">
         <h1>🎵 REAPER Script Marketplace</h1>
@@ -183,3 +207,66 @@ func getMarketplaceFooter() string {
 </body>
 </html>`
 }
+
+// getTemplatesPageTemplate returns the HTML header for the templates page
+func getTemplatesPageTemplate() string {
+	return `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>REAPER Templates</title>
+` + getPageStyles() + `</head>
+<body>
+    <div class="container">
+        <h1>📁 REAPER Templates</h1>
+        <div class="subtitle">Start a project or add tracks from your saved templates</div>
+
+        <div class="search-bar">
+            <input type="text" id="searchInput" placeholder="Search templates..." onkeyup="filterTemplates()">
+        </div>`
+}
+
+// getTemplatesPageFooter returns the closing HTML and JavaScript for the templates page
+func getTemplatesPageFooter() string {
+	return `
+        <div class="no-results" id="noResults" style="display: none;">
+            No templates found matching your search.
+        </div>
+    </div>
+
+    <script>
+        function filterTemplates() {
+            const searchTerm = document.getElementById('searchInput').value.toLowerCase();
+            const cards = document.querySelectorAll('.script-card');
+            let visibleCount = 0;
+
+            cards.forEach(card => {
+                const name = card.getAttribute('data-name').toLowerCase();
+
+                if (name.includes(searchTerm)) {
+                    card.style.display = 'block';
+                    visibleCount++;
+                } else {
+                    card.style.display = 'none';
+                }
+            });
+
+            document.getElementById('noResults').style.display = visibleCount === 0 ? 'block' : 'none';
+        }
+
+        function useTemplate(btn) {
+            const name = btn.closest('.script-card').getAttribute('data-name');
+            const request = btn.getAttribute('data-kind') === 'track'
+                ? 'insert track template ' + name
+                : 'create project from template ' + name;
+
+            // This would call back to ori-agent to execute the operation
+            // For now, show what to ask
+            alert('To use this template: Ask Ori to "' + request + '"');
+            btn.textContent = 'Use Ori to ' + (btn.getAttribute('data-kind') === 'track' ? 'Insert' : 'Create');
+        }
+    </script>
+</body>
+</html>`
+}
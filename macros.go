@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/types"
+)
+
+// runMacro runs the steps of a settings macro in order, stopping at the first failure,
+// and reports the result of each step. When a step fails, the report comes back along
+// with an error wrapping the step's, so its code is the step's code.
+func (t *reaperTool) runMacro(ctx context.Context, name string) (string, error) {
+	if strings.TrimSpace(name) == "" {
+		return "", fmt.Errorf("name is required for 'run_macro' operation")
+	}
+
+	var macro *types.Macro
+	macros := globalSettingsManager.GetMacros()
+	for i := range macros {
+		if strings.EqualFold(macros[i].Name, name) {
+			macro = &macros[i]
+			break
+		}
+	}
+	if macro == nil {
+		return "", fmt.Errorf("macro not found: %s. Use 'list_macros' to see the configured macros", name)
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Macro '%s' (%d steps):\n", macro.Name, len(macro.Steps)))
+
+	for i, step := range macro.Steps {
+		label, output, err := t.runMacroStep(ctx, step)
+		if err != nil {
+			result.WriteString(fmt.Sprintf("\n%d. %s: ❌ %v\n", i+1, label, err))
+			result.WriteString(fmt.Sprintf("\nStopped after step %d of %d.", i+1, len(macro.Steps)))
+			return result.String(), fmt.Errorf("macro '%s' stopped at step %d of %d (%s): %w", macro.Name, i+1, len(macro.Steps), label, err)
+		}
+		result.WriteString(fmt.Sprintf("\n%d. %s: ✅\n", i+1, label))
+		if output = strings.TrimSpace(output); output != "" {
+			result.WriteString(indent(output, "   ") + "\n")
+		}
+	}
+
+	result.WriteString("\nAll steps completed.")
+	return result.String(), nil
+}
+
+// runMacroStep runs one macro step and returns a label for it along with its output
+func (t *reaperTool) runMacroStep(ctx context.Context, step types.MacroStep) (string, string, error) {
+	switch {
+	case step.Action != "":
+		label := "action " + step.Action
+		client, err := newWebRemoteClient()
+		if err != nil {
+			return label, "", err
+		}
+		if err := client.RunAction(ctx, step.Action); err != nil {
+			return label, "", err
+		}
+		return label, "", nil
+
+	case step.Script != "":
+		return "script " + step.Script, "", t.callStep(ctx, map[string]interface{}{"operation": "run", "script": step.Script}, nil)
+
+	case step.Operation != "":
+		if step.Operation == "run_macro" {
+			return step.Operation, "", fmt.Errorf("macros can't run other macros")
+		}
+		args := map[string]interface{}{}
+		for key, value := range step.Params {
+			args[key] = value
+		}
+		args["operation"] = step.Operation
+		var output string
+		err := t.callStep(ctx, args, &output)
+		return step.Operation, output, err
+
+	default:
+		return "empty step", "", fmt.Errorf("step has no operation, script or action")
+	}
+}
+
+// callStep runs an operation as part of a macro. Macros are defined by the user in
+// settings, so high-risk steps run without a separate confirmation.
+func (t *reaperTool) callStep(ctx context.Context, args map[string]interface{}, output *string) error {
+	data, err := json.Marshal(args)
+	if err != nil {
+		return fmt.Errorf("failed to encode step parameters: %w", err)
+	}
+	result, err := t.call(ctx, string(data), true)
+	if output != nil {
+		*output = result
+	}
+	return err
+}
+
+// formatMacros lists the configured macros and their steps
+func formatMacros(macros []types.Macro) string {
+	if len(macros) == 0 {
+		return "No macros configured. Add them to the plugin settings under \"macros\"."
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Found %d macros:\n", len(macros)))
+	for _, macro := range macros {
+		result.WriteString(fmt.Sprintf("\n%s", macro.Name))
+		if macro.Description != "" {
+			result.WriteString(" - " + macro.Description)
+		}
+		result.WriteString("\n")
+		for i, step := range macro.Steps {
+			switch {
+			case step.Action != "":
+				result.WriteString(fmt.Sprintf("  %d. action %s\n", i+1, step.Action))
+			case step.Script != "":
+				result.WriteString(fmt.Sprintf("  %d. script %s\n", i+1, step.Script))
+			default:
+				result.WriteString(fmt.Sprintf("  %d. %s\n", i+1, step.Operation))
+			}
+		}
+	}
+	return result.String()
+}
+
+// indent prefixes every line of s with prefix
+func indent(s, prefix string) string {
+	return prefix + strings.ReplaceAll(s, "\n", "\n"+prefix)
+}
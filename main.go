@@ -4,15 +4,32 @@ import (
 	"context"
 	_ "embed"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"os"
 	"os/user"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/hashicorp/go-plugin"
 	"github.com/johnjallday/ori-agent/pluginapi"
+	"github.com/johnjallday/ori-reaper-plugin/internal/bridge"
+	"github.com/johnjallday/ori-reaper-plugin/internal/confirm"
 	reapercontext "github.com/johnjallday/ori-reaper-plugin/internal/context"
+	"github.com/johnjallday/ori-reaper-plugin/internal/errcode"
+	"github.com/johnjallday/ori-reaper-plugin/internal/hooks"
+	"github.com/johnjallday/ori-reaper-plugin/internal/i18n"
+	"github.com/johnjallday/ori-reaper-plugin/internal/metrics"
+	"github.com/johnjallday/ori-reaper-plugin/internal/progress"
+	"github.com/johnjallday/ori-reaper-plugin/internal/project"
 	"github.com/johnjallday/ori-reaper-plugin/internal/scripts"
 	"github.com/johnjallday/ori-reaper-plugin/internal/settings"
+	"github.com/johnjallday/ori-reaper-plugin/internal/timeutil"
+	"github.com/johnjallday/ori-reaper-plugin/internal/types"
 	"github.com/johnjallday/ori-reaper-plugin/internal/webpage"
 )
 
@@ -22,6 +39,71 @@ var configYAML string
 // Global settings manager
 var globalSettingsManager = settings.NewManager()
 
+// operations lists every operation accepted by Call
+var operations = []string{
+	"list", "run", "add", "delete", "list_available_scripts", "download_script",
+	"register_script", "register_all_scripts", "clean_scripts", "get_context",
+	"get_web_remote_port", "get_tracks", "get_selected_tracks", "select_tracks", "batch_rename_tracks", "get_levels", "undo", "redo", "get_undo_history",
+	"get_record_inputs", "set_record_input", "get_master", "set_master", "set_solo_mode",
+	"get_playrate", "set_playrate",
+	"list_monitor_fx", "add_monitor_fx", "set_monitor_fx", "get_selected_items", "edit_selected_items",
+	"get_edit_modes", "set_edit_modes",
+	"get_automation", "set_automation_mode", "set_automation_override", "get_envelopes",
+	"get_project_notes", "set_project_notes", "audit_media", "archive_project", "clean_peaks",
+	"list_backups", "restore_backup", "get_autosave", "set_autosave", "diff_projects",
+	"export_project_json", "export_markers", "import_markers", "export_regions",
+	"render_project", "render_stems", "get_render_stats", "insert_media",
+	"check_dependencies", "install_extension", "profile_script", "create_custom_action",
+	"export_keymap", "import_keymap", "configure_osc", "install_osc_pattern",
+	"configure_web_remote", "setup_web_remote", "list_web_interfaces", "install_web_interface",
+	"list_trash", "restore_script", "disable_script", "enable_script", "favorite_script", "tag_script", "rate_script",
+	"run_macro", "list_macros", "download_scripts",
+	"check_updates", "update_script", "pin_script", "unpin_script",
+	"list_bundles", "install_bundle", "uninstall_bundle", "onboard", "alias_script",
+	"find_duplicates", "script_history", "rollback_script", "import_scripts",
+	"publish_script", "list_extstate", "get_extstate",
+	"list_screensets", "load_screenset", "list_menus", "add_menu_item", "remove_menu_item",
+	"list_templates", "create_project_from_template", "insert_track_template",
+	"search_actions", "run_action", "insert_midi", "convert_time",
+	"log_session_note", "get_session_log", "list_executions", "get_metrics",
+}
+
+// defaultOperationTimeout bounds an operation unless settings or longOperationTimeouts say otherwise
+const defaultOperationTimeout = 2 * time.Minute
+
+// longOperationTimeouts are the defaults for operations that download, render or wait for REAPER
+var longOperationTimeouts = map[string]time.Duration{
+	"render_project":        35 * time.Minute,
+	"render_stems":          35 * time.Minute,
+	"archive_project":       30 * time.Minute,
+	"run_macro":             30 * time.Minute,
+	"download_scripts":      10 * time.Minute,
+	"update_script":         10 * time.Minute,
+	"install_bundle":        10 * time.Minute,
+	"import_scripts":        10 * time.Minute,
+	"publish_script":        3 * time.Minute,
+	"onboard":               10 * time.Minute,
+	"install_extension":     10 * time.Minute,
+	"install_web_interface": 5 * time.Minute,
+	"check_updates":         5 * time.Minute,
+	"profile_script":        3 * time.Minute,
+	"setup_web_remote":      3 * time.Minute,
+}
+
+// operationTimeout returns how long an operation may run before its context is canceled
+func operationTimeout(operation string) time.Duration {
+	if timeout := globalSettingsManager.GetOperationTimeout(operation); timeout > 0 {
+		return timeout
+	}
+	if timeout, ok := longOperationTimeouts[operation]; ok {
+		return timeout
+	}
+	return defaultOperationTimeout
+}
+
+// confirmations holds high-risk operations waiting for a confirm_token follow-up call
+var confirmations = confirm.NewStore(confirm.DefaultTTL)
+
 // reaperTool implements the PluginTool interface.
 type reaperTool struct {
 	pluginapi.BasePlugin
@@ -42,119 +124,1869 @@ var _ pluginapi.WebPageProvider = (*reaperTool)(nil)
 func (t *reaperTool) Definition() pluginapi.Tool {
 	return pluginapi.Tool{
 		Name:        "ori-reaper",
-		Description: "Manage REAPER ReaScripts: list available scripts, launch them, add new scripts, delete them, browse marketplace, configure Web Remote, or manage control surfaces",
+		Description: "Manage REAPER ReaScripts: list available scripts, launch them, add new scripts, delete them, browse marketplace, configure Web Remote, manage control surfaces, or undo/redo changes",
 		Parameters: map[string]interface{}{
 			"type": "object",
 			"properties": map[string]interface{}{
 				"operation": map[string]interface{}{
 					"type":        "string",
 					"description": "Operation to perform. Use 'download_script' to get the marketplace URL for browsing and downloading scripts visually.",
-					"enum":        []string{"list", "run", "add", "delete", "list_available_scripts", "download_script", "register_script", "register_all_scripts", "clean_scripts", "get_context", "get_web_remote_port", "get_tracks"},
+					"enum":        operations,
 				},
 				"script": map[string]interface{}{
 					"type":        "string",
-					"description": "Base name of the ReaScript (without extension). Required for 'run', 'add', and 'delete' operations.",
+					"description": "Base name of the ReaScript (without extension). For 'run' and 'delete', case, spaces and partial names are matched and aliases are accepted. For 'alias_script', the script the alias points to (omit to remove the alias). Required for 'run', 'add', 'delete', 'restore_script', 'disable_script', 'enable_script', 'favorite_script', 'tag_script', 'rate_script', 'pin_script', 'unpin_script', 'profile_script', 'publish_script', 'script_history' and 'rollback_script' operations (the latter two also accept deleted scripts, with extension). Optional for 'update_script' and 'check_updates' (omit, or use 'all', for every script installed from the marketplace). Optional for 'check_dependencies'. For 'add_menu_item', the script to add to the menu (or use command).",
 				},
 				"filename": map[string]interface{}{
 					"type":        "string",
-					"description": "Full filename of the script (including extension). Not used by 'download_script' - that operation now redirects to the marketplace.",
+					"description": "Full filename of the script (including extension). Not used by 'download_script' - that operation now redirects to the marketplace. For 'install_web_interface', the marketplace interface page to install (e.g. 'mixer.html').",
 				},
 				"content": map[string]interface{}{
 					"type":        "string",
-					"description": "Script content. Required for 'add' operation.",
+					"description": "Script content. Required for 'add' operation. For 'set_project_notes', the notes text. For 'import_markers', CSV or JSON marker data. For 'install_osc_pattern', the .ReaperOSC pattern config text. For 'log_session_note', the note to log (required), e.g. what was changed and why.",
 				},
 				"script_type": map[string]interface{}{
 					"type":        "string",
 					"description": "Script type/extension. Required for 'add' operation. Valid values: lua, eel, py",
 					"enum":        []string{"lua", "eel", "py"},
 				},
+				"track": map[string]interface{}{
+					"type":        "integer",
+					"description": "Track number (1-based, as shown by 'get_tracks'). Required for 'set_automation_mode' and 'set_record_input'. Optional for 'get_envelopes' and 'get_record_inputs' (omit to list all tracks) 'insert_media' and 'insert_midi' (omit to use the selected track).",
+				},
+				"tracks": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "integer"},
+					"description": "Track numbers (1-based, as shown by 'get_tracks'; 0 is the master) to select with 'select_tracks'. Other tracks are deselected unless 'append' is set; an empty list clears the selection. For 'batch_rename_tracks', the tracks to rename (defaults to the tracks matching 'pattern', or the selected tracks). For 'render_stems', the tracks to render (defaults to the selected tracks, or all tracks in region_tracks mode).",
+				},
+				"regions": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "integer"},
+					"description": "Region numbers (as shown in REAPER) to render with 'render_stems' in regions or region_tracks mode. Defaults to every region.",
+				},
+				"replacement": map[string]interface{}{
+					"type":        "string",
+					"description": "New track name for 'batch_rename_tracks', or the replacement for 'pattern'. {name} is the old name, {n} a running number ({n:2} zero pads to 2 digits) and $1 a pattern group, e.g. 'Drum {n}'.",
+				},
+				"prefix": map[string]interface{}{
+					"type":        "string",
+					"description": "For 'batch_rename_tracks': text added in front of each name unless already there, e.g. 'GTR '. May contain {n}.",
+				},
+				"suffix": map[string]interface{}{
+					"type":        "string",
+					"description": "For 'batch_rename_tracks': text added after each name unless already there. May contain {n}.",
+				},
+				"start": map[string]interface{}{
+					"type":        "integer",
+					"description": "For 'batch_rename_tracks': first number for {n} (default 1).",
+				},
+				"input": map[string]interface{}{
+					"type":        "string",
+					"description": "Record input for 'set_record_input': 'none', a hardware input number (e.g. '2'), a stereo pair (e.g. '1-2'), or MIDI as 'midi' (all devices), 'midi:<device>' or 'midi:<device>:<channel>' ('vkb' for the virtual keyboard).",
+				},
+				"monitoring": map[string]interface{}{
+					"type":        "string",
+					"description": "Record monitoring for 'set_record_input': off, on, or tape (on only while not playing).",
+					"enum":        []string{"off", "on", "tape"},
+				},
+				"arm": map[string]interface{}{
+					"type":        "boolean",
+					"description": "For 'set_record_input': arm (true) or disarm (false) the track for recording.",
+				},
+				"volume": map[string]interface{}{
+					"type":        "number",
+					"description": "Master volume in dB for 'set_master' (e.g. -6; at most +12, -150 or lower for -inf).",
+				},
+				"pan": map[string]interface{}{
+					"type":        "number",
+					"description": "Master pan for 'set_master', from -1.0 (left) through 0 (center) to 1.0 (right).",
+				},
+				"mute": map[string]interface{}{
+					"type":        "boolean",
+					"description": "For 'set_master': mute (true) or unmute (false) the master track.",
+				},
+				"rate": map[string]interface{}{
+					"type":        "number",
+					"description": "For 'set_playrate': master playback rate from 0.25 to 4.0, e.g. 0.75 to slow down to 75% speed for transcribing or practicing; 1.0 is normal speed.",
+				},
+				"preserve_pitch": map[string]interface{}{
+					"type":        "boolean",
+					"description": "For 'set_playrate': keep the pitch of audio when the playback rate changes (true), or let it follow the rate like tape (false).",
+				},
+				"ripple": map[string]interface{}{
+					"type":        "string",
+					"description": "Ripple editing mode for 'set_edit_modes': off, per_track, or all_tracks.",
+					"enum":        []string{"off", "per_track", "all_tracks"},
+				},
+				"snap": map[string]interface{}{
+					"type":        "boolean",
+					"description": "For 'set_edit_modes': turn snapping on (true) or off (false).",
+				},
+				"grid": map[string]interface{}{
+					"type":        "string",
+					"description": "Grid spacing for 'set_edit_modes' as a note value, e.g. '1/16', '1/8T' (triplet) or '1/4.' (dotted).",
+				},
+				"grid_lines": map[string]interface{}{
+					"type":        "boolean",
+					"description": "For 'set_edit_modes': show (true) or hide (false) the grid lines.",
+				},
+				"swing": map[string]interface{}{
+					"type":        "number",
+					"description": "Grid swing for 'set_edit_modes', from -1.0 to 1.0; 0 turns swing off.",
+				},
+				"fx": map[string]interface{}{
+					"type":        "string",
+					"description": "Monitoring FX for 'set_monitor_fx': its position in the chain (1-based, as shown by 'list_monitor_fx') or part of its name, e.g. 'headphone'.",
+				},
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "Path to a .RPP project file for 'audit_media', 'archive_project', 'list_backups', 'log_session_note', 'get_session_log', 'diff_projects', 'export_project_json', 'export_markers' and 'export_regions', or a directory (or .RPP) to scan for 'clean_peaks'. Defaults to the project currently open in REAPER. For 'restore_backup', the backup file to restore (required). For 'import_markers', a CSV or JSON file to import (instead of 'content'). For 'insert_media', the audio or MIDI file to insert (required). For 'export_keymap', the .ReaperKeyMap file to write (defaults to REAPER's KeyMaps folder); for 'import_keymap', the key map to import (required). For 'install_osc_pattern', a .ReaperOSC file to install instead of 'content'. For 'install_web_interface', a local .html interface to install. For 'install_bundle', a JSON bundle manifest to install instead of a bundle from settings. For 'import_scripts', a .zip archive or script file to import, as a local path or a URL from a trusted source (required).",
+				},
+				"register": map[string]interface{}{
+					"type":        "boolean",
+					"description": "For 'import_scripts': also register the imported .lua scripts in REAPER's action list.",
+				},
+				"destination": map[string]interface{}{
+					"type":        "string",
+					"description": "Target folder (or .zip file when 'zip' is set). Required for 'archive_project'. For 'restore_backup', the new project file name (defaults to <backup>-restored.RPP). For 'export_project_json', 'export_markers' and 'export_regions', an optional file to write the output to. For 'render_stems', the output directory (defaults to the project's render directory).",
+				},
+				"compare_to": map[string]interface{}{
+					"type":        "string",
+					"description": "For 'diff_projects': the older project file to compare 'path' against. Defaults to the newest backup of 'path'.",
+				},
+				"format": map[string]interface{}{
+					"type":        "string",
+					"description": "Output format. 'json' returns any operation's result as a JSON object {operation, ok, message, data, confirmation, error}, with typed data where the operation has it; 'text' (default) returns prose. For 'export_markers' it picks the export format instead: csv (default) or json. For 'export_regions': youtube (default), ffmpeg, edl, csv or json.",
+				},
+				"frame_rate": map[string]interface{}{
+					"type":        "number",
+					"description": "Frame rate for EDL timecode in 'export_regions' (default 30). For 'convert_time': timecode frame rate, instead of the current project's.",
+				},
+				"full": map[string]interface{}{
+					"type":        "boolean",
+					"description": "For 'export_project_json': include the complete block tree of the .RPP, not just the summary.",
+				},
+				"zip": map[string]interface{}{
+					"type":        "boolean",
+					"description": "For 'archive_project': write a zip file instead of a folder.",
+				},
+				"trim": map[string]interface{}{
+					"type":        "boolean",
+					"description": "For 'archive_project': only copy media the project uses, leaving out unused files in the project folder.",
+				},
+				"dry_run": map[string]interface{}{
+					"type":        "boolean",
+					"description": "For 'clean_peaks' and 'find_duplicates': only report what would be removed (default true). Set to false to delete files (duplicates are moved to the trash). For 'batch_rename_tracks': preview the new names without renaming (default false). For 'import_scripts': only list what would be installed (default false).",
+				},
+				"interval": map[string]interface{}{
+					"type":        "integer",
+					"description": "Auto-save interval in minutes. Required for 'set_autosave'.",
+				},
+				"name": map[string]interface{}{
+					"type":        "string",
+					"description": "Macro name for 'run_macro'. Bundle name for 'install_bundle' and 'uninstall_bundle'. The alias to set or remove for 'alias_script'. Action name for 'create_custom_action' (shown as 'Custom: <name>' in the action list). Device name for 'configure_osc' (an existing surface with this name is updated). Pattern name for 'install_osc_pattern'. Plugin to add for 'add_monitor_fx', as shown in REAPER's FX browser (e.g. 'VST3: SoundID Reference'). Screenset to recall for 'load_screenset': its name (a unique part is enough) or slot number 1-10. Template for 'create_project_from_template' and 'insert_track_template', as listed by 'list_templates' (a unique part is enough). Execution ID for 'list_executions' to show that execution's script and output.",
+				},
+				"commands": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "Command IDs run in order by 'create_custom_action', e.g. [\"40001\", \"_RS1a2b3c\"].",
+				},
+				"host": map[string]interface{}{
+					"type":        "string",
+					"description": "For 'configure_osc': IP or host name of the OSC device REAPER sends feedback to. Omit for receive only.",
+				},
+				"port": map[string]interface{}{
+					"type":        "integer",
+					"description": "For 'configure_osc': local port REAPER listens on (default 8000). For 'configure_web_remote' and 'setup_web_remote': the Web Remote port.",
+				},
+				"restart": map[string]interface{}{
+					"type":        "boolean",
+					"description": "For 'setup_web_remote': quit and relaunch REAPER so the configuration takes effect. REAPER may prompt to save open projects.",
+				},
+				"enabled": map[string]interface{}{
+					"type":        "boolean",
+					"description": "For 'configure_web_remote': enable or disable the Web Remote. Omit to leave it unchanged. For 'set_monitor_fx': enable (true) or bypass (false) the plugin; omit to toggle it.",
+				},
+				"remote_port": map[string]interface{}{
+					"type":        "integer",
+					"description": "For 'configure_osc': port on the OSC device (default 9000).",
+				},
+				"pattern": map[string]interface{}{
+					"type":        "string",
+					"description": "For 'configure_osc': name of an installed .ReaperOSC pattern config. Defaults to REAPER's Default pattern. For 'batch_rename_tracks': regular expression track names must match (renames all matching tracks unless 'tracks' is set); with 'replacement', the part of the name replaced. For 'render_stems': output file name pattern using REAPER wildcards such as $project, $region and $track. For 'insert_midi': chords and notes played one after another, used when midi_notes is omitted, e.g. 'C Am F G' (chords voiced from C4, 4 beats each), 'C4 E4 G4:2 - C5' (notes with octaves, 1 beat each, '-' rests) or 'C4+E4+G4:2' (notes played together); ':<beats>' sets a step's length.",
+				},
+				"filenames": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "For 'download_scripts': script filenames to download from the marketplace, or [\"all\"] for every script.",
+				},
+				"version": map[string]interface{}{
+					"type":        "string",
+					"description": "For 'pin_script': the version SHA (or a prefix of it) to pin at. Must match the installed version; defaults to it. For 'rollback_script': the commit (or a prefix of it) to restore, as listed by 'script_history'.",
+				},
+				"tag": map[string]interface{}{
+					"type":        "string",
+					"description": "For 'list': only list scripts with this tag.",
+				},
+				"message": map[string]interface{}{
+					"type":        "string",
+					"description": "For 'publish_script': commit message (and pull request title). Defaults to script_publish.message in the settings, e.g. 'Update My_Script.lua'.",
+				},
+				"pull_request": map[string]interface{}{
+					"type":        "boolean",
+					"description": "For 'publish_script': commit to a new branch and open a pull request instead of committing directly. Defaults to script_publish.pull_request in the settings.",
+				},
+				"filter": map[string]interface{}{
+					"type":        "string",
+					"description": "For 'list': only list scripts whose name contains this text (case, spaces and underscores are ignored). For 'list_extstate': only list sections whose name contains this text. For 'search_actions' (required): a command ID or words of the action's name, e.g. 'insert new track' or 'toggle metronome'. For 'list_executions': only list executions whose script name contains this text, e.g. 'run_script'.",
+				},
+				"section": map[string]interface{}{
+					"type":        "string",
+					"description": "For 'get_extstate': the ExtState section to read from reaper-extstate.ini, usually named after the script that saved it (required; see 'list_extstate').",
+				},
+				"key": map[string]interface{}{
+					"type":        "string",
+					"description": "For 'get_extstate': read only this key, shown in full. Omit to list the whole section, with long values shortened.",
+				},
+				"menu": map[string]interface{}{
+					"type":        "string",
+					"description": "For 'list_menus': show the items of this menu instead of listing the customized menus. For 'add_menu_item' and 'remove_menu_item': the reaper-menu.ini menu to edit, e.g. 'Main actions' (the default) or 'Track control panel context'. It must have been customized and saved in REAPER once.",
+				},
+				"submenu": map[string]interface{}{
+					"type":        "string",
+					"description": "For 'add_menu_item': put the item in this submenu of the menu, e.g. 'Dolphin', creating it at the end of the menu if needed. For 'remove_menu_item': the submenu holding the item; it's removed when left empty.",
+				},
+				"label": map[string]interface{}{
+					"type":        "string",
+					"description": "For 'add_menu_item': the item's text. Defaults to the script name, or to the command. For 'remove_menu_item': the label (or command ID) of the item to remove.",
+				},
+				"command": map[string]interface{}{
+					"type":        "string",
+					"description": "For 'add_menu_item': command ID of the action to add, e.g. 40001 or _SWS_SAVESEL, when adding an action rather than a script. For 'run_action' (required): the command ID of the action to run, or its exact name; use 'search_actions' to find it.",
+				},
+				"offset": map[string]interface{}{
+					"type":        "integer",
+					"description": "For 'list': number of matching scripts to skip, for paging through long lists.",
+				},
+				"limit": map[string]interface{}{
+					"type":        "integer",
+					"description": "For 'list': maximum number of scripts to return. The result includes the total count. For 'search_actions': maximum number of actions to return (default 10). For 'get_session_log': number of latest entries to show (default 50). For 'list_executions': number of latest executions to show (default 20).",
+				},
+				"tags": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "For 'tag_script': the script's tags, replacing any existing ones. An empty list clears them.",
+				},
+				"favorite": map[string]interface{}{
+					"type":        "boolean",
+					"description": "For 'favorite_script': true (default) to mark as favorite, false to unmark. Favorites are listed first.",
+				},
+				"rating": map[string]interface{}{
+					"type":        "integer",
+					"description": "For 'rate_script': star rating from 1 to 5, or 0 to clear it. Rated scripts are listed after favorites, highest first.",
+				},
+				"notes": map[string]interface{}{
+					"type":        "string",
+					"description": "For 'rate_script': the user's notes on the script, replacing any existing ones. An empty string clears them.",
+				},
+				"confirm_token": map[string]interface{}{
+					"type":        "string",
+					"description": "Token returned by a high-risk operation (delete, register_all_scripts, clean_scripts, import_keymap, create_custom_action, restore_backup, set_autosave, configure_web_remote, setup_web_remote, configure_osc, install_bundle, uninstall_bundle, install_extension, onboard, find_duplicates with dry_run=false, import_scripts, publish_script, add_menu_item, remove_menu_item, and download_scripts in review mode). Call the same operation again with it to carry out what was described; other parameters are ignored.",
+				},
+				"undo_block": map[string]interface{}{
+					"type":        "boolean",
+					"description": "For 'add': wrap the script in Undo_BeginBlock/Undo_EndBlock so its changes form a single undo point.",
+				},
+				"undo_label": map[string]interface{}{
+					"type":        "string",
+					"description": "For 'add' with undo_block: undo point description. Defaults to a label derived from the script name.",
+				},
+				"append": map[string]interface{}{
+					"type":        "boolean",
+					"description": "For 'set_project_notes': append to the existing notes instead of replacing them. For 'select_tracks': add to the current selection instead of replacing it.",
+				},
+				"extension": map[string]interface{}{
+					"type":        "string",
+					"description": "Extension id for 'install_extension': sws, reapack, reaimgui or js_reascriptapi. Omit to list missing extensions.",
+				},
+				"midi_notes": map[string]interface{}{
+					"type": "array",
+					"items": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"pitch":    map[string]interface{}{"type": "string", "description": "Note name such as C4 (middle C), F#3 or Bb2, or a MIDI note number such as 60"},
+							"start":    map[string]interface{}{"type": "number", "description": "Start in beats (quarter notes) from the start of the item"},
+							"length":   map[string]interface{}{"type": "number", "description": "Length in beats"},
+							"velocity": map[string]interface{}{"type": "integer", "description": "1-127, defaults to 96"},
+						},
+						"required": []string{"pitch", "start", "length"},
+					},
+					"description": "For 'insert_midi': the notes of the new MIDI item. Use either this or pattern.",
+				},
+				"velocity": map[string]interface{}{
+					"type":        "integer",
+					"description": "For 'insert_midi' with a pattern: velocity of its notes, 1-127 (default 96).",
+				},
+				"related_operation": map[string]interface{}{
+					"type":        "string",
+					"description": "For 'log_session_note': the operation the note is about, e.g. 'set_master' or 'render_stems'.",
+				},
+				"time": map[string]interface{}{
+					"type":        []string{"number", "string"},
+					"description": "For 'convert_time' (required): the position to convert: seconds (83.5), samples (4008000smp), bars.beats (42.3.00, or 42.3bar), h:m:s (1:23.5) or h:m:s:f timecode (00:01:23:15).",
+				},
+				"from": map[string]interface{}{
+					"type":        "string",
+					"description": "For 'convert_time': the format of time when it's ambiguous, e.g. 'bars' to read 42.3 as bar 42, beat 3: seconds, samples, bars, hms or timecode. Detected by default.",
+				},
+				"to": map[string]interface{}{
+					"type":        "string",
+					"description": "For 'convert_time': only return this format (seconds, samples, bars, hms or timecode). Returns every format by default.",
+				},
+				"tempo": map[string]interface{}{
+					"type":        "number",
+					"description": "For 'convert_time': tempo in BPM for bars.beats, instead of the current project's.",
+				},
+				"position": map[string]interface{}{
+					"type":        []string{"number", "string"},
+					"description": "Position for 'insert_media' and 'insert_midi'. Defaults to the edit cursor. Seconds, or a string in any format 'convert_time' reads, e.g. '1:23.5', '42.3.00' (bar 42, beat 3) or '00:01:23:15'.",
+				},
+				"mode": map[string]interface{}{
+					"type":        "string",
+					"description": "Automation mode. For 'set_automation_mode': trim, read, touch, write, latch, latch_preview. For 'set_automation_override': none, trim, read, touch, write, latch, bypass. For 'set_solo_mode': in_place (unsoloed tracks are silenced) or in_front (unsoloed tracks are dimmed). For 'render_stems': tracks (one file per track, the default), regions (one mix per region) or region_tracks (one file per region and track). For 'edit_selected_items': split (at the edit cursor), glue or normalize. For 'load_screenset': window (the default) or track_view.",
+				},
 			},
 			"required": []string{"operation"},
 		},
 	}
 }
 
-// Call implements the PluginTool interface
+// Call implements the PluginTool interface. Errors with a specific code start with it in
+// brackets, e.g. "[REAPER_NOT_RUNNING] ...", so the agent can tell failures apart; with
+// format "json" the code is the envelope's "code" field instead.
 func (t *reaperTool) Call(ctx context.Context, args string) (string, error) {
+	text, err := t.call(ctx, args, false)
+	if code, ok := errcode.Lookup(err); ok {
+		err = fmt.Errorf("[%s] %w", code, err)
+	}
+	return text, err
+}
+
+// call runs an operation. High-risk operations need a confirm_token unless confirmed is set,
+// as it is for the steps of a user-defined macro. With format "json" the result is returned
+// as a JSON envelope instead of text. ctx is canceled once the operation's timeout passes.
+// Progress goes to the reporter in ctx, or to stderr when there is none.
+func (t *reaperTool) call(ctx context.Context, args string, confirmed bool) (string, error) {
+	i18n.SetLocale(globalSettingsManager.GetLocale())
+
+	// Bound the operation so a hung REAPER, download or command can't hang the agent
+	var peek struct {
+		Operation string `json:"operation"`
+	}
+	json.Unmarshal([]byte(args), &peek)
+	timeout := operationTimeout(peek.Operation)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if !progress.Enabled(ctx) {
+		ctx = progress.WithFunc(ctx, stderrProgress(peek.Operation))
+	}
+
+	out := &output{}
+	started := time.Now()
+	text, err := t.dispatch(ctx, args, confirmed, out)
+	outcome := metrics.OutcomeOK
+	if err != nil {
+		outcome = metrics.OutcomeError
+	}
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		outcome = metrics.OutcomeTimeout
+		err = errcode.New(errcode.Timeout, i18n.Errorf("error.timed_out", peek.Operation, timeout, err))
+	}
+	if slices.Contains(operations, peek.Operation) {
+		metrics.ObserveOperation(peek.Operation, time.Since(started), outcome)
+	}
+	if !out.json {
+		return text, err
+	}
+	return out.encode(text, err)
+}
+
+// dispatch parses the arguments and runs the operation. Operations with a typed result
+// store it in out.data for JSON output.
+func (t *reaperTool) dispatch(ctx context.Context, args string, confirmed bool, out *output) (string, error) {
 	// Parse parameters
 	var params struct {
-		Operation  string `json:"operation"`
-		Script     string `json:"script"`
-		Filename   string `json:"filename"`
-		Content    string `json:"content"`
-		ScriptType string `json:"script_type"`
+		Operation     string             `json:"operation"`
+		Script        string             `json:"script"`
+		Filename      string             `json:"filename"`
+		Content       string             `json:"content"`
+		ScriptType    string             `json:"script_type"`
+		Track         int                `json:"track"`
+		Tracks        []int              `json:"tracks"`
+		Regions       []int              `json:"regions"`
+		Input         *string            `json:"input"`
+		Monitoring    string             `json:"monitoring"`
+		Arm           *bool              `json:"arm"`
+		Volume        *float64           `json:"volume"`
+		Pan           *float64           `json:"pan"`
+		Mute          *bool              `json:"mute"`
+		Rate          *float64           `json:"rate"`
+		PreservePitch *bool              `json:"preserve_pitch"`
+		FX            string             `json:"fx"`
+		Ripple        string             `json:"ripple"`
+		Snap          *bool              `json:"snap"`
+		Grid          string             `json:"grid"`
+		GridLines     *bool              `json:"grid_lines"`
+		Swing         *float64           `json:"swing"`
+		Replacement   string             `json:"replacement"`
+		Prefix        string             `json:"prefix"`
+		Suffix        string             `json:"suffix"`
+		Start         int                `json:"start"`
+		Mode          string             `json:"mode"`
+		Append        bool               `json:"append"`
+		Path          string             `json:"path"`
+		Destination   string             `json:"destination"`
+		Zip           bool               `json:"zip"`
+		Trim          bool               `json:"trim"`
+		DryRun        *bool              `json:"dry_run"`
+		Interval      int                `json:"interval"`
+		CompareTo     string             `json:"compare_to"`
+		Full          bool               `json:"full"`
+		Format        string             `json:"format"`
+		FrameRate     float64            `json:"frame_rate"`
+		Position      *timeutil.Value    `json:"position"`
+		Time          timeutil.Value     `json:"time"`
+		From          string             `json:"from"`
+		To            string             `json:"to"`
+		Tempo         float64            `json:"tempo"`
+		RelatedOp     string             `json:"related_operation"`
+		Extension     string             `json:"extension"`
+		UndoBlock     bool               `json:"undo_block"`
+		Name          string             `json:"name"`
+		Commands      []string           `json:"commands"`
+		Host          string             `json:"host"`
+		Port          int                `json:"port"`
+		RemotePort    int                `json:"remote_port"`
+		Enabled       *bool              `json:"enabled"`
+		Restart       bool               `json:"restart"`
+		Register      bool               `json:"register"`
+		Pattern       string             `json:"pattern"`
+		UndoLabel     string             `json:"undo_label"`
+		Tag           string             `json:"tag"`
+		Tags          []string           `json:"tags"`
+		Favorite      *bool              `json:"favorite"`
+		Rating        *int               `json:"rating"`
+		Notes         *string            `json:"notes"`
+		Filenames     []string           `json:"filenames"`
+		Version       string             `json:"version"`
+		Message       string             `json:"message"`
+		PullRequest   *bool              `json:"pull_request"`
+		Filter        string             `json:"filter"`
+		Section       string             `json:"section"`
+		Key           string             `json:"key"`
+		Menu          string             `json:"menu"`
+		Submenu       string             `json:"submenu"`
+		Label         string             `json:"label"`
+		Command       string             `json:"command"`
+		MIDINotes     []scripts.MIDINote `json:"midi_notes"`
+		Velocity      int                `json:"velocity"`
+		Offset        int                `json:"offset"`
+		Limit         int                `json:"limit"`
+
+		// Set in the pending arguments of a confirmed delete: the script the summary showed
+		ResolvedScript string `json:"resolved_script"`
+	}
+
+	// A confirm_token replays the arguments of the call that issued it
+	var confirmation struct {
+		Operation string `json:"operation"`
+		Token     string `json:"confirm_token"`
+	}
+	if err := json.Unmarshal([]byte(args), &confirmation); err != nil {
+		return "", errcode.New(errcode.InvalidParameters, i18n.Errorf("error.parse_parameters", err))
+	}
+	if confirmation.Token != "" {
+		pending, err := confirmations.Take(confirmation.Token, confirmation.Operation)
+		if err != nil {
+			return "", err
+		}
+		args = pending.Args
+		confirmed = true
 	}
 
 	if err := json.Unmarshal([]byte(args), &params); err != nil {
-		return "", fmt.Errorf("failed to parse parameters: %w", err)
+		return "", errcode.New(errcode.InvalidParameters, i18n.Errorf("error.parse_parameters", err))
+	}
+	out.operation = params.Operation
+	out.json = strings.EqualFold(params.Format, "json") && !exportFormatOperations[params.Operation]
+	if refresh, ok := projectSettingsOperations[params.Operation]; ok {
+		applyProjectSettings(ctx, refresh)
+	}
+
+	// High-risk operations only describe what they would do until confirmed
+	if !confirmed {
+		var summary string
+		switch params.Operation {
+		case "delete":
+			// Show the script the name resolves to, since it may be a fuzzy match
+			sm := scripts.NewScriptManager(globalSettingsManager.GetCurrentScriptsDir())
+			sm.SetAliases(globalSettingsManager.GetScriptAliases())
+			script, err := sm.ResolveScript(params.Script)
+			if err != nil {
+				return "", err
+			}
+			summary = i18n.T("summary.delete", script)
+			// Delete the script the user confirmed, even if the name matches another one by then
+			if args, err = withArg(args, "resolved_script", script); err != nil {
+				return "", err
+			}
+		case "register_all_scripts":
+			summary = i18n.T("summary.register_all_scripts")
+		case "find_duplicates":
+			if params.DryRun != nil && !*params.DryRun {
+				summary = i18n.T("summary.find_duplicates")
+			}
+		case "clean_scripts":
+			summary = i18n.T("summary.clean_scripts")
+		case "import_keymap":
+			summary = i18n.T("summary.import_keymap", params.Path)
+		case "create_custom_action":
+			summary = i18n.T("summary.create_custom_action", params.Name)
+		case "restore_backup":
+			if params.Destination != "" {
+				summary = i18n.T("summary.restore_backup_to", params.Path, params.Destination)
+			} else {
+				summary = i18n.T("summary.restore_backup", params.Path)
+			}
+		case "set_autosave":
+			summary = i18n.T("summary.set_autosave", params.Interval)
+		case "configure_web_remote", "setup_web_remote":
+			if params.Restart {
+				summary = i18n.T("summary.web_remote_restart")
+			} else {
+				summary = i18n.T("summary.web_remote")
+			}
+		case "configure_osc":
+			summary = i18n.T("summary.configure_osc")
+		case "publish_script":
+			publish := globalSettingsManager.GetScriptPublish()
+			if publish.Repository == "" {
+				break // PublishScript reports the missing settings
+			}
+			target := publish.Repository
+			if publish.Branch != "" {
+				target += " (" + publish.Branch + ")"
+			}
+			if (params.PullRequest == nil && publish.PullRequest) || (params.PullRequest != nil && *params.PullRequest) {
+				summary = i18n.T("summary.publish_pull_request", params.Script, target)
+			} else {
+				summary = i18n.T("summary.publish_commit", params.Script, target)
+			}
+		case "install_bundle":
+			bundle, err := findBundle(params.Name, params.Path)
+			if err != nil {
+				return "", err
+			}
+			summary = i18n.T("summary.install_bundle", bundle.Name, strings.Join(bundle.Scripts, ", "), len(bundle.Shortcuts), len(bundle.Toolbar))
+			if globalSettingsManager.GetReviewBeforeInstall() {
+				preview, err := globalSettingsManager.NewScriptDownloader().PreviewScripts(ctx, bundle.Scripts)
+				if err != nil {
+					return "", err
+				}
+				summary += "\n\n" + preview
+			}
+		case "import_scripts":
+			if params.DryRun == nil || !*params.DryRun {
+				if params.Register {
+					summary = i18n.T("summary.import_scripts_register", params.Path)
+				} else {
+					summary = i18n.T("summary.import_scripts", params.Path)
+				}
+			}
+		case "add_menu_item", "remove_menu_item":
+			menu := params.Menu
+			if strings.TrimSpace(menu) == "" {
+				menu = scripts.DefaultMenu
+			}
+			if params.Submenu != "" {
+				menu += " > " + params.Submenu
+			}
+			if params.Operation == "add_menu_item" {
+				item := params.Script
+				if item == "" {
+					item = params.Command
+				}
+				summary = i18n.T("summary.add_menu_item", item, menu)
+			} else {
+				summary = i18n.T("summary.remove_menu_item", params.Label, menu)
+			}
+		case "uninstall_bundle":
+			summary = i18n.T("summary.uninstall_bundle", params.Name)
+		case "install_extension":
+			download, err := scripts.PlanExtensionOperation(ctx, params.Extension)
+			if err != nil {
+				return "", err
+			}
+			if download != nil {
+				summary = i18n.T("summary.install_extension", download.Extension.Name, download.Version, download.URL, project.FormatSize(int64(download.Size)), download.Target)
+			}
+		}
+		if summary != "" {
+			pending, err := confirmations.Add(params.Operation, args, summary)
+			if err != nil {
+				return "", err
+			}
+			out.confirmation = pending
+			return i18n.T("confirm.required", summary, params.Operation, pending.Token, pending.Expires.Format("15:04:05")), nil
+		}
 	}
+	if err := globalSettingsManager.ApplyHTTPSettings(); err != nil {
+		return "", err
+	}
+	scripts.ConfigureGit(globalSettingsManager.GetScriptsGit())
+	bridge.SetHistoryLimit(globalSettingsManager.GetExecutionHistory())
+	bridge.SetKeepFiles(globalSettingsManager.GetKeepBridgeFiles())
+	metrics.SetEnabled(globalSettingsManager.GetMetrics())
+
 	// Get current scripts directory and create a script manager
 	scriptsDir := globalSettingsManager.GetCurrentScriptsDir()
 	scriptManager := scripts.NewScriptManager(scriptsDir)
+	scriptManager.SetTrashRetention(globalSettingsManager.GetTrashRetention())
+	scriptManager.SetAliases(globalSettingsManager.GetScriptAliases())
+	scriptManager.SetLaunchPolicy(globalSettingsManager.GetScriptLaunchPolicy())
+	if globalSettingsManager.GetCreateIfMissing() {
+		if err := scriptManager.CreateScriptsDir(); err != nil {
+			return "", err
+		}
+	}
 
 	switch params.Operation {
 	case "list":
-		return scriptManager.ListScripts()
+		return scriptManager.ListScripts(scripts.ListOptions{
+			Tag:    params.Tag,
+			Filter: params.Filter,
+			Offset: params.Offset,
+			Limit:  params.Limit,
+		})
 	case "run":
-		return scriptManager.RunScript(params.Script)
+		return scriptManager.RunScript(ctx, params.Script)
 	case "add":
-		return scriptManager.AddScript(params.Script, params.Content, params.ScriptType)
+		content := params.Content
+		if params.UndoBlock && strings.TrimSpace(content) != "" {
+			label := params.UndoLabel
+			if label == "" {
+				label = scripts.UndoLabel(params.Script)
+			}
+			wrapped, err := scripts.WrapUndoBlock(content, params.ScriptType, label)
+			if err != nil {
+				return "", err
+			}
+			content = wrapped
+		}
+		return scriptManager.AddScript(params.Script, content, params.ScriptType)
 	case "delete":
+		if params.ResolvedScript != "" {
+			script, err := scriptManager.ResolveScript(params.Script)
+			if err != nil {
+				return "", err
+			}
+			if script != params.ResolvedScript {
+				return "", errcode.New(errcode.InvalidConfirmation, i18n.Errorf("confirm.script_changed", params.Script, script, params.ResolvedScript))
+			}
+		}
 		return scriptManager.DeleteScript(params.Script)
+	case "import_scripts":
+		dryRun := params.DryRun != nil && *params.DryRun
+		result, err := globalSettingsManager.NewScriptDownloader().ImportScripts(ctx, scriptManager, params.Path, params.Register, dryRun)
+		if err != nil {
+			return "", err
+		}
+		out.data = result
+		return scripts.FormatImportResult(result), nil
+	case "script_history":
+		file, revisions, err := scriptManager.ScriptHistory(params.Script)
+		if err != nil {
+			return "", err
+		}
+		out.data = revisions
+		return scripts.FormatScriptHistory(file, revisions), nil
+	case "rollback_script":
+		return scriptManager.RollbackScript(params.Script, params.Version)
+	case "publish_script":
+		publish := globalSettingsManager.GetScriptPublish()
+		if params.PullRequest != nil {
+			publish.PullRequest = *params.PullRequest
+		}
+		result, err := scriptManager.PublishScript(ctx, publish, params.Script, params.Message)
+		if err != nil {
+			return "", err
+		}
+		out.data = result
+		return scripts.FormatPublishResult(result), nil
+	case "disable_script":
+		return scriptManager.DisableScript(params.Script)
+	case "enable_script":
+		return scriptManager.EnableScript(params.Script)
+	case "list_screensets":
+		screensets, err := scripts.ListScreensets()
+		if err != nil {
+			return "", err
+		}
+		out.data = screensets
+		return scripts.FormatScreensets(screensets), nil
+	case "load_screenset":
+		// A slot number works even without reaper-screensets.ini
+		screensets, err := scripts.ListScreensets()
+		if err != nil && strings.Trim(params.Name, "# 0123456789") != "" {
+			return "", err
+		}
+		screenset, err := scripts.FindScreenset(screensets, params.Mode, params.Name)
+		if err != nil {
+			return "", err
+		}
+		if err := scripts.LoadScreenset(ctx, screenset); err != nil {
+			return "", err
+		}
+		out.data = screenset
+		return fmt.Sprintf("Loaded %s", screenset.Label()), nil
+	case "list_templates":
+		templates, err := scripts.ListTemplates()
+		if err != nil {
+			return "", err
+		}
+		out.data = templates
+		return scripts.FormatTemplates(templates), nil
+	case "create_project_from_template", "insert_track_template":
+		templates, err := scripts.ListTemplates()
+		if err != nil {
+			return "", err
+		}
+		kind := "project"
+		if params.Operation == "insert_track_template" {
+			kind = "track"
+		}
+		template, err := scripts.FindTemplate(templates, kind, params.Name)
+		if err != nil {
+			return "", err
+		}
+		out.data = template
+		if kind == "project" {
+			if err := scripts.CreateProjectFromTemplate(ctx, template); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Created a new project from %s in a new project tab", template.Label()), nil
+		}
+		added, err := scripts.InsertTrackTemplate(ctx, template)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Inserted %s (%d track(s))", template.Label(), added), nil
+	case "list_menus":
+		if params.Menu != "" {
+			menu, entries, err := scripts.GetMenu(params.Menu)
+			if err != nil {
+				return "", err
+			}
+			out.data = entries
+			return scripts.FormatMenu(menu, entries), nil
+		}
+		menus, err := scripts.ListMenus()
+		if err != nil {
+			return "", err
+		}
+		out.data = menus
+		return scripts.FormatMenus(menus), nil
+	case "add_menu_item":
+		return scriptManager.AddMenuItem(scripts.MenuItemRequest{
+			Menu:    params.Menu,
+			Submenu: params.Submenu,
+			Script:  params.Script,
+			Command: params.Command,
+			Label:   params.Label,
+		})
+	case "remove_menu_item":
+		return scripts.RemoveMenuItem(params.Menu, params.Submenu, params.Label)
+	case "search_actions":
+		catalog, err := scripts.LoadActionCatalog()
+		if err != nil {
+			return "", err
+		}
+		actions, err := scripts.SearchActions(catalog, params.Filter, params.Limit)
+		if err != nil {
+			return "", err
+		}
+		out.data = actions
+		return scripts.FormatActions(actions, params.Filter), nil
+	case "run_action":
+		catalog, err := scripts.LoadActionCatalog()
+		if err != nil {
+			return "", err
+		}
+		commandID, err := scripts.ResolveAction(catalog, params.Command)
+		if err != nil {
+			return "", err
+		}
+		client, err := newWebRemoteClient()
+		if err != nil {
+			return "", err
+		}
+		if err := client.RunAction(ctx, commandID); err != nil {
+			return "", fmt.Errorf("failed to run action %s: %w", commandID, err)
+		}
+		return fmt.Sprintf("Ran action %s in REAPER", commandID), nil
+	case "list_extstate":
+		sections, err := scripts.ListExtStateSections(params.Filter)
+		if err != nil {
+			return "", err
+		}
+		out.data = sections
+		return scripts.FormatExtStateSections(sections, params.Filter), nil
+	case "get_extstate":
+		values, err := scripts.GetExtState(params.Section, params.Key)
+		if err != nil {
+			return "", err
+		}
+		out.data = values
+		return scripts.FormatExtState(values, params.Key != ""), nil
+	case "list_trash":
+		trashed, err := scriptManager.ListTrash()
+		if err != nil {
+			return "", err
+		}
+		out.data = trashed
+		return scripts.FormatTrash(trashed), nil
+	case "restore_script":
+		return scriptManager.RestoreScript(params.Script)
+	case "find_duplicates":
+		clean := params.DryRun != nil && !*params.DryRun
+		report, err := scriptManager.FindDuplicates(clean)
+		if err != nil {
+			return "", err
+		}
+		out.data = report
+		return scripts.FormatDuplicateReport(report), nil
+	case "alias_script":
+		alias := strings.TrimSpace(params.Name)
+		if alias == "" {
+			return "", fmt.Errorf("name (the alias) is required for 'alias_script' operation")
+		}
+		if params.Script == "" {
+			if err := globalSettingsManager.SetScriptAlias(alias, ""); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Removed alias '%s'", alias), nil
+		}
+		script, err := scriptManager.ResolveScript(params.Script)
+		if err != nil {
+			return "", err
+		}
+		if err := globalSettingsManager.SetScriptAlias(alias, script); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("'%s' now runs '%s'", alias, script), nil
+	case "favorite_script":
+		favorite := params.Favorite == nil || *params.Favorite
+		return scriptManager.SetFavorite(params.Script, favorite)
+	case "tag_script":
+		return scriptManager.SetTags(params.Script, params.Tags)
+	case "rate_script":
+		return scriptManager.SetRating(params.Script, params.Rating, params.Notes)
 	case "list_available_scripts":
-		downloader := scripts.NewScriptDownloader()
-		return downloader.ListAvailableScripts()
+		downloader := globalSettingsManager.NewScriptDownloader()
+		return downloader.ListAvailableScripts(ctx)
 	case "download_script":
 		// Redirect to marketplace for visual browsing and downloading
 		return "🎵 Browse and download scripts at the marketplace:\nhttp://localhost:8080/api/plugins/ori-reaper/pages/marketplace", nil
+	case "download_scripts":
+		downloader := globalSettingsManager.NewScriptDownloader()
+		downloader.SetProgress(downloadProgress(ctx))
+		// In review mode the first call shows the code; the confirmed call installs it
+		if globalSettingsManager.GetReviewBeforeInstall() && !confirmed {
+			preview, err := downloader.PreviewScripts(ctx, params.Filenames)
+			if err != nil {
+				return "", err
+			}
+			pending, err := confirmations.Add(params.Operation, args, i18n.T("summary.download_scripts"))
+			if err != nil {
+				return "", err
+			}
+			out.confirmation = pending
+			return i18n.T("confirm.download_scripts", preview, pending.Token, pending.Expires.Format("15:04:05")), nil
+		}
+		results, err := downloader.DownloadScripts(ctx, params.Filenames, scriptsDir)
+		if err != nil {
+			return "", err
+		}
+		out.data = results
+		return scripts.FormatDownloadResults(results), nil
+	case "update_script":
+		downloader := globalSettingsManager.NewScriptDownloader()
+		downloader.SetProgress(downloadProgress(ctx))
+		var names []string
+		if params.Script != "" {
+			names = []string{params.Script}
+		}
+		results, err := downloader.UpdateScripts(ctx, names, scriptsDir)
+		if err != nil {
+			return "", err
+		}
+		out.data = results
+		return scripts.FormatUpdateResults(results), nil
+	case "check_updates":
+		var names []string
+		if params.Script != "" {
+			names = []string{params.Script}
+		}
+		infos, err := globalSettingsManager.NewScriptDownloader().CheckUpdates(ctx, names, scriptsDir)
+		if err != nil {
+			return "", err
+		}
+		out.data = infos
+		return scripts.FormatUpdateInfo(infos), nil
+	case "onboard":
+		report := scripts.DetectSetup(scriptsDir)
+		out.data = report
+		if !report.ReaperInstalled {
+			return scripts.FormatSetupReport(report) + "\n" + i18n.T("onboard.install_reaper"), nil
+		}
+		if report.StarterInstalled {
+			return scripts.FormatSetupReport(report) + "\n" + i18n.T("onboard.ready"), nil
+		}
+		if !confirmed {
+			text := scripts.FormatSetupReport(report)
+			if globalSettingsManager.GetReviewBeforeInstall() && len(report.StarterMissing) > 0 {
+				preview, err := globalSettingsManager.NewScriptDownloader().PreviewScripts(ctx, report.StarterMissing)
+				if err != nil {
+					return "", err
+				}
+				text += "\n" + preview
+			}
+			pending, err := confirmations.Add(params.Operation, args, i18n.T("summary.onboard"))
+			if err != nil {
+				return "", err
+			}
+			out.confirmation = pending
+			return i18n.T("confirm.onboard", text, pending.Token, pending.Expires.Format("15:04:05")), nil
+		}
+		if err := os.MkdirAll(scriptsDir, 0755); err != nil {
+			return "", fmt.Errorf("failed to create scripts directory: %w", err)
+		}
+		downloader := globalSettingsManager.NewScriptDownloader()
+		downloader.SetProgress(downloadProgress(ctx))
+		result, err := downloader.InstallBundle(ctx, scripts.StarterPack, scriptsDir)
+		if err != nil {
+			return "", err
+		}
+		if !report.WebRemoteEnabled {
+			result += "\n\n" + i18n.T("onboard.next_web_remote")
+		}
+		return result, nil
+	case "list_bundles":
+		bundles := globalSettingsManager.GetBundles()
+		out.data = bundles
+		return scriptManager.FormatBundles(bundles)
+	case "install_bundle":
+		bundle, err := findBundle(params.Name, params.Path)
+		if err != nil {
+			return "", err
+		}
+		downloader := globalSettingsManager.NewScriptDownloader()
+		downloader.SetProgress(downloadProgress(ctx))
+		return downloader.InstallBundle(ctx, bundle, scriptsDir)
+	case "uninstall_bundle":
+		return scriptManager.UninstallBundle(params.Name)
+	case "pin_script":
+		return scriptManager.PinScript(params.Script, params.Version)
+	case "unpin_script":
+		return scriptManager.UnpinScript(params.Script)
 	case "register_script":
 		if params.Script == "" {
 			return "", fmt.Errorf("script name is required for 'register_script' operation")
 		}
 		return scriptManager.RegisterScript(params.Script)
 	case "register_all_scripts":
-		return scriptManager.RegisterAllScripts()
+		return scriptManager.RegisterAllScripts(ctx)
 	case "clean_scripts":
 		return scriptManager.CleanScripts()
 	case "get_context":
-		ctx, err := reapercontext.GetREAPERContext()
+		reaperCtx, err := reapercontext.GetREAPERContext(ctx)
 		if err != nil {
 			return "", fmt.Errorf("failed to get REAPER context: %w", err)
 		}
-		contextJSON, err := json.Marshal(ctx)
+		contextJSON, err := json.Marshal(reaperCtx)
 		if err != nil {
 			return "", fmt.Errorf("failed to marshal context: %w", err)
 		}
 		return string(contextJSON), nil
 	case "get_web_remote_port":
-		// Get port from configuration
-		configuredPort := globalSettingsManager.GetWebRemotePort()
+		// Get host and port from configuration
+		_, port, err := scripts.ParseWebRemoteHost(globalSettingsManager.GetWebRemoteHost(), globalSettingsManager.GetWebRemotePort())
+		if err != nil {
+			return "", err
+		}
+		client, err := newWebRemoteClient()
+		if err != nil {
+			return "", err
+		}
+		status := "reachable"
+		pingErr := client.Ping(ctx)
+		if pingErr != nil {
+			status = fmt.Sprintf("not reachable (%v)", pingErr)
+		}
+		out.data = map[string]interface{}{"port": port, "url": client.BaseURL(), "reachable": pingErr == nil}
 		result := fmt.Sprintf("REAPER Web Remote:\n"+
 			"  Configured Port: %d\n"+
-			"  URL: http://localhost:%d\n"+
-			"  Note: This port is set in plugin configuration. Ensure REAPER's Web Remote matches this port.\n",
-			configuredPort, configuredPort)
+			"  URL: %s\n"+
+			"  Status: %s\n"+
+			"  Note: This address is set in plugin configuration. Ensure REAPER's Web Remote matches this port"+
+			" and, for a REAPER on another machine, that it accepts remote connections.\n",
+			port, client.BaseURL(), status)
 		return result, nil
 	case "get_tracks":
-		// Get port from configuration
-		configuredPort := globalSettingsManager.GetWebRemotePort()
+		client, err := newWebRemoteClient()
+		if err != nil {
+			return "", err
+		}
 
-		// Create Web Remote client with configured port
-		client, err := scripts.NewWebRemoteClient(configuredPort)
+		tracks, err := client.GetTracks(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to get tracks from REAPER: %w", err)
+		}
+		// The Web Remote only flags folders and knows nothing of track groups; both come
+		// from the bridge, which talks to the REAPER on this machine
+		if globalSettingsManager.GetWebRemoteHost() == "" {
+			if layout, err := scripts.GetTrackLayout(ctx); err == nil {
+				scripts.ApplyTrackLayout(tracks, layout)
+			}
+		}
+		out.data = tracks
+		return scripts.FormatTracksTable(tracks), nil
+	case "batch_rename_tracks":
+		renames, err := scripts.PlanRenames(ctx, scripts.RenameRule{
+			Tracks:      params.Tracks,
+			Pattern:     params.Pattern,
+			Replacement: params.Replacement,
+			Prefix:      params.Prefix,
+			Suffix:      params.Suffix,
+			Start:       params.Start,
+		})
 		if err != nil {
-			return "", fmt.Errorf("failed to create web remote client: %w", err)
+			return "", err
+		}
+		out.data = renames
+		if len(renames) == 0 {
+			return "No track names would change", nil
+		}
+		if params.DryRun != nil && *params.DryRun {
+			return fmt.Sprintf("Would rename %d tracks (dry run):\n\n%s", len(renames), scripts.FormatRenames(renames)), nil
+		}
+		if err := scripts.ApplyRenames(ctx, renames); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Renamed %d tracks (undo to revert):\n\n%s", len(renames), scripts.FormatRenames(renames)), nil
+	case "get_levels":
+		client, err := newWebRemoteClient()
+		if err != nil {
+			return "", err
 		}
 
-		tracks, err := client.GetTracks()
+		snapshot, err := client.GetLevels(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to get levels from REAPER: %w", err)
+		}
+		out.data = snapshot
+		return scripts.FormatLevelsTable(snapshot), nil
+	case "get_selected_tracks":
+		client, err := newWebRemoteClient()
+		if err != nil {
+			return "", err
+		}
+
+		tracks, err := client.GetSelectedTracks(ctx)
 		if err != nil {
 			return "", fmt.Errorf("failed to get tracks from REAPER: %w", err)
 		}
+		out.data = tracks
+		if len(tracks) == 0 {
+			return "No tracks are selected in REAPER", nil
+		}
 		return scripts.FormatTracksTable(tracks), nil
+	case "select_tracks":
+		client, err := newWebRemoteClient()
+		if err != nil {
+			return "", err
+		}
+
+		tracks, err := client.SelectTracks(ctx, params.Tracks, params.Append)
+		if err != nil {
+			return "", err
+		}
+		out.data = tracks
+		if len(tracks) == 0 {
+			return "Cleared the track selection", nil
+		}
+		names := make([]string, len(tracks))
+		for i, track := range tracks {
+			names[i] = fmt.Sprintf("%d (%s)", track.Index, track.Name)
+		}
+		return fmt.Sprintf("Selected tracks: %s", strings.Join(names, ", ")), nil
+	case "undo":
+		client, err := newWebRemoteClient()
+		if err != nil {
+			return "", err
+		}
+		if err := client.Undo(ctx); err != nil {
+			return "", fmt.Errorf("failed to undo: %w", err)
+		}
+		return "Undo triggered in REAPER", nil
+	case "redo":
+		client, err := newWebRemoteClient()
+		if err != nil {
+			return "", err
+		}
+		if err := client.Redo(ctx); err != nil {
+			return "", fmt.Errorf("failed to redo: %w", err)
+		}
+		return "Redo triggered in REAPER", nil
+	case "get_undo_history":
+		state, err := scripts.GetUndoState(ctx)
+		if err != nil {
+			return "", err
+		}
+		out.data = state
+		return scripts.FormatUndoState(state), nil
+	case "get_automation":
+		state, err := scripts.GetAutomationState(ctx)
+		if err != nil {
+			return "", err
+		}
+		out.data = state
+		return scripts.FormatAutomationState(state), nil
+	case "set_automation_mode":
+		if err := scripts.SetTrackAutomationMode(ctx, params.Track, params.Mode); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Set track %d automation mode to %s", params.Track, params.Mode), nil
+	case "get_record_inputs":
+		settings, err := scripts.GetRecordSettings(ctx, params.Track)
+		if err != nil {
+			return "", err
+		}
+		out.data = settings
+		return scripts.FormatRecordSettings(settings), nil
+	case "set_record_input":
+		change := scripts.RecordChange{Monitoring: params.Monitoring, Arm: params.Arm}
+		if params.Input != nil {
+			input, err := scripts.ParseRecordInput(*params.Input)
+			if err != nil {
+				return "", err
+			}
+			change.Input = &input
+		}
+		if err := scripts.SetRecordSettings(ctx, params.Track, change); err != nil {
+			return "", err
+		}
+		settings, err := scripts.GetRecordSettings(ctx, params.Track)
+		if err != nil || len(settings) == 0 {
+			return fmt.Sprintf("Updated the record settings of track %d", params.Track), nil
+		}
+		out.data = settings[0]
+		return fmt.Sprintf("Updated the record settings of track %d:\n\n%s", params.Track, scripts.FormatRecordSettings(settings)), nil
+	case "get_master":
+		state, err := scripts.GetMasterState(ctx)
+		if err != nil {
+			return "", err
+		}
+		out.data = state
+		return scripts.FormatMasterState(state), nil
+	case "set_master":
+		change := scripts.MasterChange{Volume: params.Volume, Pan: params.Pan, Mute: params.Mute}
+		if err := scripts.SetMasterState(ctx, change); err != nil {
+			return "", err
+		}
+		state, err := scripts.GetMasterState(ctx)
+		if err != nil {
+			return "Updated the master track", nil
+		}
+		out.data = state
+		return "Updated the master track:\n\n" + scripts.FormatMasterState(state), nil
+	case "get_playrate":
+		rate, err := scripts.GetPlayRate(ctx)
+		if err != nil {
+			return "", err
+		}
+		out.data = rate
+		return scripts.FormatPlayRate(rate), nil
+	case "set_playrate":
+		change := scripts.PlayRateChange{Rate: params.Rate, PreservePitch: params.PreservePitch}
+		if err := scripts.SetPlayRate(ctx, change); err != nil {
+			return "", err
+		}
+		rate, err := scripts.GetPlayRate(ctx)
+		if err != nil {
+			return "Updated the playback rate", nil
+		}
+		out.data = rate
+		return "Updated the playback rate:\n\n" + scripts.FormatPlayRate(rate), nil
+	case "set_solo_mode":
+		if err := scripts.SetSoloMode(ctx, params.Mode); err != nil {
+			return "", err
+		}
+		mode, _ := scripts.ParseSoloMode(params.Mode)
+		return fmt.Sprintf("Set solo mode to %s", mode), nil
+	case "list_monitor_fx":
+		chain, err := scripts.ListMonitorFX(ctx)
+		if err != nil {
+			return "", err
+		}
+		out.data = chain
+		return scripts.FormatMonitorFX(chain), nil
+	case "add_monitor_fx":
+		index, err := scripts.AddMonitorFX(ctx, params.Name)
+		if err != nil {
+			return "", err
+		}
+		chain, err := scripts.ListMonitorFX(ctx)
+		if err != nil {
+			return fmt.Sprintf("Added %s to the monitoring FX chain at position %d", params.Name, index), nil
+		}
+		out.data = chain
+		return fmt.Sprintf("Added %s to the monitoring FX chain at position %d\n\n%s", params.Name, index, scripts.FormatMonitorFX(chain)), nil
+	case "set_monitor_fx":
+		chain, err := scripts.ListMonitorFX(ctx)
+		if err != nil {
+			return "", err
+		}
+		plugin, err := scripts.FindMonitorFX(chain, params.FX)
+		if err != nil {
+			return "", err
+		}
+		enabled := !plugin.Enabled
+		if params.Enabled != nil {
+			enabled = *params.Enabled
+		}
+		if err := scripts.SetMonitorFXEnabled(ctx, plugin.Index, enabled); err != nil {
+			return "", err
+		}
+		plugin.Enabled = enabled
+		out.data = plugin
+		state := "Enabled"
+		if !enabled {
+			state = "Bypassed"
+		}
+		return fmt.Sprintf("%s monitoring FX %d: %s", state, plugin.Index, plugin.Name), nil
+	case "get_selected_items":
+		selection, err := scripts.GetSelectedItems(ctx)
+		if err != nil {
+			return "", err
+		}
+		out.data = selection
+		return scripts.FormatSelectedItems(selection), nil
+	case "edit_selected_items":
+		if err := scripts.EditSelectedItems(ctx, params.Mode); err != nil {
+			return "", err
+		}
+		selection, err := scripts.GetSelectedItems(ctx)
+		if err != nil {
+			return fmt.Sprintf("Applied %s to the selected items", params.Mode), nil
+		}
+		out.data = selection
+		return fmt.Sprintf("Applied %s to the selected items. Now selected:\n\n%s", params.Mode, scripts.FormatSelectedItems(selection)), nil
+	case "get_edit_modes":
+		modes, err := scripts.GetEditModes(ctx)
+		if err != nil {
+			return "", err
+		}
+		out.data = modes
+		return scripts.FormatEditModes(modes), nil
+	case "set_edit_modes":
+		err := scripts.SetEditModes(ctx, scripts.EditModesChange{
+			Ripple:      params.Ripple,
+			Snap:        params.Snap,
+			GridVisible: params.GridLines,
+			Grid:        params.Grid,
+			Swing:       params.Swing,
+		})
+		if err != nil {
+			return "", err
+		}
+		modes, err := scripts.GetEditModes(ctx)
+		if err != nil {
+			return "Updated the edit modes", nil
+		}
+		out.data = modes
+		return "Updated the edit modes:\n\n" + scripts.FormatEditModes(modes), nil
+	case "set_automation_override":
+		if err := scripts.SetAutomationOverride(ctx, params.Mode); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Set global automation override to %s", params.Mode), nil
+	case "get_envelopes":
+		envelopes, err := scripts.GetEnvelopes(ctx, params.Track)
+		if err != nil {
+			return "", err
+		}
+		out.data = envelopes
+		return scripts.FormatEnvelopesTable(envelopes), nil
+	case "get_project_notes":
+		notes, err := project.GetNotes(ctx)
+		if err != nil {
+			return "", err
+		}
+		if strings.TrimSpace(notes) == "" {
+			return "Project notes are empty", nil
+		}
+		return "Project notes:\n\n" + notes, nil
+	case "set_project_notes":
+		if err := project.SetNotes(ctx, params.Content, params.Append); err != nil {
+			return "", err
+		}
+		if params.Append {
+			return "Appended to project notes", nil
+		}
+		return "Updated project notes", nil
+	case "log_session_note":
+		projectFile, err := resolveProjectFile(ctx, params.Path)
+		if err != nil {
+			return "", err
+		}
+		entry, err := project.AppendSessionNote(projectFile, params.RelatedOp, params.Content)
+		if err != nil {
+			return "", err
+		}
+		out.data = entry
+		return fmt.Sprintf("Logged to %s", project.SessionLogPath(projectFile)), nil
+	case "get_session_log":
+		projectFile, err := resolveProjectFile(ctx, params.Path)
+		if err != nil {
+			return "", err
+		}
+		entries, err := project.ReadSessionLog(projectFile, params.Limit)
+		if err != nil {
+			return "", err
+		}
+		out.data = entries
+		return project.FormatSessionLog(projectFile, entries), nil
+	case "get_metrics":
+		snapshot := metrics.Get()
+		out.data = snapshot
+		return metrics.Format(snapshot), nil
+	case "list_executions":
+		if params.Name != "" {
+			execution, script, err := bridge.GetExecution(params.Name)
+			if err != nil {
+				return "", err
+			}
+			out.data = execution
+			return bridge.FormatExecution(execution, script), nil
+		}
+		limit := params.Limit
+		if limit <= 0 {
+			limit = 20
+		}
+		executions, err := bridge.ListExecutions(params.Filter, limit)
+		if err != nil {
+			return "", err
+		}
+		out.data = executions
+		return bridge.FormatExecutions(executions), nil
+	case "audit_media":
+		projectFile, err := resolveProjectFile(ctx, params.Path)
+		if err != nil {
+			return "", err
+		}
+		audit, err := project.AuditMedia(projectFile)
+		if err != nil {
+			return "", err
+		}
+		out.data = audit
+		return project.FormatMediaAudit(audit), nil
+	case "archive_project":
+		projectFile, err := resolveProjectFile(ctx, params.Path)
+		if err != nil {
+			return "", err
+		}
+		result, err := project.Archive(ctx, projectFile, project.ArchiveOptions{
+			Destination: params.Destination,
+			Zip:         params.Zip,
+			Trim:        params.Trim,
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to archive project: %w", err)
+		}
+		out.data = result
+		return project.FormatArchiveResult(result), nil
+	case "clean_peaks":
+		dir := params.Path
+		if dir == "" || strings.EqualFold(filepath.Ext(dir), ".rpp") {
+			projectFile, err := resolveProjectFile(ctx, dir)
+			if err != nil {
+				return "", err
+			}
+			dir = filepath.Dir(projectFile)
+		}
+		dryRun := params.DryRun == nil || *params.DryRun
+		cleanup, err := project.CleanPeaks(dir, dryRun)
+		if err != nil {
+			return "", err
+		}
+		out.data = cleanup
+		return project.FormatPeakCleanup(cleanup), nil
+	case "list_backups":
+		projectFile, err := resolveProjectFile(ctx, params.Path)
+		if err != nil {
+			return "", err
+		}
+		backups, err := project.ListBackups(projectFile)
+		if err != nil {
+			return "", err
+		}
+		out.data = backups
+		return project.FormatBackups(projectFile, backups), nil
+	case "restore_backup":
+		if params.Path == "" {
+			return "", fmt.Errorf("path of the backup file is required for 'restore_backup' operation")
+		}
+		restored, err := project.RestoreBackup(params.Path, params.Destination)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Restored backup to %s\nOpen it in REAPER with File → Open project.", restored), nil
+	case "get_autosave":
+		autosave, err := scripts.GetAutosaveSettings()
+		if err != nil {
+			return "", err
+		}
+		out.data = autosave
+		return scripts.FormatAutosaveSettings(autosave), nil
+	case "set_autosave":
+		if err := scripts.SetAutosaveInterval(params.Interval); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Set auto-save interval to %d minute(s) in reaper.ini\nNote: REAPER rewrites reaper.ini on exit; make this change while REAPER is closed, or restart REAPER and re-apply.", params.Interval), nil
+	case "diff_projects":
+		projectFile, err := resolveProjectFile(ctx, params.Path)
+		if err != nil {
+			return "", err
+		}
+		compareTo := params.CompareTo
+		if compareTo == "" {
+			backups, err := project.ListBackups(projectFile)
+			if err != nil {
+				return "", err
+			}
+			if len(backups) == 0 {
+				return "", fmt.Errorf("no backups found for %s; specify the file to compare with 'compare_to'", filepath.Base(projectFile))
+			}
+			compareTo = backups[0].Path
+		}
+		diff, err := project.DiffFiles(compareTo, projectFile)
+		if err != nil {
+			return "", err
+		}
+		out.data = diff
+		return project.FormatDiff(diff), nil
+	case "export_project_json":
+		projectFile, err := resolveProjectFile(ctx, params.Path)
+		if err != nil {
+			return "", err
+		}
+		data, err := project.ExportJSON(projectFile, params.Full)
+		if err != nil {
+			return "", err
+		}
+		if params.Destination == "" {
+			return string(data), nil
+		}
+		if err := os.WriteFile(params.Destination, data, 0644); err != nil {
+			return "", fmt.Errorf("failed to write %s: %w", params.Destination, err)
+		}
+		return fmt.Sprintf("Exported %s to %s", filepath.Base(projectFile), params.Destination), nil
+	case "export_markers":
+		projectFile, err := resolveProjectFile(ctx, params.Path)
+		if err != nil {
+			return "", err
+		}
+		root, err := project.ParseFile(projectFile)
+		if err != nil {
+			return "", err
+		}
+		data, err := project.EncodeMarkers(project.ReadMarkers(root), params.Format)
+		if err != nil {
+			return "", err
+		}
+		if params.Destination == "" {
+			return string(data), nil
+		}
+		if err := os.WriteFile(params.Destination, data, 0644); err != nil {
+			return "", fmt.Errorf("failed to write %s: %w", params.Destination, err)
+		}
+		return fmt.Sprintf("Exported markers from %s to %s", filepath.Base(projectFile), params.Destination), nil
+	case "import_markers":
+		data := params.Content
+		if data == "" && params.Path != "" {
+			fileData, err := os.ReadFile(params.Path)
+			if err != nil {
+				return "", fmt.Errorf("failed to read %s: %w", params.Path, err)
+			}
+			data = string(fileData)
+		}
+		markers, err := project.DecodeMarkers(data)
+		if err != nil {
+			return "", err
+		}
+		if err := project.ImportMarkers(ctx, markers); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Imported %d marker(s)/region(s) into the current project", len(markers)), nil
+	case "export_regions":
+		projectFile, err := resolveProjectFile(ctx, params.Path)
+		if err != nil {
+			return "", err
+		}
+		root, err := project.ParseFile(projectFile)
+		if err != nil {
+			return "", err
+		}
+		title := strings.TrimSuffix(filepath.Base(projectFile), filepath.Ext(projectFile))
+		data, err := project.EncodeRegions(project.ReadMarkers(root), params.Format, title, params.FrameRate)
+		if err != nil {
+			return "", err
+		}
+		if params.Destination == "" {
+			return string(data), nil
+		}
+		if err := os.WriteFile(params.Destination, data, 0644); err != nil {
+			return "", fmt.Errorf("failed to write %s: %w", params.Destination, err)
+		}
+		return fmt.Sprintf("Exported regions from %s to %s", filepath.Base(projectFile), params.Destination), nil
+	case "render_project":
+		result, err := project.Render(ctx)
+		if err != nil {
+			return "", err
+		}
+		out.data = result
+		report := project.FormatRenderResult(result)
+		if postRenderHooks := globalSettingsManager.GetPostRenderHooks(); len(postRenderHooks) > 0 {
+			report += "\n\n" + hooks.FormatResults(hooks.RunPostRender(ctx, postRenderHooks, result.Files))
+		}
+		return report, nil
+	case "render_stems":
+		result, err := project.RenderStems(ctx, project.StemRequest{
+			Mode:      params.Mode,
+			Tracks:    params.Tracks,
+			Regions:   params.Regions,
+			Pattern:   params.Pattern,
+			Directory: params.Destination,
+		})
+		if err != nil {
+			return "", err
+		}
+		out.data = result
+		report := project.FormatRenderResult(result)
+		if postRenderHooks := globalSettingsManager.GetPostRenderHooks(); len(postRenderHooks) > 0 {
+			report += "\n\n" + hooks.FormatResults(hooks.RunPostRender(ctx, postRenderHooks, result.Files))
+		}
+		return report, nil
+	case "get_render_stats":
+		result, err := project.GetRenderStats(ctx)
+		if err != nil {
+			return "", err
+		}
+		out.data = result
+		return project.FormatRenderResult(result), nil
+	case "insert_media":
+		position, err := resolvePosition(ctx, params.Position)
+		if err != nil {
+			return "", err
+		}
+		if err := project.InsertMedia(ctx, params.Path, params.Track, position); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Inserted %s into the current project", filepath.Base(params.Path)), nil
+	case "insert_midi":
+		notes := params.MIDINotes
+		if len(notes) == 0 && strings.TrimSpace(params.Pattern) != "" {
+			var err error
+			if notes, err = scripts.ParseMIDIPattern(params.Pattern, params.Velocity); err != nil {
+				return "", err
+			}
+		}
+		position, err := resolvePosition(ctx, params.Position)
+		if err != nil {
+			return "", err
+		}
+		result, err := scripts.InsertMIDI(ctx, notes, params.Track, position)
+		if err != nil {
+			return "", err
+		}
+		out.data = result
+		return scripts.FormatMIDIInsertResult(result), nil
+	case "convert_time":
+		timing, timingErr := project.GetTiming(ctx)
+		if params.Tempo > 0 {
+			timing.Tempo = params.Tempo
+		}
+		if params.FrameRate > 0 {
+			timing.FrameRate = params.FrameRate
+		}
+		seconds, err := timeutil.Parse(string(params.Time), strings.ToLower(params.From), timing)
+		if err != nil {
+			return "", err
+		}
+		conversion := timeutil.Convert(seconds, timing)
+		out.data = conversion
+		var result string
+		if params.To != "" {
+			if result, err = timeutil.Format(seconds, strings.ToLower(params.To), timing); err != nil {
+				return "", err
+			}
+			result = fmt.Sprintf("%s = %s (%s)", params.Time, result, strings.ToLower(params.To))
+		} else {
+			result = fmt.Sprintf("%s is:\n\n%s", params.Time, timeutil.FormatConversion(conversion))
+		}
+		if timingErr != nil {
+			result += fmt.Sprintf("\n\nCouldn't read the project's timing (%v), so REAPER's defaults were assumed where needed: 120 BPM in 4/4, 30 fps, 48 kHz.", timingErr)
+		}
+		return result, nil
+	case "check_dependencies":
+		return scriptManager.CheckDependencies(params.Script)
+	case "install_extension":
+		return scripts.InstallExtensionOperation(ctx, params.Extension)
+	case "profile_script":
+		result, err := scriptManager.ProfileScript(ctx, params.Script)
+		if err != nil {
+			return "", err
+		}
+		out.data = result
+		return scripts.FormatProfileResult(result), nil
+	case "create_custom_action":
+		commandID, err := scripts.CreateCustomAction(params.Name, params.Commands)
+		if err != nil {
+			return "", err
+		}
+		out.data = map[string]string{"name": "Custom: " + params.Name, "command_id": commandID}
+		return fmt.Sprintf("Created custom action 'Custom: %s' (command ID %s). Restart REAPER to load it, then bind a shortcut in the Actions list.", params.Name, commandID), nil
+	case "export_keymap":
+		path, err := scripts.ExportKeymap(params.Path)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Exported key bindings to %s", path), nil
+	case "import_keymap":
+		backup, entries, err := scripts.ImportKeymap(params.Path)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Imported %d key map entries from %s\nPrevious bindings backed up to %s\nRestart REAPER to load the new bindings.", entries, params.Path, backup), nil
+	case "configure_osc":
+		config, err := scripts.ConfigureOSC(scripts.OSCConfig{
+			Name:       params.Name,
+			Host:       params.Host,
+			LocalPort:  params.Port,
+			RemotePort: params.RemotePort,
+			Pattern:    params.Pattern,
+		})
+		if err != nil {
+			return "", err
+		}
+		out.data = config
+		return scripts.FormatOSCConfig(config) + "\nRestart REAPER to apply the control surface settings.", nil
+	case "install_osc_pattern":
+		path, err := scripts.InstallOSCPattern(params.Name, params.Content, params.Path)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Installed OSC pattern config to %s\nUse it with 'configure_osc' and pattern set to its name.", path), nil
+	case "configure_web_remote":
+		if params.Enabled == nil && params.Port == 0 {
+			return "", fmt.Errorf("'enabled' or 'port' is required for 'configure_web_remote' operation")
+		}
+		config, err := scripts.ConfigureWebRemote(params.Enabled, params.Port)
+		if err != nil {
+			return "", err
+		}
+		out.data = config
+		state := "disabled"
+		if config.Enabled {
+			state = "enabled"
+		}
+		return fmt.Sprintf("Web Remote %s on port %d (csurf_%d).\n"+
+			"Restart REAPER for the change to take effect, and make sure the plugin's Web Remote port setting is %d.",
+			state, config.Port, config.CSurfID, config.Port), nil
+	case "setup_web_remote":
+		result, err := scripts.SetupWebRemote(ctx, params.Port, params.Restart)
+		if err != nil {
+			return "", err
+		}
+		if globalSettingsManager.GetWebRemotePort() != result.Config.Port {
+			if err := globalSettingsManager.SetWebRemotePort(result.Config.Port); err != nil {
+				return "", err
+			}
+			result.Steps = append(result.Steps, fmt.Sprintf("Updated plugin settings to port %d", result.Config.Port))
+		}
+		out.data = result
+		return scripts.FormatWebRemoteSetup(result), nil
+	case "list_web_interfaces":
+		interfaces, err := scripts.ListWebInterfaces(ctx)
+		if err != nil {
+			return "", err
+		}
+		out.data = interfaces
+		return scripts.FormatWebInterfaces(interfaces), nil
+	case "install_web_interface":
+		path, err := globalSettingsManager.NewScriptDownloader().InstallWebInterface(ctx, params.Filename, params.Path)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Installed web interface to %s\nOpen it at http://localhost:%d/%s", path, globalSettingsManager.GetWebRemotePort(), filepath.Base(path)), nil
+	case "run_macro":
+		return t.runMacro(ctx, params.Name)
+	case "list_macros":
+		macros := globalSettingsManager.GetMacros()
+		out.data = macros
+		return formatMacros(macros), nil
 	default:
-		return "", fmt.Errorf("unknown operation: %s. Valid operations: list, run, add, delete, list_available_scripts, download_script, register_script, register_all_scripts, clean_scripts, get_context, get_web_remote_port, get_tracks", params.Operation)
+		return "", errcode.New(errcode.UnknownOperation, i18n.Errorf("error.unknown_operation", params.Operation, strings.Join(operations, ", ")))
+	}
+}
+
+// withArg returns the JSON arguments args with key set to value
+func withArg(args, key string, value any) (string, error) {
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(args), &fields); err != nil {
+		return "", errcode.New(errcode.InvalidParameters, i18n.Errorf("error.parse_parameters", err))
+	}
+	fields[key] = value
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// How often the open project is checked for a settings override file, and how long the
+// check may take
+const (
+	projectSettingsInterval = 30 * time.Second
+	projectSettingsTimeout  = 2 * time.Second
+)
+
+// projectSettingsOperations are the operations that use the scripts directory or script
+// sources, which the open project can override. Those mapped to false only list what's
+// there, so they use the project REAPER last reported rather than asking it.
+var projectSettingsOperations = map[string]bool{
+	"list": false, "list_trash": false, "list_bundles": false, "list_macros": false, "script_history": false,
+	"run": true, "add": true, "delete": true, "import_scripts": true, "rollback_script": true,
+	"publish_script": true, "disable_script": true, "enable_script": true, "restore_script": true,
+	"find_duplicates": true, "favorite_script": true, "tag_script": true, "rate_script": true,
+	"alias_script": true, "add_menu_item": true, "install_bundle": true, "uninstall_bundle": true,
+	"pin_script": true, "unpin_script": true, "register_script": true, "register_all_scripts": true,
+	"clean_scripts": true, "check_dependencies": true, "profile_script": true, "check_updates": true,
+	"update_script": true, "list_available_scripts": true, "download_scripts": true, "onboard": true,
+}
+
+// projectSettingsChecked is when applyProjectSettings last asked REAPER for the open project
+var projectSettingsChecked struct {
+	mu sync.Mutex
+	at time.Time
+}
+
+// applyProjectSettings loads the overrides of the project open in REAPER, so each
+// production can use its own scripts directory and sources. It uses the project REAPER
+// last reported (e.g. to get_context) while that's recent. Otherwise, if refresh is set,
+// REAPER is asked through the Lua bridge at most once per projectSettingsInterval.
+func applyProjectSettings(ctx context.Context, refresh bool) {
+	projectSettingsChecked.mu.Lock()
+	defer projectSettingsChecked.mu.Unlock()
+	dir, reported := reapercontext.LastProjectDir()
+	if refresh && time.Since(reported) >= projectSettingsInterval && time.Since(projectSettingsChecked.at) >= projectSettingsInterval {
+		projectSettingsChecked.at = time.Now()
+		ctx, cancel := context.WithTimeout(ctx, projectSettingsTimeout)
+		defer cancel()
+		var err error
+		if dir, err = reapercontext.GetProjectDir(ctx); err != nil {
+			// Keep the overrides of the last known project
+			return
+		}
+	} else if reported.IsZero() {
+		return // REAPER hasn't reported a project yet
+	}
+	if err := globalSettingsManager.SetProjectDir(dir); err != nil {
+		log.Printf("Ignoring project settings: %v", err)
 	}
 }
 
+// resolveProjectFile returns path, or the .RPP file of the project currently open in REAPER if path is empty
+func resolveProjectFile(ctx context.Context, path string) (string, error) {
+	if path != "" {
+		return path, nil
+	}
+	reaperCtx, err := reapercontext.GetREAPERContext(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get REAPER context: %w", err)
+	}
+	if !reaperCtx.IsRunning {
+		return "", errcode.New(errcode.ReaperNotRunning, i18n.Errorf("error.reaper_not_running_path"))
+	}
+	if reaperCtx.ProjectPath == "" {
+		return "", i18n.Errorf("error.no_saved_project")
+	}
+	return filepath.Join(reaperCtx.ProjectPath, reaperCtx.ProjectName), nil
+}
+
+// resolvePosition converts a position parameter to seconds, reading the project's tempo,
+// frame rate and sample rate when it's not given in seconds. nil stays nil.
+func resolvePosition(ctx context.Context, position *timeutil.Value) (*float64, error) {
+	if position == nil {
+		return nil, nil
+	}
+	var timing timeutil.Timing
+	if !position.IsSeconds() {
+		var err error
+		if timing, err = project.GetTiming(ctx); err != nil {
+			return nil, err
+		}
+	}
+	seconds, err := timeutil.Parse(string(*position), "", timing)
+	if err != nil {
+		return nil, fmt.Errorf("invalid position: %w", err)
+	}
+	return &seconds, nil
+}
+
+// findBundle loads a bundle from a manifest file if path is set, or from settings by name
+func findBundle(name, path string) (types.Bundle, error) {
+	if path != "" {
+		return scripts.LoadBundleManifest(path)
+	}
+	return scripts.FindBundle(globalSettingsManager.GetBundles(), name)
+}
+
+// newWebRemoteClient creates a Web Remote client using the configured port
+func newWebRemoteClient() (*scripts.WebRemoteClient, error) {
+	host, port, err := scripts.ParseWebRemoteHost(globalSettingsManager.GetWebRemoteHost(), globalSettingsManager.GetWebRemotePort())
+	if err != nil {
+		return nil, err
+	}
+
+	var client *scripts.WebRemoteClient
+	if scripts.IsLocalHost(host) {
+		client, err = scripts.NewWebRemoteClient(port)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create web remote client: %w", err)
+		}
+	} else {
+		// reaper.ini describes this machine, so a remote REAPER only uses the configured port and credentials
+		client = scripts.NewWebRemoteClientAt(host, port)
+	}
+	client.SetRetryPolicy(globalSettingsManager.GetWebRemoteRetryPolicy())
+	if username, password := globalSettingsManager.GetWebRemoteCredentials(); username != "" || password != "" {
+		client.SetCredentials(username, password)
+	}
+	return client, nil
+}
+
 // GetDefaultSettings returns the default settings as JSON
 func (t *reaperTool) GetDefaultSettings() (string, error) {
 	return globalSettingsManager.GetDefaultSettingsJSON()
@@ -175,6 +2007,13 @@ func (t *reaperTool) GetRequiredConfig() []pluginapi.ConfigVariable {
 			DefaultValue: defaultReascriptDir,
 			Placeholder:  defaultReascriptDir,
 		},
+		{
+			Key:          "create_if_missing",
+			Name:         "Create Scripts Directory",
+			Description:  "Create the scripts directory if it doesn't exist yet, e.g. on a fresh REAPER install",
+			Type:         pluginapi.ConfigTypeBool,
+			DefaultValue: "true",
+		},
 	}
 
 	// Try to detect existing web remote port from reaper.ini
@@ -203,6 +2042,17 @@ func (t *reaperTool) ValidateConfig(config map[string]interface{}) error {
 	if !ok || scriptsDir == "" {
 		return fmt.Errorf("scripts_dir is required")
 	}
+	// Bools may arrive as strings from the settings form
+	var createIfMissing bool
+	switch v := config["create_if_missing"].(type) {
+	case bool:
+		createIfMissing = v
+	case string:
+		createIfMissing = v == "true"
+	}
+	if err := scripts.NewScriptManager(scriptsDir).CheckScriptsDir(createIfMissing); err != nil {
+		return err
+	}
 
 	// Validate web_remote_port if provided (it's optional if auto-detected)
 	if portValue, ok := config["web_remote_port"]; ok {
@@ -232,7 +2082,15 @@ func (t *reaperTool) InitializeWithConfig(config map[string]interface{}) error {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 
-	return globalSettingsManager.SetSettings(string(data))
+	// Agents that pass their data directory spare the plugin from searching for it
+	if dir, ok := config["agent_data_dir"].(string); ok && dir != "" {
+		globalSettingsManager.SetDataDir(dir)
+	}
+	if err := globalSettingsManager.SetSettings(string(data)); err != nil {
+		return err
+	}
+	startRESTAPI(t)
+	return nil
 }
 
 // GetWebPages returns list of available web pages
@@ -267,6 +2125,20 @@ func main() {
 		tool.SetMetadata(metadata)
 	}
 
+	// Count from the start when metrics are on, so scrapes cover the whole run
+	metrics.SetEnabled(globalSettingsManager.GetMetrics())
+
+	// Optional REST endpoints for Stream Deck, shortcut apps and dashboards
+	startRESTAPI(tool)
+
+	// Pick up settings changed in the agent UI without a restart
+	if err := globalSettingsManager.Watch(func() {
+		metrics.SetEnabled(globalSettingsManager.GetMetrics())
+		startRESTAPI(tool)
+	}); err != nil {
+		log.Printf("Settings changes need a plugin restart: %v", err)
+	}
+
 	plugin.Serve(&plugin.ServeConfig{
 		HandshakeConfig: pluginapi.Handshake,
 		Plugins: map[string]plugin.Plugin{
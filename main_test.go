@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/errcode"
+	"github.com/johnjallday/ori-reaper-plugin/internal/scripts"
+	"github.com/johnjallday/ori-reaper-plugin/internal/settings"
+)
+
+// useTestScriptsDir points the plugin at a temporary scripts directory holding files
+// and returns it. REAPER isn't asked for the open project's settings.
+func useTestScriptsDir(t *testing.T, files ...string) string {
+	t.Helper()
+	t.Setenv(settings.DataDirEnv, t.TempDir())
+	dir := t.TempDir()
+	for _, file := range files {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte("-- test\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := globalSettingsManager.SetSettings(`{"scripts_dir": "` + filepath.ToSlash(dir) + `"}`); err != nil {
+		t.Fatal(err)
+	}
+	projectSettingsChecked.mu.Lock()
+	projectSettingsChecked.at = time.Now()
+	projectSettingsChecked.mu.Unlock()
+	return dir
+}
+
+// requestDelete asks to delete script and returns the confirm_token
+func requestDelete(t *testing.T, script string) string {
+	t.Helper()
+	out := &output{}
+	if _, err := (&reaperTool{}).dispatch(context.Background(), `{"operation": "delete", "script": "`+script+`"}`, false, out); err != nil {
+		t.Fatal(err)
+	}
+	if out.confirmation == nil {
+		t.Fatal("delete ran without confirmation")
+	}
+	return out.confirmation.Token
+}
+
+func TestConfirmedDeleteUsesResolvedScript(t *testing.T) {
+	t.Run("unchanged", func(t *testing.T) {
+		dir := useTestScriptsDir(t, "Normalize_Items.lua")
+		token := requestDelete(t, "normalize")
+		if _, err := (&reaperTool{}).dispatch(context.Background(), `{"operation": "delete", "confirm_token": "`+token+`"}`, false, &output{}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "Normalize_Items.lua")); !os.IsNotExist(err) {
+			t.Errorf("Normalize_Items.lua still there after the confirmed delete: %v", err)
+		}
+	})
+
+	t.Run("name matches another script", func(t *testing.T) {
+		dir := useTestScriptsDir(t, "Normalize_Items.lua")
+		token := requestDelete(t, "normalize")
+		// The confirmed script is gone and the name now matches a different one
+		if err := os.Rename(filepath.Join(dir, "Normalize_Items.lua"), filepath.Join(dir, "Normalize_Tracks.lua")); err != nil {
+			t.Fatal(err)
+		}
+		// The cached script list follows the directory through file system events
+		for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+			if names, _ := scripts.CachedLuaScripts(dir); slices.Contains(names, "Normalize_Tracks") || time.Now().After(deadline) {
+				break
+			}
+		}
+		_, err := (&reaperTool{}).dispatch(context.Background(), `{"operation": "delete", "confirm_token": "`+token+`"}`, false, &output{})
+		if !errors.Is(err, errcode.InvalidConfirmation) {
+			t.Errorf("confirmed delete error = %v, want %s", err, errcode.InvalidConfirmation)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "Normalize_Tracks.lua")); err != nil {
+			t.Errorf("Normalize_Tracks.lua deleted without confirmation: %v", err)
+		}
+	})
+}
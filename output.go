@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/confirm"
+	"github.com/johnjallday/ori-reaper-plugin/internal/errcode"
+)
+
+// structuredPrefix marks operation results that are already JSON, such as 'list'
+const structuredPrefix = "STRUCTURED_DATA:"
+
+// exportFormatOperations use the format parameter to choose their export format,
+// so format "json" there selects the export format rather than the JSON envelope
+var exportFormatOperations = map[string]bool{
+	"export_markers": true,
+	"export_regions": true,
+}
+
+// output collects an operation's typed result for format "json"
+type output struct {
+	operation    string
+	json         bool
+	data         interface{}
+	confirmation *confirm.Pending
+}
+
+// jsonResult is the envelope returned for every operation with format "json"
+type jsonResult struct {
+	Operation    string           `json:"operation"`
+	OK           bool             `json:"ok"`
+	Message      string           `json:"message,omitempty"` // The result, or for some failures such as a macro's, what was done before it
+	Data         interface{}      `json:"data,omitempty"`
+	Confirmation *confirm.Pending `json:"confirmation,omitempty"` // Set when the operation needs a confirm_token
+	Error        string           `json:"error,omitempty"`
+	Code         errcode.Code     `json:"code,omitempty"` // Machine-readable kind of error, e.g. SCRIPT_NOT_FOUND; see internal/errcode
+}
+
+// encode wraps an operation's text result or error in the JSON envelope. Results that are
+// already JSON become the data when the operation didn't set any.
+func (o *output) encode(text string, err error) (string, error) {
+	result := jsonResult{
+		Operation:    o.operation,
+		OK:           err == nil,
+		Data:         o.data,
+		Confirmation: o.confirmation,
+	}
+	if err != nil {
+		result.Error = err.Error()
+		result.Code = errcode.Of(err)
+		result.Message = text
+	} else {
+		trimmed := strings.TrimPrefix(text, structuredPrefix)
+		if result.Data == nil && json.Valid([]byte(trimmed)) {
+			result.Data = json.RawMessage(trimmed)
+		} else {
+			result.Message = text
+		}
+	}
+
+	data, marshalErr := json.Marshal(result)
+	if marshalErr != nil {
+		return "", fmt.Errorf("failed to marshal result: %w", marshalErr)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,39 @@
+package reaper
+
+import (
+	"github.com/johnjallday/ori-reaper-plugin/internal/bridge"
+	"github.com/johnjallday/ori-reaper-plugin/internal/platform"
+	"github.com/johnjallday/ori-reaper-plugin/internal/scripts"
+)
+
+// FS is the file system access used for scripts and REAPER's config files
+type FS = platform.FS
+
+// ProcessChecker reports whether REAPER is running
+type ProcessChecker = platform.ProcessChecker
+
+// Launcher starts REAPER and runs scripts in it
+type Launcher = platform.Launcher
+
+// Backend bundles file system and process access
+type Backend = platform.Backend
+
+// OSFS is the local file system
+type OSFS = platform.OSFS
+
+// DefaultBackend returns the backend for the local machine
+func DefaultBackend() Backend {
+	return platform.DefaultBackend()
+}
+
+// SetConfigFS replaces the file system used for reaper.ini and reaper-kb.ini. nil restores
+// the local file system.
+func SetConfigFS(fsys FS) {
+	scripts.SetConfigFS(fsys)
+}
+
+// SetBridgeBackend replaces how Lua snippets are run inside REAPER, which Run and
+// ReadContext rely on. Unset fields keep the local default.
+func SetBridgeBackend(b Backend) {
+	bridge.SetBackend(b)
+}
@@ -0,0 +1,16 @@
+package reaper
+
+import (
+	"context"
+
+	reapercontext "github.com/johnjallday/ori-reaper-plugin/internal/context"
+)
+
+// Context is the current state of REAPER: whether it runs, the open project, render
+// settings and Python support
+type Context = reapercontext.REAPERContext
+
+// ReadContext returns the current REAPER context
+func ReadContext(ctx context.Context) (*Context, error) {
+	return reapercontext.GetREAPERContext(ctx)
+}
@@ -0,0 +1,28 @@
+// Package reaper is a Go library for controlling REAPER: managing ReaScripts, talking to
+// the Web Remote, reading and writing reaper.ini, and reading the current REAPER context.
+// It is the code behind the ori-agent plugin, exposed for other tools to embed.
+//
+// The exported API of this package follows semantic versioning: within a major version,
+// identifiers are not removed and signatures don't change incompatibly. Structs may gain
+// fields. Everything under internal/ can change at any time and is not part of the API.
+//
+// A minimal example:
+//
+//	manager := reaper.NewScriptManager(reaper.DefaultScriptsDir())
+//	scripts, err := manager.Scripts()
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, s := range scripts {
+//		fmt.Println(s.Name, s.Description)
+//	}
+//
+//	client, err := reaper.NewWebRemoteClient(0) // Port from reaper.ini
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	tracks, err := client.Tracks(context.Background())
+//
+// File system and process access can be replaced with SetBackend and SetConfigFS, which
+// makes the package usable in tests without a REAPER install.
+package reaper
@@ -0,0 +1,63 @@
+package reaper
+
+import (
+	"github.com/johnjallday/ori-reaper-plugin/internal/scripts"
+)
+
+// WebRemoteConfig is the Web Remote control surface entry in reaper.ini
+type WebRemoteConfig = scripts.WebRemoteConfig
+
+// CSurfEntry is a control surface entry (csurf_N) in reaper.ini
+type CSurfEntry = scripts.CSurfEntry
+
+// ResourcePath returns REAPER's resource directory, which holds reaper.ini and Scripts
+func ResourcePath() (string, error) {
+	return scripts.GetReaperResourcePath()
+}
+
+// IniPath returns the path of reaper.ini
+func IniPath() (string, error) {
+	return scripts.GetReaperIniPath()
+}
+
+// KeymapPath returns the path of reaper-kb.ini, REAPER's actions and shortcuts file
+func KeymapPath() (string, error) {
+	return scripts.GetReaperKBIniPath()
+}
+
+// ExtStateSection is a section of reaper-extstate.ini
+type ExtStateSection = scripts.ExtStateSection
+
+// ExtStateValue is one persistent ExtState key from reaper-extstate.ini
+type ExtStateValue = scripts.ExtStateValue
+
+// ExtStateSections lists the sections of reaper-extstate.ini whose name contains filter
+func ExtStateSections(filter string) ([]ExtStateSection, error) {
+	return scripts.ListExtStateSections(filter)
+}
+
+// ExtState reads a section of reaper-extstate.ini, or a single key of it when key is set
+func ExtState(section, key string) ([]ExtStateValue, error) {
+	return scripts.GetExtState(section, key)
+}
+
+// IniValue reads key from section of reaper.ini. The bool is false if the key isn't set.
+func IniValue(section, key string) (string, bool, error) {
+	return scripts.GetReaperIniValue(section, key)
+}
+
+// SetIniValue writes key in section of reaper.ini. REAPER overwrites reaper.ini when it
+// exits, so change it while REAPER is closed.
+func SetIniValue(section, key, value string) error {
+	return scripts.SetReaperIniValue(section, key, value)
+}
+
+// ReadWebRemoteConfig returns the Web Remote entry from reaper.ini
+func ReadWebRemoteConfig() (*WebRemoteConfig, error) {
+	return scripts.GetWebRemoteConfig()
+}
+
+// CSurfEntries returns all control surface entries in reaper.ini
+func CSurfEntries() ([]CSurfEntry, error) {
+	return scripts.ParseCSurfEntries()
+}
@@ -0,0 +1,120 @@
+package reaper
+
+import (
+	"context"
+	"time"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/platform"
+	"github.com/johnjallday/ori-reaper-plugin/internal/scripts"
+)
+
+// ScriptInfo is a script in the scripts directory with the fields of its metadata header
+type ScriptInfo = scripts.ScriptInfo
+
+// LaunchPolicy limits how often Run launches scripts in REAPER
+type LaunchPolicy = scripts.LaunchPolicy
+
+// DefaultLaunchPolicy is the launch policy of a new ScriptManager
+var DefaultLaunchPolicy = scripts.DefaultLaunchPolicy
+
+// ScriptManager manages the ReaScripts in one scripts directory
+type ScriptManager struct {
+	sm *scripts.ScriptManager
+}
+
+// NewScriptManager returns a manager for the scripts in dir
+func NewScriptManager(dir string) *ScriptManager {
+	return &ScriptManager{sm: scripts.NewScriptManager(dir)}
+}
+
+// DefaultScriptsDir returns REAPER's scripts directory for the current platform
+func DefaultScriptsDir() string {
+	return platform.DefaultScriptsDir()
+}
+
+// Dir returns the scripts directory
+func (m *ScriptManager) Dir() string {
+	return m.sm.ScriptsDir()
+}
+
+// SetBackend replaces the file system and process access. Unset fields keep the local default.
+func (m *ScriptManager) SetBackend(b Backend) {
+	m.sm.SetBackend(b)
+}
+
+// SetAliases sets alternative names for scripts (alias -> script base name) used by Resolve
+func (m *ScriptManager) SetAliases(aliases map[string]string) {
+	m.sm.SetAliases(aliases)
+}
+
+// SetTrashRetention sets how long deleted scripts are kept in the trash; 0 keeps them
+func (m *ScriptManager) SetTrashRetention(retention time.Duration) {
+	m.sm.SetTrashRetention(retention)
+}
+
+// SetLaunchPolicy changes how Run rate limits and debounces script launches
+func (m *ScriptManager) SetLaunchPolicy(policy LaunchPolicy) {
+	m.sm.SetLaunchPolicy(policy)
+}
+
+// Scripts returns the .lua scripts in the directory with their metadata headers
+func (m *ScriptManager) Scripts() ([]ScriptInfo, error) {
+	return m.sm.Scripts()
+}
+
+// Resolve finds the script a user means by name: the exact name, an alias, a
+// case-insensitive match or a unique partial match. Returns the script's base name.
+func (m *ScriptManager) Resolve(name string) (string, error) {
+	return m.sm.ResolveScript(name)
+}
+
+// Run runs a script in REAPER and returns a status message. A runtime error in the
+// script is returned with its stack trace.
+func (m *ScriptManager) Run(ctx context.Context, name string) (string, error) {
+	return m.sm.RunScript(ctx, name)
+}
+
+// Add writes a new script. scriptType is "lua", "eel" or "py".
+func (m *ScriptManager) Add(name, content, scriptType string) (string, error) {
+	return m.sm.AddScript(name, content, scriptType)
+}
+
+// Delete moves a script to the trash
+func (m *ScriptManager) Delete(name string) (string, error) {
+	return m.sm.DeleteScript(name)
+}
+
+// Restore brings a deleted script back from the trash
+func (m *ScriptManager) Restore(name string) (string, error) {
+	return m.sm.RestoreScript(name)
+}
+
+// Register adds a script to REAPER's action list (reaper-kb.ini)
+func (m *ScriptManager) Register(name string) (string, error) {
+	return m.sm.RegisterScript(name)
+}
+
+// Disable moves a script to the Disabled folder, hiding it without deleting it
+func (m *ScriptManager) Disable(name string) (string, error) {
+	return m.sm.DisableScript(name)
+}
+
+// Enable moves a disabled script back into the scripts directory
+func (m *ScriptManager) Enable(name string) (string, error) {
+	return m.sm.EnableScript(name)
+}
+
+// SetFavorite marks or unmarks a script as a favorite
+func (m *ScriptManager) SetFavorite(name string, favorite bool) (string, error) {
+	return m.sm.SetFavorite(name, favorite)
+}
+
+// SetRating sets a script's star rating and notes; nil leaves either unchanged
+func (m *ScriptManager) SetRating(name string, stars *int, notes *string) (string, error) {
+	return m.sm.SetRating(name, stars, notes)
+}
+
+// SetTags replaces a script's tags
+func (m *ScriptManager) SetTags(name string, tags []string) (string, error) {
+	return m.sm.SetTags(name, tags)
+}
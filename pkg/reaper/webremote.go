@@ -0,0 +1,94 @@
+package reaper
+
+import (
+	"context"
+	"time"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/scripts"
+)
+
+// Track is a REAPER track as reported by the Web Remote
+type Track = scripts.Track
+
+// GroupMembership is a track's part in a REAPER track group
+type GroupMembership = scripts.GroupMembership
+
+// LevelSnapshot is the meter readings of all tracks at one moment
+type LevelSnapshot = scripts.LevelSnapshot
+
+// TrackLevel is the meter reading of one track
+type TrackLevel = scripts.TrackLevel
+
+// WebRemoteClient talks to REAPER's Web Remote HTTP interface
+type WebRemoteClient struct {
+	c *scripts.WebRemoteClient
+}
+
+// NewWebRemoteClient returns a client for the local Web Remote on port. With port 0 the port
+// is read from reaper.ini, along with any credentials the Web Remote requires.
+func NewWebRemoteClient(port int) (*WebRemoteClient, error) {
+	c, err := scripts.NewWebRemoteClient(port)
+	if err != nil {
+		return nil, err
+	}
+	return &WebRemoteClient{c: c}, nil
+}
+
+// NewWebRemoteClientAt returns a client for the Web Remote on host:port, which may be
+// another machine
+func NewWebRemoteClientAt(host string, port int) *WebRemoteClient {
+	return &WebRemoteClient{c: scripts.NewWebRemoteClientAt(host, port)}
+}
+
+// BaseURL returns the address the client talks to
+func (w *WebRemoteClient) BaseURL() string {
+	return w.c.BaseURL()
+}
+
+// SetCredentials sets the basic auth username and password sent with every request
+func (w *WebRemoteClient) SetCredentials(username, password string) {
+	w.c.SetCredentials(username, password)
+}
+
+// Ping checks that the Web Remote answers
+func (w *WebRemoteClient) Ping(ctx context.Context) error {
+	return w.c.Ping(ctx)
+}
+
+// Tracks returns the tracks of the current project
+func (w *WebRemoteClient) Tracks(ctx context.Context) ([]Track, error) {
+	return w.c.GetTracks(ctx)
+}
+
+// SelectedTracks returns the tracks currently selected in REAPER
+func (w *WebRemoteClient) SelectedTracks(ctx context.Context) ([]Track, error) {
+	return w.c.GetSelectedTracks(ctx)
+}
+
+// SelectTracks selects the tracks with the given indexes (1-based, 0 being the master).
+// Unless add is set, every other track is deselected. Returns the tracks selected afterwards.
+func (w *WebRemoteClient) SelectTracks(ctx context.Context, indexes []int, add bool) ([]Track, error) {
+	return w.c.SelectTracks(ctx, indexes, add)
+}
+
+// Levels samples the peak levels of all tracks
+func (w *WebRemoteClient) Levels(ctx context.Context) (*LevelSnapshot, error) {
+	return w.c.GetLevels(ctx)
+}
+
+// PollLevels samples the peak levels every interval (at least 50ms) until ctx is done,
+// then closes the returned channel. Failed samples are sent with Error set.
+func (w *WebRemoteClient) PollLevels(ctx context.Context, interval time.Duration) <-chan LevelSnapshot {
+	return w.c.PollLevels(ctx, interval)
+}
+
+// RunAction triggers a REAPER action by command ID, e.g. "40029" or "_SWS_ABOUT"
+func (w *WebRemoteClient) RunAction(ctx context.Context, commandID string) error {
+	return w.c.RunAction(ctx, commandID)
+}
+
+// SendCommand sends raw Web Remote commands, such as "TRANSPORT" or "SET/TRACK/1/MUTE/-1",
+// and returns the response body
+func (w *WebRemoteClient) SendCommand(ctx context.Context, commands ...string) (string, error) {
+	return w.c.SendCommand(ctx, commands...)
+}
@@ -0,0 +1,354 @@
+// Package mockserver emulates the parts of REAPER's Web Remote HTTP API that the plugin
+// uses: track listing and SET/TRACK changes, transport state and command IDs.
+// It lets tests and web page work run without a live REAPER.
+//
+// Requests have the same shape as REAPER's: GET /_/<command>;<command>;...
+// Responses are REAPER's tab-separated lines, one per returned object.
+package mockserver
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Track flags, as reported in the flags field of a TRACK line
+const (
+	FlagFolder   = 1
+	FlagSelected = 2
+	FlagHasFX    = 4
+	FlagMuted    = 8
+	FlagSoloed   = 16
+	FlagRecArmed = 64
+)
+
+// Play states reported by TRANSPORT
+const (
+	Stopped   = 0
+	Playing   = 1
+	Paused    = 2
+	Recording = 5
+)
+
+// Action command IDs the server simulates; any other ID is recorded but has no effect
+const (
+	ActionPlay         = "1007"
+	ActionPause        = "1008"
+	ActionRecord       = "1013"
+	ActionStop         = "1016"
+	ActionToggleRepeat = "1068"
+	ActionInsertTrack  = "40001"
+	ActionRemoveTracks = "40005"
+	ActionUndo         = "40029"
+	ActionRedo         = "40030"
+)
+
+// Track is a simulated REAPER track
+type Track struct {
+	Name     string
+	Volume   float64 // Linear gain; 1.0 is 0 dB
+	Pan      float64 // -1.0 (left) to 1.0 (right)
+	Mute     bool
+	Solo     bool
+	RecArm   bool
+	Selected bool
+	HasFX    bool
+	Color    int     // REAPER native color with 0x1000000 set, or 0 for none
+	Peak     float64 // Linear meter peak reported as last_meter_peak; 0 is silence, 1.0 is 0 dB
+}
+
+// peak returns the meter peak of t in tenths of a dB, as the Web Remote reports it
+func (t Track) peak() int {
+	if t.Peak <= 0 {
+		return -1500
+	}
+	return int(math.Round(200 * math.Log10(t.Peak)))
+}
+
+// flags returns the TRACK flags bitmask for t
+func (t Track) flags() int {
+	var f int
+	if t.Selected {
+		f |= FlagSelected
+	}
+	if t.HasFX {
+		f |= FlagHasFX
+	}
+	if t.Mute {
+		f |= FlagMuted
+	}
+	if t.Solo {
+		f |= FlagSoloed
+	}
+	if t.RecArm {
+		f |= FlagRecArmed
+	}
+	return f
+}
+
+// Server is a fake REAPER Web Remote. It is safe for concurrent use.
+type Server struct {
+	mu        sync.Mutex
+	master    Track
+	tracks    []Track
+	playState int
+	position  float64 // Seconds
+	repeat    bool
+	commands  []string // Every command received, in order
+	username  string
+	password  string
+}
+
+// New returns a server with the given tracks (numbered from 1) and a stopped transport
+func New(tracks ...Track) *Server {
+	return &Server{
+		master: Track{Name: "MASTER", Volume: 1},
+		tracks: append([]Track(nil), tracks...),
+	}
+}
+
+// DemoTracks is a small session for running the server standalone
+func DemoTracks() []Track {
+	return []Track{
+		{Name: "Drums", Volume: 1, HasFX: true, Peak: 1.12},
+		{Name: "Bass", Volume: 0.7, Pan: -0.2, Peak: 0.5},
+		{Name: "Guitar", Volume: 0.8, Pan: 0.4, Selected: true, Peak: 0.3},
+		{Name: "Vocals", Volume: 1, RecArm: true, HasFX: true},
+	}
+}
+
+// SetCredentials requires HTTP basic auth with the given username and password,
+// like a Web Remote configured with a login
+func (s *Server) SetCredentials(username, password string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.username, s.password = username, password
+}
+
+// Tracks returns a copy of the current tracks
+func (s *Server) Tracks() []Track {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Track(nil), s.tracks...)
+}
+
+// SetTracks replaces the simulated tracks
+func (s *Server) SetTracks(tracks ...Track) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tracks = append([]Track(nil), tracks...)
+}
+
+// PlayState returns the transport state (Stopped, Playing, Paused or Recording)
+func (s *Server) PlayState() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.playState
+}
+
+// Commands returns every command received so far, in order
+func (s *Server) Commands() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.commands...)
+}
+
+// ServeHTTP handles Web Remote requests
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.username != "" || s.password != "" {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != s.username || pass != s.password {
+			w.Header().Set("WWW-Authenticate", `Basic realm="REAPER"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	path := r.URL.Path
+	if path == "/_" || path == "/_/" {
+		w.Header().Set("Content-Type", "text/plain")
+		return
+	}
+	if !strings.HasPrefix(path, "/_/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	var out strings.Builder
+	for _, command := range strings.Split(strings.TrimPrefix(path, "/_/"), ";") {
+		if command == "" {
+			continue
+		}
+		s.commands = append(s.commands, command)
+		s.handle(&out, command)
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	fmt.Fprint(w, out.String())
+}
+
+// handle runs one command and writes its response lines. Must be called with mu held.
+func (s *Server) handle(out *strings.Builder, command string) {
+	parts := strings.Split(command, "/")
+	switch strings.ToUpper(parts[0]) {
+	case "TRACK":
+		s.writeTracks(out, parts[1:])
+	case "NTRACK":
+		fmt.Fprintf(out, "NTRACK\t%d\n", len(s.tracks))
+	case "TRANSPORT":
+		s.writeTransport(out)
+	case "SET":
+		s.set(parts[1:])
+	default:
+		s.runAction(parts[0])
+	}
+}
+
+// writeTracks writes TRACK lines for all tracks (master included), one index, or a range "a-b"
+func (s *Server) writeTracks(out *strings.Builder, args []string) {
+	first, last := 0, len(s.tracks)
+	if len(args) > 0 && args[0] != "" {
+		from, to, isRange := strings.Cut(args[0], "-")
+		a, err := strconv.Atoi(from)
+		if err != nil {
+			return
+		}
+		first, last = a, a
+		if isRange {
+			if b, err := strconv.Atoi(to); err == nil {
+				last = b
+			}
+		}
+	}
+	for i := max(first, 0); i <= last && i <= len(s.tracks); i++ {
+		t := s.master
+		if i > 0 {
+			t = s.tracks[i-1]
+		}
+		// TRACK index name flags volume pan last_meter_peak last_meter_pos width/pan2 panmode sendcnt recvcnt hwoutcnt color
+		peak := t.peak()
+		fmt.Fprintf(out, "TRACK\t%d\t%s\t%d\t%f\t%f\t%d\t%d\t1.000000\t3\t0\t0\t0\t%d\n",
+			i, t.Name, t.flags(), t.Volume, t.Pan, peak, peak, t.Color)
+	}
+}
+
+// writeTransport writes the TRANSPORT line: playstate position repeat position_string position_beats
+func (s *Server) writeTransport(out *strings.Builder) {
+	repeat := 0
+	if s.repeat {
+		repeat = 1
+	}
+	minutes := int(s.position) / 60
+	seconds := s.position - float64(minutes*60)
+	fmt.Fprintf(out, "TRANSPORT\t%d\t%.6f\t%d\t%d:%06.3f\t1.1.00\n", s.playState, s.position, repeat, minutes, seconds)
+}
+
+// set applies SET/TRACK/<index>/<property>/<value>, SET/POS/<seconds> and SET/REPEAT/<value>
+func (s *Server) set(args []string) {
+	if len(args) == 0 {
+		return
+	}
+	switch strings.ToUpper(args[0]) {
+	case "POS":
+		if len(args) > 1 {
+			if pos, err := strconv.ParseFloat(args[1], 64); err == nil && pos >= 0 {
+				s.position = pos
+			}
+		}
+	case "REPEAT":
+		if len(args) > 1 {
+			s.repeat = toggle(s.repeat, args[1])
+		}
+	case "TRACK":
+		if len(args) < 4 {
+			return
+		}
+		index, err := strconv.Atoi(args[1])
+		if err != nil || index < 0 || index > len(s.tracks) {
+			return
+		}
+		t := &s.master
+		if index > 0 {
+			t = &s.tracks[index-1]
+		}
+		value := args[3]
+		switch strings.ToUpper(args[2]) {
+		case "VOL":
+			if v, err := strconv.ParseFloat(value, 64); err == nil && v >= 0 {
+				t.Volume = v
+			}
+		case "PAN":
+			if v, err := strconv.ParseFloat(value, 64); err == nil {
+				t.Pan = min(max(v, -1), 1)
+			}
+		case "MUTE":
+			t.Mute = toggle(t.Mute, value)
+		case "SOLO":
+			t.Solo = toggle(t.Solo, value)
+		case "RECARM":
+			t.RecArm = toggle(t.RecArm, value)
+		case "SEL":
+			t.Selected = toggle(t.Selected, value)
+		}
+	}
+}
+
+// toggle applies a Web Remote boolean value: -1 toggles, 0 clears, anything else sets
+func toggle(current bool, value string) bool {
+	switch value {
+	case "-1":
+		return !current
+	case "0":
+		return false
+	default:
+		return true
+	}
+}
+
+// runAction simulates the effect of an action command ID
+func (s *Server) runAction(id string) {
+	switch id {
+	case ActionPlay:
+		s.playState = Playing
+	case ActionPause:
+		if s.playState == Paused {
+			s.playState = Playing
+		} else if s.playState != Stopped {
+			s.playState = Paused
+		}
+	case ActionRecord:
+		s.playState = Recording
+	case ActionStop:
+		s.playState = Stopped
+	case ActionToggleRepeat:
+		s.repeat = !s.repeat
+	case ActionInsertTrack:
+		s.tracks = append(s.tracks, Track{Volume: 1})
+	case ActionRemoveTracks:
+		kept := s.tracks[:0]
+		for _, t := range s.tracks {
+			if !t.Selected {
+				kept = append(kept, t)
+			}
+		}
+		s.tracks = kept
+	}
+}
+
+// Start serves the mock on addr (e.g. "127.0.0.1:0" for a free port) in the background
+// and returns the listening address. Close the returned server to stop it.
+func (s *Server) Start(addr string) (*http.Server, string, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, "", err
+	}
+	server := &http.Server{Handler: s}
+	go server.Serve(listener)
+	return server, listener.Addr().String(), nil
+}
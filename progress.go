@@ -0,0 +1,25 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/johnjallday/ori-reaper-plugin/internal/progress"
+	"github.com/johnjallday/ori-reaper-plugin/internal/scripts"
+)
+
+// stderrProgress writes an operation's progress to stderr, which the agent host records
+// in its log while the operation runs; the tool result itself only arrives at the end
+func stderrProgress(operation string) progress.Func {
+	return func(update progress.Update) {
+		fmt.Fprintf(os.Stderr, "[ori-reaper] %s: %s\n", operation, update)
+	}
+}
+
+// downloadProgress passes a downloader's progress on to the operation's progress reporter
+func downloadProgress(ctx context.Context) scripts.ProgressFunc {
+	return func(p scripts.Progress) {
+		progress.Report(ctx, "Downloading "+p.String(), 0, 0)
+	}
+}
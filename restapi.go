@@ -0,0 +1,391 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/johnjallday/ori-agent/pluginapi"
+	"github.com/johnjallday/ori-reaper-plugin/internal/errcode"
+	"github.com/johnjallday/ori-reaper-plugin/internal/metrics"
+	"github.com/johnjallday/ori-reaper-plugin/internal/progress"
+	"github.com/johnjallday/ori-reaper-plugin/internal/types"
+)
+
+const (
+	// defaultRESTAPIPort is used when rest_api.port isn't set
+	defaultRESTAPIPort = 7878
+	// minRESTAPITokenLength rejects tokens too short to resist guessing
+	minRESTAPITokenLength = 16
+	// maxRESTAPIBody caps the size of a request's JSON parameters
+	maxRESTAPIBody = 1 << 20
+	// defaultLevelInterval is how often the level stream samples the meters
+	defaultLevelInterval = 100 * time.Millisecond
+)
+
+// readOnlyOperations are the operations the REST API runs for GET requests. They only
+// read REAPER, its files or the plugin's state; everything else needs POST.
+var readOnlyOperations = map[string]bool{
+	"list": true, "list_available_scripts": true, "download_script": true, "get_context": true,
+	"get_web_remote_port": true, "get_tracks": true, "get_selected_tracks": true, "get_levels": true,
+	"get_undo_history": true, "get_record_inputs": true, "get_master": true, "get_playrate": true,
+	"list_monitor_fx": true, "get_selected_items": true, "get_edit_modes": true, "get_automation": true,
+	"get_envelopes": true, "get_project_notes": true, "audit_media": true, "list_backups": true,
+	"get_autosave": true, "diff_projects": true, "get_render_stats": true, "check_dependencies": true,
+	"list_web_interfaces": true, "list_trash": true, "list_macros": true, "check_updates": true,
+	"list_bundles": true, "script_history": true, "list_extstate": true, "get_extstate": true,
+	"list_screensets": true, "list_menus": true, "list_templates": true, "search_actions": true,
+	"convert_time": true, "get_session_log": true, "list_executions": true, "get_metrics": true,
+}
+
+// restAPI is the running REST API server, if any
+var restAPI struct {
+	mu     sync.Mutex
+	server *http.Server
+	config types.RESTAPI
+}
+
+// startRESTAPI starts, restarts or stops the REST API server to match the settings.
+// Errors are logged rather than returned since the plugin works without the server.
+func startRESTAPI(t *reaperTool) {
+	config := globalSettingsManager.GetRESTAPI()
+	if config.Port == 0 {
+		config.Port = defaultRESTAPIPort
+	}
+
+	restAPI.mu.Lock()
+	defer restAPI.mu.Unlock()
+	if restAPI.server != nil {
+		if restAPI.config == config {
+			return
+		}
+		restAPI.server.Close()
+		restAPI.server = nil
+	}
+	if !config.Enabled {
+		return
+	}
+	if len(config.Token) < minRESTAPITokenLength {
+		log.Printf("REST API not started: rest_api.token must be at least %d characters", minRESTAPITokenLength)
+		return
+	}
+
+	host := "127.0.0.1"
+	if config.AllowRemote {
+		host = ""
+	}
+	listener, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(config.Port)))
+	if err != nil {
+		log.Printf("REST API not started: %v", err)
+		return
+	}
+
+	server := &http.Server{
+		Handler:           newRESTHandler(t, config.Token),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("REST API stopped: %v", err)
+		}
+	}()
+	restAPI.server = server
+	restAPI.config = config
+	log.Printf("REST API listening on %s", listener.Addr())
+}
+
+// newRESTHandler routes the REST API:
+//
+//	GET  /api/health                 liveness check, no token needed
+//	GET  /api/operations             the operation names
+//	GET  /api/operations/{name}      run a read-only operation with query string parameters
+//	POST /api/operations/{name}      run any operation with a JSON object of parameters,
+//	                                 and query string parameters
+//	GET  /api/operations/{name}/stream
+//	POST /api/operations/{name}/stream
+//	                                 run an operation as above, sending its progress as
+//	                                 server-sent events followed by a "result" event
+//	GET  /metrics                    operation and request metrics in the Prometheus
+//	                                 text format, when metrics are on
+//	GET  /api/levels/stream          track peak levels as server-sent events, every
+//	                                 ?interval= milliseconds (default 100)
+//
+// Results are the JSON envelope of format "json". Requests must send the token as
+// "Authorization: Bearer <token>"; it's not accepted in the URL, where it would end up in
+// logs and browser history.
+func newRESTHandler(t *reaperTool, token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/health", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+	})
+	mux.HandleFunc("GET /api/operations", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string][]string{"operations": operations})
+	})
+	mux.HandleFunc("GET /api/operations/{name}", t.serveOperation)
+	mux.HandleFunc("POST /api/operations/{name}", t.serveOperation)
+	mux.HandleFunc("GET /api/operations/{name}/stream", t.serveOperationStream)
+	mux.HandleFunc("POST /api/operations/{name}/stream", t.serveOperationStream)
+	mux.HandleFunc("GET /api/levels/stream", serveLevels)
+	mux.HandleFunc("GET /metrics", serveMetrics)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/health" && !validRESTToken(r, token) {
+			writeJSON(w, http.StatusUnauthorized, jsonResult{Error: "missing or invalid token"})
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// validRESTToken checks the request's bearer token
+func validRESTToken(r *http.Request, token string) bool {
+	given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && given != "" && subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1
+}
+
+// operationArgs returns the arguments of the operation named in the path, or the
+// status to answer with when the request is invalid
+func (t *reaperTool) operationArgs(r *http.Request) (string, int, error) {
+	name := r.PathValue("name")
+	if !slices.Contains(operations, name) {
+		return "", http.StatusNotFound, errcode.Errorf(errcode.UnknownOperation, "unknown operation: %s", name)
+	}
+	if r.Method == http.MethodGet && !readOnlyOperations[name] {
+		return "", http.StatusMethodNotAllowed, errcode.Errorf(errcode.InvalidParameters, "'%s' changes REAPER or the scripts; use POST to run it", name)
+	}
+
+	args, err := restParams(r, parameterTypes(t.Definition()))
+	if err != nil {
+		return "", http.StatusBadRequest, errcode.New(errcode.InvalidParameters, err)
+	}
+	args["operation"] = name
+	if !exportFormatOperations[name] {
+		args["format"] = "json"
+	}
+
+	data, err := json.Marshal(args)
+	if err != nil {
+		return "", http.StatusBadRequest, err
+	}
+	return string(data), http.StatusOK, nil
+}
+
+// serveOperation runs the operation named in the path
+func (t *reaperTool) serveOperation(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	args, status, err := t.operationArgs(r)
+	if err != nil {
+		writeRESTError(w, status, name, err)
+		return
+	}
+	text, err := t.call(r.Context(), args, false)
+	if exportFormatOperations[name] {
+		// The export itself is the response, in the format that was asked for
+		if err != nil {
+			writeJSON(w, http.StatusUnprocessableEntity, jsonResult{Operation: name, Error: err.Error(), Code: errcode.Of(err)})
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		io.WriteString(w, text)
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, jsonResult{Operation: name, Error: err.Error(), Code: errcode.Of(err)})
+		return
+	}
+
+	var result jsonResult
+	if err := json.Unmarshal([]byte(text), &result); err != nil {
+		// Not the envelope, so wrap the result in one
+		if text, err = (&output{operation: name}).encode(text, nil); err != nil {
+			writeJSON(w, http.StatusInternalServerError, jsonResult{Operation: name, Error: err.Error()})
+			return
+		}
+		result.OK = true
+	}
+	status = http.StatusOK
+	switch {
+	case result.Confirmation != nil:
+		status = http.StatusAccepted
+	case !result.OK:
+		status = http.StatusUnprocessableEntity
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	io.WriteString(w, text)
+}
+
+// serveOperationStream runs the operation named in the path like serveOperation, sending
+// each progress update as a "progress" event and then the JSON envelope as a "result"
+// event. Exports are wrapped in the envelope too.
+func (t *reaperTool) serveOperationStream(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	args, status, err := t.operationArgs(r)
+	if err != nil {
+		writeRESTError(w, status, name, err)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSON(w, http.StatusInternalServerError, jsonResult{Operation: name, Error: "streaming is not supported"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	// Downloads report from several goroutines, so events are written one at a time
+	var mu sync.Mutex
+	send := func(event string, data []byte) {
+		mu.Lock()
+		defer mu.Unlock()
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+		flusher.Flush()
+	}
+	logProgress := stderrProgress(name)
+	ctx := progress.WithFunc(r.Context(), func(update progress.Update) {
+		logProgress(update)
+		if data, err := json.Marshal(update); err == nil {
+			send("progress", data)
+		}
+	})
+
+	text, err := t.call(ctx, args, false)
+	if err != nil || exportFormatOperations[name] {
+		text, err = (&output{operation: name}).encode(text, err)
+		if err != nil {
+			return
+		}
+	}
+	send("result", []byte(text))
+}
+
+// serveLevels streams track peak levels as server-sent events until the client goes away
+func serveLevels(w http.ResponseWriter, r *http.Request) {
+	interval := defaultLevelInterval
+	if ms, err := strconv.Atoi(r.URL.Query().Get("interval")); err == nil && ms > 0 {
+		interval = time.Duration(ms) * time.Millisecond
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSON(w, http.StatusInternalServerError, jsonResult{Error: "streaming is not supported"})
+		return
+	}
+	if err := globalSettingsManager.ApplyHTTPSettings(); err != nil {
+		writeJSON(w, http.StatusInternalServerError, jsonResult{Error: err.Error()})
+		return
+	}
+	client, err := newWebRemoteClient()
+	if err != nil {
+		writeJSON(w, http.StatusServiceUnavailable, jsonResult{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	for snapshot := range client.PollLevels(r.Context(), interval) {
+		data, err := json.Marshal(snapshot)
+		if err != nil {
+			return
+		}
+		if _, err := fmt.Fprintf(w, "event: levels\ndata: %s\n\n", data); err != nil {
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// serveMetrics writes the metrics for a Prometheus scrape
+func serveMetrics(w http.ResponseWriter, r *http.Request) {
+	snapshot := metrics.Get()
+	if !snapshot.Enabled {
+		writeJSON(w, http.StatusNotFound, jsonResult{Error: "metrics are off; set \"metrics\": true in the plugin settings"})
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	metrics.WritePrometheus(w, snapshot)
+}
+
+// parameterTypes returns the schema type of each parameter in def, e.g. "number" for
+// "tempo". Parameters that take more than one type are left out.
+func parameterTypes(def pluginapi.Tool) map[string]string {
+	kinds := make(map[string]string)
+	properties, _ := def.Parameters["properties"].(map[string]interface{})
+	for name, property := range properties {
+		schema, _ := property.(map[string]interface{})
+		if kind, ok := schema["type"].(string); ok {
+			kinds[name] = kind
+		}
+	}
+	return kinds
+}
+
+// restParams collects the operation parameters from the JSON body and the query string.
+// Query values are strings, except for parameters kinds declares as numbers or booleans,
+// which are converted.
+func restParams(r *http.Request, kinds map[string]string) (map[string]interface{}, error) {
+	args := make(map[string]interface{})
+	if r.Method == http.MethodPost {
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxRESTAPIBody))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+		if len(strings.TrimSpace(string(body))) > 0 {
+			if err := json.Unmarshal(body, &args); err != nil {
+				return nil, fmt.Errorf("request body must be a JSON object of parameters: %w", err)
+			}
+		}
+	}
+	for key, values := range r.URL.Query() {
+		if len(values) == 0 {
+			continue
+		}
+		value := strings.TrimSpace(values[0])
+		switch kinds[key] {
+		case "number", "integer":
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s must be a number, got %q", key, values[0])
+			}
+			args[key] = n
+		case "boolean":
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s must be true or false, got %q", key, values[0])
+			}
+			args[key] = b
+		default:
+			args[key] = values[0]
+		}
+	}
+	return args, nil
+}
+
+// writeRESTError answers a request that couldn't run with status
+func writeRESTError(w http.ResponseWriter, status int, operation string, err error) {
+	if status == http.StatusMethodNotAllowed {
+		w.Header().Set("Allow", http.MethodPost)
+	}
+	writeJSON(w, status, jsonResult{Operation: operation, Error: err.Error(), Code: errcode.Of(err)})
+}
+
+// writeJSON writes v as a JSON response with the given status
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
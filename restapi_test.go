@@ -0,0 +1,68 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+const testRESTToken = "0123456789abcdef"
+
+func TestRESTParamsFollowSchemaTypes(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/api/operations/run_action?command=40044&version=1.0&name=true&input=1&limit=5&append=true&position=1.5", nil)
+	args, err := restParams(r, parameterTypes((&reaperTool{}).Definition()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"command":  "40044", // Numeric strings stay strings
+		"version":  "1.0",
+		"name":     "true",
+		"input":    "1",
+		"limit":    float64(5),
+		"append":   true,
+		"position": "1.5", // Number or string; timeutil.Value reads both
+	}
+	for key, value := range want {
+		if args[key] != value {
+			t.Errorf("%s = %#v, want %#v", key, args[key], value)
+		}
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/api/operations/list?limit=ten", nil)
+	if _, err := restParams(r, parameterTypes((&reaperTool{}).Definition())); err == nil {
+		t.Error("restParams accepted limit=ten, want an error")
+	}
+}
+
+func TestRESTReadOnlyOperationsExist(t *testing.T) {
+	for name := range readOnlyOperations {
+		if !slices.Contains(operations, name) {
+			t.Errorf("read-only operation %s is not an operation", name)
+		}
+	}
+}
+
+func TestRESTGetRejectsChanges(t *testing.T) {
+	handler := newRESTHandler(&reaperTool{}, testRESTToken)
+	for _, name := range []string{"delete", "register_all_scripts", "publish_script", "run"} {
+		r := httptest.NewRequest(http.MethodGet, "/api/operations/"+name+"?script=Normalize", nil)
+		r.Header.Set("Authorization", "Bearer "+testRESTToken)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != http.MethodPost {
+			t.Errorf("GET %s = %d (Allow %q), want 405 allowing POST", name, w.Code, w.Header().Get("Allow"))
+		}
+	}
+}
+
+func TestRESTTokenNotAcceptedInURL(t *testing.T) {
+	handler := newRESTHandler(&reaperTool{}, testRESTToken)
+	r := httptest.NewRequest(http.MethodGet, "/api/operations?token="+testRESTToken, nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("token in the URL = %d, want 401", w.Code)
+	}
+}